@@ -0,0 +1,156 @@
+// Package broker adalah pub/sub di dalam proses untuk meneruskan peristiwa
+// ke banyak pelanggan, misalnya koneksi SSE dashboard. Penerbit tidak pernah
+// menunggu: setiap pelanggan punya buffer sendiri dan pelanggan yang lambat
+// kehilangan pesan tertua, bukan memperlambat penerbit atau pelanggan lain.
+// Beberapa pesan terakhir disimpan agar pelanggan yang tersambung ulang
+// bisa mengejar pesan yang terlewat.
+package broker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pesan adalah satu peristiwa. ID diberikan broker dan selalu naik.
+// AfdelingID 0 berarti peristiwa berlaku untuk semua afdeling.
+type Pesan struct {
+	ID         uint64      `json:"id"`
+	Jenis      string      `json:"jenis"`
+	AfdelingID uint        `json:"afdeling_id,omitempty"`
+	Waktu      time.Time   `json:"waktu"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// Filter menentukan apakah pelanggan menerima pesan; nil berarti semua
+type Filter func(Pesan) bool
+
+// FilterAfdeling meloloskan pesan untuk afdeling tertentu dan pesan yang
+// berlaku untuk semua afdeling. afdelingID 0 meloloskan semua pesan.
+func FilterAfdeling(afdelingID uint) Filter {
+	return func(m Pesan) bool {
+		return afdelingID == 0 || m.AfdelingID == 0 || m.AfdelingID == afdelingID
+	}
+}
+
+// Pelanggan menerima pesan lewat Pesan() sampai Broker.Berhenti dipanggil
+type Pelanggan struct {
+	ch     chan Pesan
+	filter Filter
+	hilang atomic.Uint64
+}
+
+// Pesan mengembalikan channel penerima; channel ditutup saat berhenti
+func (p *Pelanggan) Pesan() <-chan Pesan {
+	return p.ch
+}
+
+// Hilang adalah jumlah pesan yang dibuang karena pelanggan terlalu lambat
+func (p *Pelanggan) Hilang() uint64 {
+	return p.hilang.Load()
+}
+
+// kirim tidak pernah memblokir. Jika buffer penuh, pesan tertua dibuang
+// agar pelanggan tetap melihat keadaan terbaru.
+func (p *Pelanggan) kirim(m Pesan) {
+	if p.filter != nil && !p.filter(m) {
+		return
+	}
+	for {
+		select {
+		case p.ch <- m:
+			return
+		default:
+		}
+		select {
+		case <-p.ch:
+			p.hilang.Add(1)
+		default:
+		}
+	}
+}
+
+// Broker meneruskan pesan ke semua pelanggan
+type Broker struct {
+	mu        sync.RWMutex
+	pelanggan map[*Pelanggan]struct{}
+	buffer    int
+
+	// riwayat melingkar berisi pesan terakhir untuk penyambungan ulang
+	riwayat  []Pesan
+	awal     int
+	urutanID uint64
+}
+
+// Baru membuat broker dengan buffer per pelanggan dan panjang riwayat
+// tertentu (minimal 1 dan 0)
+func Baru(buffer, riwayat int) *Broker {
+	return &Broker{
+		pelanggan: make(map[*Pelanggan]struct{}),
+		buffer:    max(buffer, 1),
+		riwayat:   make([]Pesan, 0, max(riwayat, 0)),
+	}
+}
+
+// Langgan mendaftarkan pelanggan baru. Jika sejak > 0, pesan di riwayat
+// dengan ID lebih besar dari sejak dikirim lebih dulu (sebanyak buffer).
+func (b *Broker) Langgan(filter Filter, sejak uint64) *Pelanggan {
+	p := &Pelanggan{ch: make(chan Pesan, b.buffer), filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if sejak > 0 {
+		for i := 0; i < len(b.riwayat); i++ {
+			m := b.riwayat[(b.awal+i)%len(b.riwayat)]
+			if m.ID > sejak {
+				p.kirim(m)
+			}
+		}
+	}
+	b.pelanggan[p] = struct{}{}
+	return p
+}
+
+// Berhenti melepas pelanggan dan menutup channel-nya. Aman dipanggil
+// lebih dari sekali.
+func (b *Broker) Berhenti(p *Pelanggan) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.pelanggan[p]; ok {
+		delete(b.pelanggan, p)
+		close(p.ch)
+	}
+}
+
+// Terbitkan memberi ID dan waktu pada pesan lalu mengirimkannya ke semua
+// pelanggan yang cocok. Pesan yang sudah diberi ID dikembalikan.
+func (b *Broker) Terbitkan(m Pesan) Pesan {
+	b.mu.Lock()
+	b.urutanID++
+	m.ID = b.urutanID
+	if m.Waktu.IsZero() {
+		m.Waktu = time.Now()
+	}
+	if cap(b.riwayat) > 0 {
+		if len(b.riwayat) < cap(b.riwayat) {
+			b.riwayat = append(b.riwayat, m)
+		} else {
+			b.riwayat[b.awal] = m
+			b.awal = (b.awal + 1) % len(b.riwayat)
+		}
+	}
+	// Kirim di bawah lock agar urutan ID sama dengan urutan terima dan
+	// channel tidak ditutup di tengah pengiriman; kirim tidak memblokir
+	for p := range b.pelanggan {
+		p.kirim(m)
+	}
+	b.mu.Unlock()
+	return m
+}
+
+// JumlahPelanggan mengembalikan banyaknya pelanggan aktif
+func (b *Broker) JumlahPelanggan() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.pelanggan)
+}
@@ -0,0 +1,130 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+// terima membaca pesan yang sudah ada di buffer tanpa menunggu
+func terima(p *Pelanggan) []Pesan {
+	var hasil []Pesan
+	for {
+		select {
+		case m, ok := <-p.Pesan():
+			if !ok {
+				return hasil
+			}
+			hasil = append(hasil, m)
+		default:
+			return hasil
+		}
+	}
+}
+
+func TestTerbitkanKeSemuaPelanggan(t *testing.T) {
+	b := Baru(8, 0)
+	a, c := b.Langgan(nil, 0), b.Langgan(nil, 0)
+
+	for _, jenis := range []string{"satu", "dua", "tiga"} {
+		b.Terbitkan(Pesan{Jenis: jenis})
+	}
+
+	for nama, p := range map[string]*Pelanggan{"a": a, "c": c} {
+		got := terima(p)
+		if len(got) != 3 {
+			t.Fatalf("pelanggan %s menerima %d pesan, ingin 3", nama, len(got))
+		}
+		for i, m := range got {
+			if m.ID != uint64(i+1) || m.Waktu.IsZero() {
+				t.Errorf("pelanggan %s pesan %d = %+v, ingin ID %d dengan waktu", nama, i, m, i+1)
+			}
+		}
+	}
+}
+
+func TestBufferPenuhMembuangPesanTertua(t *testing.T) {
+	b := Baru(2, 0)
+	lambat := b.Langgan(nil, 0)
+
+	selesai := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			b.Terbitkan(Pesan{Jenis: "uji"})
+		}
+		close(selesai)
+	}()
+	select {
+	case <-selesai:
+	case <-time.After(time.Second):
+		t.Fatal("Terbitkan memblokir saat buffer pelanggan penuh")
+	}
+
+	got := terima(lambat)
+	if len(got) != 2 || got[0].ID != 4 || got[1].ID != 5 {
+		t.Fatalf("pesan tersisa = %+v, ingin ID 4 dan 5", got)
+	}
+	if lambat.Hilang() != 3 {
+		t.Errorf("Hilang = %d, ingin 3", lambat.Hilang())
+	}
+}
+
+func TestBerhentiMenutupChannel(t *testing.T) {
+	b := Baru(4, 0)
+	p := b.Langgan(nil, 0)
+	lain := b.Langgan(nil, 0)
+
+	b.Berhenti(p)
+	b.Berhenti(p) // aman dipanggil dua kali
+	if n := b.JumlahPelanggan(); n != 1 {
+		t.Fatalf("JumlahPelanggan = %d, ingin 1", n)
+	}
+	if _, ok := <-p.Pesan(); ok {
+		t.Fatal("channel pelanggan yang berhenti masih terbuka")
+	}
+
+	// Pesan berikutnya hanya sampai ke pelanggan yang masih aktif
+	b.Terbitkan(Pesan{Jenis: "uji"})
+	if got := terima(lain); len(got) != 1 {
+		t.Errorf("pelanggan aktif menerima %d pesan, ingin 1", len(got))
+	}
+}
+
+func TestFilterAfdeling(t *testing.T) {
+	b := Baru(8, 0)
+	afd1 := b.Langgan(FilterAfdeling(1), 0)
+	semua := b.Langgan(FilterAfdeling(0), 0)
+
+	b.Terbitkan(Pesan{Jenis: "afd1", AfdelingID: 1})
+	b.Terbitkan(Pesan{Jenis: "afd2", AfdelingID: 2})
+	b.Terbitkan(Pesan{Jenis: "umum"})
+
+	jenis := func(p *Pelanggan) []string {
+		var hasil []string
+		for _, m := range terima(p) {
+			hasil = append(hasil, m.Jenis)
+		}
+		return hasil
+	}
+	if got := jenis(afd1); len(got) != 2 || got[0] != "afd1" || got[1] != "umum" {
+		t.Errorf("pelanggan afdeling 1 menerima %v, ingin [afd1 umum]", got)
+	}
+	if got := jenis(semua); len(got) != 3 {
+		t.Errorf("pelanggan tanpa afdeling menerima %v, ingin 3 pesan", got)
+	}
+}
+
+func TestLanggananMengejarRiwayat(t *testing.T) {
+	b := Baru(8, 3)
+	for i := 0; i < 5; i++ {
+		b.Terbitkan(Pesan{Jenis: "uji"})
+	}
+
+	// Riwayat hanya menyimpan 3 pesan terakhir (ID 3-5)
+	got := terima(b.Langgan(nil, 3))
+	if len(got) != 2 || got[0].ID != 4 || got[1].ID != 5 {
+		t.Fatalf("pesan susulan = %+v, ingin ID 4 dan 5", got)
+	}
+	if got := terima(b.Langgan(nil, 0)); len(got) != 0 {
+		t.Errorf("pelanggan tanpa sejak menerima %d pesan riwayat, ingin 0", len(got))
+	}
+}
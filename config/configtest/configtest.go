@@ -0,0 +1,50 @@
+// Package configtest menyediakan database test untuk paket lain. Dipisah
+// dari config agar paket testing tidak ikut terbawa ke binary server.
+package configtest
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/migrations"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// InitTestDB membuka database SQLite in-memory baru, menerapkan semua
+// migrasi dan memasangnya sebagai config.DB selama test berjalan. Setiap panggilan
+// mendapat database kosong sendiri; DB sebelumnya dikembalikan dan koneksi
+// ditutup saat test selesai.
+func InitTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(config.OpenSQLite(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gagal membuka SQLite in-memory: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("gagal mengambil koneksi SQLite: %v", err)
+	}
+	// Setiap koneksi in-memory adalah database terpisah
+	sqlDB.SetMaxOpenConns(1)
+
+	if _, err := migrations.Up(db, 0); err != nil {
+		sqlDB.Close()
+		t.Fatalf("gagal migrasi database test: %v", err)
+	}
+
+	lama, rahasiaLama := config.DB, config.JWTSecret
+	config.DB = db
+	if len(config.JWTSecret) == 0 {
+		config.JWTSecret = []byte("rahasia-test")
+	}
+	t.Cleanup(func() {
+		config.DB, config.JWTSecret = lama, rahasiaLama
+		sqlDB.Close()
+	})
+	return db
+}
@@ -0,0 +1,32 @@
+package configtest
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/migrations"
+	"app-inputan-ptpn/models"
+	"testing"
+)
+
+func TestInitTestDB(t *testing.T) {
+	db := InitTestDB(t)
+	if config.DB != db {
+		t.Fatal("InitTestDB harus memasang database test sebagai config.DB")
+	}
+	if err := migrations.Verify(db); err != nil {
+		t.Fatalf("skema database test: %v", err)
+	}
+	if !config.IsSQLite() {
+		t.Fatalf("dialek = %s, ingin sqlite", db.Dialector.Name())
+	}
+
+	// Setiap panggilan mendapat database kosong sendiri
+	if err := db.Create(&models.User{Username: "uji", Password: "x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	lain := InitTestDB(t)
+	var n int64
+	lain.Model(&models.User{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("database kedua berisi %d user, ingin kosong", n)
+	}
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"app-inputan-ptpn/migrations"
 	"app-inputan-ptpn/models"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -25,41 +27,74 @@ func InitDB() {
 	}
 	JWTSecret = []byte(secret)
 
-	// Konfigurasi MySQL dari environment variables atau default values
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		dbHost = "localhost"
-	}
+	ConnectDB()
 
-	dbPort := os.Getenv("DB_PORT")
-	if dbPort == "" {
-		dbPort = "3306"
+	// Database in-memory selalu kosong saat start, jadi migrasi langsung diterapkan
+	if IsSQLite() && isSQLiteMemory(os.Getenv("DB_DSN")) {
+		if _, err := migrations.Up(DB, 0); err != nil {
+			log.Fatalf("❌ Gagal migrasi database in-memory: %v", err)
+		}
 	}
 
-	dbUser := os.Getenv("DB_USER")
-	if dbUser == "" {
-		dbUser = "root"
+	// Skema hanya diubah lewat perintah `migrate up`; server cukup memverifikasi versinya
+	log.Println("🔄 Verifying database schema version...")
+	if err := migrations.Verify(DB); err != nil {
+		log.Fatalf("❌ %v\n   Jalankan: go run . migrate up", err)
 	}
+	log.Printf("✓ Database schema is at version %d", migrations.LatestVersion())
 
-	dbPassword := os.Getenv("DB_PASSWORD")
-	if dbPassword == "" {
-		dbPassword = ""
-	}
+	// Create default admin user if not exists
+	createDefaultUser()
+}
 
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "produksi_ptpn"
+// ConnectDB membuka koneksi database tanpa mengubah skema.
+// Dipakai oleh server dan oleh perintah migrate.
+//
+// Driver dipilih dari DB_DRIVER (mysql/sqlite) atau dari bentuk DB_DSN:
+//
+//	DB_DSN=sqlite:./data/ptpn.db     SQLite (file), cocok untuk satu laptop afdeling
+//	DB_DSN=sqlite::memory:           SQLite in-memory untuk pengujian
+//	DB_DSN=user:pass@tcp(host:3306)/db?parseTime=True
+//
+// Tanpa DB_DSN, koneksi MySQL dibangun dari DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME.
+func ConnectDB() {
+	dsn := os.Getenv("DB_DSN")
+	driver := strings.ToLower(os.Getenv("DB_DRIVER"))
+	if driver == "" {
+		driver = "mysql"
+		if isSQLiteDSN(dsn) {
+			driver = "sqlite"
+		}
 	}
 
-	// MySQL DSN format
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+	gormConfig := &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true, // KEY CHANGE
+	}
 
 	var err error
-	// IMPORTANT: Disable foreign key constraint creation by GORM
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		DisableForeignKeyConstraintWhenMigrating: true, // KEY CHANGE
-	})
+	var dialector gorm.Dialector
+	var target string
+
+	switch driver {
+	case "sqlite":
+		if dsn == "" {
+			dsn = "produksi_ptpn.db"
+		}
+		dsn = normalizeSQLiteDSN(dsn)
+		dialector = openSQLite(dsn)
+		target = dsn
+	case "mysql":
+		if dsn == "" {
+			dsn, target = mysqlDSNFromEnv()
+		} else {
+			target = "DB_DSN"
+		}
+		dialector = mysql.Open(dsn)
+	default:
+		log.Fatalf("DB_DRIVER tidak dikenal: %s (gunakan mysql atau sqlite)", driver)
+	}
+
+	DB, err = gorm.Open(dialector, gormConfig)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -71,44 +106,59 @@ func InitDB() {
 	}
 
 	// Set connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	if driver == "sqlite" && isSQLiteMemory(dsn) {
+		// Setiap koneksi in-memory adalah database terpisah
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(10)
+		sqlDB.SetMaxOpenConns(100)
+	}
 
 	// Test connection
 	if err := sqlDB.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
 	}
 
-	log.Println("✓ MySQL database connected successfully")
-	log.Printf("  Database: %s@%s:%s/%s", dbUser, dbHost, dbPort, dbName)
-
-	// Auto migrate tables
-	log.Println("🔄 Migrating database tables...")
-	err = DB.AutoMigrate(
-		// Independent tables (no foreign keys)
-		&models.User{},
-		&models.Upload{},
-		&models.Master{},
-		&models.Peta{},
-		&models.Penyadap{},
-		&models.Mandor{},
-		// Tables with foreign keys
-		&models.Produksi{},
-		&models.Rekap{},
-	)
+	log.Printf("✓ %s database connected successfully", DB.Dialector.Name())
+	log.Printf("  Database: %s", target)
+}
 
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+// mysqlDSNFromEnv membangun DSN MySQL dari environment variables atau default values
+func mysqlDSNFromEnv() (string, string) {
+	dbHost := os.Getenv("DB_HOST")
+	if dbHost == "" {
+		dbHost = "localhost"
 	}
 
-	// Add foreign key constraints manually (jika diperlukan)
-	log.Println("🔄 Adding foreign key constraints...")
-	addForeignKeyConstraints()
+	dbPort := os.Getenv("DB_PORT")
+	if dbPort == "" {
+		dbPort = "3306"
+	}
 
-	// Create default admin user if not exists
-	createDefaultUser()
+	dbUser := os.Getenv("DB_USER")
+	if dbUser == "" {
+		dbUser = "root"
+	}
 
-	log.Println("✓ MySQL database migrated successfully")
+	dbPassword := os.Getenv("DB_PASSWORD")
+	if dbPassword == "" {
+		dbPassword = ""
+	}
+
+	dbName := os.Getenv("DB_NAME")
+	if dbName == "" {
+		dbName = "produksi_ptpn"
+	}
+
+	// MySQL DSN format
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		dbUser, dbPassword, dbHost, dbPort, dbName)
+	return dsn, fmt.Sprintf("%s@%s:%s/%s", dbUser, dbHost, dbPort, dbName)
+}
+
+// IsSQLite bernilai true jika aplikasi berjalan di atas SQLite
+func IsSQLite() bool {
+	return DB != nil && DB.Dialector.Name() == "sqlite"
 }
 
 func GetDB() *gorm.DB {
@@ -141,6 +191,7 @@ func createDefaultUser() {
 		defaultUser := models.User{
 			Username:  "admin",
 			Password:  HashPassword("admin123"),
+			Role:      models.RoleAdmin,
 			LastLogin: time.Now(), // Add valid datetime value
 		}
 
@@ -152,50 +203,3 @@ func createDefaultUser() {
 		}
 	}
 }
-
-// addForeignKeyConstraints adds foreign key constraints manually with proper CASCADE
-func addForeignKeyConstraints() {
-	// Hanya untuk Produksi dan Rekap yang punya FK ke Master
-	constraints := []struct {
-		table      string
-		constraint string
-		sql        string
-	}{
-		{
-			table:      "produksis",
-			constraint: "fk_produksis_master",
-			sql: `ALTER TABLE produksis 
-				  ADD CONSTRAINT fk_produksis_master 
-				  FOREIGN KEY (id_master) REFERENCES masters(id) 
-				  ON DELETE CASCADE ON UPDATE CASCADE`,
-		},
-		{
-			table:      "rekaps",
-			constraint: "fk_rekaps_master",
-			sql: `ALTER TABLE rekaps 
-				  ADD CONSTRAINT fk_rekaps_master 
-				  FOREIGN KEY (id_master) REFERENCES masters(id) 
-				  ON DELETE CASCADE ON UPDATE CASCADE`,
-		},
-	}
-
-	for _, c := range constraints {
-		// Check if constraint exists
-		var count int64
-		DB.Raw(`SELECT COUNT(*) FROM information_schema.table_constraints 
-				WHERE constraint_schema = DATABASE() 
-				AND table_name = ? 
-				AND constraint_name = ?`, c.table, c.constraint).Scan(&count)
-
-		if count == 0 {
-			if err := DB.Exec(c.sql).Error; err != nil {
-				// Log warning but don't fail - mungkin kolom belum ada
-				log.Printf("  ⚠️  Could not add constraint %s: %v", c.constraint, err)
-			} else {
-				log.Printf("  ✓ Added constraint: %s", c.constraint)
-			}
-		} else {
-			log.Printf("  ✓ Constraint exists: %s", c.constraint)
-		}
-	}
-}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteDriverName adalah driver go-sqlite3 dengan fungsi tambahan aplikasi
+const sqliteDriverName = "sqlite3_ptpn"
+
+func init() {
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// DATE() bawaan SQLite mengubah "2025-01-02 00:00:00+07:00" ke UTC
+			// sehingga tanggal bergeser sehari. go-sqlite3 menyimpan waktu dalam
+			// zona lokal, jadi cukup ambil bagian tanggalnya agar query
+			// DATE(kolom) = DATE(?) berperilaku sama seperti di MySQL.
+			return conn.RegisterFunc("date", sqliteDate, true)
+		},
+	})
+}
+
+func sqliteDate(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if len(t) >= 10 {
+			return t[:10]
+		}
+		return t
+	case []byte:
+		if len(t) >= 10 {
+			return string(t[:10])
+		}
+		return string(t)
+	case int64:
+		return time.Unix(t, 0).Format("2006-01-02")
+	case float64:
+		return time.Unix(int64(t), 0).Format("2006-01-02")
+	default:
+		return nil
+	}
+}
+
+// isSQLiteDSN mengenali DSN SQLite: prefix "sqlite:" / "file:", ":memory:",
+// atau nama file berakhiran .db / .sqlite / .sqlite3
+func isSQLiteDSN(dsn string) bool {
+	lower := strings.ToLower(dsn)
+	if strings.HasPrefix(lower, "sqlite:") || strings.HasPrefix(lower, "file:") || lower == ":memory:" {
+		return true
+	}
+	path := lower
+	if i := strings.Index(path, "?"); i != -1 {
+		path = path[:i]
+	}
+	return strings.HasSuffix(path, ".db") || strings.HasSuffix(path, ".sqlite") || strings.HasSuffix(path, ".sqlite3")
+}
+
+// normalizeSQLiteDSN membuang prefix "sqlite:" dan mengaktifkan foreign key
+// serta busy timeout (impor berjalan paralel di background)
+func normalizeSQLiteDSN(dsn string) string {
+	if strings.HasPrefix(strings.ToLower(dsn), "sqlite:") {
+		dsn = dsn[len("sqlite:"):]
+		dsn = strings.TrimPrefix(dsn, "//")
+	}
+
+	params := []string{}
+	if !strings.Contains(dsn, "_foreign_keys") && !strings.Contains(dsn, "_fk=") {
+		params = append(params, "_foreign_keys=on")
+	}
+	if !strings.Contains(dsn, "_busy_timeout") && !strings.Contains(dsn, "_timeout=") {
+		params = append(params, "_busy_timeout=5000")
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(params, "&")
+}
+
+// isSQLiteMemory bernilai true untuk database SQLite in-memory
+func isSQLiteMemory(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// OpenSQLite membuat dialector SQLite dengan driver aplikasi (fungsi date
+// tambahan, foreign key dan busy timeout aktif). Dipakai juga oleh
+// configtest untuk database in-memory.
+func OpenSQLite(dsn string) gorm.Dialector {
+	return openSQLite(normalizeSQLiteDSN(dsn))
+}
+
+func openSQLite(dsn string) gorm.Dialector {
+	return sqlite.New(sqlite.Config{
+		DriverName: sqliteDriverName,
+		DSN:        dsn,
+	})
+}
@@ -0,0 +1,492 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Tabel yang menyimpan afdeling_id beserta nama afdeling, dipakai saat rename
+var afdelingRefTables = []string{"masters", "rekaps", "produksis", "baku_mandors", "baku_details", "peta", "anomalis", "peringatan_selisihs"}
+
+// afdelingPemakaiTables adalah semua tabel dengan kolom afdeling_id, dicek
+// sebelum hapus. Daftar ini harus ikut diperbarui setiap ada tabel baru yang
+// merujuk afdeling.
+var afdelingPemakaiTables = append(afdelingRefTables[:len(afdelingRefTables):len(afdelingRefTables)],
+	"penyadap_mandors", "target_produksis", "ambang_selisihs", "penutupan_haris", "webhooks", "langganan_emails")
+
+type kebunInput struct {
+	Kode  string `json:"kode"`
+	Nama  string `json:"nama"`
+	Aktif *bool  `json:"aktif"`
+}
+
+type afdelingInput struct {
+	KebunID uint   `json:"kebun_id"`
+	Kode    string `json:"kode"`
+	Nama    string `json:"nama"`
+	Aktif   *bool  `json:"aktif"`
+}
+
+// resolveAfdeling memvalidasi input afdeling terhadap master data.
+// wajibAktif dipakai untuk input data baru; pencarian dan laporan tetap
+// menerima afdeling nonaktif agar data lama masih bisa dibuka.
+func resolveAfdeling(input string, wajibAktif bool) (models.Afdeling, error) {
+	afdeling, err := models.FindAfdeling(config.DB, input)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return afdeling, fmt.Errorf("afdeling '%s' tidak terdaftar", input)
+	}
+	if err != nil {
+		return afdeling, err
+	}
+	if wajibAktif && !afdeling.Aktif {
+		return afdeling, fmt.Errorf("afdeling '%s' tidak aktif", afdeling.Nama)
+	}
+	return afdeling, nil
+}
+
+// ================== KEBUN ==================
+
+func GetAllKebun(w http.ResponseWriter, r *http.Request) {
+	var kebuns []models.Kebun
+
+	query := config.DB.Preload("Afdelings", func(db *gorm.DB) *gorm.DB {
+		return db.Order("nama asc")
+	}).Order("nama asc")
+	if aktif := r.URL.Query().Get("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", aktif == "true" || aktif == "1")
+	}
+
+	if err := query.Find(&kebuns).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data kebun: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    kebuns,
+	})
+}
+
+func GetKebunByID(w http.ResponseWriter, r *http.Request) {
+	var kebun models.Kebun
+	if err := config.DB.Preload("Afdelings").First(&kebun, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data kebun tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data kebun berhasil ditemukan",
+		Data:    kebun,
+	})
+}
+
+func CreateKebun(w http.ResponseWriter, r *http.Request) {
+	var input kebunInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	kebun := models.Kebun{
+		Kode:  strings.ToUpper(strings.TrimSpace(input.Kode)),
+		Nama:  strings.TrimSpace(input.Nama),
+		Aktif: input.Aktif == nil || *input.Aktif,
+	}
+	if kebun.Kode == "" || kebun.Nama == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Kode dan nama kebun wajib diisi",
+		})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.Kebun{}).Where("kode = ?", kebun.Kode).Count(&count)
+	if count > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Kode kebun " + kebun.Kode + " sudah digunakan",
+		})
+		return
+	}
+
+	if err := config.DB.Create(&kebun).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan data kebun: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Data kebun berhasil ditambahkan",
+		Data:    kebun,
+	})
+}
+
+func UpdateKebun(w http.ResponseWriter, r *http.Request) {
+	var kebun models.Kebun
+	if err := config.DB.First(&kebun, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data kebun tidak ditemukan",
+		})
+		return
+	}
+
+	var input kebunInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if kode := strings.ToUpper(strings.TrimSpace(input.Kode)); kode != "" && kode != kebun.Kode {
+		var count int64
+		config.DB.Model(&models.Kebun{}).Where("kode = ? AND id <> ?", kode, kebun.ID).Count(&count)
+		if count > 0 {
+			respondJSON(w, http.StatusConflict, APIResponse{
+				Success: false,
+				Message: "Kode kebun " + kode + " sudah digunakan",
+			})
+			return
+		}
+		kebun.Kode = kode
+	}
+	if nama := strings.TrimSpace(input.Nama); nama != "" {
+		kebun.Nama = nama
+	}
+	if input.Aktif != nil {
+		kebun.Aktif = *input.Aktif
+	}
+
+	if err := config.DB.Save(&kebun).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal update kebun: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data kebun berhasil diperbarui",
+		Data:    kebun,
+	})
+}
+
+func DeleteKebun(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "ID tidak valid",
+		})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.Afdeling{}).Where("kebun_id = ?", id).Count(&count)
+	if count > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Kebun masih memiliki %d afdeling. Hapus afdelingnya atau nonaktifkan kebun", count),
+		})
+		return
+	}
+
+	result := config.DB.Delete(&models.Kebun{}, id)
+	if result.Error != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghapus data kebun: " + result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data kebun tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data kebun berhasil dihapus",
+	})
+}
+
+// ================== AFDELING ==================
+
+// GetAllAfdeling mendukung filter kebun_id dan aktif, dipakai juga untuk
+// mengisi pilihan afdeling di halaman upload dan visualisasi
+func GetAllAfdeling(w http.ResponseWriter, r *http.Request) {
+	var afdelings []models.Afdeling
+
+	query := config.DB.Preload("Kebun").Order("nama asc")
+	if kebunID := r.URL.Query().Get("kebun_id"); kebunID != "" {
+		query = query.Where("kebun_id = ?", kebunID)
+	}
+	if aktif := r.URL.Query().Get("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", aktif == "true" || aktif == "1")
+	}
+
+	if err := query.Find(&afdelings).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data afdeling: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    afdelings,
+	})
+}
+
+func GetAfdelingByID(w http.ResponseWriter, r *http.Request) {
+	var afdeling models.Afdeling
+	if err := config.DB.Preload("Kebun").First(&afdeling, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data afdeling tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data afdeling berhasil ditemukan",
+		Data:    afdeling,
+	})
+}
+
+func CreateAfdeling(w http.ResponseWriter, r *http.Request) {
+	var input afdelingInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	afdeling := models.Afdeling{
+		KebunID: input.KebunID,
+		Kode:    models.NormalizeAfdelingKode(input.Kode),
+		Nama:    strings.TrimSpace(input.Nama),
+		Aktif:   input.Aktif == nil || *input.Aktif,
+	}
+	if afdeling.Kode == "" {
+		afdeling.Kode = models.NormalizeAfdelingKode(afdeling.Nama)
+	}
+	if afdeling.KebunID == 0 || afdeling.Nama == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "kebun_id dan nama afdeling wajib diisi",
+		})
+		return
+	}
+
+	var kebun models.Kebun
+	if err := config.DB.First(&kebun, afdeling.KebunID).Error; err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Kebun tidak ditemukan",
+		})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.Afdeling{}).Where("kode = ?", afdeling.Kode).Count(&count)
+	if count > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Kode afdeling " + afdeling.Kode + " sudah digunakan",
+		})
+		return
+	}
+
+	if err := config.DB.Create(&afdeling).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan data afdeling: " + err.Error(),
+		})
+		return
+	}
+	afdeling.Kebun = &kebun
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Data afdeling berhasil ditambahkan",
+		Data:    afdeling,
+	})
+}
+
+// UpdateAfdeling juga menyalin nama baru ke kolom afdeling di tabel data
+// agar laporan yang masih membaca kolom teks tetap konsisten
+func UpdateAfdeling(w http.ResponseWriter, r *http.Request) {
+	var afdeling models.Afdeling
+	if err := config.DB.First(&afdeling, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data afdeling tidak ditemukan",
+		})
+		return
+	}
+
+	var input afdelingInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if input.KebunID != 0 && input.KebunID != afdeling.KebunID {
+		var kebun models.Kebun
+		if err := config.DB.First(&kebun, input.KebunID).Error; err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Kebun tidak ditemukan",
+			})
+			return
+		}
+		afdeling.KebunID = input.KebunID
+	}
+	if kode := models.NormalizeAfdelingKode(input.Kode); kode != "" && kode != afdeling.Kode {
+		var count int64
+		config.DB.Model(&models.Afdeling{}).Where("kode = ? AND id <> ?", kode, afdeling.ID).Count(&count)
+		if count > 0 {
+			respondJSON(w, http.StatusConflict, APIResponse{
+				Success: false,
+				Message: "Kode afdeling " + kode + " sudah digunakan",
+			})
+			return
+		}
+		afdeling.Kode = kode
+	}
+	namaChanged := false
+	if nama := strings.TrimSpace(input.Nama); nama != "" && nama != afdeling.Nama {
+		afdeling.Nama = nama
+		namaChanged = true
+	}
+	if input.Aktif != nil {
+		afdeling.Aktif = *input.Aktif
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&afdeling).Error; err != nil {
+			return err
+		}
+		if !namaChanged {
+			return nil
+		}
+		for _, table := range afdelingRefTables {
+			if err := tx.Table(table).Where("afdeling_id = ?", afdeling.ID).
+				UpdateColumn("afdeling", afdeling.Nama).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal update afdeling: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data afdeling berhasil diperbarui",
+		Data:    afdeling,
+	})
+}
+
+// DeleteAfdeling hanya untuk afdeling yang belum dipakai data apa pun;
+// afdeling yang sudah punya data cukup dinonaktifkan
+func DeleteAfdeling(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "ID tidak valid",
+		})
+		return
+	}
+
+	pemakai := make(map[string]int64)
+	var rincian []string
+	for _, table := range afdelingPemakaiTables {
+		var count int64
+		if err := config.DB.Table(table).Where("afdeling_id = ?", id).Count(&count).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Gagal memeriksa pemakaian afdeling: " + err.Error(),
+			})
+			return
+		}
+		if count > 0 {
+			pemakai[table] = count
+			rincian = append(rincian, fmt.Sprintf("%s (%d)", table, count))
+		}
+	}
+	if len(pemakai) > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Afdeling masih dipakai di " + strings.Join(rincian, ", ") + ". Nonaktifkan afdeling sebagai gantinya",
+			Data:    pemakai,
+		})
+		return
+	}
+
+	result := config.DB.Delete(&models.Afdeling{}, id)
+	if result.Error != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghapus data afdeling: " + result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data afdeling tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data afdeling berhasil dihapus",
+	})
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config/configtest"
+	"app-inputan-ptpn/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func hapusAfdeling(t *testing.T, id uint, data interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodDelete, "/api/afdeling/"+strconv.Itoa(int(id)), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(int(id))})
+	return panggilHandler(t, DeleteAfdeling, req, data)
+}
+
+// Afdeling yang dirujuk pengaturan (webhook, ambang selisih) tidak boleh
+// terhapus, baik lewat API maupun langsung di database
+func TestDeleteAfdelingMasihDipakai(t *testing.T) {
+	db := configtest.InitTestDB(t)
+
+	var kebun models.Kebun
+	if err := db.First(&kebun).Error; err != nil {
+		t.Fatal(err)
+	}
+	afd := models.Afdeling{KebunID: kebun.ID, Kode: "UJI", Nama: "Uji", Aktif: true}
+	if err := db.Create(&afd).Error; err != nil {
+		t.Fatal(err)
+	}
+	webhook := models.Webhook{Nama: "ERP", URL: "http://erp.local", Rahasia: "rahasia-webhook-uji-123", Jenis: "*", AfdelingID: &afd.ID, Aktif: true}
+	ambang := models.AmbangSelisih{AfdelingID: &afd.ID, BatasLatekPersen: 3}
+	if err := db.Create(&webhook).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&ambang).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var pemakai map[string]int64
+	if code := hapusAfdeling(t, afd.ID, &pemakai); code != http.StatusConflict {
+		t.Fatalf("hapus afdeling terpakai = %d, ingin 409", code)
+	}
+	if pemakai["webhooks"] != 1 || pemakai["ambang_selisihs"] != 1 || len(pemakai) != 2 {
+		t.Errorf("rincian pemakai = %v, ingin webhooks 1 dan ambang_selisihs 1", pemakai)
+	}
+	var n int64
+	db.Model(&models.Webhook{}).Where("afdeling_id = ?", afd.ID).Count(&n)
+	if n != 1 {
+		t.Fatalf("webhook ikut terhapus")
+	}
+
+	// Foreign key RESTRICT menolak penghapusan langsung
+	if err := db.Delete(&models.Afdeling{}, afd.ID).Error; err == nil {
+		t.Fatal("database mengizinkan hapus afdeling yang dirujuk ambang selisih")
+	}
+
+	// Setelah tidak dipakai, afdeling bisa dihapus
+	db.Delete(&webhook)
+	db.Delete(&ambang)
+	if code := hapusAfdeling(t, afd.ID, nil); code != http.StatusOK {
+		t.Fatalf("hapus afdeling tidak terpakai = %d, ingin 200", code)
+	}
+}
@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/models"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Parameter analisis anomali
+const (
+	anomaliJendelaHari  = 60  // rentang hari ke belakang untuk baseline
+	anomaliMaksBaseline = 30  // jumlah hari data terakhir yang dipakai sebagai baseline
+	anomaliMinBaseline  = 5   // baseline lebih pendek dari ini tidak dinilai
+	anomaliBatasSkor    = 3.5 // batas robust z-score (Iglewicz-Hoaglin)
+	anomaliSkalaMin     = 1.0 // skala minimum (kg) agar variasi kecil tidak ditandai
+	anomaliSkalaPersen  = 0.05
+
+	// Rentang K3 (kering / basah * 100) yang dianggap wajar
+	k3SheetMin = 20.0
+	k3SheetMax = 45.0
+	k3BrCrMin  = 40.0
+	k3BrCrMax  = 90.0
+)
+
+// Hanya satu analisis boleh berjalan; job malam dan pemicu manual bisa
+// bertabrakan pada tanggal yang sama
+var kunciAnalisisAnomali sync.Mutex
+
+// HasilAnalisisAnomali adalah ringkasan satu kali analisis
+type HasilAnalisisAnomali struct {
+	TanggalAwal  string `json:"tanggal_awal"`
+	TanggalAkhir string `json:"tanggal_akhir"`
+	Temuan       int    `json:"temuan"`
+	Baru         int    `json:"baru"`
+	Diperbarui   int    `json:"diperbarui"`
+	Dipulihkan   int    `json:"selesai_otomatis"`
+}
+
+// ukuranAnomali adalah satu nilai yang dinilai terhadap baseline
+type ukuranAnomali struct {
+	Nama  string
+	Label string
+	Ambil func(v [4]float64) float64
+}
+
+// Nilai harian disimpan sebagai [basah latek, basah lump, kering sheet, kering br/cr]
+var ukuranLonjakan = []ukuranAnomali{
+	{"basah_latek", "Basah latek", func(v [4]float64) float64 { return v[0] }},
+	{"basah_lump", "Basah lump", func(v [4]float64) float64 { return v[1] }},
+	{"kering", "Kering", func(v [4]float64) float64 { return v[2] + v[3] }},
+}
+
+func nilaiHarianProduksi(p models.Produksi) [4]float64 {
+	return [4]float64{p.BasahLatek, p.BasahLump, p.Sheet, p.BrCr}
+}
+
+func nilaiHarianRekap(r models.Rekap) [4]float64 {
+	return [4]float64{r.HariIniBasahLatekKebun, r.HariIniBasahLumpKebun, r.HariIniKeringSheet, r.HariIniKeringBrCr}
+}
+
+func nilaiKosong(v [4]float64) bool {
+	return v[0] == 0 && v[1] == 0 && v[2] == 0 && v[3] == 0
+}
+
+func median(nilai []float64) float64 {
+	s := append([]float64(nil), nilai...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// skorRobust menghitung robust z-score x terhadap baseline memakai median
+// dan MAD. Jika MAD nol dipakai rata-rata simpangan absolut, dan skala tidak
+// pernah lebih kecil dari anomaliSkalaMin atau 5% median.
+func skorRobust(x float64, baseline []float64) (skor, med float64) {
+	med = median(baseline)
+	simpangan := make([]float64, len(baseline))
+	rata := 0.0
+	for i, v := range baseline {
+		simpangan[i] = math.Abs(v - med)
+		rata += simpangan[i]
+	}
+	skala := 1.4826 * median(simpangan)
+	if skala == 0 {
+		skala = 1.2533 * rata / float64(len(baseline))
+	}
+	skala = max(skala, anomaliSkalaMin, anomaliSkalaPersen*math.Abs(med))
+	return (x - med) / skala, med
+}
+
+// kunciAnomali membentuk kunci unik temuan: jenis|sumber|ref|ukuran
+func kunciAnomali(jenis, sumber string, ref uint, ukuran string) string {
+	return fmt.Sprintf("%s|%s|%d|%s", jenis, sumber, ref, ukuran)
+}
+
+// periksaLonjakan menilai setiap ukuran terhadap baseline dan mengisi
+// temuan dari template dasar
+func periksaLonjakan(dasar models.Anomali, v [4]float64, baseline [][4]float64) []models.Anomali {
+	if len(baseline) < anomaliMinBaseline {
+		return nil
+	}
+	var hasil []models.Anomali
+	for _, u := range ukuranLonjakan {
+		seri := make([]float64, len(baseline))
+		for i, b := range baseline {
+			seri[i] = u.Ambil(b)
+		}
+		x := u.Ambil(v)
+		skor, med := skorRobust(x, seri)
+		if math.Abs(skor) <= anomaliBatasSkor {
+			continue
+		}
+		arah := "di atas"
+		if skor < 0 {
+			arah = "di bawah"
+		}
+		t := dasar
+		t.Jenis = models.AnomaliLonjakan
+		t.Ukuran = u.Nama
+		t.Nilai = roundTo(x, 2)
+		t.Acuan = roundTo(med, 2)
+		t.Skor = roundTo(skor, 2)
+		t.Pesan = fmt.Sprintf("%s %.2f kg jauh %s median %d hari terakhir (%.2f kg)", u.Label, x, arah, len(baseline), med)
+		hasil = append(hasil, t)
+	}
+	return hasil
+}
+
+// periksaK3 menandai rasio kering/basah di luar rentang wajar. Rasio hanya
+// dinilai jika basah dan kering sama-sama terisi.
+func periksaK3(dasar models.Anomali, basahLatek, sheet, basahLump, brCr float64) []models.Anomali {
+	var hasil []models.Anomali
+	cek := func(ukuran, label string, kering, basah, bawah, atas float64) {
+		if basah <= 0 || kering <= 0 {
+			return
+		}
+		k3 := kering / basah * 100
+		if k3 >= bawah && k3 <= atas {
+			return
+		}
+		acuan, skor := bawah, k3-bawah
+		if k3 > atas {
+			acuan, skor = atas, k3-atas
+		}
+		t := dasar
+		t.Jenis = models.AnomaliRasioK3
+		t.Ukuran = ukuran
+		t.Nilai = roundTo(k3, 2)
+		t.Acuan = acuan
+		t.Skor = roundTo(skor, 2)
+		t.Pesan = fmt.Sprintf("%s %.2f%% di luar rentang wajar %.0f-%.0f%%", label, k3, bawah, atas)
+		hasil = append(hasil, t)
+	}
+	cek("k3_sheet", "K3 sheet", sheet, basahLatek, k3SheetMin, k3SheetMax)
+	cek("k3_br_cr", "K3 br/cr", brCr, basahLump, k3BrCrMin, k3BrCrMax)
+	return hasil
+}
+
+// kunciMandorProduksi menyamakan baris produksi dengan baris rekap mandornya
+func kunciMandorProduksi(mandor, tipe, tahunTanam string) string {
+	return strings.ToUpper(strings.TrimSpace(mandor)) + "|" + tipe + "|" + strings.TrimSpace(tahunTanam)
+}
+
+// deteksiAnomaliTanggal menjalankan semua pemeriksaan untuk satu tanggal
+func deteksiAnomaliTanggal(db *gorm.DB, tanggal time.Time) ([]models.Anomali, error) {
+	hari := tanggal.Format("2006-01-02")
+	awalBaseline := tanggal.AddDate(0, 0, -anomaliJendelaHari).Format("2006-01-02")
+	akhirBaseline := tanggal.AddDate(0, 0, -1).Format("2006-01-02")
+	var temuan []models.Anomali
+
+	// ---- Penyadap (produksi) ----
+	var produksi []models.Produksi
+	if err := db.Where("DATE(tanggal) = ?", hari).Order("id asc").Find(&produksi).Error; err != nil {
+		return nil, err
+	}
+
+	nikPenyadap := make([]string, 0, len(produksi))
+	for _, p := range produksi {
+		nikPenyadap = append(nikPenyadap, p.NIK)
+	}
+	baselinePenyadap := make(map[string][][4]float64)
+	if len(nikPenyadap) > 0 {
+		var riwayat []models.Produksi
+		if err := db.Where("nik IN ? AND DATE(tanggal) BETWEEN ? AND ?", nikPenyadap, awalBaseline, akhirBaseline).
+			Order("tanggal desc").Find(&riwayat).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range riwayat {
+			v := nilaiHarianProduksi(p)
+			k := p.NIK + "|" + p.TipeProduksi
+			if nilaiKosong(v) || len(baselinePenyadap[k]) >= anomaliMaksBaseline {
+				continue
+			}
+			baselinePenyadap[k] = append(baselinePenyadap[k], v)
+		}
+	}
+
+	// Penyadap yang berproduksi per mandor, untuk pemeriksaan HKO
+	penyadapMandor := make(map[string]map[string]bool)
+	for _, p := range produksi {
+		v := nilaiHarianProduksi(p)
+		if nilaiKosong(v) {
+			continue
+		}
+		km := kunciMandorProduksi(p.Mandor, p.TipeProduksi, p.TahunTanam)
+		if penyadapMandor[km] == nil {
+			penyadapMandor[km] = make(map[string]bool)
+		}
+		penyadapMandor[km][p.NIK] = true
+
+		dasar := models.Anomali{
+			Tanggal:      p.Tanggal,
+			Sumber:       sumberDataProduksi,
+			RefID:        p.ID,
+			NIK:          p.NIK,
+			Nama:         p.NamaPenyadap,
+			Mandor:       p.Mandor,
+			TipeProduksi: p.TipeProduksi,
+			Afdeling:     p.Afdeling,
+			AfdelingID:   p.AfdelingID,
+		}
+		temuan = append(temuan, periksaLonjakan(dasar, v, baselinePenyadap[p.NIK+"|"+p.TipeProduksi])...)
+		temuan = append(temuan, periksaK3(dasar, p.BasahLatek, p.Sheet, p.BasahLump, p.BrCr)...)
+	}
+
+	// ---- Mandor (rekap) ----
+	var rekaps []models.Rekap
+	if err := db.Where("DATE(tanggal) = ? AND tipe_produksi != ?", hari, "REKAPITULASI").
+		Order("id asc").Find(&rekaps).Error; err != nil {
+		return nil, err
+	}
+
+	nikMandor := make([]string, 0, len(rekaps))
+	for _, r := range rekaps {
+		nikMandor = append(nikMandor, r.NIK)
+	}
+	baselineMandor := make(map[string][][4]float64)
+	if len(nikMandor) > 0 {
+		var riwayat []models.Rekap
+		if err := db.Where("nik IN ? AND tipe_produksi != ? AND DATE(tanggal) BETWEEN ? AND ?", nikMandor, "REKAPITULASI", awalBaseline, akhirBaseline).
+			Order("tanggal desc").Find(&riwayat).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range riwayat {
+			v := nilaiHarianRekap(r)
+			k := r.NIK + "|" + r.TipeProduksi + "|" + r.TahunTanam
+			if nilaiKosong(v) || len(baselineMandor[k]) >= anomaliMaksBaseline {
+				continue
+			}
+			baselineMandor[k] = append(baselineMandor[k], v)
+		}
+	}
+
+	for _, r := range rekaps {
+		dasar := models.Anomali{
+			Tanggal:      r.Tanggal,
+			Sumber:       sumberDataRekap,
+			RefID:        r.ID,
+			NIK:          r.NIK,
+			Nama:         r.Mandor,
+			Mandor:       r.Mandor,
+			TipeProduksi: r.TipeProduksi,
+			Afdeling:     r.Afdeling,
+			AfdelingID:   r.AfdelingID,
+		}
+
+		v := nilaiHarianRekap(r)
+		if !nilaiKosong(v) {
+			temuan = append(temuan, periksaLonjakan(dasar, v, baselineMandor[r.NIK+"|"+r.TipeProduksi+"|"+r.TahunTanam])...)
+			temuan = append(temuan, periksaK3(dasar, r.HariIniBasahLatekPabrik, r.HariIniKeringSheet, r.HariIniBasahLumpPabrik, r.HariIniKeringBrCr)...)
+		}
+
+		// HKO hanya bisa dicek jika data penyadap mandor tersebut sudah masuk
+		jumlah := len(penyadapMandor[kunciMandorProduksi(r.Mandor, r.TipeProduksi, r.TahunTanam)])
+		if jumlah > 0 && r.HKOHariIni != jumlah {
+			t := dasar
+			t.Jenis = models.AnomaliHKO
+			t.Ukuran = "hko"
+			t.Nilai = float64(r.HKOHariIni)
+			t.Acuan = float64(jumlah)
+			t.Skor = float64(r.HKOHariIni - jumlah)
+			t.Pesan = fmt.Sprintf("HKO rekap %d, sedangkan penyadap yang berproduksi %d", r.HKOHariIni, jumlah)
+			temuan = append(temuan, t)
+		}
+	}
+
+	for i := range temuan {
+		temuan[i].Kunci = kunciAnomali(temuan[i].Jenis, temuan[i].Sumber, temuan[i].RefID, temuan[i].Ukuran)
+		temuan[i].Status = models.StatusAnomaliBaru
+	}
+	return temuan, nil
+}
+
+// simpanAnomaliTanggal memasukkan temuan ke antrian. Temuan yang sudah ada
+// dan masih BARU diperbarui nilainya; yang sudah ditinjau tidak disentuh.
+// Temuan BARU pada tanggal itu yang tidak terdeteksi lagi (data sudah
+// dikoreksi) diselesaikan otomatis.
+func simpanAnomaliTanggal(db *gorm.DB, tanggal time.Time, temuan []models.Anomali, hasil *HasilAnalisisAnomali) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		kunci := make([]string, 0, len(temuan))
+		for _, t := range temuan {
+			kunci = append(kunci, t.Kunci)
+
+			var lama models.Anomali
+			err := tx.Where("kunci = ?", t.Kunci).First(&lama).Error
+			if err == gorm.ErrRecordNotFound {
+				if err := tx.Create(&t).Error; err != nil {
+					return err
+				}
+				hasil.Baru++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if lama.Status == models.StatusAnomaliBaru {
+				if err := tx.Model(&lama).Updates(map[string]interface{}{
+					"nilai": t.Nilai,
+					"acuan": t.Acuan,
+					"skor":  t.Skor,
+					"pesan": t.Pesan,
+				}).Error; err != nil {
+					return err
+				}
+				hasil.Diperbarui++
+			}
+		}
+
+		sekarang := time.Now()
+		query := tx.Model(&models.Anomali{}).
+			Where("DATE(tanggal) = ? AND status = ?", tanggal.Format("2006-01-02"), models.StatusAnomaliBaru)
+		if len(kunci) > 0 {
+			query = query.Where("kunci NOT IN ?", kunci)
+		}
+		res := query.Updates(map[string]interface{}{
+			"status":            models.StatusAnomaliSelesai,
+			"catatan":           "Tidak terdeteksi lagi pada analisis ulang",
+			"ditinjau_oleh":     "sistem",
+			"diselesaikan_pada": sekarang,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		hasil.Dipulihkan += int(res.RowsAffected)
+		return nil
+	})
+}
+
+// jalankanAnalisisAnomali menganalisis setiap tanggal dari awal sampai akhir
+// (inklusif). Aman dijalankan ulang untuk tanggal yang sama.
+func jalankanAnalisisAnomali(db *gorm.DB, awal, akhir time.Time) (HasilAnalisisAnomali, error) {
+	kunciAnalisisAnomali.Lock()
+	defer kunciAnalisisAnomali.Unlock()
+
+	hasil := HasilAnalisisAnomali{
+		TanggalAwal:  awal.Format("2006-01-02"),
+		TanggalAkhir: akhir.Format("2006-01-02"),
+	}
+	for t := awal; !t.After(akhir); t = t.AddDate(0, 0, 1) {
+		temuan, err := deteksiAnomaliTanggal(db, t)
+		if err != nil {
+			return hasil, fmt.Errorf("analisis %s: %w", t.Format("2006-01-02"), err)
+		}
+		if err := simpanAnomaliTanggal(db, t, temuan, &hasil); err != nil {
+			return hasil, fmt.Errorf("simpan temuan %s: %w", t.Format("2006-01-02"), err)
+		}
+		hasil.Temuan += len(temuan)
+	}
+	return hasil, nil
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/models"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSkorRobust(t *testing.T) {
+	tests := []struct {
+		nama     string
+		x        float64
+		baseline []float64
+		skor     float64
+		med      float64
+	}{
+		// MAD 4 -> skala 1.4826*4
+		{"MAD", 40, []float64{10, 12, 14, 16, 18, 20, 22}, 24 / (1.4826 * 4), 16},
+		{"MAD arah bawah", 4, []float64{10, 12, 14, 16, 18, 20, 22}, -12 / (1.4826 * 4), 16},
+		// MAD 1.5 lebih kecil dari 5% median, jadi skala 5
+		{"skala minimal persen median", 130, []float64{100, 102, 98, 101, 99, 100, 103, 97}, 6, 100},
+		// Lebih dari separuh nilai sama: MAD 0, pakai rata-rata simpangan absolut
+		{"MAD nol", 100, []float64{50, 50, 50, 50, 50, 80, 20}, 50 / (1.2533 * 60 / 7), 50},
+		// Deret konstan: skala jatuh ke 5% median
+		{"deret konstan", 50, []float64{40, 40, 40, 40, 40}, 5, 40},
+		// Nilai kecil: skala tidak kurang dari 1 kg
+		{"skala minimal 1 kg", 3, []float64{0.2, 0.2, 0.2, 0.2, 0.2}, 2.8, 0.2},
+		{"jumlah genap", 10, []float64{0, 10, 20, 30}, -5.0 / 14.826, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			skor, med := skorRobust(tt.x, tt.baseline)
+			if math.Abs(skor-tt.skor) > 1e-9 || med != tt.med {
+				t.Errorf("skorRobust = %.6f, median %.2f; ingin %.6f, %.2f", skor, med, tt.skor, tt.med)
+			}
+		})
+	}
+}
+
+func TestPeriksaLonjakan(t *testing.T) {
+	seri := func(nilai ...float64) [][4]float64 {
+		hasil := make([][4]float64, len(nilai))
+		for i, v := range nilai {
+			hasil[i] = [4]float64{v, 0, 0, 0}
+		}
+		return hasil
+	}
+	// Median 100, skala 5 (5% median): batas 3.5 berarti 117.5 / 82.5
+	normal := seri(100, 102, 98, 101, 99, 100, 103, 97)
+
+	tests := []struct {
+		nama     string
+		nilai    float64
+		baseline [][4]float64
+		skor     float64 // 0 berarti tidak ada temuan
+		arah     string
+	}{
+		{"lonjakan", 130, normal, 6, "di atas"},
+		{"penurunan", 70, normal, -6, "di bawah"},
+		{"tepat di batas", 117.5, normal, 0, ""},
+		{"sedikit di atas batas", 118, normal, 3.6, "di atas"},
+		{"masih wajar", 110, normal, 0, ""},
+		{"deret konstan", 60, seri(50, 50, 50, 50, 50), 4, "di atas"},
+		{"baseline terlalu pendek", 500, seri(100, 100, 100, 100), 0, ""},
+		{"baseline kosong", 500, nil, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			temuan := periksaLonjakan(models.Anomali{NIK: "P001"}, [4]float64{tt.nilai, 0, 0, 0}, tt.baseline)
+			if tt.skor == 0 {
+				if len(temuan) != 0 {
+					t.Fatalf("temuan = %+v, ingin kosong", temuan)
+				}
+				return
+			}
+			if len(temuan) != 1 {
+				t.Fatalf("%d temuan, ingin 1: %+v", len(temuan), temuan)
+			}
+			a := temuan[0]
+			if a.Jenis != models.AnomaliLonjakan || a.Ukuran != "basah_latek" || a.NIK != "P001" {
+				t.Errorf("temuan = %s/%s (NIK %q)", a.Jenis, a.Ukuran, a.NIK)
+			}
+			if a.Skor != tt.skor || a.Nilai != tt.nilai || !strings.Contains(a.Pesan, tt.arah) {
+				t.Errorf("skor %.2f nilai %.2f pesan %q; ingin skor %.2f nilai %.2f %s", a.Skor, a.Nilai, a.Pesan, tt.skor, tt.nilai, tt.arah)
+			}
+		})
+	}
+}
+
+func TestPeriksaK3(t *testing.T) {
+	tests := []struct {
+		nama                               string
+		basahLatek, sheet, basahLump, brCr float64
+		ingin                              map[string][2]float64 // ukuran -> {acuan, skor}
+	}{
+		{"wajar", 100, 30, 100, 60, nil},
+		{"batas bawah dan atas masih wajar", 100, 20, 100, 90, nil},
+		{"tepat batas atas sheet", 80, 36, 100, 40, nil},
+		{"sheet terlalu rendah", 100, 10, 0, 0, map[string][2]float64{"k3_sheet": {k3SheetMin, -10}}},
+		{"sheet terlalu tinggi", 100, 50, 0, 0, map[string][2]float64{"k3_sheet": {k3SheetMax, 5}}},
+		{"br/cr terlalu tinggi", 0, 0, 50, 50, map[string][2]float64{"k3_br_cr": {k3BrCrMax, 10}}},
+		{"keduanya", 100, 60, 100, 30, map[string][2]float64{"k3_sheet": {k3SheetMax, 15}, "k3_br_cr": {k3BrCrMin, -10}}},
+		// Rasio hanya dinilai jika basah dan kering sama-sama terisi
+		{"kering belum diisi", 100, 0, 100, 0, nil},
+		{"basah kosong", 0, 30, 0, 60, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			temuan := periksaK3(models.Anomali{}, tt.basahLatek, tt.sheet, tt.basahLump, tt.brCr)
+			if len(temuan) != len(tt.ingin) {
+				t.Fatalf("%d temuan, ingin %d: %+v", len(temuan), len(tt.ingin), temuan)
+			}
+			for _, a := range temuan {
+				ingin, ok := tt.ingin[a.Ukuran]
+				if !ok || a.Jenis != models.AnomaliRasioK3 {
+					t.Errorf("temuan tak terduga %s/%s", a.Jenis, a.Ukuran)
+					continue
+				}
+				if a.Acuan != ingin[0] || a.Skor != ingin[1] {
+					t.Errorf("%s acuan %.2f skor %.2f, ingin %.2f %.2f", a.Ukuran, a.Acuan, a.Skor, ingin[0], ingin[1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	anomaliDefaultLimit = 200
+	anomaliMaxLimit     = 1000
+	maksHariAnalisis    = 92 // batas rentang analisis manual
+)
+
+// MulaiJobAnomali menjalankan analisis anomali setiap malam. Jam dibaca dari
+// ANOMALI_JAM (HH:MM, default 01:00) dan setiap putaran menganalisis ulang
+// ANOMALI_HARI_MUNDUR hari terakhir (default 3) agar data yang terlambat
+// diunggah tetap diperiksa. ANOMALI_JOB=off mematikan job.
+func MulaiJobAnomali() {
+	if strings.EqualFold(os.Getenv("ANOMALI_JOB"), "off") {
+		log.Println("⚠️  Job analisis anomali dimatikan (ANOMALI_JOB=off)")
+		return
+	}
+
+	jam, menit := 1, 0
+	if v := os.Getenv("ANOMALI_JAM"); v != "" {
+		t, err := time.Parse("15:04", v)
+		if err != nil {
+			log.Printf("⚠️  ANOMALI_JAM '%s' tidak valid, memakai 01:00", v)
+		} else {
+			jam, menit = t.Hour(), t.Minute()
+		}
+	}
+	mundur := 3
+	if v := os.Getenv("ANOMALI_HARI_MUNDUR"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= maksHariAnalisis {
+			mundur = n
+		}
+	}
+
+	go func() {
+		for {
+			sekarang := time.Now()
+			berikut := time.Date(sekarang.Year(), sekarang.Month(), sekarang.Day(), jam, menit, 0, 0, sekarang.Location())
+			if !berikut.After(sekarang) {
+				berikut = berikut.AddDate(0, 0, 1)
+			}
+			time.Sleep(time.Until(berikut))
+
+			hariIni := time.Date(berikut.Year(), berikut.Month(), berikut.Day(), 0, 0, 0, 0, time.UTC)
+			hasil, err := jalankanAnalisisAnomali(config.GetDB(), hariIni.AddDate(0, 0, -mundur), hariIni.AddDate(0, 0, -1))
+			if err != nil {
+				log.Printf("❌ Analisis anomali gagal: %v", err)
+				continue
+			}
+			log.Printf("✓ Analisis anomali %s s/d %s: %d temuan (%d baru, %d selesai otomatis)",
+				hasil.TanggalAwal, hasil.TanggalAkhir, hasil.Temuan, hasil.Baru, hasil.Dipulihkan)
+		}
+	}()
+	log.Printf("✓ Job analisis anomali dijadwalkan setiap hari pukul %02d:%02d", jam, menit)
+}
+
+// GetAllAnomali menampilkan antrian temuan. Filter opsional: status (BARU,
+// DIAKUI, SELESAI atau "terbuka" untuk BARU+DIAKUI), jenis, sumber,
+// afdeling, nik, tanggalAwal, tanggalAkhir dan limit.
+func GetAllAnomali(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := config.DB.Model(&models.Anomali{})
+
+	switch status := strings.ToUpper(q.Get("status")); status {
+	case "":
+	case "TERBUKA":
+		query = query.Where("status IN ?", []string{models.StatusAnomaliBaru, models.StatusAnomaliDiakui})
+	case models.StatusAnomaliBaru, models.StatusAnomaliDiakui, models.StatusAnomaliSelesai:
+		query = query.Where("status = ?", status)
+	default:
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter status tidak valid. Gunakan: BARU, DIAKUI, SELESAI, atau terbuka",
+		})
+		return
+	}
+
+	if jenis := strings.ToUpper(q.Get("jenis")); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	if sumber := q.Get("sumber"); sumber != "" {
+		query = query.Where("sumber = ?", sumber)
+	}
+	if nik := q.Get("nik"); nik != "" {
+		query = query.Where("nik = ?", nik)
+	}
+	if afdeling := q.Get("afdeling"); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
+	}
+	if v := q.Get("tanggalAwal"); v != "" {
+		query = query.Where("DATE(tanggal) >= ?", v)
+	}
+	if v := q.Get("tanggalAkhir"); v != "" {
+		query = query.Where("DATE(tanggal) <= ?", v)
+	}
+
+	limit := anomaliDefaultLimit
+	if v := q.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= anomaliMaxLimit {
+			limit = l
+		}
+	}
+
+	var list []models.Anomali
+	if err := query.Order("tanggal desc, id desc").Limit(limit).Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data anomali: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d temuan anomali", len(list)),
+		Data:    list,
+	})
+}
+
+// JalankanAnalisisAnomali memicu analisis secara manual untuk rentang
+// tanggal, misalnya setelah koreksi data. Body: {"tanggalAwal", "tanggalAkhir"}.
+func JalankanAnalisisAnomali(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TanggalAwal  string `json:"tanggalAwal"`
+		TanggalAkhir string `json:"tanggalAkhir"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+
+	awal, errAwal := time.Parse("2006-01-02", input.TanggalAwal)
+	akhir, errAkhir := time.Parse("2006-01-02", input.TanggalAkhir)
+	if errAwal != nil || errAkhir != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "tanggalAwal dan tanggalAkhir wajib diisi (format: YYYY-MM-DD)",
+		})
+		return
+	}
+	if akhir.Before(awal) || akhir.Sub(awal).Hours()/24 >= maksHariAnalisis {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Rentang tanggal tidak valid (maksimal %d hari)", maksHariAnalisis),
+		})
+		return
+	}
+
+	hasil, err := jalankanAnalisisAnomali(config.GetDB(), awal, akhir)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Analisis gagal: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Analisis selesai: %d temuan, %d baru", hasil.Temuan, hasil.Baru),
+		Data:    hasil,
+	})
+}
+
+// ubahStatusAnomali memindahkan temuan ke status baru jika status saat ini
+// termasuk dalam asal
+func ubahStatusAnomali(w http.ResponseWriter, r *http.Request, tujuan string, asal ...string) {
+	var input struct {
+		Catatan string `json:"catatan"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+			return
+		}
+	}
+
+	id := mux.Vars(r)["id"]
+	var anomali models.Anomali
+	if err := config.DB.First(&anomali, id).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Temuan anomali tidak ditemukan"})
+		return
+	}
+
+	boleh := false
+	for _, s := range asal {
+		if anomali.Status == s {
+			boleh = true
+		}
+	}
+	if !boleh {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Temuan berstatus %s tidak dapat diubah menjadi %s", anomali.Status, tujuan),
+		})
+		return
+	}
+
+	sekarang := time.Now()
+	perubahan := map[string]interface{}{
+		"status":        tujuan,
+		"ditinjau_oleh": usernameDariRequest(r),
+	}
+	if catatan := strings.TrimSpace(input.Catatan); catatan != "" {
+		perubahan["catatan"] = catatan
+	}
+	if tujuan == models.StatusAnomaliDiakui {
+		perubahan["diakui_pada"] = sekarang
+	} else {
+		perubahan["diselesaikan_pada"] = sekarang
+	}
+
+	if err := config.DB.Model(&anomali).Updates(perubahan).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal memperbarui temuan: " + err.Error()})
+		return
+	}
+	config.DB.First(&anomali, anomali.ID)
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Status temuan menjadi " + tujuan,
+		Data:    anomali,
+	})
+}
+
+// AkuiAnomali menandai temuan sudah dilihat dan sedang ditangani
+func AkuiAnomali(w http.ResponseWriter, r *http.Request) {
+	ubahStatusAnomali(w, r, models.StatusAnomaliDiakui, models.StatusAnomaliBaru)
+}
+
+// SelesaikanAnomali menutup temuan (data sudah dikoreksi atau memang benar)
+func SelesaikanAnomali(w http.ResponseWriter, r *http.Request) {
+	ubahStatusAnomali(w, r, models.StatusAnomaliSelesai, models.StatusAnomaliBaru, models.StatusAnomaliDiakui)
+}
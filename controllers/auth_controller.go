@@ -200,6 +200,23 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// AdminMiddleware hanya meneruskan pengguna dengan role admin. Dipasang
+// setelah AuthMiddleware; role dibaca dari database agar perubahan role
+// langsung berlaku tanpa login ulang.
+func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var user models.User
+		if err := config.DB.Where("username = ?", usernameDariRequest(r)).First(&user).Error; err != nil || user.Role != models.RoleAdmin {
+			respondJSON(w, http.StatusForbidden, APIResponse{
+				Success: false,
+				Message: "Hanya admin yang boleh mengakses fitur ini",
+			})
+			return
+		}
+		next(w, r)
+	}
+}
+
 // ServeLoginPage - menampilkan halaman login
 func ServeLoginPage(w http.ResponseWriter, r *http.Request) {
 	// Check if user is already logged in
@@ -361,6 +361,7 @@ func updateBakuDetail(entry models.BakuPenyadap, action string, oldEntry *models
 			IdBakuMandor: mandor.ID,
 			Mandor:       mandor.Mandor,
 			Afdeling:     mandor.Afdeling,
+			AfdelingID:   mandor.AfdelingID,
 			TahunTanam:   mandor.TahunTanam,
 			Tipe:         entry.Tipe,
 		}
@@ -404,12 +405,32 @@ func updateBakuDetail(entry models.BakuPenyadap, action string, oldEntry *models
 	if isNewRecord {
 		if err := config.DB.Create(&detail).Error; err != nil {
 			fmt.Printf("ERROR: Gagal create BakuDetail: %v\n", err)
+			return
 		}
 	} else {
 		if err := config.DB.Save(&detail).Error; err != nil {
 			fmt.Printf("ERROR: Gagal update BakuDetail: %v\n", err)
+			return
 		}
 	}
+
+	periksaSelisihBakuDetail(detail.ID)
+	umumkanBakuBerubah(detail, action)
+}
+
+// umumkanBakuBerubah mengirim ringkasan baku detail terbaru ke pelanggan
+// peristiwa
+func umumkanBakuBerubah(detail models.BakuDetail, action string) {
+	kirimPeristiwa(Peristiwa{
+		Jenis:      PeristiwaBakuBerubah,
+		AfdelingID: nilaiAfdelingID(detail.AfdelingID),
+		Pesan: fmt.Sprintf("Baku %s mandor %s %s (%s)",
+			detail.Tipe, detail.Mandor, detail.Tanggal.Format("2006-01-02"), action),
+		Data: map[string]interface{}{
+			"aksi":   action,
+			"detail": detail,
+		},
+	})
 }
 
 // RecalculateBakuDetail - Fungsi untuk hitung ulang BakuDetail berdasarkan tanggal, mandor ID, dan tipe
@@ -454,6 +475,7 @@ func RecalculateBakuDetail(tanggal time.Time, mandorID uint, tipe models.TipePro
 			IdBakuMandor: mandor.ID,
 			Mandor:       mandor.Mandor,
 			Afdeling:     mandor.Afdeling,
+			AfdelingID:   mandor.AfdelingID,
 			Tipe:         tipe,
 			TahunTanam:   mandor.TahunTanam,
 		}
@@ -493,7 +515,13 @@ func RecalculateBakuDetail(tanggal time.Time, mandorID uint, tipe models.TipePro
 		detail.PersentaseSelisihBasahLump = 0
 	}
 
-	return config.DB.Save(&detail).Error
+	if err := config.DB.Save(&detail).Error; err != nil {
+		return err
+	}
+
+	periksaSelisihBakuDetail(detail.ID)
+	umumkanBakuBerubah(detail, "recalculate")
+	return nil
 }
 
 // ======== PAGE RENDERING ========
@@ -1086,11 +1114,11 @@ func searchMandorWithDetails(namaMandor, tanggal, tipeFilter string) ([]MandorSu
 }
 
 // Advanced search functions with tipe support
-func advancedSearchMandor(nama, tanggal, afdeling, tahunTanam, tipeFilter string) ([]MandorSummary, error) {
+func advancedSearchMandor(nama, tanggal string, afdelingID uint, tahunTanam, tipeFilter string) ([]MandorSummary, error) {
 	query := config.DB.Where("mandor LIKE ?", "%"+nama+"%")
 
-	if afdeling != "" {
-		query = query.Where("afdeling LIKE ?", "%"+afdeling+"%")
+	if afdelingID != 0 {
+		query = query.Where("afdeling_id = ?", afdelingID)
 	}
 
 	if tahunTanam != "" {
@@ -1197,7 +1225,7 @@ func advancedSearchMandor(nama, tanggal, afdeling, tahunTanam, tipeFilter string
 	return summaries, nil
 }
 
-func advancedSearchPenyadap(nama, tanggal, afdeling, tipeFilter string) ([]PenyadapDetail, error) {
+func advancedSearchPenyadap(nama, tanggal string, afdelingID uint, tipeFilter string) ([]PenyadapDetail, error) {
 	query := `
 		SELECT 
 			p.id,
@@ -1227,9 +1255,9 @@ func advancedSearchPenyadap(nama, tanggal, afdeling, tipeFilter string) ([]Penya
 	}
 
 	// Filter afdeling
-	if afdeling != "" {
-		query += " AND bm.afdeling LIKE ?"
-		args = append(args, "%"+afdeling+"%")
+	if afdelingID != 0 {
+		query += " AND bm.afdeling_id = ?"
+		args = append(args, afdelingID)
 	}
 
 	// Filter tipe
@@ -1285,18 +1313,32 @@ func SearchAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validasi afdeling jika ada
+	var afdelingID uint
+	if afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		afdelingID = afd.ID
+	}
+
 	var result interface{}
 	var err error
 
 	switch searchType {
 	case "mandor":
-		result, err = advancedSearchMandor(nama, tanggal, afdeling, tahunTanam, tipeFilter)
+		result, err = advancedSearchMandor(nama, tanggal, afdelingID, tahunTanam, tipeFilter)
 	case "penyadap":
-		result, err = advancedSearchPenyadap(nama, tanggal, afdeling, tipeFilter)
+		result, err = advancedSearchPenyadap(nama, tanggal, afdelingID, tipeFilter)
 	default:
 		// Auto detect berdasarkan hasil pencarian
-		mandorResult, _ := advancedSearchMandor(nama, tanggal, afdeling, tahunTanam, tipeFilter)
-		penyadapResult, _ := advancedSearchPenyadap(nama, tanggal, afdeling, tipeFilter)
+		mandorResult, _ := advancedSearchMandor(nama, tanggal, afdelingID, tahunTanam, tipeFilter)
+		penyadapResult, _ := advancedSearchPenyadap(nama, tanggal, afdelingID, tipeFilter)
 
 		result = map[string]interface{}{
 			"mandor":   mandorResult,
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
 	"encoding/csv"
 	"fmt"
 	"log"
@@ -145,9 +147,14 @@ func excelToCSV(excelFile string, outputFolder string, tanggal time.Time, afdeli
 
 	// Collect results
 	successCount := 0
+	ringkasan := make(map[string]interface{})
+	var kesalahan []string
 	for i := 0; i < 2; i++ {
 		result := <-dbResults
+		ringkasan[result.name] = map[string]int{"berhasil": result.saved, "gagal": result.failed}
+		kesalahan = append(kesalahan, result.errs...)
 		if result.err != nil {
+			kesalahan = append(kesalahan, fmt.Sprintf("%s: %v", result.name, result.err))
 			fmt.Printf("✗ %s gagal: %v\n", result.name, result.err)
 		} else {
 			fmt.Printf("✓ %s: %d berhasil, %d gagal\n", result.name, result.saved, result.failed)
@@ -162,6 +169,14 @@ func excelToCSV(excelFile string, outputFolder string, tanggal time.Time, afdeli
 	}
 	close(dbResults)
 
+	// Rekap dan produksi ikut afdeling_id milik master
+	if err := syncAfdelingFromMaster(idMaster); err != nil {
+		fmt.Printf("⚠️  Gagal mengisi afdeling_id: %v\n", err)
+	}
+
+	// Peringatan selisih kebun vs pabrik untuk data yang baru diimpor
+	periksaSelisihMaster(idMaster)
+
 	// Evaluate results
 	if successCount == 2 {
 		fmt.Println("\n✅ Semua proses berhasil dilakukan!")
@@ -171,9 +186,39 @@ func excelToCSV(excelFile string, outputFolder string, tanggal time.Time, afdeli
 		fmt.Println("\n⚠️  Beberapa proses gagal, periksa log di atas.")
 	}
 
+	// Beritahu dashboard dan penerima lain bahwa impor sudah selesai
+	hasil := map[string]interface{}{
+		"file_name": originalFileName,
+		"tanggal":   tanggal.Format("2006-01-02"),
+		"afdeling":  afdeling,
+		"id_master": idMaster,
+		"ringkasan": ringkasan,
+	}
+	if successCount == 2 {
+		umumkanImpor(PeristiwaImporSelesai, afdeling, "Impor "+originalFileName+" selesai", hasil)
+	} else {
+		hasil["kesalahan"] = kesalahan
+		umumkanImpor(PeristiwaImporGagal, afdeling, "Impor "+originalFileName+" sebagian gagal", hasil)
+	}
+
 	return nil
 }
 
+// umumkanImpor mengirim peristiwa impor; afdeling dicari dari nama yang
+// dipilih saat upload
+func umumkanImpor(jenis, afdeling, pesan string, data map[string]interface{}) {
+	var afdelingID uint
+	if afd, err := models.FindAfdeling(config.GetDB(), afdeling); err == nil {
+		afdelingID = afd.ID
+	}
+	kirimPeristiwa(Peristiwa{
+		Jenis:      jenis,
+		AfdelingID: afdelingID,
+		Pesan:      pesan,
+		Data:       data,
+	})
+}
+
 // processSheet processes a single Excel sheet to CSV
 func processSheet(f *excelize.File, sheetName string, outputFolder string) error {
 	rows, err := f.GetRows(sheetName)
@@ -64,19 +64,33 @@ type dashboardDataResponse struct {
 
 	TotalProduksiHariIni       float64 `json:"totalProduksiHariIni"`
 	TotalProduksiSampaiHariIni float64 `json:"totalProduksiSampaiHariIni"`
+
+	// Realisasi vs target RKAP afdeling, kosong jika belum ada target
+	Target *RealisasiTarget `json:"target,omitempty"`
 }
 
 // GetDashboardData mengambil data dashboard berdasarkan afdeling untuk tanggal hari ini
 func GetDashboardData(w http.ResponseWriter, r *http.Request) {
 	// Ambil parameter afdeling dari query string
 	afdeling := r.URL.Query().Get("afdeling")
+	format, err := formatLaporan(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if afdeling == "" {
 		http.Error(w, "Parameter afdeling diperlukan", http.StatusBadRequest)
 		return
 	}
 
+	afd, err := resolveAfdeling(afdeling, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Debug logging
-	log.Printf("📍 Request untuk afdeling: '%s'", afdeling)
+	log.Printf("📍 Request untuk afdeling: '%s' (id %d)", afd.Nama, afd.ID)
 
 	// Dapatkan tanggal hari ini (tanpa waktu)
 	today := time.Now().Truncate(24 * time.Hour)
@@ -113,8 +127,7 @@ func GetDashboardData(w http.ResponseWriter, r *http.Request) {
 
 	var result AggregateResult
 
-	// FIX: Gunakan LOWER() untuk case-insensitive comparison
-	err := db.Model(&models.Rekap{}).
+	err = db.Model(&models.Rekap{}).
 		Select(`
 			COALESCE(SUM(hko_hari_ini), 0) as total_hko_hari_ini,
 			COALESCE(SUM(hko_sampai_hari_ini), 0) as total_hko_sampai_hari_ini,
@@ -137,7 +150,7 @@ func GetDashboardData(w http.ResponseWriter, r *http.Request) {
 			COALESCE(SUM(total_produksi_hari_ini),0) as total_produksi_hari_ini,
 			COALESCE(SUM(total_produksi_sampai_hari_ini),0) as total_produksi_sampai_hari_ini
 		`).
-		Where("DATE(tanggal) = DATE(?) AND LOWER(afdeling) = LOWER(?) AND tipe_produksi != ?", today, afdeling, "REKAPITULASI").
+		Where("DATE(tanggal) = DATE(?) AND afdeling_id = ? AND tipe_produksi != ?", today, afd.ID, "REKAPITULASI").
 		Scan(&result).Error
 
 	if err != nil {
@@ -222,10 +235,59 @@ func GetDashboardData(w http.ResponseWriter, r *http.Request) {
 		response.TotalProduksiPerTaperSampaiHariIni = response.TotalSampaiHariIniKeringJumlah / float64(response.TotalHKOSampaiHariIni)
 	}
 
+	target, err := targetAfdeling(db, &afd, today)
+	if err != nil {
+		log.Printf("❌ Error menghitung target: %v", err)
+	}
+	response.Target = target
+
 	// Debug logging response
 	log.Printf("✅ Response untuk %s: Total records dengan data non-zero", afdeling)
 
+	if format != "" {
+		kirimLaporan(w, r, format, "dashboard_"+afd.Kode, tabelDashboard(response, afd.Nama, today))
+		return
+	}
+
 	// Kirim response sebagai JSON
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// tabelDashboard menyusun ringkasan dashboard: satu baris per ukuran dengan
+// kolom hari ini dan sampai hari ini
+func tabelDashboard(d dashboardDataResponse, afdeling string, tanggal time.Time) TabelLaporan {
+	tabel := TabelLaporan{
+		Judul:  "Dashboard Produksi",
+		Filter: []string{"Afdeling: " + afdeling, "Tanggal: " + tanggal.Format("2006-01-02")},
+		Kolom: []KolomLaporan{
+			{Judul: "Uraian"},
+			{Judul: "Hari Ini", Jenis: kolomAngka},
+			{Judul: "Sampai Hari Ini", Jenis: kolomAngka},
+		},
+		Baris: [][]interface{}{
+			{"HKO", float64(d.TotalHKOHariIni), float64(d.TotalHKOSampaiHariIni)},
+			{"Basah Latek Kebun (kg)", d.TotalHariIniBasahLatekKebun, d.TotalSampaiHariIniBasahLatekKebun},
+			{"Basah Latek Pabrik (kg)", d.TotalHariIniBasahLatekPabrik, d.TotalSampaiHariIniBasahLatekPabrik},
+			{"Selisih Basah Latek (%)", d.TotalHariIniBasahLatekPersen, d.TotalSampaiHariIniBasahLatekPersen},
+			{"Basah Lump Kebun (kg)", d.TotalHariIniBasahLumpKebun, d.TotalSampaiHariIniBasahLumpKebun},
+			{"Basah Lump Pabrik (kg)", d.TotalHariIniBasahLumpPabrik, d.TotalSampaiHariIniBasahLumpPabrik},
+			{"Selisih Basah Lump (%)", d.TotalHariIniBasahLumpPersen, d.TotalSampaiHariIniBasahLumpPersen},
+			{"K3 Sheet", d.TotalHariIniK3Sheet, d.TotalSampaiHariIniK3Sheet},
+			{"K3 Sheet (%)", d.TotalHariIniK3SheetPersen, d.TotalSampaiHariIniK3SheetPersen},
+			{"Kering Sheet (kg)", d.TotalHariIniKeringSheet, d.TotalSampaiHariIniKeringSheet},
+			{"Kering Br/Cr (kg)", d.TotalHariIniKeringBrCr, d.TotalSampaiHariIniKeringBrCr},
+			{"Kering Jumlah (kg)", d.TotalHariIniKeringJumlah, d.TotalSampaiHariIniKeringJumlah},
+			{"Produksi per Taper (kg)", d.TotalProduksiPerTaperHariIni, d.TotalProduksiPerTaperSampaiHariIni},
+			{"Total Produksi (kg)", d.TotalProduksiHariIni, d.TotalProduksiSampaiHariIni},
+		},
+	}
+	if d.Target != nil {
+		tabel.Baris = append(tabel.Baris,
+			[]interface{}{"Target Kering (kg)", d.Target.HariIni.Target, d.Target.SampaiHariIni.Target},
+			[]interface{}{"Varians Target (kg)", d.Target.HariIni.Varians, d.Target.SampaiHariIni.Varians},
+			[]interface{}{"Capaian Target (%)", nilaiCapaian(d.Target.HariIni), nilaiCapaian(d.Target.SampaiHariIni)},
+		)
+	}
+	return tabel
+}
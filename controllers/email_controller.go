@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/jadwal"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type langgananEmailInput struct {
+	Email    string   `json:"email"`
+	Jenis    string   `json:"jenis"`
+	Afdeling string   `json:"afdeling"`
+	Jadwal   string   `json:"jadwal"`
+	Format   []string `json:"format"`
+	Aktif    *bool    `json:"aktif"`
+}
+
+// penggunaDariRequest mengambil user yang sedang login
+func penggunaDariRequest(r *http.Request) (models.User, error) {
+	var user models.User
+	err := config.DB.Where("username = ?", usernameDariRequest(r)).First(&user).Error
+	return user, err
+}
+
+// terapkanInputLangganan memvalidasi input lalu mengisi langganan. Laporan
+// harian memakai jadwal bawaan 07:00 dan lampiran PDF jika tidak diisi.
+func terapkanInputLangganan(input langgananEmailInput, l *models.LanggananEmail) error {
+	alamat, err := mail.ParseAddress(strings.TrimSpace(input.Email))
+	if err != nil {
+		return fmt.Errorf("email '%s' tidak valid", input.Email)
+	}
+
+	jenis := strings.TrimSpace(input.Jenis)
+	if jenis != models.JenisLaporanHarian && !slices.Contains(daftarJenisPeristiwa, jenis) {
+		return fmt.Errorf("jenis '%s' tidak dikenal. Gunakan: %s, %s",
+			jenis, models.JenisLaporanHarian, strings.Join(daftarJenisPeristiwa, ", "))
+	}
+
+	l.Jadwal, l.Format = "", ""
+	if jenis == models.JenisLaporanHarian {
+		l.Jadwal = strings.TrimSpace(input.Jadwal)
+		if l.Jadwal == "" {
+			l.Jadwal = jadwalLaporanBawaan
+		}
+		j, err := jadwal.Parse(l.Jadwal)
+		if err != nil {
+			return err
+		}
+		l.Jadwal = j.String()
+
+		var format []string
+		for _, f := range input.Format {
+			f = strings.ToLower(strings.TrimSpace(f))
+			if f != formatLampiranPDF && f != formatLampiranXLSX {
+				return fmt.Errorf("format '%s' tidak valid. Gunakan: pdf, xlsx", f)
+			}
+			if !slices.Contains(format, f) {
+				format = append(format, f)
+			}
+		}
+		if len(format) == 0 {
+			format = []string{formatLampiranPDF}
+		}
+		l.Format = strings.Join(format, ",")
+	}
+
+	l.AfdelingID, l.Afdeling = nil, nil
+	if afdeling := strings.TrimSpace(input.Afdeling); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			return err
+		}
+		l.AfdelingID, l.Afdeling = &afd.ID, &afd
+	}
+
+	l.Email = alamat.Address
+	l.Jenis = jenis
+	if input.Aktif != nil {
+		l.Aktif = *input.Aktif
+	}
+	return nil
+}
+
+// isiJadwalBerikutnya mengisi waktu kirim berikutnya untuk ditampilkan
+func isiJadwalBerikutnya(l *models.LanggananEmail) {
+	if l.Jenis != models.JenisLaporanHarian || !l.Aktif {
+		return
+	}
+	if j, err := jadwal.Parse(l.Jadwal); err == nil {
+		if t := j.Berikutnya(time.Now()); !t.IsZero() {
+			l.Berikutnya = &t
+		}
+	}
+}
+
+// langgananMilikPengguna mengambil langganan {id} milik user yang login
+func langgananMilikPengguna(w http.ResponseWriter, r *http.Request) (models.LanggananEmail, bool) {
+	var l models.LanggananEmail
+	user, err := penggunaDariRequest(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, APIResponse{Success: false, Message: "Pengguna tidak ditemukan"})
+		return l, false
+	}
+	if err := config.DB.Where("user_id = ?", user.ID).First(&l, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Langganan email tidak ditemukan"})
+		return l, false
+	}
+	return l, true
+}
+
+// GetLanggananEmail menampilkan langganan email milik user yang login
+func GetLanggananEmail(w http.ResponseWriter, r *http.Request) {
+	user, err := penggunaDariRequest(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, APIResponse{Success: false, Message: "Pengguna tidak ditemukan"})
+		return
+	}
+
+	var list []models.LanggananEmail
+	if err := config.DB.Preload("Afdeling").
+		Where("user_id = ?", user.ID).
+		Order("jenis asc, id asc").
+		Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengambil langganan email: " + err.Error()})
+		return
+	}
+	for i := range list {
+		isiJadwalBerikutnya(&list[i])
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d langganan email", len(list)),
+		Data:    list,
+	})
+}
+
+// CreateLanggananEmail menambah langganan. Body: {"email", "jenis"
+// ("laporan.harian" atau jenis peristiwa), "afdeling", "jadwal" (cron, untuk
+// laporan), "format" (["pdf","xlsx"], untuk laporan), "aktif"}
+func CreateLanggananEmail(w http.ResponseWriter, r *http.Request) {
+	user, err := penggunaDariRequest(r)
+	if err != nil {
+		respondJSON(w, http.StatusUnauthorized, APIResponse{Success: false, Message: "Pengguna tidak ditemukan"})
+		return
+	}
+
+	var input langgananEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+
+	l := models.LanggananEmail{UserID: user.ID, Aktif: true}
+	if err := terapkanInputLangganan(input, &l); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	// Create mengisi Aktif=false dengan default kolom (true), jadi
+	// nonaktifkan setelahnya
+	aktif := l.Aktif
+	if err := config.DB.Create(&l).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menyimpan langganan email: " + err.Error()})
+		return
+	}
+	if !aktif {
+		config.DB.Model(&l).Update("aktif", false)
+		l.Aktif = false
+	}
+	isiJadwalBerikutnya(&l)
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Langganan email berhasil ditambahkan",
+		Data:    l,
+	})
+}
+
+// UpdateLanggananEmail mengubah langganan milik user yang login
+func UpdateLanggananEmail(w http.ResponseWriter, r *http.Request) {
+	l, ok := langgananMilikPengguna(w, r)
+	if !ok {
+		return
+	}
+
+	var input langgananEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+	if err := terapkanInputLangganan(input, &l); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := config.DB.Omit("Afdeling").Save(&l).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal memperbarui langganan email: " + err.Error()})
+		return
+	}
+	isiJadwalBerikutnya(&l)
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Langganan email berhasil diperbarui",
+		Data:    l,
+	})
+}
+
+// DeleteLanggananEmail menghapus langganan milik user yang login
+func DeleteLanggananEmail(w http.ResponseWriter, r *http.Request) {
+	l, ok := langgananMilikPengguna(w, r)
+	if !ok {
+		return
+	}
+	if err := config.DB.Delete(&l).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menghapus langganan email: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Langganan email berhasil dihapus",
+	})
+}
+
+// KirimLanggananEmail mengirim email langganan sekarang juga untuk
+// memeriksa alamat dan isi. Laporan harian memakai parameter tanggal
+// (YYYY-MM-DD, default kemarin); langganan peristiwa menerima contoh email.
+func KirimLanggananEmail(w http.ResponseWriter, r *http.Request) {
+	if !konfigurasiSMTP.Aktif() {
+		respondJSON(w, http.StatusServiceUnavailable, APIResponse{Success: false, Message: "Email belum diatur (SMTP_HOST kosong)"})
+		return
+	}
+	l, ok := langgananMilikPengguna(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	if l.Jenis == models.JenisLaporanHarian {
+		var tanggal time.Time
+		tanggal, err = parseTanggalParam(r, "tanggal", time.Now().AddDate(0, 0, -1))
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		err = kirimLaporanHarianEmail(l, tanggal)
+	} else {
+		err = kirimEmailPeristiwa(l.Email, Peristiwa{
+			ID:    idAcak(12),
+			Jenis: l.Jenis,
+			Waktu: time.Now(),
+			Pesan: "Contoh pemberitahuan, dikirim oleh " + usernameDariRequest(r),
+		})
+	}
+	catatKirimEmail(l, err)
+	if err != nil {
+		respondJSON(w, http.StatusBadGateway, APIResponse{Success: false, Message: "Gagal mengirim email: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Email terkirim ke " + l.Email,
+	})
+}
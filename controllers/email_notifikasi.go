@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/jadwal"
+	"app-inputan-ptpn/models"
+	"app-inputan-ptpn/surel"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	folderTemplateEmail = "templates/email"
+
+	// jadwalLaporanBawaan mengirim laporan harian setiap pukul 07:00
+	jadwalLaporanBawaan = "0 7 * * *"
+
+	formatLampiranPDF  = "pdf"
+	formatLampiranXLSX = "xlsx"
+)
+
+// konfigurasiSMTP dibaca dari env saat MulaiEmail dipanggil
+var konfigurasiSMTP surel.Konfigurasi
+
+// judulPeristiwaEmail dipakai sebagai subjek dan judul email peristiwa
+var judulPeristiwaEmail = map[string]string{
+	PeristiwaImporSelesai:          "Impor Data Selesai",
+	PeristiwaImporGagal:            "Impor Data Gagal",
+	PeristiwaMasterDihapus:         "Data Master Dihapus",
+	PeristiwaBakuBerubah:           "Data Baku Berubah",
+	PeristiwaHariDitutup:           "Hari Ditutup",
+	PeristiwaHariDibuka:            "Hari Dibuka Kembali",
+	PeristiwaSelisihMelewatiAmbang: "Selisih Kebun vs Pabrik Melewati Ambang",
+}
+
+// MulaiEmail membaca konfigurasi SMTP (lihat surel.DariEnv), mendaftarkan
+// email sebagai penerima peristiwa dan menjalankan penjadwal laporan yang
+// memeriksa langganan setiap menit. Tanpa SMTP_HOST email dimatikan;
+// EMAIL_JOB=off hanya mematikan penjadwal.
+func MulaiEmail() {
+	konfigurasiSMTP = surel.DariEnv()
+	if !konfigurasiSMTP.Aktif() {
+		log.Println("⚠️  Email dimatikan (SMTP_HOST belum diatur)")
+		return
+	}
+
+	DaftarkanPenerima("email", teruskanKeEmail)
+
+	if strings.EqualFold(os.Getenv("EMAIL_JOB"), "off") {
+		log.Println("⚠️  Penjadwal laporan email dimatikan (EMAIL_JOB=off)")
+		return
+	}
+	go func() {
+		for {
+			berikut := time.Now().Truncate(time.Minute).Add(time.Minute)
+			time.Sleep(time.Until(berikut))
+			jalankanJadwalEmail(berikut)
+		}
+	}()
+	log.Printf("✓ Email aktif melalui %s:%d", konfigurasiSMTP.Host, konfigurasiSMTP.Port)
+}
+
+// jalankanJadwalEmail mengirim laporan untuk langganan yang jadwalnya
+// cocok dengan menit t. TerakhirKirim mencegah kiriman ganda di menit yang
+// sama.
+func jalankanJadwalEmail(t time.Time) {
+	var list []models.LanggananEmail
+	if err := config.GetDB().
+		Where("aktif = ? AND jenis = ?", true, models.JenisLaporanHarian).
+		Find(&list).Error; err != nil {
+		log.Printf("Gagal memuat langganan email: %v", err)
+		return
+	}
+
+	for _, l := range list {
+		j, err := jadwal.Parse(l.Jadwal)
+		if err != nil || !j.Cocok(t) {
+			continue
+		}
+		if l.TerakhirKirim != nil && !l.TerakhirKirim.Before(t) {
+			continue
+		}
+		go func(l models.LanggananEmail) {
+			// Laporan pagi berisi data hari sebelumnya
+			err := kirimLaporanHarianEmail(l, t.AddDate(0, 0, -1))
+			catatKirimEmail(l, err)
+		}(l)
+	}
+}
+
+// catatKirimEmail menyimpan hasil pengiriman terakhir di langganan
+func catatKirimEmail(l models.LanggananEmail, err error) {
+	gagal := ""
+	if err != nil {
+		gagal = err.Error()
+		log.Printf("❌ Email %s ke %s gagal: %v", l.Jenis, l.Email, err)
+	}
+	config.GetDB().Model(&l).Updates(map[string]interface{}{
+		"terakhir_kirim": time.Now(),
+		"terakhir_gagal": gagal,
+	})
+}
+
+// renderEmail mengisi templates/email/<nama>.txt dan <nama>.html
+func renderEmail(nama string, data interface{}) (string, string, error) {
+	fungsi := map[string]interface{}{
+		"angka": func(v float64) string { return angkaID(v, 2) },
+	}
+
+	tt, err := texttemplate.New(nama + ".txt").Funcs(fungsi).
+		ParseFiles(filepath.Join(folderTemplateEmail, nama+".txt"))
+	if err != nil {
+		return "", "", err
+	}
+	th, err := htmltemplate.New(nama + ".html").Funcs(fungsi).
+		ParseFiles(filepath.Join(folderTemplateEmail, nama+".html"))
+	if err != nil {
+		return "", "", err
+	}
+
+	var teks, html bytes.Buffer
+	if err := tt.Execute(&teks, data); err != nil {
+		return "", "", err
+	}
+	if err := th.Execute(&html, data); err != nil {
+		return "", "", err
+	}
+	return teks.String(), html.String(), nil
+}
+
+// ambilRekapHarian mengambil rekap satu afdeling pada satu tanggal tanpa
+// baris REKAPITULASI
+func ambilRekapHarian(db *gorm.DB, afdelingID uint, tanggal time.Time) ([]models.Rekap, error) {
+	var rekaps []models.Rekap
+	err := db.Where("afdeling_id = ? AND tipe_produksi != ? AND DATE(tanggal) = ?",
+		afdelingID, "REKAPITULASI", tanggal.Format("2006-01-02")).
+		Order("id asc").
+		Find(&rekaps).Error
+	return rekaps, err
+}
+
+// lampiranLaporanHarian membuat file laporan harian satu afdeling sesuai
+// format yang dipilih: PDF (sama dengan /api/laporan/pdf/harian) dan/atau
+// Excel (sama dengan /rekap/laporan)
+func lampiranLaporanHarian(afd models.Afdeling, tanggal time.Time, rekaps []models.Rekap, format []string) ([]surel.Lampiran, error) {
+	var hasil []surel.Lampiran
+	for _, f := range format {
+		switch f {
+		case formatLampiranPDF:
+			var buf bytes.Buffer
+			if err := susunPDFHarian(afd, tanggal, rekaps).Tulis(&buf); err != nil {
+				return nil, err
+			}
+			hasil = append(hasil, surel.Lampiran{
+				Nama:       namaPDFHarian(afd, tanggal),
+				TipeKonten: "application/pdf",
+				Isi:        buf.Bytes(),
+			})
+
+		case formatLampiranXLSX:
+			judul := []string{
+				"LAPORAN PRODUKSI HARIAN",
+				"AFDELING : " + strings.ToUpper(afd.Nama),
+				"TANGGAL : " + tanggalIndonesia(tanggal),
+			}
+			bagian, rekapitulasi, total := susunLaporanRekap(rekaps)
+			wb, err := buatWorkbookRekap(judul, bagian, rekapitulasi, total, "")
+			if err != nil {
+				return nil, err
+			}
+			buf, err := wb.WriteToBuffer()
+			wb.Close()
+			if err != nil {
+				return nil, err
+			}
+			hasil = append(hasil, surel.Lampiran{
+				Nama:       fmt.Sprintf("REKAP_%s_%s.xlsx", afd.Kode, tanggal.Format("20060102")),
+				TipeKonten: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+				Isi:        buf.Bytes(),
+			})
+		}
+	}
+	return hasil, nil
+}
+
+type emailAfdelingHarian struct {
+	Nama      string
+	AdaData   bool
+	Ringkasan RingkasanHari
+}
+
+type emailLaporanHarian struct {
+	Tanggal  string
+	Afdeling []emailAfdelingHarian
+	Lampiran []string
+}
+
+// kirimLaporanHarianEmail mengirim laporan satu tanggal untuk afdeling
+// langganan (atau semua afdeling aktif). Afdeling tanpa data tetap
+// disebut di isi email tetapi tidak diberi lampiran.
+func kirimLaporanHarianEmail(l models.LanggananEmail, tanggal time.Time) error {
+	db := config.GetDB()
+	var afds []models.Afdeling
+	query := db.Order("nama asc")
+	if l.AfdelingID != nil {
+		query = query.Where("id = ?", *l.AfdelingID)
+	} else {
+		query = query.Where("aktif = ?", true)
+	}
+	if err := query.Find(&afds).Error; err != nil {
+		return err
+	}
+
+	format := strings.Split(l.Format, ",")
+	data := emailLaporanHarian{Tanggal: tanggalIndonesia(tanggal)}
+	var lampiran []surel.Lampiran
+	for _, afd := range afds {
+		rekaps, err := ambilRekapHarian(db, afd.ID, tanggal)
+		if err != nil {
+			return err
+		}
+		item := emailAfdelingHarian{Nama: afd.Nama, AdaData: len(rekaps) > 0}
+		if item.AdaData {
+			if item.Ringkasan, err = ringkasanHari(db, tanggal, afd.ID); err != nil {
+				return err
+			}
+			file, err := lampiranLaporanHarian(afd, tanggal, rekaps, format)
+			if err != nil {
+				return fmt.Errorf("gagal membuat lampiran %s: %v", afd.Nama, err)
+			}
+			lampiran = append(lampiran, file...)
+		}
+		data.Afdeling = append(data.Afdeling, item)
+	}
+	for _, f := range lampiran {
+		data.Lampiran = append(data.Lampiran, f.Nama)
+	}
+
+	teks, html, err := renderEmail("laporan_harian", data)
+	if err != nil {
+		return err
+	}
+	subjek := "Laporan Produksi Harian " + tanggal.Format("02-01-2006")
+	if len(afds) == 1 {
+		subjek += " - Afdeling " + afds[0].Nama
+	}
+	return konfigurasiSMTP.Kirim(surel.Pesan{
+		Kepada:   []string{l.Email},
+		Subjek:   subjek,
+		Teks:     teks,
+		HTML:     html,
+		Lampiran: lampiran,
+	})
+}
+
+type rincianEmail struct {
+	Label string
+	Nilai string
+}
+
+type emailPeristiwa struct {
+	Judul     string
+	Jenis     string
+	Pesan     string
+	Waktu     string
+	Gagal     bool
+	Rincian   []rincianEmail
+	Kesalahan []string
+}
+
+// susunEmailPeristiwa meratakan Data peristiwa menjadi daftar label-nilai;
+// field "kesalahan" ditampilkan sebagai daftar tersendiri
+func susunEmailPeristiwa(p Peristiwa) emailPeristiwa {
+	judul := judulPeristiwaEmail[p.Jenis]
+	if judul == "" {
+		judul = p.Jenis
+	}
+	hasil := emailPeristiwa{
+		Judul: judul,
+		Jenis: p.Jenis,
+		Pesan: p.Pesan,
+		Waktu: p.Waktu.Format("02-01-2006 15:04:05"),
+		Gagal: p.Jenis == PeristiwaImporGagal || p.Jenis == PeristiwaSelisihMelewatiAmbang,
+	}
+
+	var data map[string]interface{}
+	if raw, err := json.Marshal(p.Data); err != nil || json.Unmarshal(raw, &data) != nil {
+		return hasil
+	}
+	kunci := make([]string, 0, len(data))
+	for k := range data {
+		kunci = append(kunci, k)
+	}
+	sort.Strings(kunci)
+
+	for _, k := range kunci {
+		v := data[k]
+		if k == "kesalahan" {
+			if daftar, ok := v.([]interface{}); ok {
+				for _, e := range daftar {
+					hasil.Kesalahan = append(hasil.Kesalahan, fmt.Sprint(e))
+				}
+			}
+			continue
+		}
+		var nilai string
+		switch x := v.(type) {
+		case nil:
+			continue
+		case string:
+			nilai = x
+		case float64:
+			nilai = strconv.FormatFloat(x, 'f', -1, 64)
+		case bool:
+			nilai = strconv.FormatBool(x)
+		default:
+			raw, _ := json.Marshal(x)
+			nilai = string(raw)
+		}
+		hasil.Rincian = append(hasil.Rincian, rincianEmail{Label: strings.ReplaceAll(k, "_", " "), Nilai: nilai})
+	}
+	return hasil
+}
+
+// kirimEmailPeristiwa mengirim satu email peristiwa ke satu alamat
+func kirimEmailPeristiwa(email string, p Peristiwa) error {
+	data := susunEmailPeristiwa(p)
+	teks, html, err := renderEmail("peristiwa", data)
+	if err != nil {
+		return err
+	}
+	return konfigurasiSMTP.Kirim(surel.Pesan{
+		Kepada: []string{email},
+		Subjek: data.Judul + ": " + p.Pesan,
+		Teks:   teks,
+		HTML:   html,
+	})
+}
+
+// teruskanKeEmail langsung mengirim email ke pelanggan jenis peristiwa
+// ini, misalnya pemberitahuan impor gagal. Satu alamat hanya menerima satu
+// email per peristiwa.
+func teruskanKeEmail(p Peristiwa) {
+	var list []models.LanggananEmail
+	if err := config.GetDB().
+		Where("aktif = ? AND jenis = ?", true, p.Jenis).
+		Find(&list).Error; err != nil {
+		log.Printf("Gagal memuat langganan email: %v", err)
+		return
+	}
+
+	terkirim := make(map[string]bool)
+	for _, l := range list {
+		if l.AfdelingID != nil && p.AfdelingID != 0 && *l.AfdelingID != p.AfdelingID {
+			continue
+		}
+		alamat := strings.ToLower(l.Email)
+		if terkirim[alamat] {
+			continue
+		}
+		terkirim[alamat] = true
+		catatKirimEmail(l, kirimEmailPeristiwa(l.Email, p))
+	}
+}
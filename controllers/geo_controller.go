@@ -0,0 +1,525 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/geo"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxKMLSize membatasi ukuran file KML/KMZ yang diunggah
+const MaxKMLSize = 20 * 1024 * 1024 // 20MB
+
+// PlacemarkTanpaPeta adalah poligon yang kodenya tidak ada di tabel Peta
+type PlacemarkTanpaPeta struct {
+	Code string `json:"code"`
+	Nama string `json:"nama"`
+}
+
+// PetaTanpaPoligon adalah blok Peta yang belum punya poligon
+type PetaTanpaPoligon struct {
+	ID         uint   `json:"id"`
+	Code       string `json:"code"`
+	Blok       string `json:"blok"`
+	Afdeling   string `json:"afdeling"`
+	JenisKebun string `json:"jenis_kebun"`
+}
+
+// GeometriBelumTertaut adalah poligon yang peta_id-nya tidak sesuai dengan
+// Peta berkode sama, mis. karena data diubah langsung di database. Tautan
+// diperbaiki pada impor KML atau penyimpanan Peta berikutnya.
+type GeometriBelumTertaut struct {
+	ID               uint   `json:"id"`
+	Code             string `json:"code"`
+	PetaID           *uint  `json:"peta_id"`
+	SeharusnyaPetaID *uint  `json:"seharusnya_peta_id"`
+}
+
+// LaporanGeometri menunjukkan kecocokan antara poligon dan data Peta
+type LaporanGeometri struct {
+	PlacemarkTanpaPeta   []PlacemarkTanpaPeta   `json:"placemark_tanpa_peta"`
+	PetaTanpaPoligon     []PetaTanpaPoligon     `json:"peta_tanpa_poligon"`
+	GeometriBelumTertaut []GeometriBelumTertaut `json:"geometri_belum_tertaut"`
+}
+
+// HasilImporKML adalah ringkasan satu kali impor KML/KMZ
+type HasilImporKML struct {
+	JumlahPlacemark int      `json:"jumlah_placemark"`
+	Dibuat          int      `json:"dibuat"`
+	Diperbarui      int      `json:"diperbarui"`
+	Dilewati        []string `json:"dilewati"`
+	LaporanGeometri
+}
+
+// normalisasiKodeBlok menyamakan nama placemark dan Peta.Code
+func normalisasiKodeBlok(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), " "))
+}
+
+// cariGeometriBelumTertaut membandingkan peta_id setiap poligon dengan Peta
+// berkode sama tanpa mengubah apa pun
+func cariGeometriBelumTertaut(db *gorm.DB) ([]GeometriBelumTertaut, error) {
+	var petas []models.Peta
+	if err := db.Select("id, code").Find(&petas).Error; err != nil {
+		return nil, err
+	}
+	petaByCode := make(map[string]uint, len(petas))
+	for _, p := range petas {
+		petaByCode[normalisasiKodeBlok(p.Code)] = p.ID
+	}
+
+	var geometris []models.PetaGeometri
+	if err := db.Select("id, code, peta_id").Order("code asc").Find(&geometris).Error; err != nil {
+		return nil, err
+	}
+	hasil := []GeometriBelumTertaut{}
+	for _, g := range geometris {
+		var petaID *uint
+		if id, ok := petaByCode[g.Code]; ok {
+			petaID = &id
+		}
+		if (petaID == nil && g.PetaID == nil) || (petaID != nil && g.PetaID != nil && *petaID == *g.PetaID) {
+			continue
+		}
+		hasil = append(hasil, GeometriBelumTertaut{ID: g.ID, Code: g.Code, PetaID: g.PetaID, SeharusnyaPetaID: petaID})
+	}
+	return hasil, nil
+}
+
+// tautkanGeometriPeta mengisi ulang peta_id setiap poligon berdasarkan kode,
+// sehingga blok Peta yang ditambahkan setelah impor ikut tertaut. Hanya
+// dipanggil dari jalur tulis (impor dan CRUD Peta).
+func tautkanGeometriPeta(db *gorm.DB) error {
+	list, err := cariGeometriBelumTertaut(db)
+	if err != nil {
+		return err
+	}
+	for _, g := range list {
+		if err := db.Model(&models.PetaGeometri{}).Where("id = ?", g.ID).
+			Update("peta_id", g.SeharusnyaPetaID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// luasGeometriHa menghitung luas geodesik poligon yang tersimpan (hektar)
+func luasGeometriHa(g models.PetaGeometri) (float64, error) {
+	mp, err := geo.ParseGeoJSON(g.GeoJSON)
+	if err != nil {
+		return 0, err
+	}
+	return roundTo(mp.LuasHa(), 2), nil
+}
+
+// buatLaporanGeometri mencari poligon tanpa Peta dan Peta tanpa poligon
+func buatLaporanGeometri(db *gorm.DB) (LaporanGeometri, error) {
+	laporan := LaporanGeometri{
+		PlacemarkTanpaPeta:   []PlacemarkTanpaPeta{},
+		PetaTanpaPoligon:     []PetaTanpaPoligon{},
+		GeometriBelumTertaut: []GeometriBelumTertaut{},
+	}
+
+	if err := db.Model(&models.PetaGeometri{}).
+		Select("code, nama").
+		Where("peta_id IS NULL").
+		Order("code asc").
+		Scan(&laporan.PlacemarkTanpaPeta).Error; err != nil {
+		return laporan, err
+	}
+
+	if err := db.Table("peta").
+		Select("peta.id, peta.code, peta.blok, peta.afdeling, peta.jenis_kebun").
+		Joins("LEFT JOIN peta_geometris g ON g.peta_id = peta.id").
+		Where("g.id IS NULL AND peta.deleted_at IS NULL").
+		Order("peta.afdeling asc, peta.code asc").
+		Scan(&laporan.PetaTanpaPoligon).Error; err != nil {
+		return laporan, err
+	}
+
+	belum, err := cariGeometriBelumTertaut(db)
+	if err != nil {
+		return laporan, err
+	}
+	laporan.GeometriBelumTertaut = belum
+
+	return laporan, nil
+}
+
+// ImportKML menerima file KML/KMZ (form field "file"), menyimpan poligon
+// setiap placemark dan menaut ke Peta berdasarkan kode. Impor ulang
+// memperbarui geometri yang sudah ada, bukan menambah baris baru.
+func ImportKML(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxKMLSize)
+	if err := r.ParseMultipartForm(MaxKMLSize); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File terlalu besar atau format tidak valid",
+		})
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File KML/KMZ wajib diunggah",
+		})
+		return
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if ext != ".kml" && ext != ".kmz" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format file harus .kml atau .kmz",
+		})
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal membaca file: " + err.Error(),
+		})
+		return
+	}
+
+	placemarks, err := geo.Parse(data)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Placemark dengan nama sama (blok yang digambar terpisah) digabung
+	hasil := HasilImporKML{JumlahPlacemark: len(placemarks), Dilewati: []string{}}
+	perKode := make(map[string]*geo.Placemark)
+	var urutan []string
+	for i := range placemarks {
+		pm := placemarks[i]
+		code := normalisasiKodeBlok(pm.Name)
+		if code == "" || len(pm.Polygons) == 0 {
+			nama := pm.Name
+			if nama == "" {
+				nama = "(tanpa nama)"
+			}
+			hasil.Dilewati = append(hasil.Dilewati, nama)
+			continue
+		}
+		if existing, ok := perKode[code]; ok {
+			existing.Polygons = append(existing.Polygons, pm.Polygons...)
+			continue
+		}
+		perKode[code] = &pm
+		urutan = append(urutan, code)
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, code := range urutan {
+			pm := perKode[code]
+			geojson, err := pm.Polygons.GeoJSON()
+			if err != nil {
+				return err
+			}
+
+			var g models.PetaGeometri
+			if err := tx.Where("code = ?", code).Limit(1).Find(&g).Error; err != nil {
+				return err
+			}
+			baru := g.ID == 0
+
+			g.Code = code
+			g.Nama = pm.Name
+			g.Tipe = pm.Polygons.Tipe()
+			g.JumlahPoligon = len(pm.Polygons)
+			g.GeoJSON = geojson
+			g.SumberFile = header.Filename
+			if err := tx.Save(&g).Error; err != nil {
+				return err
+			}
+			if baru {
+				hasil.Dibuat++
+			} else {
+				hasil.Diperbarui++
+			}
+		}
+		return tautkanGeometriPeta(tx)
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan geometri: " + err.Error(),
+		})
+		return
+	}
+	hapusCachePoligonBlok()
+
+	laporan, err := buatLaporanGeometri(config.DB)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal membuat laporan geometri: " + err.Error(),
+		})
+		return
+	}
+	hasil.LaporanGeometri = laporan
+	sort.Strings(hasil.Dilewati)
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Impor KML berhasil",
+		Data:    hasil,
+	})
+}
+
+// GetLaporanGeometri mengembalikan placemark tanpa Peta, Peta tanpa poligon
+// dan poligon yang tautannya tidak sesuai kode, tanpa mengubah data
+func GetLaporanGeometri(w http.ResponseWriter, r *http.Request) {
+	laporan, err := buatLaporanGeometri(config.DB)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal membuat laporan geometri: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    laporan,
+	})
+}
+
+// ================== GEOJSON BLOK ==================
+
+// Metrik produksi yang bisa dipilih untuk properti feature
+var metrikGeoBlok = map[string]func(b ProduksiBlok) interface{}{
+	"kering":              func(b ProduksiBlok) interface{} { return b.Kering },
+	"basah_latek":         func(b ProduksiBlok) interface{} { return b.BasahLatek },
+	"basah_lump":          func(b ProduksiBlok) interface{} { return b.BasahLump },
+	"hko":                 func(b ProduksiBlok) interface{} { return b.HKO },
+	"kg_per_ha":           func(b ProduksiBlok) interface{} { return b.KgPerHa },
+	"kg_per_pohon":        func(b ProduksiBlok) interface{} { return b.KgPerPohon },
+	"selisih_persen":      func(b ProduksiBlok) interface{} { return b.SelisihLatekPersen },
+	"selisih_lump_persen": func(b ProduksiBlok) interface{} { return b.SelisihLumpPersen },
+}
+
+var metrikGeoBlokDefault = []string{"kering", "kg_per_ha", "hko", "selisih_persen"}
+
+// GeoFeature adalah satu feature GeoJSON
+type GeoFeature struct {
+	Type       string                 `json:"type"`
+	ID         uint                   `json:"id"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoFeatureCollection adalah FeatureCollection GeoJSON beserta filter yang
+// dipakai dan rentang nilai tiap metrik (untuk legenda choropleth)
+type GeoFeatureCollection struct {
+	Type     string                `json:"type"`
+	Features []GeoFeature          `json:"features"`
+	Filter   map[string]string     `json:"filter"`
+	Rentang  map[string][2]float64 `json:"rentang"`
+}
+
+// GetGeoBlocks mengembalikan FeatureCollection semua blok yang punya poligon,
+// dengan properti Peta dan metrik produksi terpilih.
+// Parameter: tanggalAwal, tanggalAkhir (default awal bulan s/d hari ini),
+// tipeProduksi, sumber (rekap/produksi), afdeling, jenisKebun,
+// metrik (dipisah koma: kering, basah_latek, basah_lump, hko, kg_per_ha,
+// kg_per_pohon, selisih_persen, selisih_lump_persen)
+func GetGeoBlocks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	now := time.Now()
+	f := filterProduksiBlok{
+		Awal:         time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+		Akhir:        now,
+		TipeProduksi: q.Get("tipeProduksi"),
+		Sumber:       q.Get("sumber"),
+	}
+	if s := q.Get("tanggalAwal"); s != "" {
+		t, err := parseTanggalPenugasan(s)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Format tanggalAwal tidak valid (gunakan: YYYY-MM-DD)",
+			})
+			return
+		}
+		f.Awal = t
+	}
+	if s := q.Get("tanggalAkhir"); s != "" {
+		t, err := parseTanggalPenugasan(s)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Format tanggalAkhir tidak valid (gunakan: YYYY-MM-DD)",
+			})
+			return
+		}
+		f.Akhir = t
+	}
+	if f.Sumber == "" {
+		f.Sumber = sumberDataRekap
+	}
+	if f.Sumber != sumberDataRekap && f.Sumber != sumberDataProduksi {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter sumber harus 'rekap' atau 'produksi'",
+		})
+		return
+	}
+
+	metrik := metrikGeoBlokDefault
+	if s := q.Get("metrik"); s != "" {
+		metrik = nil
+		for _, m := range strings.Split(s, ",") {
+			m = strings.TrimSpace(m)
+			if _, ok := metrikGeoBlok[m]; !ok {
+				respondJSON(w, http.StatusBadRequest, APIResponse{
+					Success: false,
+					Message: "Metrik '" + m + "' tidak dikenal",
+				})
+				return
+			}
+			metrik = append(metrik, m)
+		}
+	}
+
+	query := config.DB.Preload("Peta").Where("peta_id IS NOT NULL")
+	if afdeling := q.Get("afdeling"); afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		query = query.Where("peta_id IN (?)", config.DB.Model(&models.Peta{}).Select("id").Where("afdeling_id = ?", afd.ID))
+	}
+	jenisKebun := strings.TrimSpace(q.Get("jenisKebun"))
+	if jenisKebun != "" {
+		query = query.Where("peta_id IN (?)", config.DB.Model(&models.Peta{}).Select("id").
+			Where("UPPER(jenis_kebun) = ?", strings.ToUpper(jenisKebun)))
+	}
+
+	var geometris []models.PetaGeometri
+	if err := query.Order("code asc").Find(&geometris).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil geometri blok: " + err.Error(),
+		})
+		return
+	}
+
+	// Produksi dihitung tanpa filter afdeling agar alokasi manual lintas
+	// afdeling tetap terbagi sama seperti di endpoint produksi per blok
+	hasil, err := hitungProduksiBlok(config.DB, f)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghitung produksi per blok: " + err.Error(),
+		})
+		return
+	}
+	produksiByPeta := make(map[uint]ProduksiBlok, len(hasil.Blok))
+	for _, b := range hasil.Blok {
+		produksiByPeta[b.PetaID] = b
+	}
+
+	fc := GeoFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]GeoFeature, 0, len(geometris)),
+		Filter: map[string]string{
+			"tanggal_awal":  f.Awal.Format("2006-01-02"),
+			"tanggal_akhir": f.Akhir.Format("2006-01-02"),
+			"tipe_produksi": f.TipeProduksi,
+			"sumber":        f.Sumber,
+			"afdeling":      q.Get("afdeling"),
+			"jenis_kebun":   jenisKebun,
+			"metrik":        strings.Join(metrik, ","),
+		},
+		Rentang: make(map[string][2]float64),
+	}
+
+	for _, g := range geometris {
+		if g.Peta == nil {
+			continue
+		}
+		p := g.Peta
+		props := map[string]interface{}{
+			"peta_id":      p.ID,
+			"code":         p.Code,
+			"blok":         p.Blok,
+			"afdeling":     p.Afdeling,
+			"jenis_kebun":  p.JenisKebun,
+			"tahun_tanam":  p.TahunTanam,
+			"kloon":        p.Kloon,
+			"luas":         p.Luas,
+			"jumlah_pohon": p.JumlahPohon,
+		}
+
+		if luas, err := luasGeometriHa(g); err == nil {
+			props["luas_geometri"] = luas
+		}
+
+		b, ada := produksiByPeta[p.ID]
+		if !ada {
+			b = ProduksiBlok{PetaID: p.ID}
+		}
+		props["ada_produksi"] = ada
+		for _, m := range metrik {
+			v := metrikGeoBlok[m](b)
+			props[m] = v
+
+			var nilai float64
+			switch x := v.(type) {
+			case float64:
+				nilai = x
+			case *float64:
+				if x == nil {
+					continue
+				}
+				nilai = *x
+			}
+			if !ada {
+				continue
+			}
+			rentang, ok := fc.Rentang[m]
+			if !ok {
+				rentang = [2]float64{nilai, nilai}
+			}
+			rentang[0] = math.Min(rentang[0], nilai)
+			rentang[1] = math.Max(rentang[1], nilai)
+			fc.Rentang[m] = rentang
+		}
+
+		fc.Features = append(fc.Features, GeoFeature{
+			Type:       "Feature",
+			ID:         p.ID,
+			Geometry:   json.RawMessage(g.GeoJSON),
+			Properties: props,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(fc)
+}
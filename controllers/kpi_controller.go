@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Periode KPI relatif terhadap tanggal acuan
+const (
+	periodeHari  = "hari"
+	periodeBulan = "bulan"
+	periodeYTD   = "ytd"
+)
+
+// Level pengelompokan KPI
+const (
+	levelBlok     = "blok"
+	levelAfdeling = "afdeling"
+	levelKloon    = "kloon"
+)
+
+const tanpaKloon = "TANPA KLOON"
+
+// KPIProduktivitas adalah rasio produktivitas satu kelompok (blok, afdeling
+// atau kloon). Rasio dihitung dari jumlah kering dibagi jumlah luas/pohon
+// blok yang berproduksi, bukan rata-rata rasio per blok.
+type KPIProduktivitas struct {
+	Kunci       string  `json:"kunci"`
+	Nama        string  `json:"nama"`
+	JumlahBlok  int     `json:"jumlah_blok"`
+	Luas        float64 `json:"luas"`
+	JumlahPohon int64   `json:"jumlah_pohon"`
+	Kering      float64 `json:"kering"`
+	HKO         float64 `json:"hko"`
+	KgPerHa     float64 `json:"kg_per_ha"`
+	KgPerPohon  float64 `json:"kg_per_pohon"`
+	KgPerHKO    float64 `json:"kg_per_hko"`
+}
+
+// PeriodeKPI adalah KPI untuk satu rentang tanggal
+type PeriodeKPI struct {
+	Periode      string             `json:"periode"`
+	TanggalAwal  string             `json:"tanggal_awal"`
+	TanggalAkhir string             `json:"tanggal_akhir"`
+	Data         []KPIProduktivitas `json:"data"`
+	Total        KPIProduktivitas   `json:"total"`
+}
+
+// KelompokTahunTanam membandingkan kloon pada blok dengan tahun tanam serupa
+type KelompokTahunTanam struct {
+	TahunAwal  int                 `json:"tahun_awal"`
+	TahunAkhir int                 `json:"tahun_akhir"`
+	RataRata   KPIProduktivitas    `json:"rata_rata"`
+	Kloon      []PerbandinganKloon `json:"kloon"`
+}
+
+// PerbandinganKloon adalah KPI satu kloon beserta indeks terhadap rata-rata
+// kelompok tahun tanamnya (100 = sama dengan rata-rata)
+type PerbandinganKloon struct {
+	KPIProduktivitas
+	IndeksKgPerHa    float64 `json:"indeks_kg_per_ha"`
+	IndeksKgPerPohon float64 `json:"indeks_kg_per_pohon"`
+	Peringkat        int     `json:"peringkat"`
+}
+
+// rentangPeriode mengembalikan tanggal awal periode yang berakhir di tanggal acuan
+func rentangPeriode(periode string, tanggal time.Time) (time.Time, bool) {
+	switch periode {
+	case periodeHari:
+		return tanggal, true
+	case periodeBulan:
+		return time.Date(tanggal.Year(), tanggal.Month(), 1, 0, 0, 0, 0, tanggal.Location()), true
+	case periodeYTD:
+		return time.Date(tanggal.Year(), 1, 1, 0, 0, 0, 0, tanggal.Location()), true
+	}
+	return time.Time{}, false
+}
+
+// normalisasiKloon menyamakan penulisan kloon ("PB 260", "pb260" -> "PB260")
+func normalisasiKloon(kloon string) string {
+	kunci := strings.ToUpper(strings.Join(strings.Fields(kloon), ""))
+	if kunci == "" || kunci == "-" {
+		return tanpaKloon
+	}
+	return kunci
+}
+
+// kunciKPI menentukan kelompok sebuah blok untuk level tertentu
+func kunciKPI(level string, b ProduksiBlok) (kunci, nama string) {
+	switch level {
+	case levelAfdeling:
+		return strings.ToUpper(b.Afdeling), b.Afdeling
+	case levelKloon:
+		kunci = normalisasiKloon(b.Kloon)
+		if kunci == tanpaKloon {
+			return kunci, tanpaKloon
+		}
+		return kunci, strings.TrimSpace(b.Kloon)
+	}
+	nama = b.Code
+	if b.Blok != "" {
+		nama = b.Code + " - " + b.Blok
+	}
+	return b.Code, nama
+}
+
+func (k *KPIProduktivitas) tambah(b ProduksiBlok) {
+	k.JumlahBlok++
+	k.Luas += b.Luas
+	k.JumlahPohon += b.JumlahPohon
+	k.Kering += b.Kering
+	k.HKO += b.HKO
+}
+
+func (k *KPIProduktivitas) hitungRasio() {
+	k.Luas = roundTo(k.Luas, 2)
+	k.Kering = roundTo(k.Kering, 2)
+	k.HKO = roundTo(k.HKO, 2)
+	k.KgPerHa, k.KgPerPohon, k.KgPerHKO = 0, 0, 0
+	if k.Luas > 0 {
+		k.KgPerHa = roundTo(k.Kering/k.Luas, 2)
+	}
+	if k.JumlahPohon > 0 {
+		k.KgPerPohon = roundTo(k.Kering/float64(k.JumlahPohon), 4)
+	}
+	if k.HKO > 0 {
+		k.KgPerHKO = roundTo(k.Kering/k.HKO, 2)
+	}
+}
+
+// kelompokkanKPI menjumlahkan produksi blok per kelompok level
+func kelompokkanKPI(level string, blok []ProduksiBlok) ([]KPIProduktivitas, KPIProduktivitas) {
+	index := make(map[string]*KPIProduktivitas)
+	var urutan []string
+	total := KPIProduktivitas{Kunci: "TOTAL", Nama: "Total"}
+
+	for _, b := range blok {
+		kunci, nama := kunciKPI(level, b)
+		k, ok := index[kunci]
+		if !ok {
+			k = &KPIProduktivitas{Kunci: kunci, Nama: nama}
+			index[kunci] = k
+			urutan = append(urutan, kunci)
+		}
+		k.tambah(b)
+		total.tambah(b)
+	}
+
+	data := make([]KPIProduktivitas, 0, len(urutan))
+	for _, kunci := range urutan {
+		index[kunci].hitungRasio()
+		data = append(data, *index[kunci])
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].KgPerHa > data[j].KgPerHa
+	})
+	total.hitungRasio()
+	return data, total
+}
+
+// parseFilterKPI membaca parameter bersama endpoint KPI: tanggal (default
+// hari ini), afdeling, tipeProduksi, sumber
+func parseFilterKPI(r *http.Request) (time.Time, filterProduksiBlok, string) {
+	q := r.URL.Query()
+	f := filterProduksiBlok{
+		TipeProduksi: q.Get("tipeProduksi"),
+		Sumber:       q.Get("sumber"),
+	}
+
+	tanggal := time.Now()
+	if s := q.Get("tanggal"); s != "" {
+		parsed, err := parseTanggalPenugasan(s)
+		if err != nil {
+			return tanggal, f, "Format tanggal tidak valid (gunakan: YYYY-MM-DD)"
+		}
+		tanggal = parsed
+	}
+	tanggal = time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, tanggal.Location())
+
+	if f.Sumber == "" {
+		f.Sumber = sumberDataRekap
+	}
+	if f.Sumber != sumberDataRekap && f.Sumber != sumberDataProduksi {
+		return tanggal, f, "Parameter sumber harus 'rekap' atau 'produksi'"
+	}
+	if afdeling := q.Get("afdeling"); afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			return tanggal, f, err.Error()
+		}
+		f.AfdelingID = afd.ID
+	}
+	return tanggal, f, ""
+}
+
+// GetKPIProduktivitas mengembalikan kg kering per hektar, per pohon dan per
+// HKO untuk hari ini, s/d bulan ini dan s/d tahun ini (year-to-date).
+// Parameter: tanggal, level (blok/afdeling/kloon, default blok), periode
+// (hari/bulan/ytd, default ketiganya), afdeling, tipeProduksi, sumber
+func GetKPIProduktivitas(w http.ResponseWriter, r *http.Request) {
+	tanggal, f, pesan := parseFilterKPI(r)
+	if pesan != "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: pesan,
+		})
+		return
+	}
+
+	level := r.URL.Query().Get("level")
+	if level == "" {
+		level = levelBlok
+	}
+	if level != levelBlok && level != levelAfdeling && level != levelKloon {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter level harus 'blok', 'afdeling' atau 'kloon'",
+		})
+		return
+	}
+
+	periodeList := []string{periodeHari, periodeBulan, periodeYTD}
+	if p := r.URL.Query().Get("periode"); p != "" {
+		periodeList = []string{p}
+	}
+
+	hasil := make([]PeriodeKPI, 0, len(periodeList))
+	for _, periode := range periodeList {
+		awal, ok := rentangPeriode(periode, tanggal)
+		if !ok {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Parameter periode harus 'hari', 'bulan' atau 'ytd'",
+			})
+			return
+		}
+
+		f.Awal, f.Akhir = awal, tanggal
+		blok, err := hitungProduksiBlok(config.DB, f)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Gagal menghitung KPI: " + err.Error(),
+			})
+			return
+		}
+
+		data, total := kelompokkanKPI(level, blok.Blok)
+		hasil = append(hasil, PeriodeKPI{
+			Periode:      periode,
+			TanggalAwal:  awal.Format("2006-01-02"),
+			TanggalAkhir: tanggal.Format("2006-01-02"),
+			Data:         data,
+			Total:        total,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "KPI produktivitas per " + level,
+		Data:    hasil,
+	})
+}
+
+// GetPerbandinganKloon membandingkan kinerja kloon (mis. BPM 1 vs PB 260 vs
+// IRR 118) pada blok dengan tahun tanam serupa. Blok dikelompokkan per
+// rentangTahun tahun (default 5) agar umur tanaman sebanding.
+// Parameter: tanggal, periode (default ytd), rentangTahun, afdeling, tipeProduksi, sumber
+func GetPerbandinganKloon(w http.ResponseWriter, r *http.Request) {
+	tanggal, f, pesan := parseFilterKPI(r)
+	if pesan != "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: pesan,
+		})
+		return
+	}
+
+	periode := r.URL.Query().Get("periode")
+	if periode == "" {
+		periode = periodeYTD
+	}
+	awal, ok := rentangPeriode(periode, tanggal)
+	if !ok {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter periode harus 'hari', 'bulan' atau 'ytd'",
+		})
+		return
+	}
+
+	rentang := 5
+	if s := r.URL.Query().Get("rentangTahun"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Parameter rentangTahun harus bilangan bulat positif",
+			})
+			return
+		}
+		rentang = n
+	}
+
+	f.Awal, f.Akhir = awal, tanggal
+	hasilBlok, err := hitungProduksiBlok(config.DB, f)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghitung KPI: " + err.Error(),
+		})
+		return
+	}
+
+	// Kelompokkan blok per rentang tahun tanam; blok tanpa tahun dilewati
+	perKelompok := make(map[int][]ProduksiBlok)
+	for _, b := range hasilBlok.Blok {
+		tahun, err := strconv.Atoi(kunciTahunTanam(b.TahunTanam))
+		if err != nil {
+			continue
+		}
+		mulai := tahun - tahun%rentang
+		perKelompok[mulai] = append(perKelompok[mulai], b)
+	}
+
+	kelompok := make([]KelompokTahunTanam, 0, len(perKelompok))
+	for mulai, blok := range perKelompok {
+		data, rata := kelompokkanKPI(levelKloon, blok)
+		rata.Kunci, rata.Nama = "RATA-RATA", "Rata-rata kelompok"
+
+		kloon := make([]PerbandinganKloon, len(data))
+		for i, k := range data {
+			kloon[i] = PerbandinganKloon{KPIProduktivitas: k, Peringkat: i + 1}
+			if rata.KgPerHa > 0 {
+				kloon[i].IndeksKgPerHa = roundTo(k.KgPerHa/rata.KgPerHa*100, 1)
+			}
+			if rata.KgPerPohon > 0 {
+				kloon[i].IndeksKgPerPohon = roundTo(k.KgPerPohon/rata.KgPerPohon*100, 1)
+			}
+		}
+
+		kelompok = append(kelompok, KelompokTahunTanam{
+			TahunAwal:  mulai,
+			TahunAkhir: mulai + rentang - 1,
+			RataRata:   rata,
+			Kloon:      kloon,
+		})
+	}
+	sort.Slice(kelompok, func(i, j int) bool {
+		return kelompok[i].TahunAwal < kelompok[j].TahunAwal
+	})
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Perbandingan kloon " + awal.Format("2006-01-02") + " s/d " + tanggal.Format("2006-01-02"),
+		Data:    kelompok,
+	})
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Ambang default selisih luas tercatat vs luas poligon (%)
+const ambangSelisihLuasDefault = 10.0
+
+// Jenis masalah data blok
+const (
+	masalahLuasKosong          = "luas_kosong"
+	masalahLuasBerbeda         = "luas_berbeda"
+	masalahPohonKosong         = "jumlah_pohon_kosong"
+	masalahTahunTanamKosong    = "tahun_tanam_kosong"
+	masalahTahunTanamSalah     = "tahun_tanam_tidak_valid"
+	masalahTanpaPoligon        = "tanpa_poligon"
+	masalahPoligonRusak        = "poligon_tidak_valid"
+	masalahPoligonBelumTertaut = "poligon_belum_tertaut"
+)
+
+// KualitasBlok adalah satu blok Peta beserta masalah datanya
+type KualitasBlok struct {
+	PetaID        uint     `json:"peta_id"`
+	Code          string   `json:"code"`
+	Blok          string   `json:"blok"`
+	Afdeling      string   `json:"afdeling"`
+	JenisKebun    string   `json:"jenis_kebun"`
+	TahunTanam    string   `json:"tahun_tanam"`
+	JumlahPohon   int64    `json:"jumlah_pohon"`
+	Luas          float64  `json:"luas"`
+	LuasGeometri  *float64 `json:"luas_geometri"`
+	SelisihLuas   *float64 `json:"selisih_luas"`
+	SelisihPersen *float64 `json:"selisih_persen"`
+	Masalah       []string `json:"masalah"`
+}
+
+// LaporanKualitasPeta adalah ringkasan kualitas data register blok
+type LaporanKualitasPeta struct {
+	AmbangPersen     float64        `json:"ambang_persen"`
+	JumlahBlok       int            `json:"jumlah_blok"`
+	JumlahBermasalah int            `json:"jumlah_bermasalah"`
+	PerMasalah       map[string]int `json:"per_masalah"`
+	Blok             []KualitasBlok `json:"blok"`
+}
+
+// tahunTanamValid menerima "1999" atau "ex 1999" dengan tahun yang masuk akal
+func tahunTanamValid(s string) bool {
+	_, _, err := models.ParseTahunTanam(s)
+	return err == nil
+}
+
+// periksaBlok mengisi luas geometri dan daftar masalah satu blok
+func periksaBlok(p models.Peta, g *models.PetaGeometri, ambang float64) KualitasBlok {
+	k := KualitasBlok{
+		PetaID:      p.ID,
+		Code:        p.Code,
+		Blok:        p.Blok,
+		Afdeling:    p.Afdeling,
+		JenisKebun:  p.JenisKebun,
+		TahunTanam:  p.TahunTanam,
+		JumlahPohon: p.JumlahPohon,
+		Luas:        roundTo(float64(p.Luas), 2),
+		Masalah:     []string{},
+	}
+
+	if k.Luas <= 0 {
+		k.Masalah = append(k.Masalah, masalahLuasKosong)
+	}
+	if p.JumlahPohon <= 0 {
+		k.Masalah = append(k.Masalah, masalahPohonKosong)
+	}
+	if strings.TrimSpace(p.TahunTanam) == "" {
+		k.Masalah = append(k.Masalah, masalahTahunTanamKosong)
+	} else if !tahunTanamValid(p.TahunTanam) {
+		k.Masalah = append(k.Masalah, masalahTahunTanamSalah)
+	}
+
+	if g == nil {
+		k.Masalah = append(k.Masalah, masalahTanpaPoligon)
+		return k
+	}
+	luasGeo, err := luasGeometriHa(*g)
+	if err != nil {
+		k.Masalah = append(k.Masalah, masalahPoligonRusak)
+		return k
+	}
+	k.LuasGeometri = &luasGeo
+
+	if k.Luas > 0 && luasGeo > 0 {
+		selisih := roundTo(k.Luas-luasGeo, 2)
+		persen := roundTo(selisih/luasGeo*100, 2)
+		k.SelisihLuas = &selisih
+		k.SelisihPersen = &persen
+		if math.Abs(persen) > ambang {
+			k.Masalah = append(k.Masalah, masalahLuasBerbeda)
+		}
+	}
+	return k
+}
+
+// GetKualitasPeta membandingkan Peta.Luas dengan luas geodesik poligon KML
+// dan mendaftar blok dengan Luas/JumlahPohon/TahunTanam yang kosong atau
+// tidak valid. Parameter: ambang (% selisih luas, default 10), afdeling,
+// jenisKebun, semua (true = tampilkan juga blok tanpa masalah)
+func GetKualitasPeta(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	ambang := ambangSelisihLuasDefault
+	if s := q.Get("ambang"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Parameter ambang harus angka positif (persen)",
+			})
+			return
+		}
+		ambang = v
+	}
+
+	query := config.DB.Order("afdeling asc, code asc")
+	if afdeling := q.Get("afdeling"); afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
+	}
+	if jenisKebun := strings.TrimSpace(q.Get("jenisKebun")); jenisKebun != "" {
+		query = query.Where("UPPER(jenis_kebun) = ?", strings.ToUpper(jenisKebun))
+	}
+
+	var petas []models.Peta
+	if err := query.Find(&petas).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data peta: " + err.Error(),
+		})
+		return
+	}
+
+	// Poligon dicocokkan lewat kode seperti tautkanGeometriPeta; tautan yang
+	// belum sesuai dilaporkan sebagai masalah, bukan diperbaiki di sini
+	var geometris []models.PetaGeometri
+	if err := config.DB.Find(&geometris).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil geometri blok: " + err.Error(),
+		})
+		return
+	}
+	geoByCode := make(map[string]*models.PetaGeometri, len(geometris))
+	for i := range geometris {
+		geoByCode[geometris[i].Code] = &geometris[i]
+	}
+
+	semua := q.Get("semua") == "true" || q.Get("semua") == "1"
+	laporan := LaporanKualitasPeta{
+		AmbangPersen: ambang,
+		JumlahBlok:   len(petas),
+		PerMasalah:   make(map[string]int),
+		Blok:         []KualitasBlok{},
+	}
+	for _, p := range petas {
+		g := geoByCode[normalisasiKodeBlok(p.Code)]
+		k := periksaBlok(p, g, ambang)
+		if g != nil && (g.PetaID == nil || *g.PetaID != p.ID) {
+			k.Masalah = append(k.Masalah, masalahPoligonBelumTertaut)
+		}
+		for _, m := range k.Masalah {
+			laporan.PerMasalah[m]++
+		}
+		if len(k.Masalah) > 0 {
+			laporan.JumlahBermasalah++
+		}
+		if semua || len(k.Masalah) > 0 {
+			laporan.Blok = append(laporan.Blok, k)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Laporan kualitas data peta",
+		Data:    laporan,
+	})
+}
@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format unduhan laporan lewat parameter ?format=
+const (
+	formatXLSX = "xlsx"
+	formatCSV  = "csv"
+)
+
+// jenisKolom menentukan format angka kolom laporan
+type jenisKolom int
+
+const (
+	kolomTeks jenisKolom = iota
+	kolomTanggal
+	kolomBulat
+	kolomAngka
+	kolomPersen // nilai sudah dikali 100
+)
+
+// KolomLaporan adalah satu kolom tabel laporan. Jumlah menandai kolom yang
+// dijumlahkan di baris total.
+type KolomLaporan struct {
+	Judul  string
+	Jenis  jenisKolom
+	Jumlah bool
+}
+
+// TabelLaporan adalah isi file laporan: judul, keterangan filter, kolom,
+// baris data dan baris total (nil berarti tanpa baris total)
+type TabelLaporan struct {
+	Judul  string
+	Filter []string
+	Kolom  []KolomLaporan
+	Baris  [][]interface{}
+	Total  []interface{}
+}
+
+// formatLaporan membaca ?format=. String kosong berarti respons JSON biasa.
+func formatLaporan(r *http.Request) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))); f {
+	case "", "json":
+		return "", nil
+	case formatXLSX, "excel":
+		return formatXLSX, nil
+	case formatCSV:
+		return formatCSV, nil
+	default:
+		return "", fmt.Errorf("format '%s' tidak didukung (gunakan json, xlsx atau csv)", f)
+	}
+}
+
+// hitungTotal mengisi baris total dengan jumlah kolom bertanda Jumlah.
+// Kolom rasio bisa diisi pemanggil setelahnya.
+func (t *TabelLaporan) hitungTotal() []interface{} {
+	t.Total = make([]interface{}, len(t.Kolom))
+	t.Total[0] = "TOTAL"
+	for i, k := range t.Kolom {
+		if !k.Jumlah {
+			continue
+		}
+		jumlah := 0.0
+		for _, baris := range t.Baris {
+			switch v := baris[i].(type) {
+			case float64:
+				jumlah += v
+			case int:
+				jumlah += float64(v)
+			case int64:
+				jumlah += float64(v)
+			case uint:
+				jumlah += float64(v)
+			}
+		}
+		t.Total[i] = jumlah
+	}
+	return t.Total
+}
+
+// kirimLaporan menulis tabel sebagai file unduhan sesuai format
+func kirimLaporan(w http.ResponseWriter, r *http.Request, format, namaFile string, t TabelLaporan) {
+	namaFile = fmt.Sprintf("%s_%s.%s", namaFile, time.Now().Format("20060102_1504"), format)
+
+	if format == formatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename="+namaFile)
+		if err := tulisLaporanCSV(w, t); err != nil {
+			log.Printf("Error writing CSV report %s: %v", namaFile, err)
+		}
+		return
+	}
+
+	f, err := buatLaporanXLSX(t, usernameDariRequest(r))
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal membuat file Excel: " + err.Error(),
+		})
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename="+namaFile)
+	if err := f.Write(w); err != nil {
+		log.Printf("Error writing Excel report %s: %v", namaFile, err)
+	}
+}
+
+// teksSel mengubah nilai sel menjadi teks untuk CSV
+func teksSel(v interface{}, jenis jenisKolom) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return x.Format("2006-01-02")
+	case float64:
+		if jenis == kolomBulat {
+			return strconv.FormatFloat(x, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(roundTo(x, 2), 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// tulisLaporanCSV menulis header, data dan total tanpa blok judul agar file
+// tetap mudah dibaca program lain
+func tulisLaporanCSV(w http.ResponseWriter, t TabelLaporan) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = ','
+
+	header := make([]string, len(t.Kolom))
+	for i, k := range t.Kolom {
+		header[i] = k.Judul
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	baris := t.Baris
+	if t.Total != nil {
+		baris = append(baris[:len(baris):len(baris)], t.Total)
+	}
+	for _, b := range baris {
+		rec := make([]string, len(t.Kolom))
+		for i := range t.Kolom {
+			rec[i] = teksSel(b[i], t.Kolom[i].Jenis)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// buatLaporanXLSX menyusun workbook: blok judul (judul, filter, tanggal
+// cetak), header, data dengan format angka, lalu baris total
+func buatLaporanXLSX(t TabelLaporan, username string) (*excelize.File, error) {
+	f := excelize.NewFile()
+	sheet := "Laporan"
+	f.SetSheetName("Sheet1", sheet)
+
+	kolomAkhir, _ := excelize.ColumnNumberToName(len(t.Kolom))
+	fmtTanggal := "dd/mm/yyyy"
+	fmtPersen := `0.00"%"`
+
+	judul, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	ket, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Italic: true, Color: "#595959"}})
+	garis := []excelize.Border{
+		{Type: "left", Color: "#BFBFBF", Style: 1},
+		{Type: "right", Color: "#BFBFBF", Style: 1},
+		{Type: "top", Color: "#BFBFBF", Style: 1},
+		{Type: "bottom", Color: "#BFBFBF", Style: 1},
+	}
+	header, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#D9E1F2"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		Border:    garis,
+	})
+
+	// Style data dan total per jenis kolom
+	styleData := make(map[jenisKolom]int)
+	styleTotal := make(map[jenisKolom]int)
+	for _, jenis := range []jenisKolom{kolomTeks, kolomTanggal, kolomBulat, kolomAngka, kolomPersen} {
+		s := excelize.Style{Border: garis}
+		switch jenis {
+		case kolomTanggal:
+			s.CustomNumFmt = &fmtTanggal
+		case kolomBulat:
+			s.NumFmt = 3 // #,##0
+		case kolomAngka:
+			s.NumFmt = 4 // #,##0.00
+		case kolomPersen:
+			s.CustomNumFmt = &fmtPersen
+		}
+		id, err := f.NewStyle(&s)
+		if err != nil {
+			return nil, err
+		}
+		styleData[jenis] = id
+
+		s.Font = &excelize.Font{Bold: true}
+		s.Fill = excelize.Fill{Type: "pattern", Color: []string{"#F2F2F2"}, Pattern: 1}
+		if id, err = f.NewStyle(&s); err != nil {
+			return nil, err
+		}
+		styleTotal[jenis] = id
+	}
+
+	// Blok judul
+	row := 1
+	f.SetCellValue(sheet, "A1", t.Judul)
+	f.SetCellStyle(sheet, "A1", "A1", judul)
+	for _, filter := range t.Filter {
+		row++
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), filter)
+	}
+	row++
+	dicetak := "Dicetak: " + time.Now().Format("02/01/2006 15:04")
+	if username != "" {
+		dicetak += " oleh " + username
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), dicetak)
+	f.SetCellStyle(sheet, "A2", fmt.Sprintf("A%d", row), ket)
+	row += 2
+
+	// Header
+	barisHeader := row
+	for i, k := range t.Kolom {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		f.SetCellValue(sheet, cell, k.Judul)
+	}
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("%s%d", kolomAkhir, row), header)
+
+	tulis := func(nilai []interface{}, style map[jenisKolom]int) {
+		row++
+		for i, k := range t.Kolom {
+			cell, _ := excelize.CoordinatesToCellName(i+1, row)
+			if f64, ok := nilai[i].(float64); ok && k.Jenis != kolomBulat {
+				nilai[i] = roundTo(f64, 2)
+			}
+			if nilai[i] != nil {
+				f.SetCellValue(sheet, cell, nilai[i])
+			}
+			f.SetCellStyle(sheet, cell, cell, style[k.Jenis])
+		}
+	}
+	for _, b := range t.Baris {
+		tulis(b, styleData)
+	}
+	if t.Total != nil {
+		tulis(t.Total, styleTotal)
+	}
+
+	// Lebar kolom mengikuti judul kolom
+	for i, k := range t.Kolom {
+		nama, _ := excelize.ColumnNumberToName(i + 1)
+		lebar := float64(len(k.Judul)) + 2
+		if lebar < 12 {
+			lebar = 12
+		}
+		if k.Jenis == kolomTeks && lebar < 20 {
+			lebar = 20
+		}
+		f.SetColWidth(sheet, nama, nama, lebar)
+	}
+
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      barisHeader,
+		TopLeftCell: fmt.Sprintf("A%d", barisHeader+1),
+		ActivePane:  "bottomLeft",
+	})
+
+	// Siap cetak: landscape, lebar muat satu halaman
+	landscape := "landscape"
+	satu, nol := 1, 0
+	muat := true
+	f.SetSheetProps(sheet, &excelize.SheetPropsOptions{FitToPage: &muat})
+	f.SetPageLayout(sheet, &excelize.PageLayoutOptions{Orientation: &landscape, FitToWidth: &satu, FitToHeight: &nol})
+	return f, nil
+}
@@ -0,0 +1,632 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"app-inputan-ptpn/pdf"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Folder logo untuk kop laporan, sama dengan yang disajikan di /asset/
+const folderAsset = "templates/asset"
+
+var (
+	warnaLatekKebun  = pdf.Warna{31, 119, 180}
+	warnaLatekPabrik = pdf.Warna{255, 127, 14}
+	warnaKering      = pdf.Warna{44, 160, 44}
+)
+
+// angkaID menulis angka dengan format Indonesia: 1234.5 -> "1.234,50"
+func angkaID(v float64, desimal int) string {
+	s := fmt.Sprintf("%.*f", desimal, math.Abs(v))
+	bulat, pecahan := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		bulat, pecahan = s[:i], s[i+1:]
+	}
+	var b strings.Builder
+	if v < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, c := range bulat {
+		if i > 0 && (len(bulat)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if pecahan != "" {
+		b.WriteString("," + pecahan)
+	}
+	return b.String()
+}
+
+// laporanPDF menyiapkan dokumen dengan kop (logo PTPN, nama perusahaan dan
+// kebun) yang dicetak ulang di setiap halaman lanjutan
+func laporanPDF(landscape bool, kebun, judul string) (*pdf.Dokumen, float64) {
+	d := pdf.Baru(landscape)
+	d.Judul = judul
+	d.Footer = "Dicetak " + time.Now().Format("02/01/2006 15:04")
+	d.SetelahHalamanBaru = func(d *pdf.Dokumen) float64 {
+		return kopLaporan(d, kebun)
+	}
+	d.HalamanBaru()
+	return d, kopLaporan(d, kebun)
+}
+
+// kopLaporan menggambar kop surat dan mengembalikan posisi y di bawahnya.
+// Logo yang tidak ada hanya dicatat di log agar laporan tetap terbit.
+func kopLaporan(d *pdf.Dokumen, kebun string) float64 {
+	y := d.MarginAtas - 5
+	if err := d.GambarFile(filepath.Join(folderAsset, "logoptpn.png"), d.MarginKiri, y, 24, 0); err != nil {
+		log.Printf("Logo kop laporan: %v", err)
+	}
+	if err := d.GambarFile(filepath.Join(folderAsset, "Logo_BUMN.png"), d.Lebar()-d.MarginKanan-26, y+1, 26, 0); err != nil {
+		log.Printf("Logo kop laporan: %v", err)
+	}
+
+	d.SetWarnaTeks(pdf.Hitam)
+	d.SetFont(true, 13)
+	d.TeksKotak(d.MarginKiri, y+6, d.LebarIsi(), "PT PERKEBUNAN NUSANTARA I", pdf.Tengah)
+	d.SetFont(true, 10)
+	d.TeksKotak(d.MarginKiri, y+11, d.LebarIsi(), "SUPPORTING CO REGIONAL 3", pdf.Tengah)
+	d.SetFont(false, 10)
+	d.TeksKotak(d.MarginKiri, y+16, d.LebarIsi(), strings.ToUpper(kebun), pdf.Tengah)
+
+	d.SetWarnaGaris(pdf.Hitam)
+	d.SetTebalGaris(0.6)
+	d.Garis(d.MarginKiri, y+19.5, d.Lebar()-d.MarginKanan, y+19.5)
+	d.SetTebalGaris(0.2)
+	d.Garis(d.MarginKiri, y+20.5, d.Lebar()-d.MarginKanan, y+20.5)
+	return y + 25
+}
+
+// judulLaporanPDF menulis judul laporan dan baris keterangan di tengah
+func judulLaporanPDF(d *pdf.Dokumen, y float64, judul string, keterangan ...string) float64 {
+	d.SetFont(true, 11)
+	d.TeksKotak(d.MarginKiri, y+4, d.LebarIsi(), judul, pdf.Tengah)
+	y += 4
+	d.SetFont(false, 9)
+	for _, k := range keterangan {
+		y += 4.5
+		d.TeksKotak(d.MarginKiri, y, d.LebarIsi(), k, pdf.Tengah)
+	}
+	return y + 5
+}
+
+// subjudulPDF menulis judul bagian rata kiri
+func subjudulPDF(d *pdf.Dokumen, y float64, teks string) float64 {
+	if y+20 > d.BatasBawah() {
+		d.HalamanBaru()
+		y = d.SetelahHalamanBaru(d)
+	}
+	d.SetFont(true, 9)
+	d.Teks(d.MarginKiri, y+3.5, teks)
+	return y + 5.5
+}
+
+// grafikPDF menaruh grafik selebar halaman, pindah halaman jika tidak muat
+func grafikPDF(d *pdf.Dokumen, y float64, judul string, label []string, seri []pdf.SeriGrafik) float64 {
+	const tinggi = 55
+	if len(label) == 0 {
+		return y
+	}
+	if y+tinggi+4 > d.BatasBawah() {
+		d.HalamanBaru()
+		y = d.SetelahHalamanBaru(d)
+	}
+	d.GrafikGaris(d.MarginKiri, y+4, d.LebarIsi(), tinggi, judul, label, seri)
+	return y + tinggi + 8
+}
+
+// penandatangan adalah satu kolom tanda tangan
+type penandatangan struct {
+	Keterangan string // "Dibuat oleh," / "Mengetahui,"
+	Jabatan    string
+}
+
+// blokTandaTangan mencetak tempat, tanggal dan kolom tanda tangan dengan
+// nama dikosongkan untuk diisi tangan
+func blokTandaTangan(d *pdf.Dokumen, y float64, tempat string, tanggal time.Time, ttd []penandatangan) {
+	const tinggi = 36
+	if y+tinggi > d.BatasBawah() {
+		d.HalamanBaru()
+		y = d.SetelahHalamanBaru(d)
+	}
+	y += 4
+	lebar := d.LebarIsi() / float64(len(ttd))
+	d.SetFont(false, 9)
+	d.SetWarnaTeks(pdf.Hitam)
+	d.SetWarnaGaris(pdf.Hitam)
+	kolomAkhir := d.MarginKiri + lebar*float64(len(ttd)-1)
+	d.TeksKotak(kolomAkhir, y, lebar, tempat+", "+tanggalIndonesia(tanggal), pdf.Tengah)
+	for i, t := range ttd {
+		x := d.MarginKiri + lebar*float64(i)
+		d.TeksKotak(x, y+5, lebar, t.Keterangan, pdf.Tengah)
+		d.SetFont(true, 9)
+		d.TeksKotak(x, y+9.5, lebar, t.Jabatan, pdf.Tengah)
+		d.SetFont(false, 9)
+		d.Garis(x+lebar*0.2, y+30, x+lebar*0.8, y+30)
+	}
+}
+
+// kirimPDF menulis dokumen sebagai unduhan
+func kirimPDF(w http.ResponseWriter, d *pdf.Dokumen, namaFile string) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "attachment; filename="+namaFile)
+	if err := d.Tulis(w); err != nil {
+		log.Printf("Error writing PDF %s: %v", namaFile, err)
+	}
+}
+
+// namaKebun mengambil nama kebun sebuah afdeling untuk kop laporan
+func namaKebun(kebunID uint) string {
+	var kebun models.Kebun
+	if err := config.GetDB().First(&kebun, kebunID).Error; err != nil {
+		return ""
+	}
+	return kebun.Nama
+}
+
+// kolomProduksiPDF adalah kolom angka yang dipakai bersama tabel PDF: HKO,
+// basah latek, basah lump, K3, kering dan produksi per taper
+var kolomProduksiPDF = []pdf.KolomTabel{
+	{Judul: "HKO", Lebar: 12, Rata: pdf.Kanan},
+	{Judul: "Latek\nKebun (kg)", Lebar: 20, Rata: pdf.Kanan},
+	{Judul: "Latek\nPabrik (kg)", Lebar: 20, Rata: pdf.Kanan},
+	{Judul: "Selisih\nLatek %", Lebar: 13, Rata: pdf.Kanan},
+	{Judul: "Lump\nKebun (kg)", Lebar: 20, Rata: pdf.Kanan},
+	{Judul: "Lump\nPabrik (kg)", Lebar: 20, Rata: pdf.Kanan},
+	{Judul: "Selisih\nLump %", Lebar: 13, Rata: pdf.Kanan},
+	{Judul: "K3 %", Lebar: 12, Rata: pdf.Kanan},
+	{Judul: "Kering\n(kg)", Lebar: 20, Rata: pdf.Kanan},
+	{Judul: "Per Taper\n(kg/HKO)", Lebar: 17, Rata: pdf.Kanan},
+}
+
+// selProduksiPDF mengisi kolomProduksiPDF dari satu periode barisRekap
+func selProduksiPDF(hko int, n nilaiRekap, perTaper float64) []string {
+	return []string{
+		angkaID(float64(hko), 0),
+		angkaID(n.LatekKebun, 2), angkaID(n.LatekPabrik, 2), angkaID(n.LatekPersen, 2),
+		angkaID(n.LumpKebun, 2), angkaID(n.LumpPabrik, 2), angkaID(n.LumpPersen, 2),
+		angkaID(n.K3, 2), angkaID(n.Jumlah, 2), angkaID(perTaper, 2),
+	}
+}
+
+// trenHarian adalah jumlah produksi hari ini per tanggal untuk grafik
+type trenHarian struct {
+	Tanggal     string
+	LatekKebun  float64
+	LatekPabrik float64
+	Kering      float64
+	HKO         float64
+}
+
+// ambilTrenHarian menjumlahkan produksi hari ini per tanggal untuk afdeling
+// yang diminta (tanpa baris REKAPITULASI agar tidak terhitung dua kali)
+func ambilTrenHarian(afdelingID []uint, awal, akhir time.Time) ([]trenHarian, error) {
+	var hasil []trenHarian
+	err := config.GetDB().Model(&models.Rekap{}).
+		Select(`DATE(tanggal) AS tanggal,
+			SUM(hari_ini_basah_latek_kebun) AS latek_kebun,
+			SUM(hari_ini_basah_latek_pabrik) AS latek_pabrik,
+			SUM(hari_ini_kering_jumlah) AS kering,
+			SUM(hko_hari_ini) AS hko`).
+		Where("afdeling_id IN ? AND tipe_produksi != ?", afdelingID, "REKAPITULASI").
+		Where("DATE(tanggal) BETWEEN ? AND ?", awal.Format("2006-01-02"), akhir.Format("2006-01-02")).
+		Group("DATE(tanggal)").
+		Order("DATE(tanggal)").
+		Scan(&hasil).Error
+	return hasil, err
+}
+
+// labelTren mengubah tanggal tren menjadi label sumbu "dd/mm"
+func labelTren(tren []trenHarian) []string {
+	label := make([]string, len(tren))
+	for i, t := range tren {
+		if tgl, err := time.Parse("2006-01-02", t.Tanggal[:min(len(t.Tanggal), 10)]); err == nil {
+			label[i] = tgl.Format("02/01")
+		} else {
+			label[i] = t.Tanggal
+		}
+	}
+	return label
+}
+
+// parseTanggalParam membaca parameter tanggal YYYY-MM-DD dengan nilai default
+func parseTanggalParam(r *http.Request, nama string, bawaan time.Time) (time.Time, error) {
+	s := r.URL.Query().Get(nama)
+	if s == "" {
+		return bawaan, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return t, fmt.Errorf("Format %s tidak valid (gunakan: YYYY-MM-DD)", nama)
+	}
+	return t, nil
+}
+
+// LaporanPDFHarian membuat laporan produksi harian satu afdeling: rekap per
+// mandor, jumlah per tipe produksi, tren 14 hari dan tanda tangan.
+// Parameter: afdeling (wajib), tanggal (YYYY-MM-DD, default hari ini)
+func LaporanPDFHarian(w http.ResponseWriter, r *http.Request) {
+	afd, err := resolveAfdeling(r.URL.Query().Get("afdeling"), false)
+	if err != nil || r.URL.Query().Get("afdeling") == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter afdeling diperlukan dan harus terdaftar",
+		})
+		return
+	}
+	tanggal, err := parseTanggalParam(r, "tanggal", time.Now())
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	var rekaps []models.Rekap
+	if err := config.GetDB().
+		Where("afdeling_id = ? AND tipe_produksi != ? AND DATE(tanggal) = ?", afd.ID, "REKAPITULASI", tanggal.Format("2006-01-02")).
+		Order("id asc").
+		Find(&rekaps).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data rekap: " + err.Error(),
+		})
+		return
+	}
+	if len(rekaps) == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Belum ada data rekap afdeling %s tanggal %s", afd.Nama, tanggal.Format("2006-01-02")),
+		})
+		return
+	}
+
+	d := susunPDFHarian(afd, tanggal, rekaps)
+	kirimPDF(w, d, namaPDFHarian(afd, tanggal))
+}
+
+// namaPDFHarian adalah nama file laporan harian satu afdeling
+func namaPDFHarian(afd models.Afdeling, tanggal time.Time) string {
+	return fmt.Sprintf("laporan_harian_%s_%s.pdf", afd.Kode, tanggal.Format("20060102"))
+}
+
+// susunPDFHarian menyusun dokumen laporan harian dari rekap (tanpa
+// REKAPITULASI) satu afdeling; dipakai juga untuk lampiran email
+func susunPDFHarian(afd models.Afdeling, tanggal time.Time, rekaps []models.Rekap) *pdf.Dokumen {
+	bagian, rekapitulasi, total := susunLaporanRekap(rekaps)
+
+	tren, err := ambilTrenHarian([]uint{afd.ID}, tanggal.AddDate(0, 0, -13), tanggal)
+	if err != nil {
+		log.Printf("Tren laporan harian %s: %v", afd.Kode, err)
+	}
+
+	kebun := namaKebun(afd.KebunID)
+	d, y := laporanPDF(true, kebun, "Laporan Produksi Harian "+afd.Nama)
+	y = judulLaporanPDF(d, y, "LAPORAN PRODUKSI HARIAN AFDELING "+strings.ToUpper(afd.Nama),
+		"Tanggal: "+tanggalIndonesia(tanggal))
+
+	// Rekap per mandor (gabungan semua tipe produksi)
+	kolom := append([]pdf.KolomTabel{
+		{Judul: "Tahun\nTanam", Lebar: 14, Rata: pdf.Tengah},
+		{Judul: "NIK", Lebar: 22},
+		{Judul: "Mandor", Lebar: 44},
+	}, kolomProduksiPDF...)
+	kolom = append(kolom, pdf.KolomTabel{Judul: "Kering s/d\nHari Ini (kg)", Lebar: 20, Rata: pdf.Kanan})
+
+	var baris []pdf.BarisTabel
+	for _, b := range rekapitulasi {
+		sel := append([]string{b.TahunTanam, b.NIK, b.Mandor}, selProduksiPDF(b.HKO, b.Hari, b.PerTaper)...)
+		baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(b.Sd.Jumlah, 2))})
+	}
+	sel := append([]string{"", "", "JUMLAH"}, selProduksiPDF(total.HKO, total.Hari, total.PerTaper)...)
+	baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(total.Sd.Jumlah, 2)), Tebal: true})
+
+	y = subjudulPDF(d, y, "Rekapitulasi per Mandor")
+	y = d.Tabel(y, kolom, baris) + 4
+
+	// Jumlah per tipe produksi
+	kolomTipe := append([]pdf.KolomTabel{{Judul: "Tipe Produksi", Lebar: 80}}, kolom[3:]...)
+	baris = nil
+	for _, bg := range bagian {
+		sel := append([]string{bg.Tipe}, selProduksiPDF(bg.Jumlah.HKO, bg.Jumlah.Hari, bg.Jumlah.PerTaper)...)
+		baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(bg.Jumlah.Sd.Jumlah, 2))})
+	}
+	sel = append([]string{"JUMLAH"}, selProduksiPDF(total.HKO, total.Hari, total.PerTaper)...)
+	baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(total.Sd.Jumlah, 2)), Tebal: true})
+
+	y = subjudulPDF(d, y, "Jumlah per Tipe Produksi")
+	y = d.Tabel(y, kolomTipe, baris) + 2
+
+	seriLatek := []pdf.SeriGrafik{
+		{Nama: "Latek Kebun", Warna: warnaLatekKebun},
+		{Nama: "Latek Pabrik", Warna: warnaLatekPabrik},
+		{Nama: "Kering", Warna: warnaKering},
+	}
+	for _, t := range tren {
+		seriLatek[0].Nilai = append(seriLatek[0].Nilai, t.LatekKebun)
+		seriLatek[1].Nilai = append(seriLatek[1].Nilai, t.LatekPabrik)
+		seriLatek[2].Nilai = append(seriLatek[2].Nilai, t.Kering)
+	}
+	y = grafikPDF(d, y, "Tren Produksi 14 Hari (kg)", labelTren(tren), seriLatek)
+
+	blokTandaTangan(d, y, strings.TrimPrefix(kebun, "Kebun "), tanggal, []penandatangan{
+		{Keterangan: "Dibuat oleh,", Jabatan: "Krani Afdeling"},
+		{Keterangan: "Diperiksa oleh,", Jabatan: "Asisten Afdeling"},
+		{Keterangan: "Mengetahui,", Jabatan: "Manager"},
+	})
+	return d
+}
+
+// LaporanPDFBulanan membuat ringkasan bulanan satu kebun: produksi s/d akhir
+// bulan per afdeling (dari tanggal terakhir yang ada datanya), tren harian
+// kebun dan tanda tangan. Parameter: bulan (YYYY-MM, default bulan ini),
+// kebun (kode, default kebun aktif pertama)
+func LaporanPDFBulanan(w http.ResponseWriter, r *http.Request) {
+	db := config.GetDB()
+	awal := time.Now()
+	if s := r.URL.Query().Get("bulan"); s != "" {
+		t, err := time.Parse("2006-01", s)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Format bulan tidak valid (gunakan: YYYY-MM)",
+			})
+			return
+		}
+		awal = t
+	}
+	awal = time.Date(awal.Year(), awal.Month(), 1, 0, 0, 0, 0, time.Local)
+	akhir := awal.AddDate(0, 1, -1)
+
+	var kebun models.Kebun
+	q := db.Preload("Afdelings", func(tx *gorm.DB) *gorm.DB { return tx.Order("kode") })
+	if kode := r.URL.Query().Get("kebun"); kode != "" {
+		q = q.Where("kode = ?", strings.ToUpper(kode))
+	} else {
+		q = q.Where("aktif = ?", true).Order("id")
+	}
+	if err := q.First(&kebun).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Kebun tidak ditemukan",
+		})
+		return
+	}
+
+	// Satu baris per afdeling dari nilai s/d hari ini pada tanggal terakhir
+	type ringkasAfdeling struct {
+		nama     string
+		terakhir string
+		baris    barisRekap
+	}
+	var daftar []ringkasAfdeling
+	var idAfdeling []uint
+	total := barisRekap{}
+	for _, afd := range kebun.Afdelings {
+		idAfdeling = append(idAfdeling, afd.ID)
+		base := db.Model(&models.Rekap{}).
+			Where("afdeling_id = ? AND tipe_produksi != ?", afd.ID, "REKAPITULASI")
+
+		var terakhir string
+		if err := base.Session(&gorm.Session{}).
+			Where("DATE(tanggal) BETWEEN ? AND ?", awal.Format("2006-01-02"), akhir.Format("2006-01-02")).
+			Select("COALESCE(MAX(DATE(tanggal)), '')").
+			Scan(&terakhir).Error; err != nil || terakhir == "" {
+			continue
+		}
+		terakhir = terakhir[:10]
+
+		var rekaps []models.Rekap
+		if err := base.Session(&gorm.Session{}).Where("DATE(tanggal) = ?", terakhir).Find(&rekaps).Error; err != nil {
+			continue
+		}
+		ringkas := ringkasAfdeling{nama: afd.Nama, terakhir: terakhir}
+		for _, rk := range rekaps {
+			ringkas.baris.tambah(barisDariRekap(rk))
+		}
+		ringkas.baris.hitungRasio()
+		total.tambah(ringkas.baris)
+		daftar = append(daftar, ringkas)
+	}
+	if len(daftar) == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Belum ada data rekap %s bulan %s", kebun.Nama, awal.Format("2006-01")),
+		})
+		return
+	}
+	total.hitungRasio()
+
+	tren, err := ambilTrenHarian(idAfdeling, awal, akhir)
+	if err != nil {
+		log.Printf("Tren laporan bulanan %s: %v", kebun.Kode, err)
+	}
+
+	d, y := laporanPDF(true, kebun.Nama, "Ringkasan Produksi Bulanan "+kebun.Nama)
+	y = judulLaporanPDF(d, y, "RINGKASAN PRODUKSI BULANAN "+strings.ToUpper(kebun.Nama),
+		fmt.Sprintf("Bulan: %s %d", namaBulan[awal.Month()], awal.Year()))
+
+	kolom := append([]pdf.KolomTabel{
+		{Judul: "Afdeling", Lebar: 55},
+		{Judul: "Data s/d", Lebar: 25, Rata: pdf.Tengah},
+	}, kolomProduksiPDF...)
+	kolom = append(kolom, pdf.KolomTabel{Judul: "Sheet\n(kg)", Lebar: 20, Rata: pdf.Kanan})
+
+	var baris []pdf.BarisTabel
+	for _, a := range daftar {
+		tgl, _ := time.Parse("2006-01-02", a.terakhir)
+		sel := append([]string{a.nama, tgl.Format("02/01/2006")}, selProduksiPDF(a.baris.HKOSd, a.baris.Sd, a.baris.PerTaperSd)...)
+		baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(a.baris.Sd.Sheet, 2))})
+	}
+	sel := append([]string{"JUMLAH KEBUN", ""}, selProduksiPDF(total.HKOSd, total.Sd, total.PerTaperSd)...)
+	baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(total.Sd.Sheet, 2)), Tebal: true})
+
+	y = subjudulPDF(d, y, "Produksi s/d Akhir Bulan per Afdeling")
+	y = d.Tabel(y, kolom, baris) + 2
+
+	seri := []pdf.SeriGrafik{
+		{Nama: "Latek Kebun", Warna: warnaLatekKebun},
+		{Nama: "Latek Pabrik", Warna: warnaLatekPabrik},
+		{Nama: "Kering", Warna: warnaKering},
+	}
+	for _, t := range tren {
+		seri[0].Nilai = append(seri[0].Nilai, t.LatekKebun)
+		seri[1].Nilai = append(seri[1].Nilai, t.LatekPabrik)
+		seri[2].Nilai = append(seri[2].Nilai, t.Kering)
+	}
+	y = grafikPDF(d, y, "Produksi Harian Kebun (kg)", labelTren(tren), seri)
+
+	blokTandaTangan(d, y, strings.TrimPrefix(kebun.Nama, "Kebun "), akhir, []penandatangan{
+		{Keterangan: "Dibuat oleh,", Jabatan: "Asisten Kepala"},
+		{Keterangan: "Mengetahui,", Jabatan: "Manager"},
+	})
+
+	kirimPDF(w, d, fmt.Sprintf("ringkasan_bulanan_%s_%s.pdf", kebun.Kode, awal.Format("200601")))
+}
+
+// LaporanPDFMandor membuat laporan kinerja mandor satu afdeling dalam rentang
+// tanggal, diurutkan dari produksi per taper tertinggi. Indeks 100 berarti
+// sama dengan rata-rata afdeling. Parameter: afdeling (wajib), tanggalAwal
+// (default awal bulan), tanggalAkhir (default hari ini)
+func LaporanPDFMandor(w http.ResponseWriter, r *http.Request) {
+	afd, err := resolveAfdeling(r.URL.Query().Get("afdeling"), false)
+	if err != nil || r.URL.Query().Get("afdeling") == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter afdeling diperlukan dan harus terdaftar",
+		})
+		return
+	}
+	now := time.Now()
+	akhir, err := parseTanggalParam(r, "tanggalAkhir", now)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	awal, err := parseTanggalParam(r, "tanggalAwal", time.Date(akhir.Year(), akhir.Month(), 1, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if awal.After(akhir) {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "tanggalAwal tidak boleh setelah tanggalAkhir",
+		})
+		return
+	}
+
+	var rekaps []models.Rekap
+	if err := config.GetDB().
+		Where("afdeling_id = ? AND tipe_produksi != ?", afd.ID, "REKAPITULASI").
+		Where("DATE(tanggal) BETWEEN ? AND ?", awal.Format("2006-01-02"), akhir.Format("2006-01-02")).
+		Order("tanggal asc, id asc").
+		Find(&rekaps).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data rekap: " + err.Error(),
+		})
+		return
+	}
+	if len(rekaps) == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Belum ada data rekap afdeling %s pada periode tersebut", afd.Nama),
+		})
+		return
+	}
+
+	// Jumlahkan produksi hari ini per mandor selama periode
+	type kinerjaMandor struct {
+		barisRekap
+		hari map[string]bool
+	}
+	perMandor := make(map[string]*kinerjaMandor)
+	var urutan []string
+	total := barisRekap{}
+	for _, rk := range rekaps {
+		kunci := rk.NIK
+		if kunci == "" {
+			kunci = rk.Mandor
+		}
+		k, ok := perMandor[kunci]
+		if !ok {
+			k = &kinerjaMandor{barisRekap: barisRekap{NIK: rk.NIK, Mandor: rk.Mandor}, hari: make(map[string]bool)}
+			perMandor[kunci] = k
+			urutan = append(urutan, kunci)
+		}
+		b := barisDariRekap(rk)
+		k.tambah(b)
+		k.hari[rk.Tanggal.Format("2006-01-02")] = true
+		total.tambah(b)
+	}
+	total.hitungRasio()
+
+	daftar := make([]*kinerjaMandor, 0, len(urutan))
+	for _, kunci := range urutan {
+		k := perMandor[kunci]
+		k.hitungRasio()
+		daftar = append(daftar, k)
+	}
+	sort.SliceStable(daftar, func(i, j int) bool { return daftar[i].PerTaper > daftar[j].PerTaper })
+
+	tren, err := ambilTrenHarian([]uint{afd.ID}, awal, akhir)
+	if err != nil {
+		log.Printf("Tren laporan mandor %s: %v", afd.Kode, err)
+	}
+
+	kebun := namaKebun(afd.KebunID)
+	d, y := laporanPDF(true, kebun, "Laporan Kinerja Mandor "+afd.Nama)
+	y = judulLaporanPDF(d, y, "LAPORAN KINERJA MANDOR AFDELING "+strings.ToUpper(afd.Nama),
+		fmt.Sprintf("Periode: %s s/d %s", tanggalIndonesia(awal), tanggalIndonesia(akhir)))
+
+	kolom := append([]pdf.KolomTabel{
+		{Judul: "No", Lebar: 8, Rata: pdf.Tengah},
+		{Judul: "NIK", Lebar: 22},
+		{Judul: "Mandor", Lebar: 44},
+		{Judul: "Hari\nKerja", Lebar: 12, Rata: pdf.Kanan},
+	}, kolomProduksiPDF...)
+	kolom = append(kolom, pdf.KolomTabel{Judul: "Indeks", Lebar: 14, Rata: pdf.Kanan})
+
+	var baris []pdf.BarisTabel
+	for i, k := range daftar {
+		indeks := 0.0
+		if total.PerTaper > 0 {
+			indeks = k.PerTaper / total.PerTaper * 100
+		}
+		sel := append([]string{fmt.Sprint(i + 1), k.NIK, k.Mandor, fmt.Sprint(len(k.hari))},
+			selProduksiPDF(k.HKO, k.Hari, k.PerTaper)...)
+		baris = append(baris, pdf.BarisTabel{Sel: append(sel, angkaID(indeks, 1))})
+	}
+	sel := append([]string{"", "", "JUMLAH AFDELING", ""}, selProduksiPDF(total.HKO, total.Hari, total.PerTaper)...)
+	baris = append(baris, pdf.BarisTabel{Sel: append(sel, "100"), Tebal: true})
+
+	y = subjudulPDF(d, y, "Kinerja per Mandor (urut produksi per taper)")
+	y = d.Tabel(y, kolom, baris) + 2
+
+	seri := []pdf.SeriGrafik{{Nama: "Per Taper (kg/HKO)", Warna: warnaKering}}
+	for _, t := range tren {
+		v := 0.0
+		if t.HKO > 0 {
+			v = t.Kering / t.HKO
+		}
+		seri[0].Nilai = append(seri[0].Nilai, v)
+	}
+	y = grafikPDF(d, y, "Tren Produksi per Taper Afdeling", labelTren(tren), seri)
+
+	blokTandaTangan(d, y, strings.TrimPrefix(kebun, "Kebun "), akhir, []penandatangan{
+		{Keterangan: "Dibuat oleh,", Jabatan: "Asisten Afdeling"},
+		{Keterangan: "Mengetahui,", Jabatan: "Manager"},
+	})
+
+	kirimPDF(w, d, fmt.Sprintf("kinerja_mandor_%s_%s_%s.pdf", afd.Kode, awal.Format("20060102"), akhir.Format("20060102")))
+}
@@ -0,0 +1,488 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Urutan bagian tipe produksi seperti di file REKAP kebun. Tipe lain
+// ditaruh sesudahnya menurut abjad.
+var urutanTipeRekap = []string{
+	"PRODUKSI BAKU",
+	"PRODUKSI BAKU BORONG",
+	"PRODUKSI BORONG INTERNAL",
+	"PRODUKSI BORONG EKSTERNAL",
+	"PRODUKSI BORONG MINGGU",
+	"PRODUKSI TETES LANJUT",
+}
+
+var namaBulan = [...]string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// tanggalIndonesia menulis tanggal seperti "19 Oktober 2026"
+func tanggalIndonesia(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), namaBulan[t.Month()], t.Year())
+}
+
+// nilaiRekap adalah 10 kolom produksi REKAP untuk satu periode (hari ini
+// atau s/d hari ini), urutannya sama dengan mapRowRelative
+type nilaiRekap struct {
+	LatekKebun, LatekPabrik, LatekPersen float64
+	LumpKebun, LumpPabrik, LumpPersen    float64
+	K3, Sheet, BrCr, Jumlah              float64
+}
+
+func (n *nilaiRekap) tambah(m nilaiRekap) {
+	n.LatekKebun += m.LatekKebun
+	n.LatekPabrik += m.LatekPabrik
+	n.LumpKebun += m.LumpKebun
+	n.LumpPabrik += m.LumpPabrik
+	n.Sheet += m.Sheet
+	n.BrCr += m.BrCr
+	n.Jumlah += m.Jumlah
+}
+
+// hitungRasio mengisi persen selisih dan K3 dari jumlah kg, dengan rumus
+// yang sama dengan dashboard
+func (n *nilaiRekap) hitungRasio() {
+	n.LatekPersen, n.LumpPersen, n.K3 = 0, 0, 0
+	if n.LatekKebun > 0 {
+		n.LatekPersen = (n.LatekKebun - n.LatekPabrik) / n.LatekKebun * 100
+	}
+	if n.LumpKebun > 0 {
+		n.LumpPersen = (n.LumpKebun - n.LumpPabrik) / n.LumpKebun * 100
+	}
+	if n.LatekPabrik > 0 {
+		n.K3 = n.Sheet / n.LatekPabrik * 100
+	}
+}
+
+func (n nilaiRekap) kolom() []interface{} {
+	return []interface{}{n.LatekKebun, n.LatekPabrik, n.LatekPersen, n.LumpKebun, n.LumpPabrik,
+		n.LumpPersen, n.K3, n.Sheet, n.BrCr, n.Jumlah}
+}
+
+// barisRekap adalah satu baris laporan mulai kolom TAHUN TANAM
+type barisRekap struct {
+	TahunTanam, NIK, Mandor string
+	HKO, HKOSd              int
+	Hari, Sd                nilaiRekap
+	PerTaper, PerTaperSd    float64
+}
+
+func barisDariRekap(r models.Rekap) barisRekap {
+	return barisRekap{
+		TahunTanam: r.TahunTanam,
+		NIK:        r.NIK,
+		Mandor:     r.Mandor,
+		HKO:        r.HKOHariIni,
+		HKOSd:      r.HKOSampaiHariIni,
+		Hari: nilaiRekap{
+			LatekKebun: r.HariIniBasahLatekKebun, LatekPabrik: r.HariIniBasahLatekPabrik, LatekPersen: r.HariIniBasahLatekPersen,
+			LumpKebun: r.HariIniBasahLumpKebun, LumpPabrik: r.HariIniBasahLumpPabrik, LumpPersen: r.HariIniBasahLumpPersen,
+			K3: r.HariIniK3Sheet, Sheet: r.HariIniKeringSheet, BrCr: r.HariIniKeringBrCr, Jumlah: r.HariIniKeringJumlah,
+		},
+		Sd: nilaiRekap{
+			LatekKebun: r.SampaiHariIniBasahLatekKebun, LatekPabrik: r.SampaiHariIniBasahLatekPabrik, LatekPersen: r.SampaiHariIniBasahLatekPersen,
+			LumpKebun: r.SampaiHariIniBasahLumpKebun, LumpPabrik: r.SampaiHariIniBasahLumpPabrik, LumpPersen: r.SampaiHariIniBasahLumpPersen,
+			K3: r.SampaiHariIniK3Sheet, Sheet: r.SampaiHariIniKeringSheet, BrCr: r.SampaiHariIniKeringBrCr, Jumlah: r.SampaiHariIniKeringJumlah,
+		},
+		PerTaper:   r.ProduksiPerTaperHariIni,
+		PerTaperSd: r.ProduksiPerTaperSampaiHariIni,
+	}
+}
+
+func (b *barisRekap) tambah(x barisRekap) {
+	b.HKO += x.HKO
+	b.HKOSd += x.HKOSd
+	b.Hari.tambah(x.Hari)
+	b.Sd.tambah(x.Sd)
+}
+
+// hitungRasio dipakai untuk baris jumlah; baris data memakai nilai dari file
+func (b *barisRekap) hitungRasio() {
+	b.Hari.hitungRasio()
+	b.Sd.hitungRasio()
+	b.PerTaper, b.PerTaperSd = 0, 0
+	if b.HKO > 0 {
+		b.PerTaper = b.Hari.Jumlah / float64(b.HKO)
+	}
+	if b.HKOSd > 0 {
+		b.PerTaperSd = b.Sd.Jumlah / float64(b.HKOSd)
+	}
+}
+
+func (b barisRekap) kolom() []interface{} {
+	out := []interface{}{b.TahunTanam, b.NIK, b.Mandor, b.HKO, b.HKOSd}
+	out = append(out, b.Hari.kolom()...)
+	out = append(out, b.Sd.kolom()...)
+	return append(out, b.PerTaper, b.PerTaperSd)
+}
+
+// bagianRekap adalah satu tipe produksi beserta baris jumlahnya
+type bagianRekap struct {
+	Tipe   string
+	Baris  []barisRekap
+	Jumlah barisRekap
+}
+
+// susunLaporanRekap mengelompokkan baris per tipe produksi dan menyusun
+// bagian REKAPITULASI (gabungan semua tipe per mandor) beserta total
+func susunLaporanRekap(rekaps []models.Rekap) ([]bagianRekap, []barisRekap, barisRekap) {
+	perTipe := make(map[string]*bagianRekap)
+	var tipeLain []string
+	rekapitulasi := make(map[string]*barisRekap)
+	var urutanMandor []string
+	total := barisRekap{TahunTanam: "JUMLAH REKAPITULASI"}
+
+	for _, r := range rekaps {
+		tipe := strings.ToUpper(strings.TrimSpace(r.TipeProduksi))
+		b := barisDariRekap(r)
+
+		bg, ok := perTipe[tipe]
+		if !ok {
+			bg = &bagianRekap{Tipe: tipe, Jumlah: barisRekap{TahunTanam: "JUMLAH " + tipe}}
+			perTipe[tipe] = bg
+			tipeLain = append(tipeLain, tipe)
+		}
+		bg.Baris = append(bg.Baris, b)
+		bg.Jumlah.tambah(b)
+
+		kunci := b.TahunTanam + "|" + b.NIK + "|" + b.Mandor
+		rk, ok := rekapitulasi[kunci]
+		if !ok {
+			rk = &barisRekap{TahunTanam: b.TahunTanam, NIK: b.NIK, Mandor: b.Mandor}
+			rekapitulasi[kunci] = rk
+			urutanMandor = append(urutanMandor, kunci)
+		}
+		rk.tambah(b)
+		total.tambah(b)
+	}
+
+	var bagian []bagianRekap
+	for _, tipe := range urutanTipeRekap {
+		if bg, ok := perTipe[tipe]; ok {
+			bg.Jumlah.hitungRasio()
+			bagian = append(bagian, *bg)
+			delete(perTipe, tipe)
+		}
+	}
+	sort.Strings(tipeLain)
+	for _, tipe := range tipeLain {
+		if bg, ok := perTipe[tipe]; ok {
+			bg.Jumlah.hitungRasio()
+			bagian = append(bagian, *bg)
+		}
+	}
+
+	var barisRekapitulasi []barisRekap
+	for _, kunci := range urutanMandor {
+		rk := rekapitulasi[kunci]
+		rk.hitungRasio()
+		barisRekapitulasi = append(barisRekapitulasi, *rk)
+	}
+	total.hitungRasio()
+	return bagian, barisRekapitulasi, total
+}
+
+// buatWorkbookRekap menulis laporan dengan susunan kolom REKAP: TAHUN TANAM,
+// NIK, MANDOR, HKO, hari ini (10 kolom), s/d hari ini (10 kolom) dan
+// produksi per taper. Susunan ini bisa dibaca ulang oleh upload REKAP.
+func buatWorkbookRekap(judul []string, bagian []bagianRekap, rekapitulasi []barisRekap, total barisRekap, username string) (*excelize.File, error) {
+	f := excelize.NewFile()
+	sheet := "REKAP"
+	f.SetSheetName("Sheet1", sheet)
+	const jumlahKolom = 27
+	kolomAkhir, _ := excelize.ColumnNumberToName(jumlahKolom)
+
+	garis := []excelize.Border{
+		{Type: "left", Color: "#000000", Style: 1},
+		{Type: "right", Color: "#000000", Style: 1},
+		{Type: "top", Color: "#000000", Style: 1},
+		{Type: "bottom", Color: "#000000", Style: 1},
+	}
+	stJudul, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 13}})
+	stKet, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Italic: true, Color: "#595959"}})
+	stHeader, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#D9E1F2"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		Border:    garis,
+	})
+	stTipe, _ := f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"#FFF2CC"}, Pattern: 1},
+		Border: garis,
+	})
+
+	// Style per kolom: teks, bulat (HKO), kg dan rasio; versi tebal untuk jumlah
+	fmtRasio := "0.00"
+	buatStyle := func(tebal bool, numFmt int, custom *string) int {
+		s := excelize.Style{Border: garis, NumFmt: numFmt, CustomNumFmt: custom}
+		if tebal {
+			s.Font = &excelize.Font{Bold: true}
+			s.Fill = excelize.Fill{Type: "pattern", Color: []string{"#F2F2F2"}, Pattern: 1}
+		}
+		id, _ := f.NewStyle(&s)
+		return id
+	}
+	styleKolom := func(i int, tebal bool) int {
+		switch {
+		case i < 3:
+			return buatStyle(tebal, 0, nil)
+		case i < 5:
+			return buatStyle(tebal, 3, nil) // #,##0
+		case i >= 25:
+			return buatStyle(tebal, 0, &fmtRasio)
+		}
+		switch (i - 5) % 10 {
+		case 2, 5, 6: // %, %, K3
+			return buatStyle(tebal, 0, &fmtRasio)
+		}
+		return buatStyle(tebal, 4, nil) // #,##0.00
+	}
+	styleData := make([]int, jumlahKolom)
+	styleJumlah := make([]int, jumlahKolom)
+	for i := range styleData {
+		styleData[i] = styleKolom(i, false)
+		styleJumlah[i] = styleKolom(i, true)
+	}
+
+	// Blok judul
+	row := 0
+	for i, teks := range judul {
+		row++
+		cell := fmt.Sprintf("A%d", row)
+		f.SetCellValue(sheet, cell, teks)
+		if i == 0 {
+			f.SetCellStyle(sheet, cell, cell, stJudul)
+		}
+	}
+	row++
+	dicetak := "Dicetak: " + time.Now().Format("02/01/2006 15:04")
+	if username != "" {
+		dicetak += " oleh " + username
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), dicetak)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), stKet)
+	row += 2
+
+	// Header tiga baris
+	h1, h2, h3 := row, row+1, row+2
+	sel := func(kolom, baris int) string {
+		c, _ := excelize.CoordinatesToCellName(kolom, baris)
+		return c
+	}
+	gabung := func(k1, b1, k2, b2 int, teks string) {
+		f.SetCellValue(sheet, sel(k1, b1), teks)
+		if k1 != k2 || b1 != b2 {
+			f.MergeCell(sheet, sel(k1, b1), sel(k2, b2))
+		}
+	}
+	gabung(1, h1, 1, h3, "TAHUN TANAM")
+	gabung(2, h1, 2, h3, "NIK")
+	gabung(3, h1, 3, h3, "MANDOR")
+	gabung(4, h1, 5, h1, "HKO")
+	gabung(4, h2, 4, h3, "HI")
+	gabung(5, h2, 5, h3, "S/D HI")
+	for i, periode := range []string{"HARI INI", "S/D HARI INI"} {
+		awal := 6 + i*10
+		gabung(awal, h1, awal+9, h1, periode)
+		gabung(awal, h2, awal+2, h2, "BASAH LATEK")
+		gabung(awal+3, h2, awal+5, h2, "BASAH LUMP")
+		gabung(awal+6, h2, awal+9, h2, "KERING")
+		for j, sub := range []string{"KEBUN", "PABRIK", "%", "KEBUN", "PABRIK", "%", "K3", "SHEET", "BR/CR", "JUMLAH"} {
+			f.SetCellValue(sheet, sel(awal+j, h3), sub)
+		}
+	}
+	gabung(26, h1, 27, h1, "PRODUKSI PER TAPER")
+	gabung(26, h2, 26, h3, "HI")
+	gabung(27, h2, 27, h3, "S/D HI")
+	f.SetCellStyle(sheet, sel(1, h1), sel(jumlahKolom, h3), stHeader)
+	row = h3
+
+	tulis := func(b barisRekap, style []int) {
+		row++
+		for i, v := range b.kolom() {
+			if x, ok := v.(float64); ok {
+				v = roundTo(x, 2)
+			}
+			f.SetCellValue(sheet, sel(i+1, row), v)
+			f.SetCellStyle(sheet, sel(i+1, row), sel(i+1, row), style[i])
+		}
+	}
+	judulBagian := func(teks string) {
+		row++
+		f.SetCellValue(sheet, sel(1, row), teks)
+		f.SetCellStyle(sheet, sel(1, row), sel(jumlahKolom, row), stTipe)
+	}
+
+	for _, bg := range bagian {
+		judulBagian(bg.Tipe)
+		for _, b := range bg.Baris {
+			tulis(b, styleData)
+		}
+		tulis(bg.Jumlah, styleJumlah)
+		row++
+	}
+
+	judulBagian("REKAPITULASI")
+	for _, b := range rekapitulasi {
+		tulis(b, styleData)
+	}
+	tulis(total, styleJumlah)
+
+	f.SetColWidth(sheet, "A", "A", 14)
+	f.SetColWidth(sheet, "B", "B", 16)
+	f.SetColWidth(sheet, "C", "C", 24)
+	f.SetColWidth(sheet, "D", kolomAkhir, 10)
+	f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		XSplit:      3,
+		YSplit:      h3,
+		TopLeftCell: sel(4, h3+1),
+		ActivePane:  "bottomRight",
+	})
+
+	landscape := "landscape"
+	kertasA4 := 9
+	satu, nol := 1, 0
+	muat := true
+	f.SetSheetProps(sheet, &excelize.SheetPropsOptions{FitToPage: &muat})
+	f.SetPageLayout(sheet, &excelize.PageLayoutOptions{Size: &kertasA4, Orientation: &landscape, FitToWidth: &satu, FitToHeight: &nol})
+	return f, nil
+}
+
+// DownloadLaporanRekap membuat laporan produksi resmi dengan susunan REKAP
+// kebun dari database. Parameter: afdeling (wajib), tanggal (YYYY-MM-DD,
+// laporan harian, default hari ini) atau bulan (YYYY-MM, laporan bulanan
+// memakai tanggal terakhir yang ada datanya di bulan itu)
+func DownloadLaporanRekap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("afdeling") == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter afdeling diperlukan",
+		})
+		return
+	}
+	afd, err := resolveAfdeling(q.Get("afdeling"), false)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	db := config.GetDB()
+	base := func() *gorm.DB {
+		return db.Model(&models.Rekap{}).
+			Where("afdeling_id = ? AND tipe_produksi != ?", afd.ID, "REKAPITULASI")
+	}
+
+	var tanggal time.Time
+	jenis := "HARIAN"
+	if bulan := q.Get("bulan"); bulan != "" {
+		awal, err := time.Parse("2006-01", bulan)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Format bulan tidak valid (gunakan: YYYY-MM)",
+			})
+			return
+		}
+		akhir := awal.AddDate(0, 1, -1)
+
+		var terakhir string
+		if err := base().
+			Where("DATE(tanggal) BETWEEN ? AND ?", awal.Format("2006-01-02"), akhir.Format("2006-01-02")).
+			Select("COALESCE(MAX(DATE(tanggal)), '')").
+			Scan(&terakhir).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Gagal mengambil data rekap: " + err.Error(),
+			})
+			return
+		}
+		if terakhir == "" {
+			respondJSON(w, http.StatusNotFound, APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Belum ada data rekap afdeling %s bulan %s", afd.Nama, bulan),
+			})
+			return
+		}
+		tanggal, _ = time.Parse("2006-01-02", terakhir[:10])
+		jenis = "BULANAN"
+	} else {
+		tanggal = time.Now()
+		if s := q.Get("tanggal"); s != "" {
+			if tanggal, err = time.Parse("2006-01-02", s); err != nil {
+				respondJSON(w, http.StatusBadRequest, APIResponse{
+					Success: false,
+					Message: "Format tanggal tidak valid (gunakan: YYYY-MM-DD)",
+				})
+				return
+			}
+		}
+	}
+
+	var rekaps []models.Rekap
+	if err := base().
+		Where("DATE(tanggal) = ?", tanggal.Format("2006-01-02")).
+		Order("id asc").
+		Find(&rekaps).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data rekap: " + err.Error(),
+		})
+		return
+	}
+	if len(rekaps) == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Belum ada data rekap afdeling %s tanggal %s", afd.Nama, tanggal.Format("2006-01-02")),
+		})
+		return
+	}
+
+	judul := []string{
+		"LAPORAN PRODUKSI " + jenis,
+		"AFDELING : " + strings.ToUpper(afd.Nama),
+	}
+	if jenis == "BULANAN" {
+		judul = append(judul, fmt.Sprintf("BULAN : %s %d (s/d %s)", namaBulan[tanggal.Month()], tanggal.Year(), tanggalIndonesia(tanggal)))
+	} else {
+		judul = append(judul, "TANGGAL : "+tanggalIndonesia(tanggal))
+	}
+
+	bagian, rekapitulasi, total := susunLaporanRekap(rekaps)
+	f, err := buatWorkbookRekap(judul, bagian, rekapitulasi, total, usernameDariRequest(r))
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal membuat laporan: " + err.Error(),
+		})
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("REKAP_%s_%s.xlsx", afd.Kode, tanggal.Format("20060102"))
+	if jenis == "BULANAN" {
+		filename = fmt.Sprintf("REKAP_%s_%s.xlsx", afd.Kode, tanggal.Format("200601"))
+	}
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	if err := f.Write(w); err != nil {
+		log.Printf("Error writing laporan rekap %s: %v", filename, err)
+	}
+}
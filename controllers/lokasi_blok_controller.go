@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/geo"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Batas jumlah titik per permintaan batch
+const MaxTitikLokasi = 1000
+
+// TitikLokasi adalah koordinat GPS (WGS84)
+type TitikLokasi struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// LokasiBlok adalah blok yang memuat satu titik. Ditemukan false berarti
+// titik di luar semua poligon; KodePoligon terisi tanpa PetaID jika poligon
+// belum tertaut ke register blok.
+type LokasiBlok struct {
+	Lat         float64 `json:"lat"`
+	Lng         float64 `json:"lng"`
+	Ditemukan   bool    `json:"ditemukan"`
+	GeometriID  *uint   `json:"geometri_id"`
+	KodePoligon string  `json:"kode_poligon,omitempty"`
+	PetaID      *uint   `json:"peta_id"`
+	Code        string  `json:"code,omitempty"`
+	Blok        string  `json:"blok,omitempty"`
+	Afdeling    string  `json:"afdeling,omitempty"`
+	JenisKebun  string  `json:"jenis_kebun,omitempty"`
+	TahunTanam  string  `json:"tahun_tanam,omitempty"`
+	Kloon       string  `json:"kloon,omitempty"`
+}
+
+// poligonBlok adalah geometri yang sudah diurai untuk pencarian titik
+type poligonBlok struct {
+	geometri models.PetaGeometri
+	bentuk   geo.MultiPolygon
+	batas    [4]float64
+	luas     float64
+}
+
+// cachePoligonBlok menyimpan poligon yang sudah diurai agar pencarian titik
+// tidak mengurai ulang semua GeoJSON di setiap permintaan. Dikosongkan oleh
+// hapusCachePoligonBlok setiap kali geometri atau register blok berubah.
+var cachePoligonBlok struct {
+	sync.Mutex
+	data []poligonBlok
+	ada  bool
+}
+
+// hapusCachePoligonBlok dipanggil setelah transaksi yang mengubah geometri
+// atau Peta berhasil commit
+func hapusCachePoligonBlok() {
+	cachePoligonBlok.Lock()
+	cachePoligonBlok.data, cachePoligonBlok.ada = nil, false
+	cachePoligonBlok.Unlock()
+}
+
+// muatPoligonBlok mengembalikan poligon dari cache atau mengurai semua
+// geometri tersimpan. Geometri yang rusak dilewati supaya satu poligon buruk
+// tidak menggagalkan pencarian. Hasilnya hanya dibaca, jangan diubah.
+func muatPoligonBlok() ([]poligonBlok, error) {
+	cachePoligonBlok.Lock()
+	defer cachePoligonBlok.Unlock()
+	if cachePoligonBlok.ada {
+		return cachePoligonBlok.data, nil
+	}
+
+	var geometris []models.PetaGeometri
+	if err := config.DB.Preload("Peta").Order("code asc").Find(&geometris).Error; err != nil {
+		return nil, err
+	}
+
+	hasil := make([]poligonBlok, 0, len(geometris))
+	for _, g := range geometris {
+		mp, err := geo.ParseGeoJSON(g.GeoJSON)
+		if err != nil {
+			log.Printf("Geometri %s dilewati: %v", g.Code, err)
+			continue
+		}
+		hasil = append(hasil, poligonBlok{geometri: g, bentuk: mp, batas: mp.Bounds(), luas: mp.LuasHa()})
+	}
+	cachePoligonBlok.data, cachePoligonBlok.ada = hasil, true
+	return hasil, nil
+}
+
+// cariBlok mencari poligon yang memuat titik. Jika poligon bertumpuk
+// (mis. tanaman sela di dalam blok), poligon terkecil yang dipilih.
+func cariBlok(poligons []poligonBlok, t TitikLokasi) LokasiBlok {
+	lokasi := LokasiBlok{Lat: t.Lat, Lng: t.Lng}
+	p := geo.Point{t.Lng, t.Lat}
+
+	var pilih *poligonBlok
+	for i := range poligons {
+		pb := &poligons[i]
+		if !geo.DalamBounds(pb.batas, p) || !pb.bentuk.Contains(p) {
+			continue
+		}
+		if pilih == nil || pb.luas < pilih.luas {
+			pilih = pb
+		}
+	}
+	if pilih == nil {
+		return lokasi
+	}
+
+	g := pilih.geometri
+	lokasi.Ditemukan = true
+	lokasi.GeometriID = &g.ID
+	lokasi.KodePoligon = g.Code
+	if g.Peta != nil {
+		lokasi.PetaID = &g.Peta.ID
+		lokasi.Code = g.Peta.Code
+		lokasi.Blok = g.Peta.Blok
+		lokasi.Afdeling = g.Peta.Afdeling
+		lokasi.JenisKebun = g.Peta.JenisKebun
+		lokasi.TahunTanam = g.Peta.TahunTanam
+		lokasi.Kloon = g.Peta.Kloon
+	}
+	return lokasi
+}
+
+// validasiTitik memeriksa rentang lintang/bujur
+func validasiTitik(t TitikLokasi) error {
+	if t.Lat < -90 || t.Lat > 90 || t.Lng < -180 || t.Lng > 180 {
+		return fmt.Errorf("koordinat (%v, %v) di luar rentang lat -90..90 / lng -180..180", t.Lat, t.Lng)
+	}
+	return nil
+}
+
+// LocateBlok mencari blok Peta yang memuat satu koordinat.
+// Parameter: lat, lng (derajat desimal WGS84)
+func LocateBlok(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	lat, errLat := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, errLng := strconv.ParseFloat(q.Get("lng"), 64)
+	if errLat != nil || errLng != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter lat dan lng wajib diisi dengan angka desimal",
+		})
+		return
+	}
+	titik := TitikLokasi{Lat: lat, Lng: lng}
+	if err := validasiTitik(titik); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	poligons, err := muatPoligonBlok()
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil geometri blok: " + err.Error(),
+		})
+		return
+	}
+
+	lokasi := cariBlok(poligons, titik)
+	pesan := "Titik berada di blok " + lokasi.KodePoligon
+	if !lokasi.Ditemukan {
+		pesan = "Titik tidak berada di blok mana pun"
+	}
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: pesan,
+		Data:    lokasi,
+	})
+}
+
+// LocateBlokBatch mencari blok untuk banyak titik sekaligus.
+// Body: {"titik": [{"lat": -7.1, "lng": 110.4}, ...]}
+func LocateBlokBatch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Titik []TitikLokasi `json:"titik"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Body harus JSON {\"titik\": [{\"lat\": .., \"lng\": ..}]}",
+		})
+		return
+	}
+	if len(body.Titik) == 0 || len(body.Titik) > MaxTitikLokasi {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Jumlah titik harus 1 sampai %d", MaxTitikLokasi),
+		})
+		return
+	}
+	for i, t := range body.Titik {
+		if err := validasiTitik(t); err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Titik ke-%d: %s", i+1, err.Error()),
+			})
+			return
+		}
+	}
+
+	poligons, err := muatPoligonBlok()
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil geometri blok: " + err.Error(),
+		})
+		return
+	}
+
+	hasil := make([]LokasiBlok, len(body.Titik))
+	ditemukan := 0
+	for i, t := range body.Titik {
+		hasil[i] = cariBlok(poligons, t)
+		if hasil[i].Ditemukan {
+			ditemukan++
+		}
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d dari %d titik berada di dalam blok", ditemukan, len(hasil)),
+		Data:    hasil,
+	})
+}
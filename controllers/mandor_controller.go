@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 func GetAllMandor(w http.ResponseWriter, r *http.Request) {
@@ -76,6 +78,17 @@ func CreateMandor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	afdeling, err := resolveAfdeling(mandor.Afdeling, true)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Afdeling tidak valid: " + err.Error(),
+		})
+		return
+	}
+	mandor.Afdeling = afdeling.Nama
+	mandor.AfdelingID = &afdeling.ID
+
 	// UPDATED: Validate tipe produksi
 	if mandor.Tipe != "" && !models.IsValidTipeProduksi(mandor.Tipe) {
 		respondJSON(w, http.StatusBadRequest, APIResponse{
@@ -150,6 +163,22 @@ func UpdateMandor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Afdeling harus terdaftar di master data
+	afdelingChanged := false
+	if update.Afdeling != "" {
+		afdeling, err := resolveAfdeling(update.Afdeling, true)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Afdeling tidak valid: " + err.Error(),
+			})
+			return
+		}
+		update.Afdeling = afdeling.Nama
+		update.AfdelingID = &afdeling.ID
+		afdelingChanged = existingMandor.AfdelingID == nil || *existingMandor.AfdelingID != afdeling.ID
+	}
+
 	// Check if tipe is being changed
 	tipeChanged := update.Tipe != "" && update.Tipe != existingMandor.Tipe
 
@@ -162,11 +191,30 @@ func UpdateMandor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Ringkasan harian mandor ikut pindah afdeling
+	if afdelingChanged {
+		if err := config.DB.Model(&models.BakuDetail{}).
+			Where("id_baku_mandor = ?", mandorID).
+			Updates(map[string]interface{}{"afdeling": update.Afdeling, "afdeling_id": update.AfdelingID}).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Gagal update afdeling pada detail mandor: " + err.Error(),
+			})
+			return
+		}
+	}
+
 	// UPDATED: If tipe changed, update all related BakuPenyadap records
 	if tipeChanged {
-		err := config.DB.Model(&models.BakuPenyadap{}).
-			Where("id_baku_mandor = ?", mandorID).
-			Update("tipe", update.Tipe).Error
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.BakuPenyadap{}).
+				Where("id_baku_mandor = ?", mandorID).
+				Update("tipe", update.Tipe).Error; err != nil {
+				return err
+			}
+			// Update massal tidak memanggil hook per entri, catat untuk delta sync
+			return models.CatatPerubahanBaku(tx, "id_baku_mandor = ?", mandorID)
+		})
 
 		if err != nil {
 			// Log the error but don't fail the entire operation
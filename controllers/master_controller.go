@@ -20,6 +20,9 @@ func CreateMaster(tanggal time.Time, afdeling string, namaFile string) (uint64,
 		Afdeling: afdeling,
 		NamaFile: namaFile,
 	}
+	if afd, err := models.FindAfdeling(db, afdeling); err == nil {
+		master.AfdelingID = &afd.ID
+	}
 
 	// Simpan ke database
 	if err := db.Create(&master).Error; err != nil {
@@ -30,6 +33,26 @@ func CreateMaster(tanggal time.Time, afdeling string, namaFile string) (uint64,
 	return master.ID, nil
 }
 
+// syncAfdelingFromMaster menyalin afdeling_id master ke rekap dan produksi hasil impornya
+func syncAfdelingFromMaster(idMaster uint64) error {
+	db := config.GetDB()
+
+	var master models.Master
+	if err := db.First(&master, idMaster).Error; err != nil {
+		return err
+	}
+	if master.AfdelingID == nil {
+		return nil
+	}
+
+	if err := db.Model(&models.Rekap{}).Where("id_master = ?", idMaster).
+		UpdateColumn("afdeling_id", *master.AfdelingID).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Produksi{}).Where("id_master = ?", idMaster).
+		UpdateColumn("afdeling_id", *master.AfdelingID).Error
+}
+
 // GetAllMaster mengembalikan semua master dalam bentuk JSON
 func GetAllMaster(w http.ResponseWriter, r *http.Request) {
 	db := config.GetDB()
@@ -77,6 +100,19 @@ func DeleteMaster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	kirimPeristiwa(Peristiwa{
+		Jenis:      PeristiwaMasterDihapus,
+		AfdelingID: nilaiAfdelingID(master.AfdelingID),
+		Pesan:      fmt.Sprintf("Master %d (%s) dihapus", master.ID, master.NamaFile),
+		Data: map[string]interface{}{
+			"id_master":    master.ID,
+			"tanggal":      master.Tanggal.Format("2006-01-02"),
+			"afdeling":     master.Afdeling,
+			"nama_file":    master.NamaFile,
+			"dihapus_oleh": usernameDariRequest(r),
+		},
+	})
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(fmt.Sprintf("Master dengan ID %d berhasil dihapus", id)))
 }
@@ -661,6 +661,15 @@ func SmartMonitoringSearch(w http.ResponseWriter, r *http.Request) {
 	tanggalAkhir := strings.TrimSpace(r.URL.Query().Get("tanggalAkhir"))
 	tipe := strings.TrimSpace(r.URL.Query().Get("tipe"))
 
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Execute search with all combinations
 	results, searchInfo, err := executeSmartSearchAllCombinations(namaMandor, namaPenyadap, tanggalAwal, tanggalAkhir, tipe)
 	if err != nil {
@@ -671,6 +680,36 @@ func SmartMonitoringSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format != "" {
+		tabel := TabelLaporan{
+			Judul:  "Monitoring Produksi Penyadap",
+			Filter: []string{"Filter: " + searchInfo.FilterApplied, "Periode: " + searchInfo.DateRange},
+			Kolom: []KolomLaporan{
+				{Judul: "Tanggal", Jenis: kolomTanggal},
+				{Judul: "Mandor"},
+				{Judul: "Afdeling"},
+				{Judul: "Tahun Tanam"},
+				{Judul: "NIK"},
+				{Judul: "Nama Penyadap"},
+				{Judul: "Tipe"},
+				{Judul: "Basah Latek (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Sheet (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Basah Lump (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Br/Cr (kg)", Jenis: kolomAngka, Jumlah: true},
+			},
+		}
+		for _, item := range results {
+			tanggal, _ := time.Parse("2006-01-02", item.Tanggal)
+			tabel.Baris = append(tabel.Baris, []interface{}{
+				tanggal, item.Mandor, item.Afdeling, item.TahunTanam, item.NIK, item.NamaPenyadap, item.Tipe,
+				item.BasahLatex, item.Sheet, item.BasahLump, item.BrCr,
+			})
+		}
+		tabel.hitungTotal()
+		kirimLaporan(w, r, format, "monitoring_penyadap", tabel)
+		return
+	}
+
 	response := MonitoringSearchResponse{
 		Success:    true,
 		Message:    fmt.Sprintf("Pencarian berhasil dengan kombinasi '%s'", searchInfo.SearchType),
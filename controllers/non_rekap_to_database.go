@@ -215,7 +215,7 @@ func mapRowTanggalFormat(row []string, baseColIndices map[string]int, firstColId
 			return 0
 		}
 		v := strings.TrimSpace(strings.ReplaceAll(row[idx], "\"", ""))
-		if v == "" || v == "-" || v == "—" || v == "—" {
+		if v == "" || v == "-" || v == "—" || v == "–" {
 			return 0
 		}
 		if n, err := parseNumberNoRekap(v); err == nil {
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/broker"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Jenis peristiwa yang dikirim ke SSE dan penerima notifikasi. Nama memakai
+// bentuk "objek.kejadian" karena juga dipakai sistem lain.
+const (
+	PeristiwaImporSelesai          = "upload.completed"
+	PeristiwaImporGagal            = "upload.failed"
+	PeristiwaMasterDihapus         = "master.deleted"
+	PeristiwaBakuBerubah           = "baku.changed"
+	PeristiwaHariDitutup           = "day.closed"
+	PeristiwaHariDibuka            = "day.reopened"
+	PeristiwaSelisihMelewatiAmbang = "discrepancy.detected"
+)
+
+// daftarJenisPeristiwa dipakai untuk memvalidasi langganan
+var daftarJenisPeristiwa = []string{
+	PeristiwaImporSelesai,
+	PeristiwaImporGagal,
+	PeristiwaMasterDihapus,
+	PeristiwaBakuBerubah,
+	PeristiwaHariDitutup,
+	PeristiwaHariDibuka,
+	PeristiwaSelisihMelewatiAmbang,
+}
+
+// Peristiwa adalah satu kejadian yang perlu diketahui pihak lain, misalnya
+// mandor yang selisih kebun vs pabriknya melewati ambang setelah impor.
+// AfdelingID 0 berarti berlaku untuk semua afdeling.
+type Peristiwa struct {
+	ID         string      `json:"id"`
+	Jenis      string      `json:"jenis"`
+	Waktu      time.Time   `json:"waktu"`
+	AfdelingID uint        `json:"afdeling_id,omitempty"`
+	Pesan      string      `json:"pesan"`
+	Data       interface{} `json:"data"`
+}
+
+// PenerimaNotifikasi dipanggil untuk setiap peristiwa. Penerima dijalankan
+// di goroutine sendiri sehingga tidak boleh mengandalkan urutan.
+type PenerimaNotifikasi func(Peristiwa)
+
+var (
+	muPenerima     sync.RWMutex
+	daftarPenerima = map[string]PenerimaNotifikasi{}
+
+	// siaranPeristiwa meneruskan peristiwa ke koneksi SSE
+	siaranPeristiwa = broker.Baru(64, 200)
+)
+
+// DaftarkanPenerima menambahkan (atau mengganti) penerima dengan nama
+// tertentu
+func DaftarkanPenerima(nama string, f PenerimaNotifikasi) {
+	muPenerima.Lock()
+	defer muPenerima.Unlock()
+	daftarPenerima[nama] = f
+}
+
+// kirimPeristiwa menerbitkan peristiwa ke SSE lalu meneruskannya ke semua
+// penerima tanpa menunggu. Panic di penerima tidak boleh menghentikan
+// proses impor.
+func kirimPeristiwa(p Peristiwa) {
+	if p.ID == "" {
+		p.ID = idAcak(12)
+	}
+	if p.Waktu.IsZero() {
+		p.Waktu = time.Now()
+	}
+	log.Printf("🔔 %s: %s", p.Jenis, p.Pesan)
+
+	siaranPeristiwa.Terbitkan(broker.Pesan{
+		Jenis:      p.Jenis,
+		AfdelingID: p.AfdelingID,
+		Waktu:      p.Waktu,
+		Data:       p,
+	})
+
+	muPenerima.RLock()
+	defer muPenerima.RUnlock()
+	for nama, f := range daftarPenerima {
+		go func(nama string, f PenerimaNotifikasi) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Panic recovered in penerima notifikasi %s: %v", nama, r)
+				}
+			}()
+			f(p)
+		}(nama, f)
+	}
+}
+
+// nilaiAfdelingID mengubah afdeling_id opsional menjadi AfdelingID peristiwa
+func nilaiAfdelingID(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// idAcak membuat string heksadesimal acak sepanjang 2*n karakter
+func idAcak(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
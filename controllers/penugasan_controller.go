@@ -0,0 +1,554 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Batas kemiripan nama mandor (0-1) agar dianggap orang yang sama
+const minKemiripanNamaMandor = 0.8
+
+type penugasanInput struct {
+	PenyadapID uint    `json:"penyadap_id"`
+	MandorID   uint    `json:"mandor_id"`
+	AfdelingID *uint   `json:"afdeling_id"`
+	TahunTanam string  `json:"tahun_tanam"`
+	Blok       string  `json:"blok"`
+	ValidFrom  string  `json:"valid_from"`
+	ValidTo    *string `json:"valid_to"`
+}
+
+// KelompokMandorItem adalah satu penyadap dalam kelompok mandor pada suatu tanggal
+type KelompokMandorItem struct {
+	PenugasanID  uint       `json:"penugasan_id"`
+	PenyadapID   uint       `json:"penyadap_id"`
+	NIK          string     `json:"nik"`
+	NamaPenyadap string     `json:"nama_penyadap"`
+	TahunTanam   string     `json:"tahun_tanam"`
+	Blok         string     `json:"blok"`
+	ValidFrom    time.Time  `json:"valid_from"`
+	ValidTo      *time.Time `json:"valid_to"`
+	Sumber       string     `json:"sumber"`
+}
+
+// ProduksiKelompokMandor adalah total produksi penyadap anggota kelompok per tanggal
+type ProduksiKelompokMandor struct {
+	Tanggal        string  `json:"tanggal"`
+	JumlahPenyadap int     `json:"jumlah_penyadap"`
+	BasahLatek     float64 `json:"basah_latek"`
+	Sheet          float64 `json:"sheet"`
+	BasahLump      float64 `json:"basah_lump"`
+	BrCr           float64 `json:"br_cr"`
+	TotalProduksi  float64 `json:"total_produksi"`
+}
+
+// ================== PENCOCOKAN NAMA MANDOR ==================
+
+// normalisasiNama menyisakan huruf/angka kapital dengan satu spasi
+func normalisasiNama(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// kemiripanNama bernilai 1 untuk nama identik dan 0 untuk nama yang berbeda total
+func kemiripanNama(a, b string) float64 {
+	a, b = normalisasiNama(a), normalisasiNama(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	maxLen := max(len([]rune(a)), len([]rune(b)))
+	return 1 - float64(levenshtein(a, b))/float64(maxLen)
+}
+
+// cocokkanMandor mencari mandor kandidat dengan nama paling mirip.
+// Kandidat dengan tahun tanam sama selalu didahulukan.
+func cocokkanMandor(nama, tahunTanam string, kandidat []models.Mandor) (models.Mandor, bool) {
+	var best models.Mandor
+	bestScore := 0.0
+	for _, k := range kandidat {
+		score := kemiripanNama(nama, k.Nama)
+		if score < minKemiripanNamaMandor {
+			continue
+		}
+		if k.TahunTanam == tahunTanam {
+			score += 1
+		}
+		if score > bestScore {
+			best, bestScore = k, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// ================== PENCATATAN PENUGASAN ==================
+
+// catatPenugasan mencatat bahwa penyadap terlihat di kelompok mandor pada
+// tanggal tertentu. Impor diasumsikan kurang lebih berurutan tanggalnya:
+//   - penugasan yang sama dan sudah mencakup tanggal itu dibiarkan
+//   - penugasan terbuka yang sama tapi mulai lebih lambat dimundurkan
+//   - selain itu penugasan impor lain yang mencakup tanggal itu dipotong
+//     (lihat potongPenugasan) dan penugasan baru dibuat
+//
+// Penugasan manual yang mencakup tanggal tersebut selalu menang.
+func catatPenugasan(db *gorm.DB, pm models.PenyadapMandor, tanggal time.Time) error {
+	day := time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, time.Local)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.PenyadapMandor
+		if err := tx.Where("penyadap_id = ?", pm.PenyadapID).
+			Order("valid_from asc").Find(&existing).Error; err != nil {
+			return err
+		}
+
+		var nextStart *time.Time
+		var tumpang []models.PenyadapMandor
+		for i := range existing {
+			e := existing[i]
+			sama := e.MandorID == pm.MandorID && e.TahunTanam == pm.TahunTanam
+			if e.BerlakuPada(day) && (sama || e.Sumber == models.SumberPenugasanManual) {
+				return nil
+			}
+			if sama && e.ValidTo == nil && e.ValidFrom.After(day) {
+				return tx.Model(&e).Update("valid_from", day).Error
+			}
+			if e.BerlakuPada(day) {
+				tumpang = append(tumpang, e)
+				continue
+			}
+			if e.ValidFrom.After(day) && nextStart == nil {
+				start := e.ValidFrom
+				nextStart = &start
+			}
+		}
+
+		// Baru diubah setelah yakin tidak ada penugasan manual yang menang
+		for _, e := range tumpang {
+			sisa, err := potongPenugasan(tx, e, day)
+			if err != nil {
+				return err
+			}
+			if sisa != nil && (nextStart == nil || sisa.Before(*nextStart)) {
+				nextStart = sisa
+			}
+		}
+
+		pm.ID = 0
+		pm.ValidFrom = day
+		pm.ValidTo = nil
+		if nextStart != nil {
+			// Sudah ada penugasan lain sesudahnya: penugasan ini hanya sampai sebelum itu
+			end := nextStart.AddDate(0, 0, -1)
+			pm.ValidTo = &end
+		}
+		return tx.Create(&pm).Error
+	})
+}
+
+// potongPenugasan mengosongkan tanggal day dari penugasan impor e:
+//   - bagian sebelum day tetap, ditutup sehari sebelumnya
+//   - bagian sesudah day (hanya untuk penugasan tertutup) dipertahankan
+//     sebagai penugasan terpisah mulai besok
+//   - penugasan terbuka yang mulai tepat pada day diganti penugasan baru
+//
+// Nilai kembaliannya adalah awal sisa penugasan sesudah day, atau nil.
+func potongPenugasan(tx *gorm.DB, e models.PenyadapMandor, day time.Time) (*time.Time, error) {
+	tgl := day.Format("2006-01-02")
+	kemarin := day.AddDate(0, 0, -1)
+	besok := day.AddDate(0, 0, 1)
+	adaSebelum := e.ValidFrom.Format("2006-01-02") < tgl
+	adaSesudah := e.ValidTo != nil && e.ValidTo.Format("2006-01-02") > tgl
+
+	switch {
+	case adaSebelum && adaSesudah:
+		sisa := e
+		sisa.ID = 0
+		sisa.ValidFrom = besok
+		if err := tx.Create(&sisa).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&e).Update("valid_to", kemarin).Error; err != nil {
+			return nil, err
+		}
+		return &besok, nil
+	case adaSebelum:
+		return nil, tx.Model(&e).Update("valid_to", kemarin).Error
+	case adaSesudah:
+		return &besok, tx.Model(&e).Update("valid_from", besok).Error
+	default:
+		return nil, tx.Delete(&e).Error
+	}
+}
+
+// ================== API PENUGASAN ==================
+
+func parseTanggalPenugasan(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// validasiPenugasan mengisi model dari input dan memeriksa relasinya
+func validasiPenugasan(input penugasanInput, pm *models.PenyadapMandor) error {
+	if input.PenyadapID != 0 {
+		pm.PenyadapID = input.PenyadapID
+	}
+	if input.MandorID != 0 {
+		pm.MandorID = input.MandorID
+	}
+	if pm.PenyadapID == 0 || pm.MandorID == 0 {
+		return errors.New("penyadap_id dan mandor_id wajib diisi")
+	}
+
+	if err := config.DB.First(&models.Penyadap{}, pm.PenyadapID).Error; err != nil {
+		return errors.New("penyadap tidak ditemukan")
+	}
+	var mandor models.Mandor
+	if err := config.DB.First(&mandor, pm.MandorID).Error; err != nil {
+		return errors.New("mandor tidak ditemukan")
+	}
+
+	if input.AfdelingID != nil {
+		var afdeling models.Afdeling
+		if err := config.DB.First(&afdeling, *input.AfdelingID).Error; err != nil {
+			return errors.New("afdeling tidak ditemukan")
+		}
+		pm.AfdelingID = &afdeling.ID
+	}
+	if input.TahunTanam != "" {
+		pm.TahunTanam = input.TahunTanam
+	}
+	if pm.TahunTanam == "" {
+		pm.TahunTanam = mandor.TahunTanam
+	}
+	if input.Blok != "" {
+		pm.Blok = input.Blok
+	}
+
+	if input.ValidFrom != "" {
+		from, err := parseTanggalPenugasan(input.ValidFrom)
+		if err != nil {
+			return errors.New("format valid_from tidak valid (gunakan: YYYY-MM-DD)")
+		}
+		pm.ValidFrom = from
+	}
+	if pm.ValidFrom.IsZero() {
+		return errors.New("valid_from wajib diisi")
+	}
+	if input.ValidTo != nil {
+		if *input.ValidTo == "" {
+			pm.ValidTo = nil
+		} else {
+			to, err := parseTanggalPenugasan(*input.ValidTo)
+			if err != nil {
+				return errors.New("format valid_to tidak valid (gunakan: YYYY-MM-DD)")
+			}
+			pm.ValidTo = &to
+		}
+	}
+	if pm.ValidTo != nil && pm.ValidTo.Before(pm.ValidFrom) {
+		return errors.New("valid_to tidak boleh sebelum valid_from")
+	}
+	return nil
+}
+
+// GetAllPenugasan mendukung filter penyadap_id, mandor_id, dan tanggal
+func GetAllPenugasan(w http.ResponseWriter, r *http.Request) {
+	query := config.DB.Preload("Penyadap").Preload("Mandor").Preload("Afdeling").
+		Order("penyadap_id asc, valid_from asc")
+
+	if id := r.URL.Query().Get("penyadap_id"); id != "" {
+		query = query.Where("penyadap_id = ?", id)
+	}
+	if id := r.URL.Query().Get("mandor_id"); id != "" {
+		query = query.Where("mandor_id = ?", id)
+	}
+	if tanggal := r.URL.Query().Get("tanggal"); tanggal != "" {
+		day, err := parseTanggalPenugasan(tanggal)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Format tanggal tidak valid (gunakan: YYYY-MM-DD)",
+			})
+			return
+		}
+		query = query.Where("DATE(valid_from) <= DATE(?) AND (valid_to IS NULL OR DATE(valid_to) >= DATE(?))", day, day)
+	}
+
+	var list []models.PenyadapMandor
+	if err := query.Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data penugasan: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    list,
+	})
+}
+
+func CreatePenugasan(w http.ResponseWriter, r *http.Request) {
+	var input penugasanInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	pm := models.PenyadapMandor{Sumber: models.SumberPenugasanManual}
+	if err := validasiPenugasan(input, &pm); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Create(&pm).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan penugasan: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Penugasan berhasil ditambahkan",
+		Data:    pm,
+	})
+}
+
+// UpdatePenugasan dipakai untuk koreksi hasil impor; penugasan yang diubah
+// ditandai manual agar tidak ditimpa impor berikutnya
+func UpdatePenugasan(w http.ResponseWriter, r *http.Request) {
+	var pm models.PenyadapMandor
+	if err := config.DB.First(&pm, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data penugasan tidak ditemukan",
+		})
+		return
+	}
+
+	var input penugasanInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if err := validasiPenugasan(input, &pm); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	pm.Sumber = models.SumberPenugasanManual
+
+	if err := config.DB.Save(&pm).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal update penugasan: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Penugasan berhasil diperbarui",
+		Data:    pm,
+	})
+}
+
+func DeletePenugasan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "ID tidak valid",
+		})
+		return
+	}
+
+	result := config.DB.Delete(&models.PenyadapMandor{}, id)
+	if result.Error != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghapus penugasan: " + result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data penugasan tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Penugasan berhasil dihapus",
+	})
+}
+
+// GetKelompokMandor menjawab "siapa saja anggota kelompok mandor X pada tanggal D".
+// Parameter: idMandor (wajib), tanggal (opsional, default hari ini)
+func GetKelompokMandor(w http.ResponseWriter, r *http.Request) {
+	idMandor := r.URL.Query().Get("idMandor")
+	if idMandor == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter idMandor diperlukan",
+		})
+		return
+	}
+
+	day := time.Now()
+	if tanggal := r.URL.Query().Get("tanggal"); tanggal != "" {
+		parsed, err := parseTanggalPenugasan(tanggal)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Format tanggal tidak valid (gunakan: YYYY-MM-DD)",
+			})
+			return
+		}
+		day = parsed
+	}
+
+	var anggota []KelompokMandorItem
+	err := config.DB.Table("penyadap_mandors pm").
+		Select(`pm.id AS penugasan_id, pm.penyadap_id, p.nik, p.nama_penyadap,
+			pm.tahun_tanam, pm.blok, pm.valid_from, pm.valid_to, pm.sumber`).
+		Joins("JOIN penyadaps p ON p.id = pm.penyadap_id").
+		Where("pm.mandor_id = ?", idMandor).
+		Where("DATE(pm.valid_from) <= DATE(?) AND (pm.valid_to IS NULL OR DATE(pm.valid_to) >= DATE(?))", day, day).
+		Order("p.nama_penyadap asc").
+		Scan(&anggota).Error
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil kelompok mandor: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Kelompok mandor pada " + day.Format("2006-01-02"),
+		Data:    anggota,
+	})
+}
+
+// GetProduksiKelompokMandor menjumlahkan produksi penyadap per tanggal
+// berdasarkan penugasan yang berlaku pada tanggal itu, bukan nama mandor di
+// sheet produksi. Parameter: idMandor, tanggalAwal, tanggalAkhir, tipeProduksi (opsional)
+func GetProduksiKelompokMandor(w http.ResponseWriter, r *http.Request) {
+	idMandor := r.URL.Query().Get("idMandor")
+	tanggalAwal := r.URL.Query().Get("tanggalAwal")
+	tanggalAkhir := r.URL.Query().Get("tanggalAkhir")
+	tipeProduksi := r.URL.Query().Get("tipeProduksi")
+
+	if idMandor == "" || tanggalAwal == "" || tanggalAkhir == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter idMandor, tanggalAwal, dan tanggalAkhir diperlukan",
+		})
+		return
+	}
+	awal, errAwal := parseTanggalPenugasan(tanggalAwal)
+	akhir, errAkhir := parseTanggalPenugasan(tanggalAkhir)
+	if errAwal != nil || errAkhir != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format tanggal tidak valid (gunakan: YYYY-MM-DD)",
+		})
+		return
+	}
+
+	query := config.DB.Table("produksis pr").
+		Select(`DATE(pr.tanggal) AS tanggal,
+			COUNT(DISTINCT pr.nik) AS jumlah_penyadap,
+			COALESCE(SUM(pr.basah_latek), 0) AS basah_latek,
+			COALESCE(SUM(pr.sheet), 0) AS sheet,
+			COALESCE(SUM(pr.basah_lump), 0) AS basah_lump,
+			COALESCE(SUM(pr.br_cr), 0) AS br_cr,
+			COALESCE(SUM(pr.total_produksi), 0) AS total_produksi`).
+		Joins("JOIN penyadaps p ON p.nik = pr.nik").
+		Joins(`JOIN penyadap_mandors pm ON pm.penyadap_id = p.id
+			AND DATE(pm.valid_from) <= DATE(pr.tanggal)
+			AND (pm.valid_to IS NULL OR DATE(pm.valid_to) >= DATE(pr.tanggal))
+			AND pm.tahun_tanam = pr.tahun_tanam`).
+		Where("pm.mandor_id = ?", idMandor).
+		Where("DATE(pr.tanggal) BETWEEN DATE(?) AND DATE(?)", awal, akhir)
+	if tipeProduksi != "" {
+		query = query.Where("pr.tipe_produksi = ?", tipeProduksi)
+	}
+
+	var hasil []ProduksiKelompokMandor
+	if err := query.Group("DATE(pr.tanggal)").Order("tanggal asc").Scan(&hasil).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghitung produksi kelompok: " + err.Error(),
+		})
+		return
+	}
+	// MySQL mengembalikan DATE sebagai waktu penuh
+	for i := range hasil {
+		if len(hasil[i].Tanggal) > 10 {
+			hasil[i].Tanggal = hasil[i].Tanggal[:10]
+		}
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    hasil,
+	})
+}
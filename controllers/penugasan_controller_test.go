@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config/configtest"
+	"app-inputan-ptpn/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func tanggalUji(s string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return t
+}
+
+// rentangPenugasan meringkas penugasan penyadap sebagai "mandor:awal..akhir"
+func rentangPenugasan(t *testing.T, db *gorm.DB, penyadapID uint) []string {
+	t.Helper()
+	var list []models.PenyadapMandor
+	if err := db.Where("penyadap_id = ?", penyadapID).Order("valid_from asc").Find(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	hasil := make([]string, len(list))
+	for i, pm := range list {
+		akhir := ""
+		if pm.ValidTo != nil {
+			akhir = pm.ValidTo.Format("2006-01-02")
+		}
+		hasil[i] = fmt.Sprintf("%d:%s..%s", pm.MandorID, pm.ValidFrom.Format("2006-01-02"), akhir)
+	}
+	return hasil
+}
+
+// Penugasan impor tertutup milik mandor lain yang mencakup tanggal impor
+// dipecah sehingga rentang penugasan penyadap tidak pernah tumpang tindih
+func TestCatatPenugasanMemotongPenugasanTertutup(t *testing.T) {
+	tests := []struct {
+		nama    string
+		awal    string
+		akhir   string
+		tanggal string
+		ingin   []string
+	}{
+		{"di tengah", "2025-01-01", "2025-01-31", "2025-01-15",
+			[]string{"1:2025-01-01..2025-01-14", "2:2025-01-15..2025-01-15", "1:2025-01-16..2025-01-31"}},
+		{"di awal", "2025-01-01", "2025-01-31", "2025-01-01",
+			[]string{"2:2025-01-01..2025-01-01", "1:2025-01-02..2025-01-31"}},
+		{"di akhir", "2025-01-01", "2025-01-31", "2025-01-31",
+			[]string{"1:2025-01-01..2025-01-30", "2:2025-01-31.."}},
+		{"satu hari", "2025-01-10", "2025-01-10", "2025-01-10",
+			[]string{"2:2025-01-10.."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			db := configtest.InitTestDB(t)
+			penyadap := models.Penyadap{NamaPenyadap: "Sutrisno", NIK: "P001"}
+			db.Create(&penyadap)
+			for _, m := range []models.Mandor{{ID: 1, TahunTanam: "2010", Nama: "A"}, {ID: 2, TahunTanam: "2010", Nama: "B"}} {
+				db.Create(&m)
+			}
+			akhir := tanggalUji(tt.akhir)
+			lama := models.PenyadapMandor{PenyadapID: penyadap.ID, MandorID: 1, TahunTanam: "2010",
+				ValidFrom: tanggalUji(tt.awal), ValidTo: &akhir, Sumber: models.SumberPenugasanImpor}
+			if err := db.Create(&lama).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			baru := models.PenyadapMandor{PenyadapID: penyadap.ID, MandorID: 2, TahunTanam: "2010", Sumber: models.SumberPenugasanImpor}
+			if err := catatPenugasan(db, baru, tanggalUji(tt.tanggal)); err != nil {
+				t.Fatal(err)
+			}
+			if got := rentangPenugasan(t, db, penyadap.ID); fmt.Sprint(got) != fmt.Sprint(tt.ingin) {
+				t.Errorf("penugasan = %v, ingin %v", got, tt.ingin)
+			}
+		})
+	}
+}
+
+// Penugasan manual tidak dipotong oleh impor
+func TestCatatPenugasanManualMenang(t *testing.T) {
+	db := configtest.InitTestDB(t)
+	penyadap := models.Penyadap{NamaPenyadap: "Sutrisno", NIK: "P001"}
+	db.Create(&penyadap)
+	for _, m := range []models.Mandor{{ID: 1, TahunTanam: "2010", Nama: "A"}, {ID: 2, TahunTanam: "2010", Nama: "B"}} {
+		db.Create(&m)
+	}
+	akhir := tanggalUji("2025-01-31")
+	db.Create(&models.PenyadapMandor{PenyadapID: penyadap.ID, MandorID: 1, TahunTanam: "2010",
+		ValidFrom: tanggalUji("2025-01-01"), ValidTo: &akhir, Sumber: models.SumberPenugasanManual})
+
+	baru := models.PenyadapMandor{PenyadapID: penyadap.ID, MandorID: 2, TahunTanam: "2010", Sumber: models.SumberPenugasanImpor}
+	if err := catatPenugasan(db, baru, tanggalUji("2025-01-15")); err != nil {
+		t.Fatal(err)
+	}
+	ingin := []string{"1:2025-01-01..2025-01-31"}
+	if got := rentangPenugasan(t, db, penyadap.ID); fmt.Sprint(got) != fmt.Sprint(ingin) {
+		t.Errorf("penugasan = %v, ingin %v", got, ingin)
+	}
+}
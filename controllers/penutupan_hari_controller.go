@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// RingkasanHari adalah angka rekap satu afdeling pada tanggal yang
+// ditutup, dikirim bersama peristiwa day.closed
+type RingkasanHari struct {
+	JumlahMandor int     `json:"jumlah_mandor"`
+	HKO          int     `json:"hko"`
+	KeringSheet  float64 `json:"kering_sheet"`
+	KeringBrCr   float64 `json:"kering_br_cr"`
+	KeringJumlah float64 `json:"kering_jumlah"`
+}
+
+func ringkasanHari(db *gorm.DB, tanggal time.Time, afdelingID uint) (RingkasanHari, error) {
+	var hasil RingkasanHari
+	err := db.Model(&models.Rekap{}).
+		Select(`COUNT(*) as jumlah_mandor,
+			COALESCE(SUM(hko_hari_ini), 0) as hko,
+			COALESCE(SUM(hari_ini_kering_sheet), 0) as kering_sheet,
+			COALESCE(SUM(hari_ini_kering_br_cr), 0) as kering_br_cr,
+			COALESCE(SUM(hari_ini_kering_jumlah), 0) as kering_jumlah`).
+		Where("DATE(tanggal) = ? AND afdeling_id = ? AND tipe_produksi != ?",
+			tanggal.Format("2006-01-02"), afdelingID, "REKAPITULASI").
+		Scan(&hasil).Error
+	hasil.KeringSheet = roundTo(hasil.KeringSheet, 2)
+	hasil.KeringBrCr = roundTo(hasil.KeringBrCr, 2)
+	hasil.KeringJumlah = roundTo(hasil.KeringJumlah, 2)
+	return hasil, err
+}
+
+// GetAllPenutupanHari menampilkan hari yang sudah ditutup. Filter opsional:
+// afdeling, tanggalAwal, tanggalAkhir.
+func GetAllPenutupanHari(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := config.DB.Preload("Afdeling")
+
+	if afdeling := q.Get("afdeling"); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
+	}
+	if v := q.Get("tanggalAwal"); v != "" {
+		query = query.Where("DATE(tanggal) >= ?", v)
+	}
+	if v := q.Get("tanggalAkhir"); v != "" {
+		query = query.Where("DATE(tanggal) <= ?", v)
+	}
+
+	var list []models.PenutupanHari
+	if err := query.Order("tanggal desc, afdeling_id").Limit(500).Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data penutupan hari: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d hari ditutup", len(list)),
+		Data:    list,
+	})
+}
+
+// TutupHari menandai satu tanggal di satu afdeling selesai lalu mengirim
+// peristiwa day.closed. Body: {"tanggal", "afdeling", "catatan"}.
+func TutupHari(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Tanggal  string `json:"tanggal"`
+		Afdeling string `json:"afdeling"`
+		Catatan  string `json:"catatan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+
+	tanggal, err := time.Parse("2006-01-02", input.Tanggal)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "tanggal wajib diisi (format: YYYY-MM-DD)"})
+		return
+	}
+	if strings.TrimSpace(input.Afdeling) == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "afdeling wajib diisi"})
+		return
+	}
+	afd, err := resolveAfdeling(input.Afdeling, false)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	var jumlah int64
+	config.DB.Model(&models.PenutupanHari{}).
+		Where("DATE(tanggal) = ? AND afdeling_id = ?", input.Tanggal, afd.ID).
+		Count(&jumlah)
+	if jumlah > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Tanggal %s afdeling %s sudah ditutup", input.Tanggal, afd.Nama),
+		})
+		return
+	}
+
+	penutupan := models.PenutupanHari{
+		Tanggal:     tanggal,
+		AfdelingID:  afd.ID,
+		DitutupOleh: usernameDariRequest(r),
+		Catatan:     strings.TrimSpace(input.Catatan),
+	}
+	if err := config.DB.Create(&penutupan).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menutup hari: " + err.Error()})
+		return
+	}
+	penutupan.Afdeling = &afd
+
+	ringkasan, err := ringkasanHari(config.DB, tanggal, afd.ID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menghitung ringkasan: " + err.Error()})
+		return
+	}
+
+	kirimPeristiwa(Peristiwa{
+		Jenis:      PeristiwaHariDitutup,
+		AfdelingID: afd.ID,
+		Pesan:      fmt.Sprintf("Tanggal %s afdeling %s ditutup oleh %s", input.Tanggal, afd.Nama, penutupan.DitutupOleh),
+		Data: map[string]interface{}{
+			"tanggal":      input.Tanggal,
+			"afdeling":     afd.Nama,
+			"afdeling_id":  afd.ID,
+			"ditutup_oleh": penutupan.DitutupOleh,
+			"catatan":      penutupan.Catatan,
+			"ringkasan":    ringkasan,
+		},
+	})
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Tanggal %s afdeling %s ditutup", input.Tanggal, afd.Nama),
+		Data:    penutupan,
+	})
+}
+
+// BukaHari membatalkan penutupan, misalnya karena ada koreksi data
+func BukaHari(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var penutupan models.PenutupanHari
+	if err := config.DB.Preload("Afdeling").First(&penutupan, id).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Penutupan hari tidak ditemukan"})
+		return
+	}
+
+	if err := config.DB.Delete(&penutupan).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal membuka hari: " + err.Error()})
+		return
+	}
+
+	namaAfdeling := ""
+	if penutupan.Afdeling != nil {
+		namaAfdeling = penutupan.Afdeling.Nama
+	}
+	tanggal := penutupan.Tanggal.Format("2006-01-02")
+	kirimPeristiwa(Peristiwa{
+		Jenis:      PeristiwaHariDibuka,
+		AfdelingID: penutupan.AfdelingID,
+		Pesan:      fmt.Sprintf("Tanggal %s afdeling %s dibuka kembali oleh %s", tanggal, namaAfdeling, usernameDariRequest(r)),
+		Data: map[string]interface{}{
+			"tanggal":     tanggal,
+			"afdeling":    namaAfdeling,
+			"afdeling_id": penutupan.AfdelingID,
+			"dibuka_oleh": usernameDariRequest(r),
+		},
+	})
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Tanggal %s afdeling %s dibuka kembali", tanggal, namaAfdeling),
+	})
+}
@@ -1,9 +1,572 @@
 package controllers
 
 import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 func ServePerbandinganPage(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "templates/html/perbandingan.html")
 }
+
+// Dimensi pengelompokan perbandingan
+const (
+	dimensiTotal    = "total"
+	dimensiAfdeling = "afdeling"
+	dimensiMandor   = "mandor"
+	dimensiPenyadap = "penyadap"
+	dimensiTipe     = "tipe"
+)
+
+// Batas jumlah periode dalam satu perbandingan
+const maksPeriodePerbandingan = 6
+
+// validSatuanProduksi adalah satuan rekap yang juga bisa dihitung dari data
+// produksi per penyadap (tidak ada data pabrik di tingkat penyadap)
+var validSatuanProduksi = map[string]bool{
+	"hko":                true,
+	"basah_latek_kebun":  true,
+	"basah_lump_kebun":   true,
+	"kering_sheet":       true,
+	"kering_br_cr":       true,
+	"kering_jumlah":      true,
+	"produksi_per_taper": true,
+}
+
+// PeriodePerbandingan adalah satu rentang tanggal yang dibandingkan
+type PeriodePerbandingan struct {
+	Label      string `json:"label"`
+	Awal       string `json:"awal"`
+	Akhir      string `json:"akhir"`
+	JumlahHari int    `json:"jumlah_hari"`
+
+	awal, akhir time.Time
+}
+
+// DeltaPerbandingan adalah selisih periode utama (periode pertama)
+// terhadap periode lain. Persen nil jika nilai pembanding nol.
+type DeltaPerbandingan struct {
+	Terhadap string   `json:"terhadap"`
+	Selisih  float64  `json:"selisih"`
+	Persen   *float64 `json:"persen"`
+}
+
+// SeriPerbandingan adalah nilai satu anggota dimensi pada setiap periode.
+// Harian berisi nilai per hari yang disejajarkan menurut urutan hari dalam
+// periode (hari ke-1, ke-2, ...); nil berarti tidak ada data atau hari itu
+// di luar periode.
+type SeriPerbandingan struct {
+	Kunci  string              `json:"kunci"`
+	Nama   string              `json:"nama"`
+	Nilai  []float64           `json:"nilai"`
+	Delta  []DeltaPerbandingan `json:"delta"`
+	Harian [][]*float64        `json:"harian"`
+}
+
+// PerbandinganResponse adalah hasil perbandingan beberapa periode
+type PerbandinganResponse struct {
+	Satuan  string                `json:"satuan"`
+	Dimensi string                `json:"dimensi"`
+	Sumber  string                `json:"sumber"`
+	Periode []PeriodePerbandingan `json:"periode"`
+	Hari    []string              `json:"hari"`
+	Seri    []SeriPerbandingan    `json:"seri"`
+	Total   SeriPerbandingan      `json:"total"`
+}
+
+// filterPerbandingan adalah filter data yang berlaku untuk semua periode
+type filterPerbandingan struct {
+	AfdelingID   uint
+	TipeProduksi string
+	Mandor       *models.Mandor
+	Penyadap     *models.Penyadap
+}
+
+// geserBulan memundurkan tanggal n bulan dengan hari dibatasi akhir bulan
+// (31 Maret mundur satu bulan menjadi 28/29 Februari, bukan 3 Maret)
+func geserBulan(t time.Time, n int) time.Time {
+	awalBulan := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, n, 0)
+	akhirBulan := awalBulan.AddDate(0, 1, -1).Day()
+	return time.Date(awalBulan.Year(), awalBulan.Month(), min(t.Day(), akhirBulan), 0, 0, 0, 0, t.Location())
+}
+
+func periodeBaru(label string, awal, akhir time.Time) PeriodePerbandingan {
+	return PeriodePerbandingan{
+		Label:      label,
+		Awal:       awal.Format("2006-01-02"),
+		Akhir:      akhir.Format("2006-01-02"),
+		JumlahHari: int(akhir.Sub(awal).Hours()/24) + 1,
+		awal:       awal,
+		akhir:      akhir,
+	}
+}
+
+// parsePeriodePerbandingan membaca periode dari parameter periode
+// ("YYYY-MM-DD,YYYY-MM-DD", boleh diulang) atau dari tanggalAwal,
+// tanggalAkhir dan banding (periode_lalu, bulan_lalu, tahun_lalu; boleh
+// dipisah koma). Periode pertama menjadi acuan delta.
+func parsePeriodePerbandingan(r *http.Request) ([]PeriodePerbandingan, error) {
+	q := r.URL.Query()
+	parse := func(s, nama string) (time.Time, error) {
+		t, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+		if err != nil {
+			return t, fmt.Errorf("Format %s tidak valid (gunakan: YYYY-MM-DD)", nama)
+		}
+		return t, nil
+	}
+
+	var hasil []PeriodePerbandingan
+	if daftar := q["periode"]; len(daftar) > 0 {
+		for _, p := range daftar {
+			bagian := strings.Split(p, ",")
+			if len(bagian) != 2 {
+				return nil, fmt.Errorf("Parameter periode harus berformat YYYY-MM-DD,YYYY-MM-DD")
+			}
+			awal, err := parse(bagian[0], "periode")
+			if err != nil {
+				return nil, err
+			}
+			akhir, err := parse(bagian[1], "periode")
+			if err != nil {
+				return nil, err
+			}
+			hasil = append(hasil, periodeBaru(awal.Format("2006-01-02")+" s/d "+akhir.Format("2006-01-02"), awal, akhir))
+		}
+	} else {
+		if q.Get("tanggalAwal") == "" || q.Get("tanggalAkhir") == "" {
+			return nil, fmt.Errorf("Gunakan parameter periode (minimal dua) atau tanggalAwal, tanggalAkhir dan banding")
+		}
+		awal, err := parse(q.Get("tanggalAwal"), "tanggalAwal")
+		if err != nil {
+			return nil, err
+		}
+		akhir, err := parse(q.Get("tanggalAkhir"), "tanggalAkhir")
+		if err != nil {
+			return nil, err
+		}
+		hasil = append(hasil, periodeBaru("Periode ini", awal, akhir))
+
+		banding := q.Get("banding")
+		if banding == "" {
+			banding = "periode_lalu"
+		}
+		for _, b := range strings.Split(banding, ",") {
+			switch strings.TrimSpace(b) {
+			case "periode_lalu":
+				hari := int(akhir.Sub(awal).Hours() / 24)
+				akhirLalu := awal.AddDate(0, 0, -1)
+				hasil = append(hasil, periodeBaru("Periode lalu", akhirLalu.AddDate(0, 0, -hari), akhirLalu))
+			case "bulan_lalu":
+				hasil = append(hasil, periodeBaru("Bulan lalu", geserBulan(awal, -1), geserBulan(akhir, -1)))
+			case "tahun_lalu":
+				hasil = append(hasil, periodeBaru("Tahun lalu", geserBulan(awal, -12), geserBulan(akhir, -12)))
+			default:
+				return nil, fmt.Errorf("Parameter banding tidak valid. Gunakan: periode_lalu, bulan_lalu, atau tahun_lalu")
+			}
+		}
+	}
+
+	if len(hasil) < 2 {
+		return nil, fmt.Errorf("Perbandingan membutuhkan minimal dua periode")
+	}
+	if len(hasil) > maksPeriodePerbandingan {
+		return nil, fmt.Errorf("Maksimal %d periode dalam satu perbandingan", maksPeriodePerbandingan)
+	}
+	for _, p := range hasil {
+		if p.akhir.Before(p.awal) {
+			return nil, fmt.Errorf("Tanggal akhir periode %s lebih awal dari tanggal awal", p.Label)
+		}
+		if p.JumlahHari > 366 {
+			return nil, fmt.Errorf("Periode %s lebih dari satu tahun", p.Label)
+		}
+	}
+	return hasil, nil
+}
+
+// dataPerbandingan menampung titik per anggota dimensi dan per hari dalam
+// satu periode
+type dataPerbandingan struct {
+	nama   map[string]string
+	harian map[string]map[int]*tempDataPoint
+}
+
+func (d *dataPerbandingan) titik(kunci, nama string, hari int) *tempDataPoint {
+	if _, ok := d.harian[kunci]; !ok {
+		d.harian[kunci] = make(map[int]*tempDataPoint)
+		d.nama[kunci] = nama
+	}
+	p, ok := d.harian[kunci][hari]
+	if !ok {
+		p = &tempDataPoint{}
+		d.harian[kunci][hari] = p
+	}
+	return p
+}
+
+// kunciDimensiRekap menentukan anggota dimensi satu baris rekap
+func kunciDimensiRekap(dimensi string, r models.Rekap) (string, string) {
+	switch dimensi {
+	case dimensiAfdeling:
+		if r.AfdelingID != nil {
+			return strconv.FormatUint(uint64(*r.AfdelingID), 10), r.Afdeling
+		}
+		return r.Afdeling, r.Afdeling
+	case dimensiMandor:
+		return r.NIK, r.Mandor
+	case dimensiTipe:
+		return r.TipeProduksi, r.TipeProduksi
+	}
+	return dimensiTotal, "TOTAL"
+}
+
+// ambilDataRekap mengagregasi rekap satu periode (tanpa REKAPITULASI)
+func ambilDataRekap(db *gorm.DB, p PeriodePerbandingan, dimensi string, f filterPerbandingan) (*dataPerbandingan, error) {
+	query := db.Model(&models.Rekap{}).
+		Where("tipe_produksi != ? AND DATE(tanggal) BETWEEN ? AND ?", "REKAPITULASI", p.Awal, p.Akhir)
+	if f.AfdelingID != 0 {
+		query = query.Where("afdeling_id = ?", f.AfdelingID)
+	}
+	if f.TipeProduksi != "" {
+		query = query.Where("tipe_produksi = ?", f.TipeProduksi)
+	}
+	if f.Mandor != nil {
+		query = query.Where("nik = ?", f.Mandor.NIK)
+	}
+
+	var rekaps []models.Rekap
+	if err := query.Find(&rekaps).Error; err != nil {
+		return nil, err
+	}
+
+	data := &dataPerbandingan{nama: map[string]string{}, harian: map[string]map[int]*tempDataPoint{}}
+	for _, r := range rekaps {
+		tanggal := time.Date(r.Tanggal.Year(), r.Tanggal.Month(), r.Tanggal.Day(), 0, 0, 0, 0, p.awal.Location())
+		hari := int(tanggal.Sub(p.awal).Hours() / 24)
+		kunci, nama := kunciDimensiRekap(dimensi, r)
+		data.titik(kunci, nama, hari).tambahRekap(r)
+	}
+	return data, nil
+}
+
+// ambilDataProduksi mengagregasi produksi per penyadap satu periode. HKO
+// dihitung sebagai jumlah penyadap yang berproduksi per hari.
+func ambilDataProduksi(db *gorm.DB, p PeriodePerbandingan, dimensi string, f filterPerbandingan) (*dataPerbandingan, error) {
+	query := db.Model(&models.Produksi{}).
+		Where("DATE(tanggal) BETWEEN ? AND ?", p.Awal, p.Akhir)
+	if f.AfdelingID != 0 {
+		query = query.Where("afdeling_id = ?", f.AfdelingID)
+	}
+	if f.TipeProduksi != "" {
+		query = query.Where("tipe_produksi = ?", f.TipeProduksi)
+	}
+	if f.Mandor != nil {
+		query = query.Where("UPPER(mandor) = ?", strings.ToUpper(strings.TrimSpace(f.Mandor.Nama)))
+	}
+	if f.Penyadap != nil {
+		query = query.Where("nik = ?", f.Penyadap.NIK)
+	}
+
+	var list []models.Produksi
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	data := &dataPerbandingan{nama: map[string]string{}, harian: map[string]map[int]*tempDataPoint{}}
+	hadir := make(map[string]bool)
+	for _, pr := range list {
+		tanggal := time.Date(pr.Tanggal.Year(), pr.Tanggal.Month(), pr.Tanggal.Day(), 0, 0, 0, 0, p.awal.Location())
+		hari := int(tanggal.Sub(p.awal).Hours() / 24)
+
+		var kunci, nama string
+		switch dimensi {
+		case dimensiPenyadap:
+			kunci, nama = pr.NIK, pr.NamaPenyadap
+		case dimensiAfdeling:
+			kunci, nama = pr.Afdeling, pr.Afdeling
+			if pr.AfdelingID != nil {
+				kunci = strconv.FormatUint(uint64(*pr.AfdelingID), 10)
+			}
+		case dimensiMandor:
+			kunci, nama = strings.ToUpper(strings.TrimSpace(pr.Mandor)), pr.Mandor
+		case dimensiTipe:
+			kunci, nama = pr.TipeProduksi, pr.TipeProduksi
+		default:
+			kunci, nama = dimensiTotal, "TOTAL"
+		}
+
+		t := data.titik(kunci, nama, hari)
+		t.BasahLatekKebun += pr.BasahLatek
+		t.BasahLumpKebun += pr.BasahLump
+		t.KeringSheet += pr.Sheet
+		t.KeringBrCr += pr.BrCr
+		t.KeringJumlah += pr.Sheet + pr.BrCr
+
+		// Satu penyadap dihitung sekali per hari per anggota dimensi
+		// walaupun tercatat di beberapa tahun tanam
+		kunciHadir := fmt.Sprintf("%s|%d|%s", kunci, hari, pr.NIK)
+		if !hadir[kunciHadir] && pr.BasahLatek+pr.BasahLump+pr.Sheet+pr.BrCr > 0 {
+			hadir[kunciHadir] = true
+			t.HKO++
+		}
+	}
+	return data, nil
+}
+
+// susunSeriPerbandingan menghitung nilai, delta dan deret harian satu
+// anggota dimensi dari titik per periode
+func susunSeriPerbandingan(kunci, nama, satuan string, periode []PeriodePerbandingan, harian []map[int]*tempDataPoint, panjang int) SeriPerbandingan {
+	seri := SeriPerbandingan{
+		Kunci:  kunci,
+		Nama:   nama,
+		Nilai:  make([]float64, len(periode)),
+		Delta:  []DeltaPerbandingan{},
+		Harian: make([][]*float64, len(periode)),
+	}
+	for i := range periode {
+		var jumlah tempDataPoint
+		seri.Harian[i] = make([]*float64, panjang)
+		for hari, t := range harian[i] {
+			jumlah.tambah(*t)
+			if hari >= 0 && hari < panjang {
+				v := roundTo(t.nilai(satuan), 2)
+				seri.Harian[i][hari] = &v
+			}
+		}
+		seri.Nilai[i] = roundTo(jumlah.nilai(satuan), 2)
+	}
+	for i := 1; i < len(periode); i++ {
+		d := DeltaPerbandingan{
+			Terhadap: periode[i].Label,
+			Selisih:  roundTo(seri.Nilai[0]-seri.Nilai[i], 2),
+		}
+		if seri.Nilai[i] != 0 {
+			persen := roundTo((seri.Nilai[0]-seri.Nilai[i])/seri.Nilai[i]*100, 2)
+			d.Persen = &persen
+		}
+		seri.Delta = append(seri.Delta, d)
+	}
+	return seri
+}
+
+// GetPerbandingan membandingkan dua periode atau lebih untuk satu satuan,
+// dikelompokkan menurut dimensi (total, afdeling, mandor, penyadap, tipe).
+// Filter opsional: afdeling, tipeProduksi, idMandor, idPenyadap. Dimensi
+// penyadap atau filter idPenyadap memakai data produksi; selain itu rekap.
+// Total dan nilai rasio dihitung dari jumlah komponennya di server.
+func GetPerbandingan(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	satuan := q.Get("satuan")
+	if !validSatuanRekap[satuan] {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Parameter satuan tidak valid"})
+		return
+	}
+
+	dimensi := q.Get("dimensi")
+	if dimensi == "" {
+		dimensi = dimensiTotal
+	}
+	switch dimensi {
+	case dimensiTotal, dimensiAfdeling, dimensiMandor, dimensiPenyadap, dimensiTipe:
+	default:
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter dimensi tidak valid. Gunakan: total, afdeling, mandor, penyadap, atau tipe",
+		})
+		return
+	}
+
+	periode, err := parsePeriodePerbandingan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	db := config.GetDB()
+	var f filterPerbandingan
+	filterTeks := []string{"Satuan: " + satuan, "Dimensi: " + dimensi}
+	if afdeling := q.Get("afdeling"); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		f.AfdelingID = afd.ID
+		filterTeks = append(filterTeks, "Afdeling: "+afd.Nama)
+	}
+	if tipe := q.Get("tipeProduksi"); tipe != "" && tipe != "-" {
+		f.TipeProduksi = tipe
+		filterTeks = append(filterTeks, "Tipe Produksi: "+tipe)
+	}
+	if id := q.Get("idMandor"); id != "" {
+		var m models.Mandor
+		if err := db.First(&m, id).Error; err != nil {
+			respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Data mandor dengan ID " + id + " tidak ditemukan"})
+			return
+		}
+		f.Mandor = &m
+		filterTeks = append(filterTeks, "Mandor: "+m.Nama)
+	}
+	if id := q.Get("idPenyadap"); id != "" {
+		var p models.Penyadap
+		if err := db.First(&p, id).Error; err != nil {
+			respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Data penyadap dengan ID " + id + " tidak ditemukan"})
+			return
+		}
+		f.Penyadap = &p
+		filterTeks = append(filterTeks, "Penyadap: "+p.NamaPenyadap)
+	}
+
+	sumber := sumberDataRekap
+	ambil := ambilDataRekap
+	if dimensi == dimensiPenyadap || f.Penyadap != nil {
+		sumber, ambil = sumberDataProduksi, ambilDataProduksi
+		if !validSatuanProduksi[satuan] {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Satuan " + satuan + " tidak tersedia untuk data penyadap. Gunakan: hko, basah_latek_kebun, basah_lump_kebun, kering_sheet, kering_br_cr, kering_jumlah, atau produksi_per_taper",
+			})
+			return
+		}
+	}
+
+	data := make([]*dataPerbandingan, len(periode))
+	panjang := 0
+	for i, p := range periode {
+		if data[i], err = ambil(db, p, dimensi, f); err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Error mengambil data: " + err.Error(),
+			})
+			return
+		}
+		panjang = max(panjang, p.JumlahHari)
+	}
+
+	// Anggota dimensi adalah gabungan dari semua periode
+	nama := make(map[string]string)
+	for _, d := range data {
+		for k, n := range d.nama {
+			if _, ok := nama[k]; !ok || nama[k] == "" {
+				nama[k] = n
+			}
+		}
+	}
+	kunci := make([]string, 0, len(nama))
+	for k := range nama {
+		kunci = append(kunci, k)
+	}
+	sort.Slice(kunci, func(i, j int) bool {
+		if nama[kunci[i]] != nama[kunci[j]] {
+			return nama[kunci[i]] < nama[kunci[j]]
+		}
+		return kunci[i] < kunci[j]
+	})
+
+	hasil := PerbandinganResponse{
+		Satuan:  satuan,
+		Dimensi: dimensi,
+		Sumber:  sumber,
+		Periode: periode,
+		Hari:    make([]string, panjang),
+		Seri:    []SeriPerbandingan{},
+	}
+	for i := range hasil.Hari {
+		hasil.Hari[i] = fmt.Sprintf("Hari %d", i+1)
+	}
+
+	total := make([]map[int]*tempDataPoint, len(periode))
+	for i := range total {
+		total[i] = make(map[int]*tempDataPoint)
+	}
+	for _, k := range kunci {
+		harian := make([]map[int]*tempDataPoint, len(periode))
+		for i, d := range data {
+			harian[i] = d.harian[k]
+			for hari, t := range d.harian[k] {
+				if _, ok := total[i][hari]; !ok {
+					total[i][hari] = &tempDataPoint{}
+				}
+				total[i][hari].tambah(*t)
+			}
+		}
+		hasil.Seri = append(hasil.Seri, susunSeriPerbandingan(k, nama[k], satuan, periode, harian, panjang))
+	}
+	hasil.Total = susunSeriPerbandingan(dimensiTotal, "TOTAL", satuan, periode, total, panjang)
+
+	if format != "" {
+		kirimLaporan(w, r, format, "perbandingan_"+satuan, tabelPerbandingan(hasil, filterTeks))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    hasil,
+	})
+}
+
+// tabelPerbandingan menyusun ekspor satu baris per anggota dimensi dengan
+// nilai setiap periode lalu selisih dan persen terhadap periode utama
+func tabelPerbandingan(h PerbandinganResponse, filter []string) TabelLaporan {
+	jenis := kolomAngka
+	switch {
+	case h.Satuan == "hko":
+		jenis = kolomBulat
+	case strings.HasSuffix(h.Satuan, "_persen") || h.Satuan == "k3_sheet":
+		jenis = kolomPersen
+	}
+
+	kolom := []KolomLaporan{{Judul: "Kunci"}, {Judul: strings.ToUpper(h.Dimensi[:1]) + h.Dimensi[1:]}}
+	for _, p := range h.Periode {
+		kolom = append(kolom, KolomLaporan{Judul: fmt.Sprintf("%s (%s s/d %s)", p.Label, p.Awal, p.Akhir), Jenis: jenis})
+	}
+	for _, p := range h.Periode[1:] {
+		kolom = append(kolom,
+			KolomLaporan{Judul: "Selisih vs " + p.Label, Jenis: jenis},
+			KolomLaporan{Judul: "Selisih vs " + p.Label + " (%)", Jenis: kolomPersen},
+		)
+	}
+
+	baris := func(s SeriPerbandingan) []interface{} {
+		b := []interface{}{s.Kunci, s.Nama}
+		for _, v := range s.Nilai {
+			b = append(b, v)
+		}
+		for _, d := range s.Delta {
+			var persen interface{}
+			if d.Persen != nil {
+				persen = *d.Persen
+			}
+			b = append(b, d.Selisih, persen)
+		}
+		return b
+	}
+
+	tabel := TabelLaporan{
+		Judul:  "Perbandingan Produksi - " + h.Satuan,
+		Filter: append(filter, "Sumber: "+h.Sumber),
+		Kolom:  kolom,
+	}
+	for _, s := range h.Seri {
+		tabel.Baris = append(tabel.Baris, baris(s))
+	}
+	// Total dihitung dari komponen, bukan dijumlahkan per kolom, agar
+	// satuan rasio tetap benar
+	tabel.Total = baris(h.Total)
+	tabel.Total[0] = "TOTAL"
+	return tabel
+}
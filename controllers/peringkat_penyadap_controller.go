@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ukuran pengurutan peringkat penyadap
+const (
+	urutKering      = "kering"
+	urutRataRata    = "rata_rata"
+	urutKonsistensi = "konsistensi"
+	urutHariNol     = "hari_nol"
+)
+
+// PeringkatPenyadap adalah kinerja satu penyadap pada satu periode. Kering
+// adalah sheet + br/cr. HariNol dihitung dari hari yang ada produksi di
+// afdeling penyadap tetapi penyadap tersebut tidak berproduksi. Koefisien
+// variasi (persen) dihitung dari kering harian pada hari kerja; makin kecil
+// makin konsisten, nil jika hari kerja kurang dari dua.
+type PeringkatPenyadap struct {
+	PenyadapID   *uint    `json:"penyadap_id"`
+	NIK          string   `json:"nik"`
+	Nama         string   `json:"nama"`
+	Afdeling     string   `json:"afdeling"`
+	Mandor       string   `json:"mandor"`
+	Kering       float64  `json:"kering"`
+	HariKerja    int      `json:"hari_kerja"`
+	HariNol      int      `json:"hari_nol"`
+	RataRata     float64  `json:"rata_rata_per_hari_kerja"`
+	KoefVariasi  *float64 `json:"koefisien_variasi"`
+	Persentil    float64  `json:"persentil"`
+	PeringkatSeb *int     `json:"peringkat_sebelumnya"`
+	Pergerakan   *int     `json:"pergerakan"`
+
+	PeringkatKering      int `json:"peringkat_kering"`
+	PeringkatRataRata    int `json:"peringkat_rata_rata"`
+	PeringkatKonsistensi int `json:"peringkat_konsistensi"`
+	PeringkatHariNol     int `json:"peringkat_hari_nol"`
+}
+
+// PeringkatPenyadapResponse adalah hasil peringkat yang sudah diurutkan
+// menurut ukuran Urut. Pergerakan positif berarti naik peringkat dibanding
+// periode sebelumnya dengan panjang yang sama.
+type PeringkatPenyadapResponse struct {
+	TanggalAwal     string              `json:"tanggal_awal"`
+	TanggalAkhir    string              `json:"tanggal_akhir"`
+	SebelumnyaAwal  string              `json:"sebelumnya_awal"`
+	SebelumnyaAkhir string              `json:"sebelumnya_akhir"`
+	Urut            string              `json:"urut"`
+	JumlahPenyadap  int                 `json:"jumlah_penyadap"`
+	Penyadap        []PeringkatPenyadap `json:"penyadap"`
+}
+
+// filterPeringkat membatasi data produksi yang diperingkat
+type filterPeringkat struct {
+	AfdelingID   uint
+	Mandor       string
+	TipeProduksi string
+}
+
+// hitungPeringkatPenyadap menghitung kinerja dan semua peringkat untuk satu
+// periode. Hasil diurutkan menurut ukuran urut.
+func hitungPeringkatPenyadap(db *gorm.DB, awal, akhir time.Time, f filterPeringkat, urut string) ([]PeringkatPenyadap, error) {
+	query := db.Model(&models.Produksi{}).
+		Where("DATE(tanggal) BETWEEN ? AND ?", awal.Format("2006-01-02"), akhir.Format("2006-01-02"))
+	if f.AfdelingID != 0 {
+		query = query.Where("afdeling_id = ?", f.AfdelingID)
+	}
+	if f.Mandor != "" {
+		query = query.Where("UPPER(mandor) = ?", strings.ToUpper(f.Mandor))
+	}
+	if f.TipeProduksi != "" {
+		query = query.Where("tipe_produksi = ?", f.TipeProduksi)
+	}
+
+	var list []models.Produksi
+	if err := query.Order("tanggal asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	type akumulasi struct {
+		data   *PeringkatPenyadap
+		harian map[string]float64
+	}
+	perNIK := make(map[string]*akumulasi)
+	var urutanNIK []string
+	hariAfdeling := make(map[string]map[string]bool)
+
+	for _, p := range list {
+		tanggal := p.Tanggal.Format("2006-01-02")
+		kering := p.Sheet + p.BrCr
+		if kering > 0 {
+			if hariAfdeling[p.Afdeling] == nil {
+				hariAfdeling[p.Afdeling] = make(map[string]bool)
+			}
+			hariAfdeling[p.Afdeling][tanggal] = true
+		}
+
+		a, ok := perNIK[p.NIK]
+		if !ok {
+			a = &akumulasi{
+				data:   &PeringkatPenyadap{NIK: p.NIK, Nama: p.NamaPenyadap, Afdeling: p.Afdeling, Mandor: p.Mandor},
+				harian: make(map[string]float64),
+			}
+			perNIK[p.NIK] = a
+			urutanNIK = append(urutanNIK, p.NIK)
+		}
+		a.data.Kering += kering
+		if kering > 0 {
+			a.harian[tanggal] += kering
+		}
+	}
+
+	// Hubungkan ke master penyadap agar frontend bisa membuka detail
+	var master []models.Penyadap
+	if len(urutanNIK) > 0 {
+		if err := db.Where("nik IN ?", urutanNIK).Find(&master).Error; err != nil {
+			return nil, err
+		}
+	}
+	idPenyadap := make(map[string]uint, len(master))
+	for _, m := range master {
+		idPenyadap[m.NIK] = m.ID
+	}
+
+	hasil := make([]PeringkatPenyadap, 0, len(perNIK))
+	for _, nik := range urutanNIK {
+		a := perNIK[nik]
+		d := a.data
+		if id, ok := idPenyadap[nik]; ok {
+			d.PenyadapID = &id
+		}
+		d.HariKerja = len(a.harian)
+		d.HariNol = len(hariAfdeling[d.Afdeling]) - d.HariKerja
+		if d.HariNol < 0 {
+			d.HariNol = 0
+		}
+		if d.HariKerja > 0 {
+			d.RataRata = d.Kering / float64(d.HariKerja)
+		}
+		if d.HariKerja >= 2 && d.RataRata > 0 {
+			varians := 0.0
+			for _, v := range a.harian {
+				varians += (v - d.RataRata) * (v - d.RataRata)
+			}
+			cv := roundTo(math.Sqrt(varians/float64(d.HariKerja))/d.RataRata*100, 2)
+			d.KoefVariasi = &cv
+		}
+		d.Kering = roundTo(d.Kering, 2)
+		d.RataRata = roundTo(d.RataRata, 2)
+		hasil = append(hasil, *d)
+	}
+
+	// Peringkat setiap ukuran; seri memakai NIK agar hasil tetap stabil
+	beriPeringkat := func(lebihBaik func(a, b *PeringkatPenyadap) int, set func(p *PeringkatPenyadap, n int)) {
+		sort.SliceStable(hasil, func(i, j int) bool {
+			if c := lebihBaik(&hasil[i], &hasil[j]); c != 0 {
+				return c < 0
+			}
+			return hasil[i].NIK < hasil[j].NIK
+		})
+		for i := range hasil {
+			set(&hasil[i], i+1)
+		}
+	}
+	banding := func(a, b float64) int {
+		switch {
+		case a > b:
+			return -1
+		case a < b:
+			return 1
+		}
+		return 0
+	}
+	ukuran := map[string]func(a, b *PeringkatPenyadap) int{
+		urutKering:   func(a, b *PeringkatPenyadap) int { return banding(a.Kering, b.Kering) },
+		urutRataRata: func(a, b *PeringkatPenyadap) int { return banding(a.RataRata, b.RataRata) },
+		urutKonsistensi: func(a, b *PeringkatPenyadap) int {
+			// CV kecil lebih baik; penyadap tanpa CV di urutan akhir
+			switch {
+			case a.KoefVariasi == nil && b.KoefVariasi == nil:
+				return 0
+			case a.KoefVariasi == nil:
+				return 1
+			case b.KoefVariasi == nil:
+				return -1
+			}
+			return -banding(*a.KoefVariasi, *b.KoefVariasi)
+		},
+		urutHariNol: func(a, b *PeringkatPenyadap) int {
+			if c := -banding(float64(a.HariNol), float64(b.HariNol)); c != 0 {
+				return c
+			}
+			return banding(a.Kering, b.Kering)
+		},
+	}
+	beriPeringkat(ukuran[urutKonsistensi], func(p *PeringkatPenyadap, n int) { p.PeringkatKonsistensi = n })
+	beriPeringkat(ukuran[urutHariNol], func(p *PeringkatPenyadap, n int) { p.PeringkatHariNol = n })
+	beriPeringkat(ukuran[urutRataRata], func(p *PeringkatPenyadap, n int) { p.PeringkatRataRata = n })
+	beriPeringkat(ukuran[urutKering], func(p *PeringkatPenyadap, n int) { p.PeringkatKering = n })
+	if urut != urutKering {
+		beriPeringkat(ukuran[urut], func(*PeringkatPenyadap, int) {})
+	}
+
+	// Persentil menurut ukuran urut: peringkat pertama 100, terakhir 0
+	n := len(hasil)
+	for i := range hasil {
+		if n > 1 {
+			hasil[i].Persentil = roundTo(float64(n-1-i)/float64(n-1)*100, 2)
+		} else {
+			hasil[i].Persentil = 100
+		}
+	}
+	return hasil, nil
+}
+
+// GetPeringkatPenyadap mengurutkan penyadap pada satu periode (tanggalAwal,
+// tanggalAkhir) dengan filter opsional afdeling, idMandor dan tipeProduksi.
+// Parameter urut: kering (default), rata_rata, konsistensi atau hari_nol.
+// Pergerakan dibandingkan dengan periode sebelumnya yang sama panjang.
+// Mendukung ?format=xlsx/csv.
+func GetPeringkatPenyadap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	awal, errAwal := time.Parse("2006-01-02", q.Get("tanggalAwal"))
+	akhir, errAkhir := time.Parse("2006-01-02", q.Get("tanggalAkhir"))
+	if errAwal != nil || errAkhir != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter tanggalAwal dan tanggalAkhir wajib diisi (format: YYYY-MM-DD)",
+		})
+		return
+	}
+	if akhir.Before(awal) {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "tanggalAkhir tidak boleh lebih awal dari tanggalAwal"})
+		return
+	}
+
+	urut := q.Get("urut")
+	if urut == "" {
+		urut = urutKering
+	}
+	switch urut {
+	case urutKering, urutRataRata, urutKonsistensi, urutHariNol:
+	default:
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter urut tidak valid. Gunakan: kering, rata_rata, konsistensi, atau hari_nol",
+		})
+		return
+	}
+
+	db := config.GetDB()
+	var f filterPeringkat
+	filterTeks := []string{"Periode: " + awal.Format("2006-01-02") + " s/d " + akhir.Format("2006-01-02"), "Urut: " + urut}
+	if afdeling := q.Get("afdeling"); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		f.AfdelingID = afd.ID
+		filterTeks = append(filterTeks, "Afdeling: "+afd.Nama)
+	}
+	if id := q.Get("idMandor"); id != "" {
+		var m models.Mandor
+		if err := db.First(&m, id).Error; err != nil {
+			respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Data mandor dengan ID " + id + " tidak ditemukan"})
+			return
+		}
+		f.Mandor = strings.TrimSpace(m.Nama)
+		filterTeks = append(filterTeks, "Mandor: "+m.Nama)
+	}
+	if tipe := q.Get("tipeProduksi"); tipe != "" && tipe != "-" {
+		f.TipeProduksi = tipe
+		filterTeks = append(filterTeks, "Tipe Produksi: "+tipe)
+	}
+
+	sekarang, err := hitungPeringkatPenyadap(db, awal, akhir, f, urut)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Error mengambil data: " + err.Error()})
+		return
+	}
+
+	hari := int(akhir.Sub(awal).Hours() / 24)
+	akhirSeb := awal.AddDate(0, 0, -1)
+	awalSeb := akhirSeb.AddDate(0, 0, -hari)
+	sebelumnya, err := hitungPeringkatPenyadap(db, awalSeb, akhirSeb, f, urut)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Error mengambil data: " + err.Error()})
+		return
+	}
+	peringkatSeb := make(map[string]int, len(sebelumnya))
+	for i, p := range sebelumnya {
+		peringkatSeb[p.NIK] = i + 1
+	}
+	for i := range sekarang {
+		if seb, ok := peringkatSeb[sekarang[i].NIK]; ok {
+			gerak := seb - (i + 1)
+			sekarang[i].PeringkatSeb = &seb
+			sekarang[i].Pergerakan = &gerak
+		}
+	}
+
+	hasil := PeringkatPenyadapResponse{
+		TanggalAwal:     awal.Format("2006-01-02"),
+		TanggalAkhir:    akhir.Format("2006-01-02"),
+		SebelumnyaAwal:  awalSeb.Format("2006-01-02"),
+		SebelumnyaAkhir: akhirSeb.Format("2006-01-02"),
+		Urut:            urut,
+		JumlahPenyadap:  len(sekarang),
+		Penyadap:        sekarang,
+	}
+
+	if format != "" {
+		kirimLaporan(w, r, format, "peringkat_penyadap", tabelPeringkatPenyadap(hasil, filterTeks))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Peringkat %d penyadap", len(sekarang)),
+		Data:    hasil,
+	})
+}
+
+func tabelPeringkatPenyadap(h PeringkatPenyadapResponse, filter []string) TabelLaporan {
+	tabel := TabelLaporan{
+		Judul:  "Peringkat Penyadap",
+		Filter: filter,
+		Kolom: []KolomLaporan{
+			{Judul: "No", Jenis: kolomBulat},
+			{Judul: "NIK"},
+			{Judul: "Nama"},
+			{Judul: "Afdeling"},
+			{Judul: "Mandor"},
+			{Judul: "Kering (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Hari Kerja", Jenis: kolomBulat, Jumlah: true},
+			{Judul: "Hari Nol", Jenis: kolomBulat, Jumlah: true},
+			{Judul: "Rata-rata per Hari Kerja (kg)", Jenis: kolomAngka},
+			{Judul: "Koefisien Variasi (%)", Jenis: kolomPersen},
+			{Judul: "Persentil", Jenis: kolomAngka},
+			{Judul: "Peringkat Kering", Jenis: kolomBulat},
+			{Judul: "Peringkat Rata-rata", Jenis: kolomBulat},
+			{Judul: "Peringkat Konsistensi", Jenis: kolomBulat},
+			{Judul: "Peringkat Hari Nol", Jenis: kolomBulat},
+			{Judul: "Peringkat Sebelumnya", Jenis: kolomBulat},
+			{Judul: "Pergerakan", Jenis: kolomBulat},
+		},
+	}
+	for i, p := range h.Penyadap {
+		var cv, seb, gerak interface{}
+		if p.KoefVariasi != nil {
+			cv = *p.KoefVariasi
+		}
+		if p.PeringkatSeb != nil {
+			seb = float64(*p.PeringkatSeb)
+			gerak = float64(*p.Pergerakan)
+		}
+		tabel.Baris = append(tabel.Baris, []interface{}{
+			float64(i + 1), p.NIK, p.Nama, p.Afdeling, p.Mandor,
+			p.Kering, float64(p.HariKerja), float64(p.HariNol), p.RataRata, cv, p.Persentil,
+			float64(p.PeringkatKering), float64(p.PeringkatRataRata), float64(p.PeringkatKonsistensi), float64(p.PeringkatHariNol),
+			seb, gerak,
+		})
+	}
+	tabel.hitungTotal()
+	return tabel
+}
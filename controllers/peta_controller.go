@@ -4,10 +4,13 @@ import (
 	"app-inputan-ptpn/config"
 	"app-inputan-ptpn/models"
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // Struct untuk menerima JSON yang fleksibel
@@ -19,31 +22,122 @@ type PetaInput struct {
 	JumlahPohon int64       `json:"JumlahPohon"`
 	JenisKebun  string      `json:"JenisKebun"`
 	TahunTanam  interface{} `json:"TahunTanam"` // Terima number atau string
-	Kloon       string      `json:"Kloon"`
+	// TahunTanamEx menandai tanaman eks, sama dengan menulis "ex 1999"
+	TahunTanamEx bool   `json:"TahunTanamEx"`
+	Kloon        string `json:"Kloon"`
 }
 
-// Helper untuk convert TahunTanam ke string
-func convertTahunTanam(value interface{}) string {
-	if value == nil {
-		return ""
-	}
-
+// Helper untuk membaca TahunTanam dari number, "1999" atau "ex 1999"
+func parseTahunTanamInput(value interface{}, ex bool) (*int, bool, error) {
 	switch v := value.(type) {
+	case nil:
+		return nil, false, nil
 	case string:
-		return v
+		tahun, exTeks, err := models.ParseTahunTanam(v)
+		return tahun, ex || exTeks, err
 	case float64:
 		if v == 0 {
-			return ""
+			return nil, false, nil
 		}
-		return strconv.Itoa(int(v))
-	case int:
-		if v == 0 {
-			return ""
+		tahun, _, err := models.ParseTahunTanam(strconv.Itoa(int(v)))
+		return tahun, ex, err
+	}
+	return nil, false, fmt.Errorf("tahun tanam tidak valid")
+}
+
+// usernameDariRequest mengambil username yang ditaruh AuthMiddleware
+func usernameDariRequest(r *http.Request) string {
+	username, _ := r.Context().Value("username").(string)
+	return username
+}
+
+// terapkanInputPeta memvalidasi input lalu mengisi peta. Kode harus unik,
+// termasuk terhadap blok yang sudah dihapus (pulihkan blok itu jika perlu).
+func terapkanInputPeta(db *gorm.DB, input PetaInput, peta *models.Peta) error {
+	input.Code = strings.TrimSpace(input.Code)
+	if input.Code == "" || input.Afdeling == "" {
+		return fmt.Errorf("Field 'Code' dan 'Afdeling' wajib diisi")
+	}
+	if input.Luas < 0 || input.JumlahPohon < 0 {
+		return fmt.Errorf("Luas dan JumlahPohon tidak boleh negatif")
+	}
+	afdeling, err := resolveAfdeling(input.Afdeling, true)
+	if err != nil {
+		return err
+	}
+	tahun, ex, err := parseTahunTanamInput(input.TahunTanam, input.TahunTanamEx)
+	if err != nil {
+		return err
+	}
+
+	var lain models.Peta
+	if err := db.Unscoped().Where("code = ? AND id != ?", input.Code, peta.ID).Limit(1).Find(&lain).Error; err != nil {
+		return err
+	}
+	if lain.ID != 0 {
+		if lain.DeletedAt.Valid {
+			return fmt.Errorf("kode %s dipakai blok yang sudah dihapus (id %d), pulihkan blok tersebut", input.Code, lain.ID)
 		}
-		return strconv.Itoa(v)
-	default:
-		return ""
+		return fmt.Errorf("kode %s sudah dipakai blok lain", input.Code)
 	}
+
+	peta.Blok = strings.TrimSpace(input.Blok)
+	peta.Code = input.Code
+	peta.Afdeling = afdeling.Nama
+	peta.AfdelingID = &afdeling.ID
+	peta.Luas = input.Luas
+	peta.JumlahPohon = input.JumlahPohon
+	peta.JenisKebun = strings.TrimSpace(input.JenisKebun)
+	peta.SetTahunTanam(tahun, ex)
+	peta.Kloon = strings.TrimSpace(input.Kloon)
+	return nil
+}
+
+// snapshotPeta adalah field blok yang dicatat di riwayat
+func snapshotPeta(p models.Peta) map[string]interface{} {
+	return map[string]interface{}{
+		"Blok":        p.Blok,
+		"Code":        p.Code,
+		"Afdeling":    p.Afdeling,
+		"Luas":        p.Luas,
+		"JumlahPohon": p.JumlahPohon,
+		"JenisKebun":  p.JenisKebun,
+		"TahunTanam":  p.TahunTanam,
+		"Kloon":       p.Kloon,
+	}
+}
+
+// catatRiwayatPeta menyimpan field yang berubah antara lama dan baru.
+// lama nil berarti blok baru dibuat.
+func catatRiwayatPeta(db *gorm.DB, aksi string, lama *models.Peta, baru models.Peta, username string) error {
+	perubahan := make(map[string]map[string]interface{})
+	sesudah := snapshotPeta(baru)
+	sebelum := map[string]interface{}{}
+	if lama != nil {
+		sebelum = snapshotPeta(*lama)
+	}
+	for field, nilaiBaru := range sesudah {
+		nilaiLama, ada := sebelum[field]
+		if ada && fmt.Sprint(nilaiLama) == fmt.Sprint(nilaiBaru) {
+			continue
+		}
+		perubahan[field] = map[string]interface{}{"lama": nilaiLama, "baru": nilaiBaru}
+	}
+	if len(perubahan) == 0 && (aksi == models.AksiPetaUbah || aksi == models.AksiPetaImpor) {
+		return nil
+	}
+
+	teks, err := json.Marshal(perubahan)
+	if err != nil {
+		return err
+	}
+	return db.Create(&models.PetaRiwayat{
+		PetaID:    baru.ID,
+		Code:      baru.Code,
+		Aksi:      aksi,
+		Perubahan: string(teks),
+		Username:  username,
+	}).Error
 }
 
 func ServePetaPage(w http.ResponseWriter, r *http.Request) {
@@ -107,37 +201,7 @@ func EditPeta(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Input data: %+v", input)
 
-	// Validasi field wajib
-	if input.Code == "" || input.Afdeling == "" {
-		log.Printf("ERROR: Validasi gagal - Code atau Afdeling kosong")
-		http.Error(w, "Field 'Code' dan 'Afdeling' wajib diisi", http.StatusBadRequest)
-		return
-	}
-
-	// Update field dengan konversi TahunTanam
-	peta.Blok = input.Blok
-	peta.Code = input.Code
-	peta.Afdeling = input.Afdeling
-	peta.Luas = input.Luas
-	peta.JumlahPohon = input.JumlahPohon
-	peta.JenisKebun = input.JenisKebun
-	peta.TahunTanam = convertTahunTanam(input.TahunTanam) // PENTING: Convert di sini
-	peta.Kloon = input.Kloon
-
-	log.Printf("Data setelah update: %+v", peta)
-
-	if err := db.Save(&peta).Error; err != nil {
-		log.Printf("ERROR: Gagal save ke database: %v", err)
-		http.Error(w, "Gagal update data: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	log.Printf("SUCCESS: Data berhasil disimpan untuk id %s", idPeta)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Data berhasil diperbarui",
-		"data":    peta,
-	})
+	simpanPeta(w, r, peta, input, http.StatusOK, "Data berhasil diperbarui")
 }
 
 func UpdatePetaByCode(w http.ResponseWriter, r *http.Request) {
@@ -155,12 +219,6 @@ func UpdatePetaByCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validasi field wajib
-	if input.Afdeling == "" {
-		http.Error(w, "Field 'Afdeling' wajib diisi", http.StatusBadRequest)
-		return
-	}
-
 	db := config.GetDB()
 	var existing models.Peta
 	if err := db.Where("code = ?", code).First(&existing).Error; err != nil {
@@ -169,33 +227,25 @@ func UpdatePetaByCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update semua field dengan konversi
-	existing.Blok = input.Blok
-	existing.Afdeling = input.Afdeling
-	existing.Luas = input.Luas
-	existing.JumlahPohon = input.JumlahPohon
-	existing.JenisKebun = input.JenisKebun
-	existing.TahunTanam = convertTahunTanam(input.TahunTanam) // PENTING: Convert di sini
-	existing.Kloon = input.Kloon
-
-	if err := db.Save(&existing).Error; err != nil {
-		log.Printf("Error saving peta code %s: %v", code, err)
-		http.Error(w, "Gagal menyimpan perubahan: "+err.Error(), http.StatusInternalServerError)
-		return
+	// Kode diambil dari query jika tidak dikirim di body
+	if strings.TrimSpace(input.Code) == "" {
+		input.Code = existing.Code
 	}
 
-	log.Printf("Successfully updated peta with code: %s", code)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Data berhasil diperbarui",
-		"data":    existing,
-	})
+	simpanPeta(w, r, existing, input, http.StatusOK, "Data berhasil diperbarui")
 }
 
+// GetAllPeta mengembalikan semua blok aktif, atau blok yang sudah dihapus
+// jika ?terhapus=true
 func GetAllPeta(w http.ResponseWriter, r *http.Request) {
 	db := config.GetDB()
 	var petas []models.Peta
-	if err := db.Find(&petas).Error; err != nil {
+
+	query := db.Order("id asc")
+	if terhapus := r.URL.Query().Get("terhapus"); terhapus == "true" || terhapus == "1" {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if err := query.Find(&petas).Error; err != nil {
 		log.Printf("Error getting all peta: %v", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
@@ -219,36 +269,129 @@ func CreatePeta(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if input.Code == "" || input.Afdeling == "" {
-		http.Error(w, "Field 'Code' dan 'Afdeling' wajib diisi", http.StatusBadRequest)
+	simpanPeta(w, r, models.Peta{}, input, http.StatusCreated, "Data peta berhasil dibuat")
+}
+
+// simpanPeta dipakai create dan update: validasi, simpan, catat riwayat, lalu
+// taut ulang poligon karena kode blok bisa berubah
+func simpanPeta(w http.ResponseWriter, r *http.Request, peta models.Peta, input PetaInput, status int, pesan string) {
+	db := config.GetDB()
+	lama := peta
+
+	if err := terapkanInputPeta(db, input, &peta); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	aksi := models.AksiPetaUbah
+	var sebelum *models.Peta = &lama
+	if lama.ID == 0 {
+		aksi = models.AksiPetaBuat
+		sebelum = nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&peta).Error; err != nil {
+			return err
+		}
+		if err := catatRiwayatPeta(tx, aksi, sebelum, peta, usernameDariRequest(r)); err != nil {
+			return err
+		}
+		return tautkanGeometriPeta(tx)
+	})
+	if err != nil {
+		log.Printf("Error saving peta code %s: %v", peta.Code, err)
+		http.Error(w, "Gagal menyimpan data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	hapusCachePoligonBlok()
 
+	log.Printf("Successfully saved peta with code: %s", peta.Code)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": pesan,
+		"data":    peta,
+	})
+}
+
+// DeletePeta menghapus blok secara lunak; riwayat, alokasi dan poligonnya
+// tetap tersimpan sehingga blok bisa dipulihkan
+func DeletePeta(w http.ResponseWriter, r *http.Request) {
 	db := config.GetDB()
 
-	// Convert ke models.Peta dengan konversi TahunTanam
-	peta := models.Peta{
-		Blok:        input.Blok,
-		Code:        input.Code,
-		Afdeling:    input.Afdeling,
-		Luas:        input.Luas,
-		JumlahPohon: input.JumlahPohon,
-		JenisKebun:  input.JenisKebun,
-		TahunTanam:  convertTahunTanam(input.TahunTanam), // PENTING: Convert di sini
-		Kloon:       input.Kloon,
+	var peta models.Peta
+	if err := db.First(&peta, mux.Vars(r)["id"]).Error; err != nil {
+		http.Error(w, "Data tidak ditemukan", http.StatusNotFound)
+		return
 	}
 
-	if err := db.Create(&peta).Error; err != nil {
-		log.Printf("Error creating peta: %v", err)
-		http.Error(w, "Gagal menyimpan data: "+err.Error(), http.StatusInternalServerError)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&peta).Error; err != nil {
+			return err
+		}
+		if err := catatRiwayatPeta(tx, models.AksiPetaHapus, &peta, peta, usernameDariRequest(r)); err != nil {
+			return err
+		}
+		return tautkanGeometriPeta(tx)
+	})
+	if err != nil {
+		log.Printf("Error deleting peta id %d: %v", peta.ID, err)
+		http.Error(w, "Gagal menghapus data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	hapusCachePoligonBlok()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Data peta berhasil dihapus",
+	})
+}
+
+// RestorePeta memulihkan blok yang sudah dihapus
+func RestorePeta(w http.ResponseWriter, r *http.Request) {
+	db := config.GetDB()
+
+	var peta models.Peta
+	if err := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", mux.Vars(r)["id"]).First(&peta).Error; err != nil {
+		http.Error(w, "Data terhapus tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&peta).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		peta.DeletedAt = gorm.DeletedAt{}
+		if err := catatRiwayatPeta(tx, models.AksiPetaPulihkan, &peta, peta, usernameDariRequest(r)); err != nil {
+			return err
+		}
+		return tautkanGeometriPeta(tx)
+	})
+	if err != nil {
+		log.Printf("Error restoring peta id %d: %v", peta.ID, err)
+		http.Error(w, "Gagal memulihkan data: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	hapusCachePoligonBlok()
 
-	log.Printf("Successfully created peta with code: %s", input.Code)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Data peta berhasil dibuat",
+		"message": "Data peta berhasil dipulihkan",
 		"data":    peta,
 	})
 }
+
+// GetRiwayatPeta mengembalikan riwayat perubahan satu blok, terbaru dulu
+func GetRiwayatPeta(w http.ResponseWriter, r *http.Request) {
+	var riwayat []models.PetaRiwayat
+	if err := config.GetDB().Where("peta_id = ?", mux.Vars(r)["id"]).
+		Order("created_at desc, id desc").Find(&riwayat).Error; err != nil {
+		log.Printf("Error getting riwayat peta: %v", err)
+		http.Error(w, "Gagal mengambil riwayat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(riwayat)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import "testing"
+
+func TestParseTahunTanamInput(t *testing.T) {
+	tests := []struct {
+		nama    string
+		value   interface{}
+		ex      bool
+		tahun   int
+		inginEx bool
+		galat   bool
+	}{
+		{"angka", float64(1999), false, 1999, false, false},
+		{"angka dengan ex", float64(1999), true, 1999, true, false},
+		{"teks", "2005", false, 2005, false, false},
+		{"teks ex", "ex 2005", false, 2005, true, false},
+		{"teks dengan flag ex", "2005", true, 2005, true, false},
+		{"kosong", nil, true, 0, false, false},
+		{"angka nol", float64(0), true, 0, false, false},
+		{"angka di luar rentang", float64(1800), true, 0, false, true},
+		{"tipe lain", true, false, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			tahun, ex, err := parseTahunTanamInput(tt.value, tt.ex)
+			if (err != nil) != tt.galat {
+				t.Fatalf("err = %v, ingin galat %v", err, tt.galat)
+			}
+			if tt.galat {
+				return
+			}
+			got := 0
+			if tahun != nil {
+				got = *tahun
+			}
+			if got != tt.tahun || ex != tt.inginEx {
+				t.Errorf("parseTahunTanamInput(%v, %v) = %d, %v; ingin %d, %v", tt.value, tt.ex, got, ex, tt.tahun, tt.inginEx)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Kolom register blok, urutan ini dipakai untuk ekspor dan file seed
+var kolomRegisterPeta = []string{
+	"Kode", "Blok", "Afdeling", "Jenis Kebun", "Tahun Tanam", "Luas (ha)", "Jumlah Pohon", "Kloon",
+}
+
+// Nama header lain yang diterima saat impor
+var aliasKolomPeta = map[string]string{
+	"CODE":         "Kode",
+	"KODE BLOK":    "Kode",
+	"NAMA BLOK":    "Blok",
+	"AFD":          "Afdeling",
+	"JENIS":        "Jenis Kebun",
+	"TT":           "Tahun Tanam",
+	"LUAS":         "Luas (ha)",
+	"POHON":        "Jumlah Pohon",
+	"JUMLAH POKOK": "Jumlah Pohon",
+	"KLON":         "Kloon",
+}
+
+// BarisGagalImpor adalah baris Excel yang tidak bisa diimpor
+type BarisGagalImpor struct {
+	Baris int    `json:"baris"`
+	Kode  string `json:"kode"`
+	Pesan string `json:"pesan"`
+}
+
+// HasilImporPeta adalah ringkasan impor register blok
+type HasilImporPeta struct {
+	JumlahBaris  int               `json:"jumlah_baris"`
+	Dibuat       int               `json:"dibuat"`
+	Diperbarui   int               `json:"diperbarui"`
+	Dipulihkan   int               `json:"dipulihkan"`
+	TidakBerubah int               `json:"tidak_berubah"`
+	Gagal        []BarisGagalImpor `json:"gagal"`
+}
+
+// indeksKolomPeta memetakan header ke nama kolom baku
+func indeksKolomPeta(header []string) map[string]int {
+	baku := make(map[string]string, len(kolomRegisterPeta))
+	for _, k := range kolomRegisterPeta {
+		baku[strings.ToUpper(k)] = k
+	}
+
+	indeks := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToUpper(strings.Join(strings.Fields(h), " "))
+		if k, ok := baku[h]; ok {
+			indeks[k] = i
+		} else if k, ok := aliasKolomPeta[h]; ok {
+			indeks[k] = i
+		}
+	}
+	return indeks
+}
+
+// barisKeInputPeta mengubah satu baris Excel menjadi PetaInput
+func barisKeInputPeta(row []string, indeks map[string]int) (PetaInput, error) {
+	ambil := func(kolom string) string {
+		i, ok := indeks[kolom]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	input := PetaInput{
+		Code:       ambil("Kode"),
+		Blok:       ambil("Blok"),
+		Afdeling:   ambil("Afdeling"),
+		JenisKebun: ambil("Jenis Kebun"),
+		TahunTanam: ambil("Tahun Tanam"),
+		Kloon:      ambil("Kloon"),
+	}
+
+	// Sel teks boleh memakai koma desimal ("9,83")
+	if s := strings.ReplaceAll(ambil("Luas (ha)"), ",", "."); s != "" {
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return input, fmt.Errorf("luas '%s' bukan angka", s)
+		}
+		input.Luas = float32(v)
+	}
+	// Sel teks boleh memakai pemisah ribuan ("11.800" / "11,800")
+	if s := strings.NewReplacer(".", "", ",", "").Replace(ambil("Jumlah Pohon")); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return input, fmt.Errorf("jumlah pohon '%s' bukan bilangan bulat", s)
+		}
+		input.JumlahPohon = v
+	}
+	return input, nil
+}
+
+// ExportPeta mengunduh register blok (blok aktif) sebagai file Excel
+func ExportPeta(w http.ResponseWriter, r *http.Request) {
+	var petas []models.Peta
+	if err := config.GetDB().Order("afdeling asc, code asc").Find(&petas).Error; err != nil {
+		http.Error(w, "Gagal mengambil data peta: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Register Blok"
+	f.SetSheetName("Sheet1", sheet)
+
+	header, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#D9E1F2"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	angka, _ := f.NewStyle(&excelize.Style{NumFmt: 4}) // #,##0.00
+	bulat, _ := f.NewStyle(&excelize.Style{NumFmt: 3}) // #,##0
+
+	for i, kolom := range kolomRegisterPeta {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, kolom)
+	}
+	f.SetCellStyle(sheet, "A1", "H1", header)
+
+	for i, p := range petas {
+		row := i + 2
+		nilai := []interface{}{p.Code, p.Blok, p.Afdeling, p.JenisKebun, p.TahunTanam, roundTo(float64(p.Luas), 2), p.JumlahPohon, p.Kloon}
+		for j, v := range nilai {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			f.SetCellValue(sheet, cell, v)
+		}
+	}
+	if len(petas) > 0 {
+		last := strconv.Itoa(len(petas) + 1)
+		f.SetCellStyle(sheet, "F2", "F"+last, angka)
+		f.SetCellStyle(sheet, "G2", "G"+last, bulat)
+	}
+
+	f.SetColWidth(sheet, "A", "A", 24)
+	f.SetColWidth(sheet, "B", "D", 18)
+	f.SetColWidth(sheet, "E", "G", 14)
+	f.SetColWidth(sheet, "H", "H", 20)
+	f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	filename := fmt.Sprintf("register_blok_%s.xlsx", time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	if err := f.Write(w); err != nil {
+		log.Printf("Error writing peta export: %v", err)
+	}
+}
+
+// ImportPeta memperbarui register blok dari file Excel (form field "file").
+// Baris dicocokkan berdasarkan kode: kode baru dibuat, kode yang ada
+// diperbarui, dan blok yang terhapus dipulihkan. Baris yang tidak valid
+// dilaporkan tanpa membatalkan baris lain.
+func ImportPeta(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(MaxFileSize); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File terlalu besar atau format tidak valid",
+		})
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File Excel wajib diunggah",
+		})
+		return
+	}
+	defer file.Close()
+
+	if ext := strings.ToLower(filepath.Ext(header.Filename)); ext != ".xlsx" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format file harus .xlsx",
+		})
+		return
+	}
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File Excel tidak valid: " + err.Error(),
+		})
+		return
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0), excelize.Options{RawCellValue: true})
+	if err != nil || len(rows) == 0 {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Sheet pertama kosong atau tidak bisa dibaca",
+		})
+		return
+	}
+
+	indeks := indeksKolomPeta(rows[0])
+	if _, ok := indeks["Kode"]; !ok {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Kolom 'Kode' tidak ditemukan di baris pertama",
+		})
+		return
+	}
+	if _, ok := indeks["Afdeling"]; !ok {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Kolom 'Afdeling' tidak ditemukan di baris pertama",
+		})
+		return
+	}
+
+	db := config.GetDB()
+	username := usernameDariRequest(r)
+	hasil := HasilImporPeta{Gagal: []BarisGagalImpor{}}
+	sudah := make(map[string]int)
+
+	for i, row := range rows[1:] {
+		nomor := i + 2
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		hasil.JumlahBaris++
+
+		input, err := barisKeInputPeta(row, indeks)
+		if err == nil && sudah[strings.ToUpper(input.Code)] > 0 {
+			err = fmt.Errorf("kode sama dengan baris %d", sudah[strings.ToUpper(input.Code)])
+		}
+		if err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: input.Code, Pesan: err.Error()})
+			continue
+		}
+		sudah[strings.ToUpper(input.Code)] = nomor
+
+		var peta models.Peta
+		if err := db.Unscoped().Where("code = ?", input.Code).Limit(1).Find(&peta).Error; err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: input.Code, Pesan: err.Error()})
+			continue
+		}
+		lama := peta
+		dipulihkan := peta.DeletedAt.Valid
+
+		if err := terapkanInputPeta(db, input, &peta); err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: input.Code, Pesan: err.Error()})
+			continue
+		}
+		peta.DeletedAt = gorm.DeletedAt{}
+
+		var sebelum *models.Peta
+		aksi := models.AksiPetaBuat
+		if lama.ID != 0 {
+			sebelum = &lama
+			aksi = models.AksiPetaImpor
+			if !dipulihkan && fmt.Sprint(snapshotPeta(lama)) == fmt.Sprint(snapshotPeta(peta)) {
+				hasil.TidakBerubah++
+				continue
+			}
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Save(&peta).Error; err != nil {
+				return err
+			}
+			if dipulihkan {
+				if err := catatRiwayatPeta(tx, models.AksiPetaPulihkan, sebelum, lama, username); err != nil {
+					return err
+				}
+			}
+			return catatRiwayatPeta(tx, aksi, sebelum, peta, username)
+		})
+		if err == nil {
+			switch {
+			case lama.ID == 0:
+				hasil.Dibuat++
+			case dipulihkan:
+				hasil.Dipulihkan++
+			default:
+				hasil.Diperbarui++
+			}
+		}
+		if err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: input.Code, Pesan: err.Error()})
+		}
+	}
+
+	if err := tautkanGeometriPeta(db); err != nil {
+		log.Printf("Gagal menaut geometri setelah impor peta: %v", err)
+	}
+	hapusCachePoligonBlok()
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: len(hasil.Gagal) == 0,
+		Message: fmt.Sprintf("Impor selesai: %d dibuat, %d diperbarui, %d dipulihkan, %d gagal",
+			hasil.Dibuat, hasil.Diperbarui, hasil.Dipulihkan, len(hasil.Gagal)),
+		Data: hasil,
+	})
+}
@@ -0,0 +1,603 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Sumber data produksi per blok
+const (
+	sumberDataRekap    = "rekap"
+	sumberDataProduksi = "produksi"
+)
+
+var polaTahunTanam = regexp.MustCompile(`\d{4}`)
+
+type blokAlokasiInput struct {
+	PetaID     uint    `json:"peta_id"`
+	MandorID   *uint   `json:"mandor_id"`
+	PenyadapID *uint   `json:"penyadap_id"`
+	Bobot      float64 `json:"bobot"`
+	Keterangan string  `json:"keterangan"`
+}
+
+// produksiSumberBlok adalah produksi satu mandor/penyadap pada satu afdeling
+// dan tahun tanam, sebelum dibagi ke blok
+type produksiSumberBlok struct {
+	AfdelingID *uint   `json:"afdeling_id"`
+	Afdeling   string  `json:"afdeling"`
+	TahunTanam string  `json:"tahun_tanam"`
+	NIK        string  `json:"nik"`
+	Nama       string  `json:"nama"`
+	Kering     float64 `json:"kering"`
+	BasahLatek float64 `json:"basah_latek"`
+	BasahLump  float64 `json:"basah_lump"`
+	HKO        float64 `json:"hko"`
+
+	// Timbangan pabrik hanya tersedia dari rekap
+	BasahLatekPabrik float64 `json:"basah_latek_pabrik"`
+	BasahLumpPabrik  float64 `json:"basah_lump_pabrik"`
+}
+
+// ProduksiBlok adalah produksi yang dipetakan ke satu blok Peta
+type ProduksiBlok struct {
+	PetaID      uint    `json:"peta_id"`
+	Code        string  `json:"code"`
+	Blok        string  `json:"blok"`
+	Afdeling    string  `json:"afdeling"`
+	JenisKebun  string  `json:"jenis_kebun"`
+	TahunTanam  string  `json:"tahun_tanam"`
+	Kloon       string  `json:"kloon"`
+	Luas        float64 `json:"luas"`
+	JumlahPohon int64   `json:"jumlah_pohon"`
+	Kering      float64 `json:"kering"`
+	BasahLatek  float64 `json:"basah_latek"`
+	BasahLump   float64 `json:"basah_lump"`
+	HKO         float64 `json:"hko"`
+	KgPerHa     float64 `json:"kg_per_ha"`
+	KgPerPohon  float64 `json:"kg_per_pohon"`
+	Sumber      string  `json:"sumber"`
+
+	BasahLatekPabrik float64 `json:"basah_latek_pabrik"`
+	BasahLumpPabrik  float64 `json:"basah_lump_pabrik"`
+	// Selisih pabrik terhadap kebun (%), nil jika tidak ada timbangan pabrik
+	SelisihLatekPersen *float64 `json:"selisih_latek_persen"`
+	SelisihLumpPersen  *float64 `json:"selisih_lump_persen"`
+}
+
+// HasilProduksiBlok berisi produksi per blok dan produksi yang tidak bisa
+// dipetakan ke blok mana pun
+type HasilProduksiBlok struct {
+	Blok            []ProduksiBlok       `json:"blok"`
+	TidakTerpetakan []produksiSumberBlok `json:"tidak_terpetakan"`
+}
+
+// filterProduksiBlok adalah parameter perhitungan produksi per blok
+type filterProduksiBlok struct {
+	Awal         time.Time
+	Akhir        time.Time
+	AfdelingID   uint
+	TipeProduksi string
+	Sumber       string
+}
+
+// ================== PEMETAAN PRODUKSI KE BLOK ==================
+
+// kunciTahunTanam menyamakan penulisan tahun tanam antara sheet dan Peta
+// ("2008", "TT 2008", "ex 2008" -> "2008")
+func kunciTahunTanam(s string) string {
+	if tahun := polaTahunTanam.FindString(s); tahun != "" {
+		return tahun
+	}
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+func roundTo(v float64, desimal int) float64 {
+	p := math.Pow(10, float64(desimal))
+	return math.Round(v*p) / p
+}
+
+func kunciAfdelingTahun(afdelingID *uint, tahunTanam string) string {
+	id := uint(0)
+	if afdelingID != nil {
+		id = *afdelingID
+	}
+	return strconv.FormatUint(uint64(id), 10) + "|" + kunciTahunTanam(tahunTanam)
+}
+
+// ambilProduksiSumberBlok menjumlahkan produksi per mandor (rekap) atau per
+// penyadap (produksi) untuk setiap afdeling dan tahun tanam
+func ambilProduksiSumberBlok(db *gorm.DB, f filterProduksiBlok) ([]produksiSumberBlok, error) {
+	var query *gorm.DB
+	if f.Sumber == sumberDataProduksi {
+		query = db.Table("produksis").
+			Select(`afdeling_id, afdeling, tahun_tanam, nik, MAX(nama_penyadap) AS nama,
+				COALESCE(SUM(sheet + br_cr), 0) AS kering,
+				COALESCE(SUM(basah_latek), 0) AS basah_latek,
+				COALESCE(SUM(basah_lump), 0) AS basah_lump,
+				COUNT(DISTINCT CASE WHEN basah_latek + basah_lump + sheet + br_cr > 0 THEN DATE(tanggal) END) AS hko,
+				0 AS basah_latek_pabrik, 0 AS basah_lump_pabrik`)
+	} else {
+		query = db.Table("rekaps").
+			Select(`afdeling_id, afdeling, tahun_tanam, nik, MAX(mandor) AS nama,
+				COALESCE(SUM(hari_ini_kering_jumlah), 0) AS kering,
+				COALESCE(SUM(hari_ini_basah_latek_kebun), 0) AS basah_latek,
+				COALESCE(SUM(hari_ini_basah_lump_kebun), 0) AS basah_lump,
+				COALESCE(SUM(hko_hari_ini), 0) AS hko,
+				COALESCE(SUM(hari_ini_basah_latek_pabrik), 0) AS basah_latek_pabrik,
+				COALESCE(SUM(hari_ini_basah_lump_pabrik), 0) AS basah_lump_pabrik`).
+			Where("tipe_produksi != ?", "REKAPITULASI")
+	}
+
+	query = query.Where("DATE(tanggal) BETWEEN DATE(?) AND DATE(?)", f.Awal, f.Akhir)
+	if f.AfdelingID != 0 {
+		query = query.Where("afdeling_id = ?", f.AfdelingID)
+	}
+	if f.TipeProduksi != "" {
+		query = query.Where("tipe_produksi = ?", f.TipeProduksi)
+	}
+
+	var hasil []produksiSumberBlok
+	err := query.Group("afdeling_id, afdeling, tahun_tanam, nik").Scan(&hasil).Error
+	return hasil, err
+}
+
+// ambilAlokasiEksplisit mengembalikan alokasi manual per NIK+tahun tanam.
+// Untuk rekap alokasi dicari lewat mandor, untuk produksi lewat penyadap.
+func ambilAlokasiEksplisit(db *gorm.DB, sumber string) (map[string][]models.BlokAlokasi, error) {
+	var list []models.BlokAlokasi
+	query := db.Preload("Mandor").Preload("Penyadap")
+	if sumber == sumberDataProduksi {
+		query = query.Where("penyadap_id IS NOT NULL")
+	} else {
+		query = query.Where("mandor_id IS NOT NULL")
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	alokasi := make(map[string][]models.BlokAlokasi)
+	for _, a := range list {
+		var key string
+		switch {
+		case sumber == sumberDataProduksi && a.Penyadap != nil:
+			// Penyadap tidak terikat tahun tanam, berlaku untuk semua baris
+			key = a.Penyadap.NIK
+		case a.Mandor != nil:
+			key = a.Mandor.NIK + "|" + kunciTahunTanam(a.Mandor.TahunTanam)
+		default:
+			continue
+		}
+		alokasi[key] = append(alokasi[key], a)
+	}
+	return alokasi, nil
+}
+
+// persenSelisih menghitung (pabrik - kebun) / kebun * 100 seperti BakuDetail
+func persenSelisih(kebun, pabrik float64) *float64 {
+	if kebun <= 0 || pabrik <= 0 {
+		return nil
+	}
+	persen := roundTo((pabrik-kebun)/kebun*100, 2)
+	return &persen
+}
+
+// bagiProporsional membagi nilai ke blok sesuai bobot; jika semua bobot 0
+// dibagi menurut luas, dan jika luas juga 0 dibagi rata
+func bagiProporsional(bobot []float64, luas []float64) []float64 {
+	total := 0.0
+	for _, b := range bobot {
+		total += b
+	}
+	pakai := bobot
+	if total <= 0 {
+		pakai = luas
+		total = 0
+		for _, l := range luas {
+			total += l
+		}
+	}
+
+	porsi := make([]float64, len(pakai))
+	for i := range pakai {
+		if total > 0 {
+			porsi[i] = pakai[i] / total
+		} else {
+			porsi[i] = 1 / float64(len(pakai))
+		}
+	}
+	return porsi
+}
+
+// hitungProduksiBlok memetakan produksi ke blok Peta. Alokasi eksplisit
+// didahulukan; selebihnya dipetakan ke blok karet dengan afdeling dan tahun
+// tanam yang sama (atau blok jenis apa pun jika tidak ada blok karet).
+func hitungProduksiBlok(db *gorm.DB, f filterProduksiBlok) (HasilProduksiBlok, error) {
+	hasil := HasilProduksiBlok{
+		Blok:            []ProduksiBlok{},
+		TidakTerpetakan: []produksiSumberBlok{},
+	}
+
+	var petas []models.Peta
+	if err := db.Order("afdeling asc, code asc").Find(&petas).Error; err != nil {
+		return hasil, err
+	}
+
+	blokByID := make(map[uint]*ProduksiBlok, len(petas))
+	karet := make(map[string][]*ProduksiBlok)
+	lainnya := make(map[string][]*ProduksiBlok)
+	urutan := make([]*ProduksiBlok, 0, len(petas))
+	for _, p := range petas {
+		b := &ProduksiBlok{
+			PetaID:      p.ID,
+			Code:        p.Code,
+			Blok:        p.Blok,
+			Afdeling:    p.Afdeling,
+			JenisKebun:  p.JenisKebun,
+			TahunTanam:  p.TahunTanam,
+			Kloon:       p.Kloon,
+			Luas:        float64(p.Luas),
+			JumlahPohon: p.JumlahPohon,
+		}
+		blokByID[p.ID] = b
+		urutan = append(urutan, b)
+
+		key := kunciAfdelingTahun(p.AfdelingID, p.TahunTanam)
+		if strings.Contains(strings.ToUpper(p.JenisKebun), "KARET") {
+			karet[key] = append(karet[key], b)
+		} else {
+			lainnya[key] = append(lainnya[key], b)
+		}
+	}
+
+	sumberList, err := ambilProduksiSumberBlok(db, f)
+	if err != nil {
+		return hasil, err
+	}
+	alokasi, err := ambilAlokasiEksplisit(db, f.Sumber)
+	if err != nil {
+		return hasil, err
+	}
+
+	tandai := func(b *ProduksiBlok, sumber string) {
+		if b.Sumber == "" {
+			b.Sumber = sumber
+		} else if b.Sumber != sumber {
+			b.Sumber = "campuran"
+		}
+	}
+
+	for _, s := range sumberList {
+		var target []*ProduksiBlok
+		var bobot []float64
+		sumber := models.SumberBlokManual
+
+		key := s.NIK + "|" + kunciTahunTanam(s.TahunTanam)
+		if f.Sumber == sumberDataProduksi {
+			key = s.NIK
+		}
+		for _, a := range alokasi[key] {
+			if b, ok := blokByID[a.PetaID]; ok {
+				target = append(target, b)
+				bobot = append(bobot, a.Bobot)
+			}
+		}
+
+		if len(target) == 0 {
+			sumber = models.SumberBlokOtomatis
+			key := kunciAfdelingTahun(s.AfdelingID, s.TahunTanam)
+			target = karet[key]
+			if len(target) == 0 {
+				target = lainnya[key]
+			}
+			bobot = make([]float64, len(target))
+		}
+
+		if len(target) == 0 {
+			hasil.TidakTerpetakan = append(hasil.TidakTerpetakan, s)
+			continue
+		}
+
+		luas := make([]float64, len(target))
+		for i, b := range target {
+			luas[i] = b.Luas
+		}
+		for i, porsi := range bagiProporsional(bobot, luas) {
+			b := target[i]
+			b.Kering += s.Kering * porsi
+			b.BasahLatek += s.BasahLatek * porsi
+			b.BasahLump += s.BasahLump * porsi
+			b.HKO += s.HKO * porsi
+			b.BasahLatekPabrik += s.BasahLatekPabrik * porsi
+			b.BasahLumpPabrik += s.BasahLumpPabrik * porsi
+			tandai(b, sumber)
+		}
+	}
+
+	// Hanya blok yang menerima produksi yang dikembalikan
+	for _, b := range urutan {
+		if b.Sumber == "" {
+			continue
+		}
+		b.Kering = roundTo(b.Kering, 2)
+		b.BasahLatek = roundTo(b.BasahLatek, 2)
+		b.BasahLump = roundTo(b.BasahLump, 2)
+		b.HKO = roundTo(b.HKO, 2)
+		b.BasahLatekPabrik = roundTo(b.BasahLatekPabrik, 2)
+		b.BasahLumpPabrik = roundTo(b.BasahLumpPabrik, 2)
+		b.SelisihLatekPersen = persenSelisih(b.BasahLatek, b.BasahLatekPabrik)
+		b.SelisihLumpPersen = persenSelisih(b.BasahLump, b.BasahLumpPabrik)
+		if b.Luas > 0 {
+			b.KgPerHa = roundTo(b.Kering/b.Luas, 2)
+		}
+		if b.JumlahPohon > 0 {
+			b.KgPerPohon = roundTo(b.Kering/float64(b.JumlahPohon), 4)
+		}
+		hasil.Blok = append(hasil.Blok, *b)
+	}
+
+	sort.Slice(hasil.TidakTerpetakan, func(i, j int) bool {
+		return hasil.TidakTerpetakan[i].Kering > hasil.TidakTerpetakan[j].Kering
+	})
+	return hasil, nil
+}
+
+// ================== API PRODUKSI PER BLOK ==================
+
+// GetProduksiPerBlok mengembalikan produksi per blok Peta untuk layer peta.
+// Parameter: tanggalAwal, tanggalAkhir (wajib), afdeling, tipeProduksi,
+// sumber (rekap = per mandor, produksi = per penyadap; default rekap)
+func GetProduksiPerBlok(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("tanggalAwal") == "" || q.Get("tanggalAkhir") == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter tanggalAwal dan tanggalAkhir diperlukan",
+		})
+		return
+	}
+	awal, errAwal := parseTanggalPenugasan(q.Get("tanggalAwal"))
+	akhir, errAkhir := parseTanggalPenugasan(q.Get("tanggalAkhir"))
+	if errAwal != nil || errAkhir != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format tanggal tidak valid (gunakan: YYYY-MM-DD)",
+		})
+		return
+	}
+
+	f := filterProduksiBlok{
+		Awal:         awal,
+		Akhir:        akhir,
+		TipeProduksi: q.Get("tipeProduksi"),
+		Sumber:       q.Get("sumber"),
+	}
+	if f.Sumber == "" {
+		f.Sumber = sumberDataRekap
+	}
+	if f.Sumber != sumberDataRekap && f.Sumber != sumberDataProduksi {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Parameter sumber harus 'rekap' atau 'produksi'",
+		})
+		return
+	}
+	if afdeling := q.Get("afdeling"); afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		f.AfdelingID = afd.ID
+	}
+
+	hasil, err := hitungProduksiBlok(config.DB, f)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghitung produksi per blok: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    hasil,
+	})
+}
+
+// ================== CRUD ALOKASI BLOK ==================
+
+func validasiBlokAlokasi(input blokAlokasiInput, a *models.BlokAlokasi) error {
+	if input.PetaID == 0 {
+		return errors.New("peta_id wajib diisi")
+	}
+	if (input.MandorID == nil) == (input.PenyadapID == nil) {
+		return errors.New("isi salah satu dari mandor_id atau penyadap_id")
+	}
+	if input.Bobot < 0 {
+		return errors.New("bobot tidak boleh negatif")
+	}
+
+	if err := config.DB.First(&models.Peta{}, input.PetaID).Error; err != nil {
+		return errors.New("blok peta tidak ditemukan")
+	}
+	if input.MandorID != nil {
+		if err := config.DB.First(&models.Mandor{}, *input.MandorID).Error; err != nil {
+			return errors.New("mandor tidak ditemukan")
+		}
+	}
+	if input.PenyadapID != nil {
+		if err := config.DB.First(&models.Penyadap{}, *input.PenyadapID).Error; err != nil {
+			return errors.New("penyadap tidak ditemukan")
+		}
+	}
+
+	dup := config.DB.Model(&models.BlokAlokasi{}).Where("peta_id = ? AND id != ?", input.PetaID, a.ID)
+	if input.MandorID != nil {
+		dup = dup.Where("mandor_id = ?", *input.MandorID)
+	} else {
+		dup = dup.Where("penyadap_id = ?", *input.PenyadapID)
+	}
+	var count int64
+	dup.Count(&count)
+	if count > 0 {
+		return errors.New("alokasi ke blok ini sudah ada")
+	}
+
+	a.PetaID = input.PetaID
+	a.MandorID = input.MandorID
+	a.PenyadapID = input.PenyadapID
+	a.Bobot = input.Bobot
+	a.Keterangan = strings.TrimSpace(input.Keterangan)
+	return nil
+}
+
+// GetAllBlokAlokasi mendukung filter peta_id, mandor_id, dan penyadap_id
+func GetAllBlokAlokasi(w http.ResponseWriter, r *http.Request) {
+	query := config.DB.Preload("Peta").Preload("Mandor").Preload("Penyadap").Order("peta_id asc")
+	for _, kolom := range []string{"peta_id", "mandor_id", "penyadap_id"} {
+		if v := r.URL.Query().Get(kolom); v != "" {
+			query = query.Where(kolom+" = ?", v)
+		}
+	}
+
+	var list []models.BlokAlokasi
+	if err := query.Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data alokasi blok: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    list,
+	})
+}
+
+func CreateBlokAlokasi(w http.ResponseWriter, r *http.Request) {
+	var input blokAlokasiInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	var a models.BlokAlokasi
+	if err := validasiBlokAlokasi(input, &a); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Create(&a).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan alokasi blok: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Alokasi blok berhasil ditambahkan",
+		Data:    a,
+	})
+}
+
+func UpdateBlokAlokasi(w http.ResponseWriter, r *http.Request) {
+	var a models.BlokAlokasi
+	if err := config.DB.First(&a, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data alokasi blok tidak ditemukan",
+		})
+		return
+	}
+
+	var input blokAlokasiInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if err := validasiBlokAlokasi(input, &a); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Save(&a).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal update alokasi blok: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Alokasi blok berhasil diperbarui",
+		Data:    a,
+	})
+}
+
+func DeleteBlokAlokasi(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "ID tidak valid",
+		})
+		return
+	}
+
+	result := config.DB.Delete(&models.BlokAlokasi{}, id)
+	if result.Error != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghapus alokasi blok: " + result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data alokasi blok tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Alokasi blok berhasil dihapus",
+	})
+}
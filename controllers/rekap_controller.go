@@ -5,6 +5,7 @@ import (
 	"app-inputan-ptpn/models"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 )
 
@@ -40,12 +41,50 @@ type BakuDetailResponse struct {
 	JumlahKering float64 `json:"jumlah_kering"`
 }
 
+// kolomBakuDetail adalah kolom ekspor rekap baku detail (hari ini dan bulan ini)
+var kolomBakuDetail = []KolomLaporan{
+	{Judul: "Latek Pabrik (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "Latek Kebun (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "Selisih Latek (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "% Selisih Latek", Jenis: kolomPersen},
+	{Judul: "Sheet (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "K3 Sheet", Jenis: kolomAngka},
+	{Judul: "Lump Pabrik (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "Lump Kebun (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "Selisih Lump (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "% Selisih Lump", Jenis: kolomPersen},
+	{Judul: "Br/Cr (kg)", Jenis: kolomAngka, Jumlah: true},
+	{Judul: "K3 Br/Cr", Jenis: kolomAngka},
+	{Judul: "Kering (kg)", Jenis: kolomAngka, Jumlah: true},
+}
+
+// totalSelisihBakuDetail mengisi persen selisih baris total dari jumlah
+// selisih dan jumlah kebun, bukan penjumlahan persen per baris
+func totalSelisihBakuDetail(t *TabelLaporan, awal int) {
+	total := t.hitungTotal()
+	if kebun, _ := total[awal+1].(float64); kebun != 0 {
+		total[awal+3] = total[awal+2].(float64) / kebun * 100
+	}
+	if kebun, _ := total[awal+7].(float64); kebun != 0 {
+		total[awal+9] = total[awal+8].(float64) / kebun * 100
+	}
+}
+
 func GetBakuDetailToday(w http.ResponseWriter, r *http.Request) {
 	today := time.Now().Format("2006-01-02")
 
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	var details []BakuDetailResponse
 
-	err := config.DB.Table("baku_details").
+	err = config.DB.Table("baku_details").
 		Select(`
 			baku_details.id, 
 			baku_details.tanggal, 
@@ -65,11 +104,10 @@ func GetBakuDetailToday(w http.ResponseWriter, r *http.Request) {
 			baku_details.selisih_basah_lump,
 			baku_details.persentase_selisih_basah_lump,
 			baku_details.jumlah_br_cr,
-			baku_details.k3_br_cr,
-			baku_details.total_produksi,
+			baku_details.k3_br_cr
 		`).
 		Joins("LEFT JOIN baku_mandors ON baku_mandors.id = baku_details.id_baku_mandor").
-		Where("DATE(baku_details.tanggal) = DATE(?)", today).
+		Where("DATE(baku_details.tanggal) = DATE(?) AND baku_details.deleted_at IS NULL", today).
 		Order("baku_details.mandor asc").
 		Scan(&details).Error
 
@@ -94,6 +132,33 @@ func GetBakuDetailToday(w http.ResponseWriter, r *http.Request) {
 		details[i].JumlahKering = details[i].JumlahSheet + details[i].JumlahBrCr
 	}
 
+	if format != "" {
+		tabel := TabelLaporan{
+			Judul:  "Rekap Baku Detail Hari Ini",
+			Filter: []string{"Tanggal: " + today},
+			Kolom: append([]KolomLaporan{
+				{Judul: "Tanggal", Jenis: kolomTanggal},
+				{Judul: "Mandor"},
+				{Judul: "NIK"},
+				{Judul: "Afdeling"},
+				{Judul: "Tahun Tanam"},
+				{Judul: "Tipe"},
+			}, kolomBakuDetail...),
+		}
+		for _, d := range details {
+			tabel.Baris = append(tabel.Baris, []interface{}{
+				d.Tanggal, d.Mandor, d.NIK, d.Afdeling, d.TahunTanam, d.Tipe,
+				d.JumlahPabrikBasahLatek, d.JumlahKebunBasahLatek, d.SelisihBasahLatek, d.PersentaseSelisihBasahLatek,
+				d.JumlahSheet, d.K3Sheet,
+				d.JumlahPabrikBasahLump, d.JumlahKebunBasahLump, d.SelisihBasahLump, d.PersentaseSelisihBasahLump,
+				d.JumlahBrCr, d.K3BrCr, d.JumlahKering,
+			})
+		}
+		totalSelisihBakuDetail(&tabel, 6)
+		kirimLaporan(w, r, format, "rekap_baku_hari_ini", tabel)
+		return
+	}
+
 	respondJSON(w, http.StatusOK, APIResponse{
 		Success: true,
 		Message: "Detail berhasil ditemukan",
@@ -104,6 +169,15 @@ func GetBakuDetailToday(w http.ResponseWriter, r *http.Request) {
 func GetBakuDetailUntilTodayThisMonth(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// Awal bulan
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	endOfMonth := now
@@ -186,6 +260,51 @@ func GetBakuDetailUntilTodayThisMonth(w http.ResponseWriter, r *http.Request) {
 	for _, v := range rekapMap {
 		rekapList = append(rekapList, *v)
 	}
+	sort.Slice(rekapList, func(i, j int) bool {
+		a, b := rekapList[i], rekapList[j]
+		if a.Mandor != b.Mandor {
+			return a.Mandor < b.Mandor
+		}
+		if a.TahunTanam != b.TahunTanam {
+			return a.TahunTanam < b.TahunTanam
+		}
+		return a.Tipe < b.Tipe
+	})
+
+	if format != "" {
+		tabel := TabelLaporan{
+			Judul: "Rekap Baku Detail Bulan Ini",
+			Filter: []string{fmt.Sprintf("Periode: %s s/d %s",
+				startOfMonth.Format("2006-01-02"), endOfMonth.Format("2006-01-02"))},
+			Kolom: append([]KolomLaporan{
+				{Judul: "Mandor"},
+				{Judul: "Tahun Tanam"},
+				{Judul: "Tipe"},
+			}, kolomBakuDetail...),
+		}
+		// Persen selisih dihitung ulang dari jumlah kg; penjumlahan persen
+		// harian tidak bermakna untuk laporan
+		persen := func(selisih, kebun float64) float64 {
+			if kebun == 0 {
+				return 0
+			}
+			return selisih / kebun * 100
+		}
+		for _, d := range rekapList {
+			tabel.Baris = append(tabel.Baris, []interface{}{
+				d.Mandor, d.TahunTanam, string(d.Tipe),
+				d.JumlahPabrikBasahLatek, d.JumlahKebunBasahLatek, d.SelisihBasahLatek,
+				persen(d.SelisihBasahLatek, d.JumlahKebunBasahLatek),
+				d.JumlahSheet, d.K3Sheet,
+				d.JumlahPabrikBasahLump, d.JumlahKebunBasahLump, d.SelisihBasahLump,
+				persen(d.SelisihBasahLump, d.JumlahKebunBasahLump),
+				d.JumlahBrCr, d.K3BrCr, d.JumlahSheet + d.JumlahBrCr,
+			})
+		}
+		totalSelisihBakuDetail(&tabel, 3)
+		kirimLaporan(w, r, format, "rekap_baku_bulan_ini", tabel)
+		return
+	}
 
 	respondJSON(w, http.StatusOK, APIResponse{
 		Success: true,
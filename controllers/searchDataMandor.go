@@ -52,6 +52,16 @@ func SearchMandor(w http.ResponseWriter, r *http.Request) {
 	tipeProduksi := r.URL.Query().Get("tipeProduksi")
 	afdeling := r.URL.Query().Get("afdeling")
 
+	format, err := formatLaporan(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(SearchMandorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	if idMandorStr == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(SearchMandorResponse{
@@ -101,7 +111,16 @@ func SearchMandor(w http.ResponseWriter, r *http.Request) {
 
 	// Filter berdasarkan afdeling jika ada
 	if afdeling != "" {
-		query = query.Where("afdeling = ?", afdeling)
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(SearchMandorResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
 	}
 
 	// Filter berdasarkan tanggal
@@ -151,6 +170,18 @@ func SearchMandor(w http.ResponseWriter, r *http.Request) {
 	// Hitung summary
 	summary := calculateMandorSummary(rekapList)
 
+	if format != "" {
+		filter := []string{"Mandor: " + namaMandor + " (NIK " + nik + ")", "Periode: " + keteranganPeriode(tanggalAwal, tanggalAkhir)}
+		if tipeProduksi != "" {
+			filter = append(filter, "Tipe Produksi: "+tipeProduksi)
+		}
+		if afdeling != "" {
+			filter = append(filter, "Afdeling: "+afdeling)
+		}
+		kirimLaporan(w, r, format, "produksi_mandor_"+nik, tabelRekapMandor(rekapList, filter))
+		return
+	}
+
 	// Response success dengan informasi mandor
 	json.NewEncoder(w).Encode(SearchMandorResponse{
 		Success:    true,
@@ -163,6 +194,67 @@ func SearchMandor(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// keteranganPeriode menulis rentang tanggal filter untuk judul laporan
+func keteranganPeriode(tanggalAwal, tanggalAkhir string) string {
+	switch {
+	case tanggalAwal != "" && tanggalAkhir != "":
+		return tanggalAwal + " s/d " + tanggalAkhir
+	case tanggalAwal != "":
+		return "mulai " + tanggalAwal
+	}
+	return "semua tanggal"
+}
+
+// tabelRekapMandor menyusun tabel ekspor rekap harian satu mandor. Baris
+// total menghitung ulang rasio dari jumlah kg dengan rumus dashboard.
+func tabelRekapMandor(data []models.Rekap, filter []string) TabelLaporan {
+	tabel := TabelLaporan{
+		Judul:  "Produksi Mandor",
+		Filter: filter,
+		Kolom: []KolomLaporan{
+			{Judul: "Tanggal", Jenis: kolomTanggal},
+			{Judul: "Tipe Produksi"},
+			{Judul: "Afdeling"},
+			{Judul: "Tahun Tanam"},
+			{Judul: "HKO", Jenis: kolomBulat, Jumlah: true},
+			{Judul: "Latek Kebun (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Latek Pabrik (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "% Latek", Jenis: kolomPersen},
+			{Judul: "Lump Kebun (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Lump Pabrik (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "% Lump", Jenis: kolomPersen},
+			{Judul: "K3 Sheet", Jenis: kolomAngka},
+			{Judul: "Kering Sheet (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Kering Br/Cr (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Kering Jumlah (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Produksi/Taper (kg)", Jenis: kolomAngka},
+			{Judul: "Total Produksi (kg)", Jenis: kolomAngka, Jumlah: true},
+		},
+	}
+	for _, r := range data {
+		tabel.Baris = append(tabel.Baris, []interface{}{
+			r.Tanggal, r.TipeProduksi, r.Afdeling, r.TahunTanam, r.HKOHariIni,
+			r.HariIniBasahLatekKebun, r.HariIniBasahLatekPabrik, r.HariIniBasahLatekPersen,
+			r.HariIniBasahLumpKebun, r.HariIniBasahLumpPabrik, r.HariIniBasahLumpPersen,
+			r.HariIniK3Sheet, r.HariIniKeringSheet, r.HariIniKeringBrCr, r.HariIniKeringJumlah,
+			r.ProduksiPerTaperHariIni, r.TotalProduksiHariIni,
+		})
+	}
+
+	total := tabel.hitungTotal()
+	nilai := func(i int) float64 { return total[i].(float64) }
+	if nilai(5) > 0 {
+		total[7] = (nilai(5) - nilai(6)) / nilai(5) * 100
+	}
+	if nilai(8) > 0 {
+		total[10] = (nilai(8) - nilai(9)) / nilai(8) * 100
+	}
+	if nilai(4) > 0 {
+		total[15] = nilai(14) / nilai(4)
+	}
+	return tabel
+}
+
 // calculateMandorSummary menghitung total dari data rekap mandor
 func calculateMandorSummary(data []models.Rekap) *SummaryMandor {
 	if len(data) == 0 {
@@ -43,6 +43,16 @@ func SearchPenyadap(w http.ResponseWriter, r *http.Request) {
 	tipeProduksi := r.URL.Query().Get("tipeProduksi")
 	afdeling := r.URL.Query().Get("afdeling")
 
+	format, err := formatLaporan(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(SearchResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	if idPenyadapStr == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(SearchResponse{
@@ -92,7 +102,16 @@ func SearchPenyadap(w http.ResponseWriter, r *http.Request) {
 
 	// Filter berdasarkan afdeling jika ada
 	if afdeling != "" {
-		query = query.Where("afdeling = ?", afdeling)
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(SearchResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
 	}
 
 	// Filter berdasarkan tanggal
@@ -143,6 +162,40 @@ func SearchPenyadap(w http.ResponseWriter, r *http.Request) {
 	// Hitung summary
 	summary := calculateSummary(produksiList)
 
+	if format != "" {
+		tabel := TabelLaporan{
+			Judul:  "Produksi Penyadap",
+			Filter: []string{"Penyadap: " + namaPenyadap + " (NIK " + nik + ")", "Periode: " + keteranganPeriode(tanggalAwal, tanggalAkhir)},
+			Kolom: []KolomLaporan{
+				{Judul: "Tanggal", Jenis: kolomTanggal},
+				{Judul: "Tipe Produksi"},
+				{Judul: "Mandor"},
+				{Judul: "Afdeling"},
+				{Judul: "Tahun Tanam"},
+				{Judul: "Basah Latek (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Sheet (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Basah Lump (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Br/Cr (kg)", Jenis: kolomAngka, Jumlah: true},
+				{Judul: "Total Produksi (kg)", Jenis: kolomAngka, Jumlah: true},
+			},
+		}
+		if tipeProduksi != "" {
+			tabel.Filter = append(tabel.Filter, "Tipe Produksi: "+tipeProduksi)
+		}
+		if afdeling != "" {
+			tabel.Filter = append(tabel.Filter, "Afdeling: "+afdeling)
+		}
+		for _, p := range produksiList {
+			tabel.Baris = append(tabel.Baris, []interface{}{
+				p.Tanggal, p.TipeProduksi, p.Mandor, p.Afdeling, p.TahunTanam,
+				p.BasahLatek, p.Sheet, p.BasahLump, p.BrCr, p.TotalProduksi,
+			})
+		}
+		tabel.hitungTotal()
+		kirimLaporan(w, r, format, "produksi_penyadap_"+nik, tabel)
+		return
+	}
+
 	// Response success dengan informasi penyadap
 	json.NewEncoder(w).Encode(SearchResponse{
 		Success:      true,
@@ -0,0 +1,522 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ambangSelisihInput adalah body tambah/ubah ambang. Afdeling kosong berarti
+// semua afdeling, tipe kosong berarti semua tipe.
+type ambangSelisihInput struct {
+	Afdeling         string  `json:"afdeling"`
+	TipeProduksi     string  `json:"tipe_produksi"`
+	BatasLatekPersen float64 `json:"batas_latek_persen"`
+	BatasLumpPersen  float64 `json:"batas_lump_persen"`
+	Keterangan       string  `json:"keterangan"`
+}
+
+// normalisasiTipeSelisih menerima tipe rekap ("PRODUKSI BAKU") maupun tipe
+// baku ("BAKU_BORONG")
+func normalisasiTipeSelisih(tipe string) (string, error) {
+	tipe = strings.Join(strings.Fields(strings.ToUpper(tipe)), " ")
+	if tipe == "" || models.IsValidTipeProduksi(models.TipeProduksi(tipe)) {
+		return tipe, nil
+	}
+	return normalisasiTipeTarget(tipe)
+}
+
+// terapkanInputAmbang memvalidasi input dan memastikan kombinasi afdeling
+// dan tipe belum dipakai ambang lain
+func terapkanInputAmbang(db *gorm.DB, input ambangSelisihInput, a *models.AmbangSelisih) error {
+	if input.BatasLatekPersen < 0 || input.BatasLumpPersen < 0 {
+		return fmt.Errorf("Batas selisih tidak boleh negatif")
+	}
+	tipe, err := normalisasiTipeSelisih(input.TipeProduksi)
+	if err != nil {
+		return err
+	}
+
+	var afdelingID *uint
+	if strings.TrimSpace(input.Afdeling) != "" {
+		afd, err := models.FindAfdeling(db, input.Afdeling)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("afdeling '%s' tidak terdaftar", input.Afdeling)
+		}
+		if err != nil {
+			return err
+		}
+		afdelingID = &afd.ID
+	}
+
+	q := db.Model(&models.AmbangSelisih{}).Where("tipe_produksi = ? AND id <> ?", tipe, a.ID)
+	if afdelingID == nil {
+		q = q.Where("afdeling_id IS NULL")
+	} else {
+		q = q.Where("afdeling_id = ?", *afdelingID)
+	}
+	var jumlah int64
+	if err := q.Count(&jumlah).Error; err != nil {
+		return err
+	}
+	if jumlah > 0 {
+		return fmt.Errorf("ambang untuk kombinasi afdeling dan tipe ini sudah ada")
+	}
+
+	a.AfdelingID = afdelingID
+	a.TipeProduksi = tipe
+	a.BatasLatekPersen = input.BatasLatekPersen
+	a.BatasLumpPersen = input.BatasLumpPersen
+	a.Keterangan = strings.TrimSpace(input.Keterangan)
+	return nil
+}
+
+func GetAllAmbangSelisih(w http.ResponseWriter, r *http.Request) {
+	var list []models.AmbangSelisih
+	if err := config.DB.Preload("Afdeling").Order("afdeling_id asc, tipe_produksi asc, id asc").Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data ambang: " + err.Error(),
+		})
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    list,
+	})
+}
+
+func CreateAmbangSelisih(w http.ResponseWriter, r *http.Request) {
+	var input ambangSelisihInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+
+	var a models.AmbangSelisih
+	if err := terapkanInputAmbang(config.DB, input, &a); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := config.DB.Create(&a).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menyimpan ambang: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Ambang berhasil ditambahkan. Jalankan evaluasi ulang untuk menerapkannya pada data lama.",
+		Data:    a,
+	})
+}
+
+func UpdateAmbangSelisih(w http.ResponseWriter, r *http.Request) {
+	var a models.AmbangSelisih
+	if err := config.DB.First(&a, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Data ambang tidak ditemukan"})
+		return
+	}
+
+	var input ambangSelisihInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+	if err := terapkanInputAmbang(config.DB, input, &a); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := config.DB.Save(&a).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menyimpan ambang: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Ambang berhasil diperbarui",
+		Data:    a,
+	})
+}
+
+func DeleteAmbangSelisih(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "ID tidak valid"})
+		return
+	}
+
+	result := config.DB.Delete(&models.AmbangSelisih{}, id)
+	if result.Error != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menghapus ambang: " + result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Data ambang tidak ditemukan"})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Ambang berhasil dihapus"})
+}
+
+// EvaluasiSelisih menerapkan ulang ambang ke rekap dan baku detail pada
+// rentang tanggal, misalnya setelah ambang diubah. Evaluasi ulang tidak
+// mengirim notifikasi. Body: {"tanggalAwal", "tanggalAkhir"}.
+func EvaluasiSelisih(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TanggalAwal  string `json:"tanggalAwal"`
+		TanggalAkhir string `json:"tanggalAkhir"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+	awal, errAwal := time.Parse("2006-01-02", input.TanggalAwal)
+	akhir, errAkhir := time.Parse("2006-01-02", input.TanggalAkhir)
+	if errAwal != nil || errAkhir != nil || akhir.Before(awal) {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "tanggalAwal dan tanggalAkhir wajib diisi (format: YYYY-MM-DD)",
+		})
+		return
+	}
+
+	db := config.GetDB()
+	ambang, err := muatAmbangSelisih(db)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal memuat ambang: " + err.Error()})
+		return
+	}
+	rentang := []interface{}{awal.Format("2006-01-02"), akhir.Format("2006-01-02")}
+	baruRekap, err := evaluasiSelisihRekap(db, ambang, db.Where("DATE(tanggal) BETWEEN ? AND ?", rentang...))
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengevaluasi rekap: " + err.Error()})
+		return
+	}
+	baruBaku, err := evaluasiSelisihBaku(db, ambang, db.Where("DATE(tanggal) BETWEEN ? AND ?", rentang...))
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengevaluasi baku: " + err.Error()})
+		return
+	}
+
+	var jumlah int64
+	db.Model(&models.PeringatanSelisih{}).Where("DATE(tanggal) BETWEEN ? AND ?", rentang...).Count(&jumlah)
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Evaluasi selesai: %d peringatan, %d baru", jumlah, len(baruRekap)+len(baruBaku)),
+		Data: map[string]interface{}{
+			"peringatan": jumlah,
+			"baru":       len(baruRekap) + len(baruBaku),
+		},
+	})
+}
+
+// queryPeringatanSelisih menerapkan filter bersama daftar peringatan dan
+// pelanggar berulang
+func queryPeringatanSelisih(db *gorm.DB, r *http.Request) (*gorm.DB, []string, error) {
+	q := r.URL.Query()
+	query := db.Model(&models.PeringatanSelisih{})
+	var filter []string
+
+	if afdeling := q.Get("afdeling"); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
+		filter = append(filter, "Afdeling: "+afd.Nama)
+	}
+	if tipe := q.Get("tipeProduksi"); tipe != "" && tipe != "-" {
+		query = query.Where("tipe_produksi = ?", strings.ToUpper(tipe))
+		filter = append(filter, "Tipe Produksi: "+strings.ToUpper(tipe))
+	}
+	if jenis := q.Get("jenis"); jenis != "" {
+		if jenis != models.SelisihBasahLatek && jenis != models.SelisihBasahLump {
+			return nil, nil, fmt.Errorf("Parameter jenis tidak valid. Gunakan: basah_latek atau basah_lump")
+		}
+		query = query.Where("jenis = ?", jenis)
+		filter = append(filter, "Jenis: "+jenis)
+	}
+	if sumber := q.Get("sumber"); sumber != "" {
+		query = query.Where("sumber = ?", sumber)
+	}
+	if nik := q.Get("nik"); nik != "" {
+		query = query.Where("nik = ?", nik)
+	}
+	return query, filter, nil
+}
+
+// GetPeringatanSelisih menampilkan hari-mandor yang melewati ambang pada
+// rentang tanggal (default 30 hari terakhir). Mendukung ?format=xlsx/csv.
+func GetPeringatanSelisih(w http.ResponseWriter, r *http.Request) {
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	hariIni := time.Now().Truncate(24 * time.Hour)
+	awal, errAwal := parseTanggalParam(r, "tanggalAwal", hariIni.AddDate(0, 0, -29))
+	akhir, errAkhir := parseTanggalParam(r, "tanggalAkhir", hariIni)
+	if errAwal != nil || errAkhir != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format tanggal harus YYYY-MM-DD"})
+		return
+	}
+
+	query, filter, err := queryPeringatanSelisih(config.DB, r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	var list []models.PeringatanSelisih
+	if err := query.Where("DATE(tanggal) BETWEEN ? AND ?", awal.Format("2006-01-02"), akhir.Format("2006-01-02")).
+		Order("tanggal desc, afdeling asc, mandor asc, jenis asc").Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengambil peringatan: " + err.Error()})
+		return
+	}
+
+	if format != "" {
+		filter = append([]string{"Periode: " + awal.Format("2006-01-02") + " s/d " + akhir.Format("2006-01-02")}, filter...)
+		kirimLaporan(w, r, format, "peringatan_selisih", tabelPeringatanSelisih(list, filter))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d peringatan selisih", len(list)),
+		Data:    list,
+	})
+}
+
+func tabelPeringatanSelisih(list []models.PeringatanSelisih, filter []string) TabelLaporan {
+	tabel := TabelLaporan{
+		Judul:  "Peringatan Selisih Kebun vs Pabrik",
+		Filter: filter,
+		Kolom: []KolomLaporan{
+			{Judul: "Tanggal", Jenis: kolomTanggal},
+			{Judul: "Afdeling"},
+			{Judul: "NIK Mandor"},
+			{Judul: "Mandor"},
+			{Judul: "Tipe Produksi"},
+			{Judul: "Sumber"},
+			{Judul: "Jenis"},
+			{Judul: "Kebun (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Pabrik (kg)", Jenis: kolomAngka, Jumlah: true},
+			{Judul: "Selisih (%)", Jenis: kolomPersen},
+			{Judul: "Ambang (%)", Jenis: kolomPersen},
+		},
+	}
+	for _, p := range list {
+		tabel.Baris = append(tabel.Baris, []interface{}{
+			p.Tanggal, p.Afdeling, p.NIK, p.Mandor, p.TipeProduksi, p.Sumber, p.Jenis,
+			p.Kebun, p.Pabrik, p.Persen, p.Batas,
+		})
+	}
+	tabel.hitungTotal()
+	return tabel
+}
+
+// PelanggarSelisih adalah ringkasan satu mandor yang berulang kali melewati
+// ambang. Mingguan sejajar dengan Minggu pada respons; Tren membandingkan
+// jumlah hari dengan periode sebelumnya yang sama panjang.
+type PelanggarSelisih struct {
+	NIK              string  `json:"nik"`
+	Mandor           string  `json:"mandor"`
+	Afdeling         string  `json:"afdeling"`
+	JumlahHari       int     `json:"jumlah_hari"`
+	JumlahPeringatan int     `json:"jumlah_peringatan"`
+	RataPersen       float64 `json:"rata_rata_persen"`
+	MaksPersen       float64 `json:"maks_persen"`
+	Terakhir         string  `json:"terakhir"`
+	HariSebelumnya   int     `json:"jumlah_hari_sebelumnya"`
+	Tren             string  `json:"tren"`
+	Mingguan         []int   `json:"mingguan"`
+}
+
+type PelanggarSelisihResponse struct {
+	TanggalAwal  string             `json:"tanggal_awal"`
+	TanggalAkhir string             `json:"tanggal_akhir"`
+	Minggu       []string           `json:"minggu"`
+	Pelanggar    []PelanggarSelisih `json:"pelanggar"`
+}
+
+// GetPelanggarSelisih mengelompokkan peringatan per mandor untuk melihat
+// pelanggar berulang. Hanya mandor dengan minimal hari peringatan (default
+// 2) yang ditampilkan. Mendukung ?format=xlsx/csv.
+func GetPelanggarSelisih(w http.ResponseWriter, r *http.Request) {
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	hariIni := time.Now().Truncate(24 * time.Hour)
+	awal, errAwal := parseTanggalParam(r, "tanggalAwal", hariIni.AddDate(0, 0, -89))
+	akhir, errAkhir := parseTanggalParam(r, "tanggalAkhir", hariIni)
+	if errAwal != nil || errAkhir != nil || akhir.Before(awal) {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Rentang tanggal tidak valid (format: YYYY-MM-DD)"})
+		return
+	}
+	minimal := 2
+	if v := r.URL.Query().Get("minimal"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Parameter minimal harus angka >= 1"})
+			return
+		}
+		minimal = n
+	}
+
+	hari := int(akhir.Sub(awal).Hours() / 24)
+	akhirSeb := awal.AddDate(0, 0, -1)
+	awalSeb := akhirSeb.AddDate(0, 0, -hari)
+
+	query, filter, err := queryPeringatanSelisih(config.DB, r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	var list []models.PeringatanSelisih
+	if err := query.Where("DATE(tanggal) BETWEEN ? AND ?", awalSeb.Format("2006-01-02"), akhir.Format("2006-01-02")).
+		Order("tanggal asc").Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengambil peringatan: " + err.Error()})
+		return
+	}
+
+	minggu := sumbuGranularitas(awal, akhir, granularitasMinggu)
+	indeksMinggu := make(map[string]int, len(minggu))
+	for i, m := range minggu {
+		indeksMinggu[m] = i
+	}
+
+	type akumulasi struct {
+		data       *PelanggarSelisih
+		hari       map[string]bool
+		hariSeb    map[string]bool
+		mingguHari map[string]bool
+		jumlahPers float64
+	}
+	perMandor := make(map[string]*akumulasi)
+	var urutan []string
+	for _, p := range list {
+		kunci := p.NIK + "|" + p.Afdeling
+		a, ok := perMandor[kunci]
+		if !ok {
+			a = &akumulasi{
+				data:       &PelanggarSelisih{NIK: p.NIK, Mandor: p.Mandor, Afdeling: p.Afdeling, Mingguan: make([]int, len(minggu))},
+				hari:       make(map[string]bool),
+				hariSeb:    make(map[string]bool),
+				mingguHari: make(map[string]bool),
+			}
+			perMandor[kunci] = a
+			urutan = append(urutan, kunci)
+		}
+		tanggal := p.Tanggal.Format("2006-01-02")
+		if p.Tanggal.Before(awal) {
+			a.hariSeb[tanggal] = true
+			continue
+		}
+		d := a.data
+		d.JumlahPeringatan++
+		a.jumlahPers += math.Abs(p.Persen)
+		d.MaksPersen = max(d.MaksPersen, math.Abs(p.Persen))
+		d.Terakhir = tanggal
+		if !a.hari[tanggal] {
+			a.hari[tanggal] = true
+			d.Mingguan[indeksMinggu[kunciGranularitas(p.Tanggal, granularitasMinggu)]]++
+		}
+	}
+
+	hasil := PelanggarSelisihResponse{
+		TanggalAwal:  awal.Format("2006-01-02"),
+		TanggalAkhir: akhir.Format("2006-01-02"),
+		Minggu:       minggu,
+		Pelanggar:    []PelanggarSelisih{},
+	}
+	for _, k := range urutan {
+		a := perMandor[k]
+		d := a.data
+		d.JumlahHari = len(a.hari)
+		if d.JumlahHari < minimal {
+			continue
+		}
+		d.HariSebelumnya = len(a.hariSeb)
+		d.RataPersen = roundTo(a.jumlahPers/float64(d.JumlahPeringatan), 2)
+		d.MaksPersen = roundTo(d.MaksPersen, 2)
+		switch {
+		case d.HariSebelumnya == 0:
+			d.Tren = "baru"
+		case d.JumlahHari > d.HariSebelumnya:
+			d.Tren = "naik"
+		case d.JumlahHari < d.HariSebelumnya:
+			d.Tren = "turun"
+		default:
+			d.Tren = "tetap"
+		}
+		hasil.Pelanggar = append(hasil.Pelanggar, *d)
+	}
+	sort.SliceStable(hasil.Pelanggar, func(i, j int) bool {
+		a, b := hasil.Pelanggar[i], hasil.Pelanggar[j]
+		if a.JumlahHari != b.JumlahHari {
+			return a.JumlahHari > b.JumlahHari
+		}
+		return a.RataPersen > b.RataPersen
+	})
+
+	if format != "" {
+		filter = append([]string{"Periode: " + hasil.TanggalAwal + " s/d " + hasil.TanggalAkhir, fmt.Sprintf("Minimal hari: %d", minimal)}, filter...)
+		kirimLaporan(w, r, format, "pelanggar_selisih", tabelPelanggarSelisih(hasil, filter))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d mandor melewati ambang minimal %d hari", len(hasil.Pelanggar), minimal),
+		Data:    hasil,
+	})
+}
+
+func tabelPelanggarSelisih(h PelanggarSelisihResponse, filter []string) TabelLaporan {
+	tabel := TabelLaporan{
+		Judul:  "Pelanggar Berulang Selisih Kebun vs Pabrik",
+		Filter: filter,
+		Kolom: []KolomLaporan{
+			{Judul: "NIK Mandor"},
+			{Judul: "Mandor"},
+			{Judul: "Afdeling"},
+			{Judul: "Jumlah Hari", Jenis: kolomBulat, Jumlah: true},
+			{Judul: "Jumlah Peringatan", Jenis: kolomBulat, Jumlah: true},
+			{Judul: "Rata-rata Selisih (%)", Jenis: kolomPersen},
+			{Judul: "Maks Selisih (%)", Jenis: kolomPersen},
+			{Judul: "Terakhir"},
+			{Judul: "Hari Periode Sebelumnya", Jenis: kolomBulat, Jumlah: true},
+			{Judul: "Tren"},
+		},
+	}
+	for _, m := range h.Minggu {
+		tabel.Kolom = append(tabel.Kolom, KolomLaporan{Judul: m, Jenis: kolomBulat, Jumlah: true})
+	}
+	for _, p := range h.Pelanggar {
+		baris := []interface{}{
+			p.NIK, p.Mandor, p.Afdeling, float64(p.JumlahHari), float64(p.JumlahPeringatan),
+			p.RataPersen, p.MaksPersen, p.Terakhir, float64(p.HariSebelumnya), p.Tren,
+		}
+		for _, n := range p.Mingguan {
+			baris = append(baris, float64(n))
+		}
+		tabel.Baris = append(tabel.Baris, baris)
+	}
+	tabel.hitungTotal()
+	return tabel
+}
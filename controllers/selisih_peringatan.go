@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sumber data selisih kebun vs pabrik
+const sumberDataBaku = "baku"
+
+// barisSelisih adalah satu selisih hari-mandor dari rekap atau baku detail
+type barisSelisih struct {
+	Sumber       string
+	RefID        uint
+	Tanggal      time.Time
+	NIK          string
+	Mandor       string
+	TipeProduksi string
+	Afdeling     string
+	AfdelingID   *uint
+	Jenis        string
+	Kebun        float64
+	Pabrik       float64
+	Persen       float64
+}
+
+func (b barisSelisih) kunci() string {
+	return fmt.Sprintf("%s|%d|%s", b.Sumber, b.RefID, b.Jenis)
+}
+
+// persenSelisihRekap memakai persen yang tersimpan di rekap, atau
+// menghitungnya dari kebun dan pabrik jika kosong
+func persenSelisihRekap(persen, kebun, pabrik float64) float64 {
+	if persen == 0 {
+		if p := persenSelisih(kebun, pabrik); p != nil {
+			return *p
+		}
+	}
+	return persen
+}
+
+func barisSelisihRekap(r models.Rekap) []barisSelisih {
+	dasar := barisSelisih{
+		Sumber:       sumberDataRekap,
+		RefID:        r.ID,
+		Tanggal:      r.Tanggal,
+		NIK:          r.NIK,
+		Mandor:       r.Mandor,
+		TipeProduksi: r.TipeProduksi,
+		Afdeling:     r.Afdeling,
+		AfdelingID:   r.AfdelingID,
+	}
+	latek, lump := dasar, dasar
+	latek.Jenis, latek.Kebun, latek.Pabrik = models.SelisihBasahLatek, r.HariIniBasahLatekKebun, r.HariIniBasahLatekPabrik
+	latek.Persen = persenSelisihRekap(r.HariIniBasahLatekPersen, latek.Kebun, latek.Pabrik)
+	lump.Jenis, lump.Kebun, lump.Pabrik = models.SelisihBasahLump, r.HariIniBasahLumpKebun, r.HariIniBasahLumpPabrik
+	lump.Persen = persenSelisihRekap(r.HariIniBasahLumpPersen, lump.Kebun, lump.Pabrik)
+	return []barisSelisih{latek, lump}
+}
+
+func barisSelisihBaku(d models.BakuDetail, nik string) []barisSelisih {
+	dasar := barisSelisih{
+		Sumber:       sumberDataBaku,
+		RefID:        d.ID,
+		Tanggal:      d.Tanggal,
+		NIK:          nik,
+		Mandor:       d.Mandor,
+		TipeProduksi: string(d.Tipe),
+		Afdeling:     d.Afdeling,
+		AfdelingID:   d.AfdelingID,
+	}
+	latek, lump := dasar, dasar
+	latek.Jenis, latek.Kebun, latek.Pabrik = models.SelisihBasahLatek, d.JumlahKebunBasahLatek, d.JumlahPabrikBasahLatek
+	latek.Persen = d.PersentaseSelisihBasahLatek
+	lump.Jenis, lump.Kebun, lump.Pabrik = models.SelisihBasahLump, d.JumlahKebunBasahLump, d.JumlahPabrikBasahLump
+	lump.Persen = d.PersentaseSelisihBasahLump
+	return []barisSelisih{latek, lump}
+}
+
+// daftarAmbang memilih ambang paling spesifik: afdeling+tipe, afdeling,
+// tipe, lalu ambang umum
+type daftarAmbang []models.AmbangSelisih
+
+func muatAmbangSelisih(db *gorm.DB) (daftarAmbang, error) {
+	var list []models.AmbangSelisih
+	err := db.Find(&list).Error
+	return list, err
+}
+
+func (d daftarAmbang) batas(afdelingID *uint, tipe, jenis string) float64 {
+	var terpilih *models.AmbangSelisih
+	nilaiTerpilih := -1
+	for i := range d {
+		a := &d[i]
+		nilai := 0
+		if a.AfdelingID != nil {
+			if afdelingID == nil || *a.AfdelingID != *afdelingID {
+				continue
+			}
+			nilai += 2
+		}
+		if a.TipeProduksi != "" {
+			if a.TipeProduksi != tipe {
+				continue
+			}
+			nilai++
+		}
+		if nilai > nilaiTerpilih {
+			terpilih, nilaiTerpilih = a, nilai
+		}
+	}
+	if terpilih == nil {
+		return 0
+	}
+	if jenis == models.SelisihBasahLump {
+		return terpilih.BatasLumpPersen
+	}
+	return terpilih.BatasLatekPersen
+}
+
+// terapkanAmbangSelisih membandingkan nilai absolut persen selisih dengan
+// ambang. Peringatan dibuat atau diperbarui untuk yang melewati batas dan
+// dihapus untuk yang tidak; yang dikembalikan hanya peringatan yang baru
+// dibuat.
+func terapkanAmbangSelisih(db *gorm.DB, ambang daftarAmbang, baris []barisSelisih) ([]models.PeringatanSelisih, error) {
+	var baru []models.PeringatanSelisih
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, b := range baris {
+			batas := ambang.batas(b.AfdelingID, b.TipeProduksi, b.Jenis)
+			lewat := batas > 0 && b.Kebun > 0 && math.Abs(b.Persen) > batas
+
+			var lama models.PeringatanSelisih
+			res := tx.Where("kunci = ?", b.kunci()).Limit(1).Find(&lama)
+			if res.Error != nil {
+				return res.Error
+			}
+			ada := res.RowsAffected > 0
+
+			if !lewat {
+				if ada {
+					if err := tx.Delete(&lama).Error; err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			p := models.PeringatanSelisih{
+				Kunci:        b.kunci(),
+				Tanggal:      b.Tanggal,
+				Sumber:       b.Sumber,
+				RefID:        b.RefID,
+				Jenis:        b.Jenis,
+				NIK:          b.NIK,
+				Mandor:       b.Mandor,
+				TipeProduksi: b.TipeProduksi,
+				Afdeling:     b.Afdeling,
+				AfdelingID:   b.AfdelingID,
+				Kebun:        roundTo(b.Kebun, 2),
+				Pabrik:       roundTo(b.Pabrik, 2),
+				Persen:       roundTo(b.Persen, 2),
+				Batas:        batas,
+			}
+			if ada {
+				p.ID, p.CreatedAt = lama.ID, lama.CreatedAt
+				if err := tx.Save(&p).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Create(&p).Error; err != nil {
+				return err
+			}
+			baru = append(baru, p)
+		}
+		return nil
+	})
+	return baru, err
+}
+
+// evaluasiSelisihRekap memeriksa baris rekap dari query (tanpa REKAPITULASI)
+func evaluasiSelisihRekap(db *gorm.DB, ambang daftarAmbang, query *gorm.DB) ([]models.PeringatanSelisih, error) {
+	var rekaps []models.Rekap
+	if err := query.Where("tipe_produksi != ?", "REKAPITULASI").Find(&rekaps).Error; err != nil {
+		return nil, err
+	}
+	var baris []barisSelisih
+	for _, r := range rekaps {
+		baris = append(baris, barisSelisihRekap(r)...)
+	}
+	return terapkanAmbangSelisih(db, ambang, baris)
+}
+
+// evaluasiSelisihBaku memeriksa baku detail dari query; NIK diambil dari
+// baku mandor
+func evaluasiSelisihBaku(db *gorm.DB, ambang daftarAmbang, query *gorm.DB) ([]models.PeringatanSelisih, error) {
+	var details []models.BakuDetail
+	if err := query.Find(&details).Error; err != nil {
+		return nil, err
+	}
+	nik := make(map[uint]string)
+	var baris []barisSelisih
+	for _, d := range details {
+		if _, ok := nik[d.IdBakuMandor]; !ok {
+			var m models.BakuMandor
+			if err := db.Unscoped().Select("id", "nik").First(&m, d.IdBakuMandor).Error; err == nil {
+				nik[d.IdBakuMandor] = m.NIK
+			}
+		}
+		baris = append(baris, barisSelisihBaku(d, nik[d.IdBakuMandor])...)
+	}
+	return terapkanAmbangSelisih(db, ambang, baris)
+}
+
+// umumkanPeringatanSelisih mengirim peristiwa untuk setiap peringatan baru
+func umumkanPeringatanSelisih(baru []models.PeringatanSelisih) {
+	for _, p := range baru {
+		kirimPeristiwa(Peristiwa{
+			Jenis:      PeristiwaSelisihMelewatiAmbang,
+			AfdelingID: nilaiAfdelingID(p.AfdelingID),
+			Pesan: fmt.Sprintf("Selisih %s mandor %s (%s) %s %.2f%% melewati ambang %.2f%%",
+				p.Jenis, p.Mandor, p.Afdeling, p.Tanggal.Format("2006-01-02"), p.Persen, p.Batas),
+			Data: p,
+		})
+	}
+}
+
+// periksaSelisihMaster dipanggil setelah impor Excel selesai. Peringatan
+// baru dari impor ini diumumkan ke penerima notifikasi.
+func periksaSelisihMaster(idMaster uint64) {
+	db := config.GetDB()
+	ambang, err := muatAmbangSelisih(db)
+	if err != nil {
+		log.Printf("Gagal memuat ambang selisih: %v", err)
+		return
+	}
+	baru, err := evaluasiSelisihRekap(db, ambang, db.Where("id_master = ?", idMaster))
+	if err != nil {
+		log.Printf("Gagal memeriksa selisih master %d: %v", idMaster, err)
+		return
+	}
+	umumkanPeringatanSelisih(baru)
+}
+
+// periksaSelisihBakuDetail dipanggil setiap kali baku detail dihitung ulang
+func periksaSelisihBakuDetail(detailID uint) {
+	db := config.GetDB()
+	ambang, err := muatAmbangSelisih(db)
+	if err != nil {
+		log.Printf("Gagal memuat ambang selisih: %v", err)
+		return
+	}
+	baru, err := evaluasiSelisihBaku(db, ambang, db.Where("id = ?", detailID))
+	if err != nil {
+		log.Printf("Gagal memeriksa selisih baku detail %d: %v", detailID, err)
+		return
+	}
+	umumkanPeringatanSelisih(baru)
+}
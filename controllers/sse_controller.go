@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/broker"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jedaDetakSSE menjaga koneksi tetap hidup melewati proxy yang menutup
+// koneksi diam
+const jedaDetakSSE = 25 * time.Second
+
+// StreamPeristiwa mengirim peristiwa (impor selesai/gagal, baku berubah,
+// hari ditutup, dll.) sebagai Server-Sent Events.
+//
+// Query:
+//   - afdeling: hanya peristiwa afdeling ini (dan peristiwa umum)
+//   - jenis: daftar jenis dipisah koma, misal "upload.completed,day.closed"
+//
+// Klien yang tersambung ulang mengirim header Last-Event-ID (atau query
+// lastEventId) agar peristiwa yang terlewat dikirim ulang dari riwayat.
+func StreamPeristiwa(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Streaming tidak didukung",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+	var afdelingID uint
+	if input := strings.TrimSpace(q.Get("afdeling")); input != "" {
+		afd, err := resolveAfdeling(input, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		afdelingID = afd.ID
+	}
+
+	jenis := make(map[string]bool)
+	for _, j := range strings.Split(q.Get("jenis"), ",") {
+		if j = strings.TrimSpace(j); j != "" {
+			jenis[j] = true
+		}
+	}
+
+	terakhir := r.Header.Get("Last-Event-ID")
+	if terakhir == "" {
+		terakhir = q.Get("lastEventId")
+	}
+	sejak, _ := strconv.ParseUint(terakhir, 10, 64)
+
+	cocokAfdeling := broker.FilterAfdeling(afdelingID)
+	p := siaranPeristiwa.Langgan(func(m broker.Pesan) bool {
+		return cocokAfdeling(m) && (len(jenis) == 0 || jenis[m.Jenis])
+	}, sejak)
+	defer siaranPeristiwa.Berhenti(p)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n: terhubung\n\n")
+	flusher.Flush()
+
+	detak := time.NewTicker(jedaDetakSSE)
+	defer detak.Stop()
+
+	var hilang uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-detak.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case m, ok := <-p.Pesan():
+			if !ok {
+				return
+			}
+			// Beritahu klien jika ada peristiwa yang dibuang karena koneksi
+			// terlalu lambat, supaya klien memuat ulang data
+			if h := p.Hilang(); h > hilang {
+				fmt.Fprintf(w, "event: stream.lost\ndata: {\"hilang\":%d}\n\n", h-hilang)
+				hilang = h
+			}
+			data, err := json.Marshal(m.Data)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.ID, m.Jenis, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ======== SYNC BAKU (OFFLINE CAPTURE) ========
+// Mandor di lapangan mencatat BakuPenyadap tanpa sinyal. Setiap entri diberi UUID
+// oleh perangkat, operasi disimpan di antrean lokal lalu dikirim sekaligus ke
+// POST /api/sync/baku saat perangkat kembali online. Perubahan dari perangkat
+// lain diambil lewat GET /api/sync/baku?since=<cursor>; cursor adalah nomor urut
+// log baku_perubahans.
+
+const (
+	syncMaxBatch     = 500
+	syncDefaultLimit = 200
+	syncMaxLimit     = 1000
+
+	// syncJedaAman adalah lama menunggu nomor urut yang bolong di log
+	// perubahan sebelum dilewati
+	syncJedaAman = time.Minute
+)
+
+// SyncBakuData adalah isi entri yang dikirim perangkat untuk create/update
+type SyncBakuData struct {
+	IdBakuMandor uint    `json:"idBakuMandor"`
+	IdPenyadap   uint    `json:"idPenyadap"`
+	Tanggal      string  `json:"tanggal"` // YYYY-MM-DD
+	BasahLatex   float64 `json:"basahLatex"`
+	Sheet        float64 `json:"sheet"`
+	BasahLump    float64 `json:"basahLump"`
+	BrCr         float64 `json:"brCr"`
+}
+
+// SyncOperationRequest adalah satu operasi dari antrean perangkat
+type SyncOperationRequest struct {
+	OpID          string        `json:"op_id"`
+	Action        string        `json:"action"`
+	UUID          string        `json:"uuid"`
+	BaseUpdatedAt *time.Time    `json:"base_updated_at,omitempty"` // UpdatedAt server terakhir yang diketahui perangkat
+	Data          *SyncBakuData `json:"data,omitempty"`
+}
+
+type SyncBakuRequest struct {
+	DeviceID   string                 `json:"device_id"`
+	Operations []SyncOperationRequest `json:"operations"`
+}
+
+// SyncOperationResult adalah hasil penerapan satu operasi
+type SyncOperationResult struct {
+	OpID    string               `json:"op_id"`
+	UUID    string               `json:"uuid"`
+	Action  string               `json:"action"`
+	Status  string               `json:"status"`
+	Message string               `json:"message,omitempty"`
+	Server  *models.BakuPenyadap `json:"server,omitempty"` // Versi server saat ini, dipakai perangkat untuk menyelesaikan konflik
+}
+
+// SyncChange adalah satu perubahan yang dikembalikan oleh delta GET
+type SyncChange struct {
+	Seq       uint64               `json:"seq"`
+	UUID      string               `json:"uuid"`
+	Action    string               `json:"action"` // upsert atau delete
+	UpdatedAt time.Time            `json:"updated_at"`
+	Entry     *models.BakuPenyadap `json:"entry"`
+}
+
+// SyncBaku menerapkan antrean operasi dari perangkat secara idempoten
+func SyncBaku(w http.ResponseWriter, r *http.Request) {
+	var req SyncBakuRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if req.DeviceID == "" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "device_id wajib diisi",
+		})
+		return
+	}
+
+	if len(req.Operations) > syncMaxBatch {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Maksimal %d operasi per batch", syncMaxBatch),
+		})
+		return
+	}
+
+	results := make([]SyncOperationResult, 0, len(req.Operations))
+	summary := map[string]int{
+		models.SyncStatusApplied:   0,
+		models.SyncStatusDuplicate: 0,
+		models.SyncStatusConflict:  0,
+		models.SyncStatusError:     0,
+	}
+
+	// Operasi diterapkan berurutan sesuai antrean perangkat
+	for _, op := range req.Operations {
+		result := applySyncOperation(config.DB, req.DeviceID, op)
+		summary[result.Status]++
+		results = append(results, result)
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d operasi diproses", len(results)),
+		Data: map[string]interface{}{
+			"results":     results,
+			"summary":     summary,
+			"server_time": time.Now(),
+		},
+	})
+}
+
+// applySyncOperation menerapkan satu operasi dan mencatatnya di sync_operations
+func applySyncOperation(db *gorm.DB, deviceID string, op SyncOperationRequest) SyncOperationResult {
+	result := SyncOperationResult{OpID: op.OpID, UUID: op.UUID, Action: op.Action}
+
+	if !models.IsValidUUID(op.OpID) || !models.IsValidUUID(op.UUID) {
+		result.Status = models.SyncStatusError
+		result.Message = "op_id dan uuid wajib berformat UUID"
+		return result
+	}
+
+	// Operasi yang sama sudah pernah diterapkan: kembalikan hasil sebelumnya
+	var logged models.SyncOperation
+	if err := db.Where("op_id = ?", op.OpID).First(&logged).Error; err == nil {
+		result.Status = models.SyncStatusDuplicate
+		result.Message = "Operasi sudah diterapkan sebelumnya (" + logged.Status + ")"
+		result.Server = findBakuPenyadapByUUID(db, op.UUID)
+		return result
+	}
+
+	var entry, oldEntry models.BakuPenyadap
+	var status, message string
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch op.Action {
+		case models.SyncActionCreate:
+			status, message, err = syncCreateBaku(tx, op, &entry)
+		case models.SyncActionUpdate:
+			status, message, err = syncUpdateBaku(tx, op, &entry, &oldEntry)
+		case models.SyncActionDelete:
+			status, message, err = syncDeleteBaku(tx, op, &entry)
+		default:
+			status, message = models.SyncStatusError, "Aksi tidak dikenal: "+op.Action
+		}
+		if err != nil {
+			return err
+		}
+
+		// Konflik dan error tidak dicatat agar perangkat bisa mengirim ulang setelah diselesaikan
+		if status != models.SyncStatusApplied && status != models.SyncStatusDuplicate {
+			return nil
+		}
+
+		return tx.Create(&models.SyncOperation{
+			OpID:      op.OpID,
+			DeviceID:  deviceID,
+			EntryUUID: op.UUID,
+			Action:    op.Action,
+			Status:    status,
+			Message:   message,
+		}).Error
+	})
+	if err != nil {
+		result.Status = models.SyncStatusError
+		result.Message = err.Error()
+		return result
+	}
+
+	// Hitung ulang BakuDetail di luar transaksi, sama seperti handler CRUD
+	if status == models.SyncStatusApplied {
+		switch op.Action {
+		case models.SyncActionCreate:
+			updateBakuDetail(entry, "create", nil)
+		case models.SyncActionUpdate:
+			updateBakuDetail(entry, "update", &oldEntry)
+			if oldEntry.IdBakuMandor != entry.IdBakuMandor || !sameDay(oldEntry.Tanggal, entry.Tanggal) || oldEntry.Tipe != entry.Tipe {
+				updateBakuDetail(oldEntry, "update", nil)
+			}
+		case models.SyncActionDelete:
+			updateBakuDetail(entry, "delete", nil)
+		}
+	}
+
+	result.Status = status
+	result.Message = message
+	result.Server = findBakuPenyadapByUUID(db, op.UUID)
+	return result
+}
+
+// syncCreateBaku membuat entri baru dengan UUID dari perangkat
+func syncCreateBaku(tx *gorm.DB, op SyncOperationRequest, entry *models.BakuPenyadap) (string, string, error) {
+	if op.Data == nil {
+		return models.SyncStatusError, "data wajib diisi untuk create", nil
+	}
+
+	// UUID sudah ada: create yang dikirim ulang dianggap duplikat
+	var existing models.BakuPenyadap
+	err := tx.Unscoped().Where("uuid = ?", op.UUID).First(&existing).Error
+	if err == nil {
+		if existing.DeletedAt.Valid {
+			return models.SyncStatusConflict, "Entri dengan uuid ini sudah dihapus di server", nil
+		}
+		*entry = existing
+		return models.SyncStatusDuplicate, "Entri sudah ada di server", nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", err
+	}
+
+	if err := fillBakuFromSync(tx, op.Data, entry); err != nil {
+		return models.SyncStatusError, err.Error(), nil
+	}
+	entry.UUID = op.UUID
+
+	// Perangkat lain sudah mencatat penyadap yang sama pada hari dan mandor yang sama
+	if message, err := cekEntriSamaHari(tx, entry); message != "" || err != nil {
+		return models.SyncStatusConflict, message, err
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return "", "", err
+	}
+	return models.SyncStatusApplied, "Entri dibuat", nil
+}
+
+// syncUpdateBaku mengubah entri jika versi server belum berubah sejak base_updated_at
+func syncUpdateBaku(tx *gorm.DB, op SyncOperationRequest, entry, oldEntry *models.BakuPenyadap) (string, string, error) {
+	if op.Data == nil {
+		return models.SyncStatusError, "data wajib diisi untuk update", nil
+	}
+	if op.BaseUpdatedAt == nil {
+		return models.SyncStatusError, "base_updated_at wajib diisi untuk update", nil
+	}
+
+	var existing models.BakuPenyadap
+	err := tx.Unscoped().Where("uuid = ?", op.UUID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.SyncStatusError, "Entri dengan uuid ini tidak ditemukan", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if existing.DeletedAt.Valid {
+		return models.SyncStatusConflict, "Entri sudah dihapus di server", nil
+	}
+	if isSyncConflict(existing.UpdatedAt, *op.BaseUpdatedAt) {
+		return models.SyncStatusConflict, "Entri sudah diubah di server sejak " + op.BaseUpdatedAt.Format(time.RFC3339), nil
+	}
+
+	*oldEntry = existing
+	*entry = existing
+	if err := fillBakuFromSync(tx, op.Data, entry); err != nil {
+		return models.SyncStatusError, err.Error(), nil
+	}
+
+	// Perubahan penyadap, mandor, tipe, atau tanggal tidak boleh menabrak entri lain
+	if message, err := cekEntriSamaHari(tx, entry); message != "" || err != nil {
+		return models.SyncStatusConflict, message, err
+	}
+
+	if err := tx.Save(entry).Error; err != nil {
+		return "", "", err
+	}
+	return models.SyncStatusApplied, "Entri diperbarui", nil
+}
+
+// syncDeleteBaku menghapus (soft delete) entri jika versi server belum berubah.
+// Seperti update, base_updated_at wajib agar perubahan dari perangkat lain
+// tidak ikut terhapus tanpa terlihat.
+func syncDeleteBaku(tx *gorm.DB, op SyncOperationRequest, entry *models.BakuPenyadap) (string, string, error) {
+	if op.BaseUpdatedAt == nil {
+		return models.SyncStatusError, "base_updated_at wajib diisi untuk delete", nil
+	}
+
+	var existing models.BakuPenyadap
+	err := tx.Unscoped().Where("uuid = ?", op.UUID).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.SyncStatusDuplicate, "Entri tidak ada di server", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if existing.DeletedAt.Valid {
+		*entry = existing
+		return models.SyncStatusDuplicate, "Entri sudah dihapus", nil
+	}
+	if isSyncConflict(existing.UpdatedAt, *op.BaseUpdatedAt) {
+		return models.SyncStatusConflict, "Entri sudah diubah di server sejak " + op.BaseUpdatedAt.Format(time.RFC3339), nil
+	}
+
+	// Hook AfterSave mencatat penghapusan di log perubahan untuk delta GET
+	now := time.Now()
+	if err := tx.Unscoped().Model(&existing).Updates(map[string]interface{}{
+		"deleted_at": now,
+		"updated_at": now,
+	}).Error; err != nil {
+		return "", "", err
+	}
+
+	*entry = existing
+	return models.SyncStatusApplied, "Entri dihapus", nil
+}
+
+// cekEntriSamaHari mengembalikan pesan konflik jika entri lain sudah mencatat
+// penyadap yang sama pada hari, mandor, dan tipe yang sama
+func cekEntriSamaHari(tx *gorm.DB, entry *models.BakuPenyadap) (string, error) {
+	query := tx.Where("id_penyadap = ? AND id_baku_mandor = ? AND tipe = ? AND DATE(tanggal) = DATE(?)",
+		entry.IdPenyadap, entry.IdBakuMandor, entry.Tipe, entry.Tanggal)
+	if entry.ID != 0 {
+		query = query.Where("id <> ?", entry.ID)
+	}
+
+	var sameDayEntry models.BakuPenyadap
+	err := query.First(&sameDayEntry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Penyadap sudah dicatat pada %s oleh entri %s",
+		entry.Tanggal.Format("2006-01-02"), sameDayEntry.UUID), nil
+}
+
+// fillBakuFromSync memvalidasi data perangkat dan menyalinnya ke entri
+func fillBakuFromSync(tx *gorm.DB, data *SyncBakuData, entry *models.BakuPenyadap) error {
+	if data.IdBakuMandor == 0 || data.IdPenyadap == 0 {
+		return fmt.Errorf("ID mandor dan ID penyadap wajib diisi")
+	}
+
+	tanggal, err := time.Parse("2006-01-02", data.Tanggal)
+	if err != nil {
+		return fmt.Errorf("format tanggal tidak valid, gunakan YYYY-MM-DD")
+	}
+
+	var mandor models.BakuMandor
+	if err := tx.First(&mandor, data.IdBakuMandor).Error; err != nil {
+		return fmt.Errorf("mandor dengan ID %d tidak ditemukan", data.IdBakuMandor)
+	}
+
+	var penyadap models.Penyadap
+	if err := tx.First(&penyadap, data.IdPenyadap).Error; err != nil {
+		return fmt.Errorf("penyadap dengan ID %d tidak ditemukan", data.IdPenyadap)
+	}
+
+	// Tipe dan tahun tanam selalu mengikuti profil mandor
+	entry.IdBakuMandor = mandor.ID
+	entry.IdPenyadap = penyadap.ID
+	entry.Tanggal = tanggal.Truncate(24 * time.Hour)
+	entry.Tipe = mandor.Tipe
+	entry.TahunTanam = mandor.TahunTanam
+	entry.BasahLatex = data.BasahLatex
+	entry.Sheet = data.Sheet
+	entry.BasahLump = data.BasahLump
+	entry.BrCr = data.BrCr
+	return nil
+}
+
+// isSyncConflict bernilai true jika server sudah berubah setelah versi yang dilihat perangkat.
+// Dibandingkan per milidetik karena presisi datetime di database.
+func isSyncConflict(serverUpdatedAt, baseUpdatedAt time.Time) bool {
+	return serverUpdatedAt.Truncate(time.Millisecond).After(baseUpdatedAt.Truncate(time.Millisecond))
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func findBakuPenyadapByUUID(db *gorm.DB, uuid string) *models.BakuPenyadap {
+	var entry models.BakuPenyadap
+	if err := db.Unscoped().Where("uuid = ?", uuid).First(&entry).Error; err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// GetSyncBakuChanges mengembalikan perubahan BakuPenyadap sejak cursor.
+// Cursor adalah nomor urut terakhir di log baku_perubahans. Nomor urut yang
+// masih bolong (transaksi belum commit) ditunggu selama syncJedaAman agar
+// perubahan yang commit terlambat tidak terlewat oleh perangkat.
+func GetSyncBakuChanges(w http.ResponseWriter, r *http.Request) {
+	since := r.URL.Query().Get("since")
+	limitStr := r.URL.Query().Get("limit")
+
+	limit := syncDefaultLimit
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= syncMaxLimit {
+			limit = l
+		}
+	}
+
+	var cursor uint64
+	if since != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(since, 10, 64); err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Cursor tidak valid, kirim tanpa since untuk sinkron ulang dari awal",
+			})
+			return
+		}
+	}
+
+	var perubahan []models.PerubahanBaku
+	if err := config.DB.Where("id > ?", cursor).Order("id asc").Limit(limit).Find(&perubahan).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil perubahan: " + err.Error(),
+		})
+		return
+	}
+	hasMore := len(perubahan) == limit
+	if n := batasUrutanAman(cursor, perubahan, time.Now()); n < len(perubahan) {
+		perubahan, hasMore = perubahan[:n], false
+	}
+
+	// Satu entri bisa berubah beberapa kali; kirim keadaan terbarunya sekali
+	// pada urutan perubahan terakhirnya
+	urutan := make(map[uint]uint64, len(perubahan))
+	var ids []uint
+	for _, p := range perubahan {
+		if _, ok := urutan[p.BakuPenyadapID]; !ok {
+			ids = append(ids, p.BakuPenyadapID)
+		}
+		urutan[p.BakuPenyadapID] = p.ID
+	}
+
+	var entries []models.BakuPenyadap
+	if len(ids) > 0 {
+		if err := config.DB.Unscoped().Where("id IN ?", ids).Find(&entries).Error; err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Gagal mengambil perubahan: " + err.Error(),
+			})
+			return
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return urutan[entries[i].ID] < urutan[entries[j].ID] })
+
+	changes := make([]SyncChange, 0, len(entries))
+	for i := range entries {
+		action := "upsert"
+		if entries[i].DeletedAt.Valid {
+			action = "delete"
+		}
+		changes = append(changes, SyncChange{
+			Seq:       urutan[entries[i].ID],
+			UUID:      entries[i].UUID,
+			Action:    action,
+			UpdatedAt: entries[i].UpdatedAt,
+			Entry:     &entries[i],
+		})
+	}
+
+	nextCursor := cursor
+	if len(perubahan) > 0 {
+		nextCursor = perubahan[len(perubahan)-1].ID
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d perubahan ditemukan", len(changes)),
+		Data: map[string]interface{}{
+			"changes":  changes,
+			"cursor":   strconv.FormatUint(nextCursor, 10),
+			"has_more": hasMore,
+		},
+	})
+}
+
+// batasUrutanAman mengembalikan banyaknya baris log yang aman dikirim.
+// Nomor urut autoincrement dibagikan saat insert, bukan saat commit, jadi
+// celah berarti ada transaksi yang mungkin belum commit. Pengiriman berhenti
+// sebelum celah yang umurnya belum syncJedaAman; celah yang lebih tua
+// dianggap transaksi yang dibatalkan.
+func batasUrutanAman(cursor uint64, perubahan []models.PerubahanBaku, sekarang time.Time) int {
+	sebelum := cursor
+	for i, p := range perubahan {
+		if p.ID != sebelum+1 && sekarang.Sub(p.CreatedAt) < syncJedaAman {
+			return i
+		}
+		sebelum = p.ID
+	}
+	return len(perubahan)
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config/configtest"
+	"app-inputan-ptpn/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type syncBakuHasil struct {
+	Results []SyncOperationResult `json:"results"`
+}
+
+type syncBakuDelta struct {
+	Changes []SyncChange `json:"changes"`
+	Cursor  string       `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+}
+
+// panggilHandler menjalankan handler dan membaca field data dari APIResponse
+func panggilHandler(t *testing.T, h http.HandlerFunc, req *http.Request, data interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	var resp struct {
+		Success bool            `json:"success"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("respons bukan JSON: %v\n%s", err, rec.Body.String())
+	}
+	if data != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			t.Fatalf("gagal membaca data: %v\n%s", err, resp.Data)
+		}
+	}
+	return rec.Code
+}
+
+func kirimSync(t *testing.T, deviceID string, ops ...SyncOperationRequest) []SyncOperationResult {
+	t.Helper()
+	body, _ := json.Marshal(SyncBakuRequest{DeviceID: deviceID, Operations: ops})
+	req := httptest.NewRequest(http.MethodPost, "/api/sync/baku", bytes.NewReader(body))
+
+	var hasil syncBakuHasil
+	if code := panggilHandler(t, SyncBaku, req, &hasil); code != http.StatusOK {
+		t.Fatalf("POST /api/sync/baku = %d", code)
+	}
+	if len(hasil.Results) != len(ops) {
+		t.Fatalf("hasil %d operasi, ingin %d", len(hasil.Results), len(ops))
+	}
+	return hasil.Results
+}
+
+func ambilDelta(t *testing.T, since string) syncBakuDelta {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/sync/baku?since="+since, nil)
+
+	var delta syncBakuDelta
+	if code := panggilHandler(t, GetSyncBakuChanges, req, &delta); code != http.StatusOK {
+		t.Fatalf("GET /api/sync/baku?since=%s = %d", since, code)
+	}
+	return delta
+}
+
+// Dua perangkat mandor mencatat penyadap yang sama pada hari yang sama
+func TestSyncBakuDuaPerangkat(t *testing.T) {
+	db := configtest.InitTestDB(t)
+
+	mandor := models.BakuMandor{TahunTanam: 2010, NIK: "M-01", Mandor: "Mandor Uji", Afdeling: "Gebugan"}
+	penyadap := models.Penyadap{NamaPenyadap: "Penyadap Uji", NIK: "P-01"}
+	if err := db.Create(&mandor).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&penyadap).Error; err != nil {
+		t.Fatal(err)
+	}
+	data := &SyncBakuData{
+		IdBakuMandor: mandor.ID,
+		IdPenyadap:   penyadap.ID,
+		Tanggal:      "2025-03-10",
+		BasahLatex:   12.5,
+	}
+
+	// Perangkat A membuat entri lebih dulu
+	createA := SyncOperationRequest{OpID: models.NewUUID(), Action: models.SyncActionCreate, UUID: models.NewUUID(), Data: data}
+	hasil := kirimSync(t, "perangkat-a", createA)
+	if hasil[0].Status != models.SyncStatusApplied || hasil[0].Server == nil {
+		t.Fatalf("create A = %s (%s), ingin applied", hasil[0].Status, hasil[0].Message)
+	}
+	versiA := hasil[0].Server.UpdatedAt
+
+	awal := ambilDelta(t, "")
+	if len(awal.Changes) != 1 || awal.Changes[0].UUID != createA.UUID || awal.Changes[0].Action != "upsert" {
+		t.Fatalf("delta awal = %+v, ingin satu upsert %s", awal.Changes, createA.UUID)
+	}
+
+	// Perangkat B mencatat penyadap yang sama pada hari yang sama dengan uuid lain
+	createB := SyncOperationRequest{OpID: models.NewUUID(), Action: models.SyncActionCreate, UUID: models.NewUUID(), Data: data}
+	if hasil := kirimSync(t, "perangkat-b", createB); hasil[0].Status != models.SyncStatusConflict {
+		t.Fatalf("create B hari yang sama = %s, ingin conflict", hasil[0].Status)
+	}
+
+	// Batch A dikirim ulang karena sinyal putus: op_id sama tidak diterapkan lagi
+	if hasil := kirimSync(t, "perangkat-a", createA); hasil[0].Status != models.SyncStatusDuplicate {
+		t.Fatalf("kirim ulang op_id = %s, ingin duplicate", hasil[0].Status)
+	}
+	var jumlah int64
+	db.Model(&models.BakuPenyadap{}).Count(&jumlah)
+	if jumlah != 1 {
+		t.Fatalf("jumlah entri = %d, ingin 1", jumlah)
+	}
+
+	// B mengambil entri A lalu mengubahnya dari versi terbaru
+	time.Sleep(5 * time.Millisecond)
+	ubahB := SyncOperationRequest{
+		OpID: models.NewUUID(), Action: models.SyncActionUpdate, UUID: createA.UUID,
+		BaseUpdatedAt: &versiA,
+		Data:          &SyncBakuData{IdBakuMandor: mandor.ID, IdPenyadap: penyadap.ID, Tanggal: "2025-03-10", BasahLatex: 15},
+	}
+	if hasil := kirimSync(t, "perangkat-b", ubahB); hasil[0].Status != models.SyncStatusApplied {
+		t.Fatalf("update B = %s (%s), ingin applied", hasil[0].Status, hasil[0].Message)
+	}
+
+	// A masih memegang versi lama
+	ubahA := SyncOperationRequest{
+		OpID: models.NewUUID(), Action: models.SyncActionUpdate, UUID: createA.UUID,
+		BaseUpdatedAt: &versiA,
+		Data:          &SyncBakuData{IdBakuMandor: mandor.ID, IdPenyadap: penyadap.ID, Tanggal: "2025-03-10", BasahLatex: 20},
+	}
+	hasil = kirimSync(t, "perangkat-a", ubahA)
+	if hasil[0].Status != models.SyncStatusConflict {
+		t.Fatalf("update A dengan base lama = %s, ingin conflict", hasil[0].Status)
+	}
+	if hasil[0].Server == nil || hasil[0].Server.BasahLatex != 15 {
+		t.Fatalf("konflik harus membawa versi server (basah latex 15), dapat %+v", hasil[0].Server)
+	}
+
+	// Cursor maju: perubahan B terlihat sekali, lalu tidak ada lagi
+	lanjut := ambilDelta(t, awal.Cursor)
+	if len(lanjut.Changes) != 1 || lanjut.Changes[0].Entry.BasahLatex != 15 {
+		t.Fatalf("delta setelah update = %+v, ingin satu entri basah latex 15", lanjut.Changes)
+	}
+	if lanjut.Cursor == awal.Cursor || lanjut.Changes[0].Seq <= awal.Changes[0].Seq {
+		t.Fatalf("cursor tidak maju: %s -> %s", awal.Cursor, lanjut.Cursor)
+	}
+	if akhir := ambilDelta(t, lanjut.Cursor); len(akhir.Changes) != 0 || akhir.Cursor != lanjut.Cursor {
+		t.Fatalf("delta tanpa perubahan = %+v (cursor %s), ingin kosong dengan cursor %s", akhir.Changes, akhir.Cursor, lanjut.Cursor)
+	}
+}
+
+func TestBatasUrutanAman(t *testing.T) {
+	sekarang := time.Now()
+	lama := sekarang.Add(-2 * syncJedaAman)
+	baru := sekarang.Add(-time.Second)
+
+	tests := []struct {
+		nama   string
+		cursor uint64
+		log    []models.PerubahanBaku
+		ingin  int
+	}{
+		{"berurutan", 3, []models.PerubahanBaku{{ID: 4, CreatedAt: baru}, {ID: 5, CreatedAt: baru}}, 2},
+		{"celah baru ditunggu", 3, []models.PerubahanBaku{{ID: 4, CreatedAt: baru}, {ID: 6, CreatedAt: baru}}, 1},
+		{"celah di awal ditunggu", 3, []models.PerubahanBaku{{ID: 5, CreatedAt: baru}}, 0},
+		{"celah lama dilewati", 3, []models.PerubahanBaku{{ID: 4, CreatedAt: lama}, {ID: 6, CreatedAt: lama}}, 2},
+		{"kosong", 3, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := batasUrutanAman(tt.cursor, tt.log, sekarang); got != tt.ingin {
+				t.Errorf("batasUrutanAman = %d, ingin %d", got, tt.ingin)
+			}
+		})
+	}
+}
+
+// siapkanSyncUji membuat satu mandor dan penyadap untuk operasi sync
+func siapkanSyncUji(t *testing.T) (*gorm.DB, models.BakuMandor, models.Penyadap) {
+	t.Helper()
+	db := configtest.InitTestDB(t)
+	mandor := models.BakuMandor{TahunTanam: 2010, NIK: "M-01", Mandor: "Mandor Uji", Afdeling: "Gebugan"}
+	penyadap := models.Penyadap{NamaPenyadap: "Penyadap Uji", NIK: "P-01"}
+	if err := db.Create(&mandor).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&penyadap).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, mandor, penyadap
+}
+
+// Update yang memindahkan entri ke hari yang sudah tercatat ditolak seperti create
+func TestSyncUpdateBakuHariSama(t *testing.T) {
+	db, mandor, penyadap := siapkanSyncUji(t)
+	buat := func(tanggal string) SyncOperationResult {
+		op := SyncOperationRequest{OpID: models.NewUUID(), Action: models.SyncActionCreate, UUID: models.NewUUID(),
+			Data: &SyncBakuData{IdBakuMandor: mandor.ID, IdPenyadap: penyadap.ID, Tanggal: tanggal, BasahLatex: 10}}
+		hasil := kirimSync(t, "perangkat-a", op)
+		if hasil[0].Status != models.SyncStatusApplied {
+			t.Fatalf("create %s = %s (%s)", tanggal, hasil[0].Status, hasil[0].Message)
+		}
+		return hasil[0]
+	}
+	senin := buat("2025-03-10")
+	selasa := buat("2025-03-11")
+
+	ubah := func(tanggal string) SyncOperationResult {
+		return kirimSync(t, "perangkat-b", SyncOperationRequest{
+			OpID: models.NewUUID(), Action: models.SyncActionUpdate, UUID: selasa.UUID,
+			BaseUpdatedAt: &selasa.Server.UpdatedAt,
+			Data:          &SyncBakuData{IdBakuMandor: mandor.ID, IdPenyadap: penyadap.ID, Tanggal: tanggal, BasahLatex: 20},
+		})[0]
+	}
+
+	hasil := ubah("2025-03-10")
+	if hasil.Status != models.SyncStatusConflict {
+		t.Fatalf("update ke hari yang sudah tercatat = %s, ingin conflict", hasil.Status)
+	}
+	if hasil.Server == nil || !sameDay(hasil.Server.Tanggal, selasa.Server.Tanggal) || hasil.Server.BasahLatex != 10 {
+		t.Fatalf("entri server berubah: %+v", hasil.Server)
+	}
+	if got := findBakuPenyadapByUUID(db, senin.UUID); got == nil || got.BasahLatex != 10 {
+		t.Fatalf("entri hari senin berubah: %+v", got)
+	}
+
+	// Mengubah entri tanpa memindah harinya tidak bentrok dengan dirinya sendiri
+	if hasil := ubah("2025-03-11"); hasil.Status != models.SyncStatusApplied {
+		t.Fatalf("update di hari yang sama = %s (%s), ingin applied", hasil.Status, hasil.Message)
+	}
+}
+
+// Delete tanpa base_updated_at ditolak; delete dengan base lama menjadi konflik
+func TestSyncDeleteBakuWajibBase(t *testing.T) {
+	_, mandor, penyadap := siapkanSyncUji(t)
+	create := SyncOperationRequest{OpID: models.NewUUID(), Action: models.SyncActionCreate, UUID: models.NewUUID(),
+		Data: &SyncBakuData{IdBakuMandor: mandor.ID, IdPenyadap: penyadap.ID, Tanggal: "2025-03-10", BasahLatex: 10}}
+	versi := kirimSync(t, "perangkat-a", create)[0].Server.UpdatedAt
+
+	hapus := func(base *time.Time) SyncOperationResult {
+		return kirimSync(t, "perangkat-a", SyncOperationRequest{
+			OpID: models.NewUUID(), Action: models.SyncActionDelete, UUID: create.UUID, BaseUpdatedAt: base,
+		})[0]
+	}
+
+	if hasil := hapus(nil); hasil.Status != models.SyncStatusError {
+		t.Fatalf("delete tanpa base_updated_at = %s, ingin error", hasil.Status)
+	}
+	lama := versi.Add(-time.Hour)
+	if hasil := hapus(&lama); hasil.Status != models.SyncStatusConflict {
+		t.Fatalf("delete dengan base lama = %s, ingin conflict", hasil.Status)
+	}
+	if hasil := hapus(&versi); hasil.Status != models.SyncStatusApplied {
+		t.Fatalf("delete dengan base terbaru = %s (%s), ingin applied", hasil.Status, hasil.Message)
+	}
+}
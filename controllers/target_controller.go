@@ -0,0 +1,705 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// targetInput dipakai API dan impor Excel. Mandor bisa diisi lewat id atau
+// NIK, blok lewat id atau kode; pola lewat kode.
+type targetInput struct {
+	Tahun        int     `json:"tahun"`
+	Bulan        int     `json:"bulan"`
+	Afdeling     string  `json:"afdeling"`
+	TipeProduksi string  `json:"tipe_produksi"`
+	MandorID     *uint   `json:"mandor_id"`
+	NIKMandor    string  `json:"nik_mandor"`
+	PetaID       *uint   `json:"peta_id"`
+	KodeBlok     string  `json:"kode_blok"`
+	KeringKg     float64 `json:"kering_kg"`
+	Pola         string  `json:"pola"`
+	Keterangan   string  `json:"keterangan"`
+}
+
+type polaDistribusiInput struct {
+	Kode       string `json:"kode"`
+	Nama       string `json:"nama"`
+	BobotBulan string `json:"bobot_bulan"`
+	BobotHari  string `json:"bobot_hari"`
+}
+
+// Kolom file Excel target, urutan ini dipakai untuk ekspor dan templat impor
+var kolomTargetExcel = []string{"Tahun", "Bulan", "Afdeling", "Tipe Produksi", "NIK Mandor", "Kode Blok", "Target Kering (kg)", "Pola", "Keterangan"}
+
+// HasilImporTarget adalah ringkasan impor target
+type HasilImporTarget struct {
+	JumlahBaris int               `json:"jumlah_baris"`
+	Dibuat      int               `json:"dibuat"`
+	Diperbarui  int               `json:"diperbarui"`
+	Gagal       []BarisGagalImpor `json:"gagal"`
+}
+
+// normalisasiTipeTarget menerima nama tipe seperti di REKAP ("PRODUKSI
+// BAKU"); string kosong berarti semua tipe
+func normalisasiTipeTarget(tipe string) (string, error) {
+	tipe = strings.Join(strings.Fields(strings.ToUpper(tipe)), " ")
+	if tipe == "" {
+		return "", nil
+	}
+	for _, t := range urutanTipeRekap {
+		if t == tipe {
+			return tipe, nil
+		}
+	}
+	return "", fmt.Errorf("tipe produksi '%s' tidak dikenal (gunakan salah satu: %s, atau kosong untuk semua tipe)",
+		tipe, strings.Join(urutanTipeRekap, ", "))
+}
+
+// kunciTargetSama membatasi query ke target dengan kombinasi periode,
+// afdeling, tipe, mandor dan blok yang sama
+func kunciTargetSama(q *gorm.DB, t models.TargetProduksi) *gorm.DB {
+	q = q.Where("tahun = ? AND bulan = ? AND afdeling_id = ? AND tipe_produksi = ?",
+		t.Tahun, t.Bulan, t.AfdelingID, t.TipeProduksi)
+	if t.MandorID == nil {
+		q = q.Where("mandor_id IS NULL")
+	} else {
+		q = q.Where("mandor_id = ?", *t.MandorID)
+	}
+	if t.PetaID == nil {
+		q = q.Where("peta_id IS NULL")
+	} else {
+		q = q.Where("peta_id = ?", *t.PetaID)
+	}
+	return q
+}
+
+// terapkanInputTarget memvalidasi input lalu mengisi target. Keunikan
+// kombinasi diperiksa terpisah dengan cariTargetSama.
+func terapkanInputTarget(db *gorm.DB, input targetInput, t *models.TargetProduksi) error {
+	if input.Tahun < 2000 || input.Tahun > 2100 {
+		return fmt.Errorf("Tahun harus diisi (2000-2100)")
+	}
+	if input.Bulan < 0 || input.Bulan > 12 {
+		return fmt.Errorf("Bulan harus 1-12, atau 0 untuk target tahunan")
+	}
+	if input.KeringKg < 0 {
+		return fmt.Errorf("Target kering tidak boleh negatif")
+	}
+	if strings.TrimSpace(input.Afdeling) == "" {
+		return fmt.Errorf("Afdeling wajib diisi")
+	}
+	afd, err := models.FindAfdeling(db, input.Afdeling)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("afdeling '%s' tidak terdaftar", input.Afdeling)
+	}
+	if err != nil {
+		return err
+	}
+	tipe, err := normalisasiTipeTarget(input.TipeProduksi)
+	if err != nil {
+		return err
+	}
+
+	var mandorID *uint
+	switch {
+	case input.MandorID != nil:
+		var m models.Mandor
+		if err := db.First(&m, *input.MandorID).Error; err != nil {
+			return fmt.Errorf("mandor id %d tidak ditemukan", *input.MandorID)
+		}
+		mandorID = &m.ID
+	case strings.TrimSpace(input.NIKMandor) != "":
+		var m models.Mandor
+		if err := db.Where("nik = ?", strings.TrimSpace(input.NIKMandor)).First(&m).Error; err != nil {
+			return fmt.Errorf("mandor dengan NIK '%s' tidak ditemukan", input.NIKMandor)
+		}
+		mandorID = &m.ID
+	}
+
+	var petaID *uint
+	var peta models.Peta
+	switch {
+	case input.PetaID != nil:
+		if err := db.First(&peta, *input.PetaID).Error; err != nil {
+			return fmt.Errorf("blok id %d tidak ditemukan", *input.PetaID)
+		}
+		petaID = &peta.ID
+	case strings.TrimSpace(input.KodeBlok) != "":
+		if err := db.Where("code = ?", strings.TrimSpace(input.KodeBlok)).First(&peta).Error; err != nil {
+			return fmt.Errorf("blok dengan kode '%s' tidak ditemukan", input.KodeBlok)
+		}
+		petaID = &peta.ID
+	}
+	if petaID != nil && peta.AfdelingID != nil && *peta.AfdelingID != afd.ID {
+		return fmt.Errorf("blok %s bukan milik afdeling %s", peta.Code, afd.Nama)
+	}
+	if mandorID != nil && petaID != nil {
+		return fmt.Errorf("Target hanya boleh per mandor atau per blok, tidak keduanya")
+	}
+
+	var polaID *uint
+	if kode := strings.ToUpper(strings.TrimSpace(input.Pola)); kode != "" {
+		var pola models.PolaDistribusi
+		if err := db.Where("kode = ?", kode).First(&pola).Error; err != nil {
+			return fmt.Errorf("pola distribusi '%s' tidak ditemukan", kode)
+		}
+		polaID = &pola.ID
+	}
+
+	t.Tahun = input.Tahun
+	t.Bulan = input.Bulan
+	t.AfdelingID = afd.ID
+	t.TipeProduksi = tipe
+	t.MandorID = mandorID
+	t.PetaID = petaID
+	t.KeringKg = input.KeringKg
+	t.PolaDistribusiID = polaID
+	t.Keterangan = strings.TrimSpace(input.Keterangan)
+	return nil
+}
+
+// cariTargetSama mencari target lain dengan kombinasi yang sama; ID 0
+// berarti belum ada
+func cariTargetSama(db *gorm.DB, t models.TargetProduksi) (models.TargetProduksi, error) {
+	var lain models.TargetProduksi
+	err := kunciTargetSama(db, t).Where("id <> ?", t.ID).Limit(1).Find(&lain).Error
+	return lain, err
+}
+
+// ================== API TARGET ==================
+
+// GetAllTarget mendukung filter tahun, bulan, afdeling dan tipeProduksi
+func GetAllTarget(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := config.DB.Preload("Afdeling").Preload("Mandor").Preload("Peta").Preload("PolaDistribusi").
+		Order("tahun asc, afdeling_id asc, bulan asc, tipe_produksi asc, id asc")
+
+	if tahun := q.Get("tahun"); tahun != "" {
+		query = query.Where("tahun = ?", tahun)
+	}
+	if bulan := q.Get("bulan"); bulan != "" {
+		query = query.Where("bulan = ?", bulan)
+	}
+	if tipe := q.Get("tipeProduksi"); tipe != "" {
+		query = query.Where("tipe_produksi = ?", strings.ToUpper(tipe))
+	}
+	if afdeling := q.Get("afdeling"); afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		query = query.Where("afdeling_id = ?", afd.ID)
+	}
+
+	var list []models.TargetProduksi
+	if err := query.Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data target: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    list,
+	})
+}
+
+func CreateTarget(w http.ResponseWriter, r *http.Request) {
+	var input targetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	var t models.TargetProduksi
+	if err := terapkanInputTarget(config.DB, input, &t); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if lain, err := cariTargetSama(config.DB, t); err == nil && lain.ID != 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Target untuk kombinasi ini sudah ada (id %d)", lain.ID),
+		})
+		return
+	}
+
+	if err := config.DB.Create(&t).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan target: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Target berhasil ditambahkan",
+		Data:    t,
+	})
+}
+
+func UpdateTarget(w http.ResponseWriter, r *http.Request) {
+	var t models.TargetProduksi
+	if err := config.DB.First(&t, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data target tidak ditemukan",
+		})
+		return
+	}
+
+	var input targetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if err := terapkanInputTarget(config.DB, input, &t); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if lain, err := cariTargetSama(config.DB, t); err == nil && lain.ID != 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: fmt.Sprintf("Target untuk kombinasi ini sudah ada (id %d)", lain.ID),
+		})
+		return
+	}
+
+	if err := config.DB.Save(&t).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal update target: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Target berhasil diperbarui",
+		Data:    t,
+	})
+}
+
+func DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "ID tidak valid",
+		})
+		return
+	}
+
+	result := config.DB.Delete(&models.TargetProduksi{}, id)
+	if result.Error != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menghapus target: " + result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Data target tidak ditemukan",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Target berhasil dihapus",
+	})
+}
+
+// ================== API POLA DISTRIBUSI ==================
+
+func terapkanInputPola(input polaDistribusiInput, p *models.PolaDistribusi) error {
+	p.Kode = strings.ToUpper(strings.TrimSpace(input.Kode))
+	p.Nama = strings.TrimSpace(input.Nama)
+	p.BobotBulan = strings.ReplaceAll(input.BobotBulan, " ", "")
+	p.BobotHari = strings.ReplaceAll(input.BobotHari, " ", "")
+	if p.Kode == "" || p.Nama == "" {
+		return fmt.Errorf("Field 'kode' dan 'nama' wajib diisi")
+	}
+	_, _, err := p.Bobot()
+	return err
+}
+
+func GetAllPolaDistribusi(w http.ResponseWriter, r *http.Request) {
+	var list []models.PolaDistribusi
+	if err := config.DB.Order("kode asc").Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil pola distribusi: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Data berhasil diambil",
+		Data:    list,
+	})
+}
+
+func CreatePolaDistribusi(w http.ResponseWriter, r *http.Request) {
+	var input polaDistribusiInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	var p models.PolaDistribusi
+	if err := terapkanInputPola(input, &p); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.PolaDistribusi{}).Where("kode = ?", p.Kode).Count(&count)
+	if count > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Kode pola sudah digunakan",
+		})
+		return
+	}
+
+	if err := config.DB.Create(&p).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal menyimpan pola distribusi: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Pola distribusi berhasil ditambahkan",
+		Data:    p,
+	})
+}
+
+func UpdatePolaDistribusi(w http.ResponseWriter, r *http.Request) {
+	var p models.PolaDistribusi
+	if err := config.DB.First(&p, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{
+			Success: false,
+			Message: "Pola distribusi tidak ditemukan",
+		})
+		return
+	}
+
+	var input polaDistribusiInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format JSON tidak valid: " + err.Error(),
+		})
+		return
+	}
+
+	if err := terapkanInputPola(input, &p); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.PolaDistribusi{}).Where("kode = ? AND id <> ?", p.Kode, p.ID).Count(&count)
+	if count > 0 {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Kode pola sudah digunakan",
+		})
+		return
+	}
+
+	if err := config.DB.Save(&p).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal update pola distribusi: " + err.Error(),
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Pola distribusi berhasil diperbarui",
+		Data:    p,
+	})
+}
+
+// ================== EKSPOR/IMPOR EXCEL ==================
+
+// ExportTarget mengunduh target satu tahun (parameter tahun, default tahun
+// ini) dengan kolom yang sama dengan file impor
+func ExportTarget(w http.ResponseWriter, r *http.Request) {
+	tahun := time.Now().Year()
+	if s := r.URL.Query().Get("tahun"); s != "" {
+		t, err := strconv.Atoi(s)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Parameter tahun tidak valid",
+			})
+			return
+		}
+		tahun = t
+	}
+
+	var list []models.TargetProduksi
+	if err := config.DB.Preload("Afdeling").Preload("Mandor").Preload("Peta").Preload("PolaDistribusi").
+		Where("tahun = ?", tahun).
+		Order("afdeling_id asc, bulan asc, tipe_produksi asc, id asc").
+		Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data target: " + err.Error(),
+		})
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Target"
+	f.SetSheetName("Sheet1", sheet)
+	for i, judul := range kolomTargetExcel {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, judul)
+	}
+	for i, t := range list {
+		nilai := []interface{}{t.Tahun, t.Bulan, "", t.TipeProduksi, "", "", t.KeringKg, "", t.Keterangan}
+		if t.Afdeling != nil {
+			nilai[2] = t.Afdeling.Kode
+		}
+		if t.Mandor != nil {
+			nilai[4] = t.Mandor.NIK
+		}
+		if t.Peta != nil {
+			nilai[5] = t.Peta.Code
+		}
+		if t.PolaDistribusi != nil {
+			nilai[7] = t.PolaDistribusi.Kode
+		}
+		for j, v := range nilai {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheet, cell, v)
+		}
+	}
+	f.SetColWidth(sheet, "A", "B", 8)
+	f.SetColWidth(sheet, "C", "I", 20)
+
+	filename := fmt.Sprintf("target_rkap_%d.xlsx", tahun)
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	if err := f.Write(w); err != nil {
+		log.Printf("Error writing %s: %v", filename, err)
+	}
+}
+
+// barisKeInputTarget mengubah satu baris Excel menjadi targetInput
+func barisKeInputTarget(row []string, indeks map[string]int) (targetInput, error) {
+	ambil := func(kolom string) string {
+		if i, ok := indeks[kolom]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	angka := func(kolom string) (float64, error) {
+		s := ambil(kolom)
+		if s == "" {
+			return 0, nil
+		}
+		v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+		if err != nil {
+			return 0, fmt.Errorf("kolom '%s' harus angka", kolom)
+		}
+		return v, nil
+	}
+
+	input := targetInput{
+		Afdeling:     ambil("Afdeling"),
+		TipeProduksi: ambil("Tipe Produksi"),
+		NIKMandor:    ambil("NIK Mandor"),
+		KodeBlok:     ambil("Kode Blok"),
+		Pola:         ambil("Pola"),
+		Keterangan:   ambil("Keterangan"),
+	}
+	tahun, err := angka("Tahun")
+	if err != nil {
+		return input, err
+	}
+	bulan, err := angka("Bulan")
+	if err != nil {
+		return input, err
+	}
+	if input.KeringKg, err = angka("Target Kering (kg)"); err != nil {
+		return input, err
+	}
+	input.Tahun, input.Bulan = int(tahun), int(bulan)
+	return input, nil
+}
+
+// ImportTarget membaca target dari file Excel (form field "file") dengan
+// kolom seperti hasil ExportTarget. Target dengan kombinasi yang sama
+// diperbarui, selebihnya dibuat. Baris yang tidak valid dilaporkan tanpa
+// membatalkan baris lain.
+func ImportTarget(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(MaxFileSize); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File terlalu besar atau format tidak valid",
+		})
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File Excel wajib diunggah",
+		})
+		return
+	}
+	defer file.Close()
+
+	if ext := strings.ToLower(filepath.Ext(header.Filename)); ext != ".xlsx" {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Format file harus .xlsx",
+		})
+		return
+	}
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "File Excel tidak valid: " + err.Error(),
+		})
+		return
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0), excelize.Options{RawCellValue: true})
+	if err != nil || len(rows) == 0 {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Sheet pertama kosong atau tidak bisa dibaca",
+		})
+		return
+	}
+
+	indeks := make(map[string]int)
+	for i, h := range rows[0] {
+		for _, kolom := range kolomTargetExcel {
+			if strings.EqualFold(strings.TrimSpace(h), kolom) {
+				indeks[kolom] = i
+			}
+		}
+	}
+	for _, wajib := range []string{"Tahun", "Afdeling", "Target Kering (kg)"} {
+		if _, ok := indeks[wajib]; !ok {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: fmt.Sprintf("Kolom '%s' tidak ditemukan di baris pertama", wajib),
+			})
+			return
+		}
+	}
+
+	db := config.GetDB()
+	hasil := HasilImporTarget{Gagal: []BarisGagalImpor{}}
+	for i, row := range rows[1:] {
+		nomor := i + 2
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		hasil.JumlahBaris++
+
+		input, err := barisKeInputTarget(row, indeks)
+		kode := strings.TrimSpace(input.Afdeling + " " + input.TipeProduksi)
+		if err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: kode, Pesan: err.Error()})
+			continue
+		}
+
+		var t models.TargetProduksi
+		if err := terapkanInputTarget(db, input, &t); err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: kode, Pesan: err.Error()})
+			continue
+		}
+		lama, err := cariTargetSama(db, t)
+		if err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: kode, Pesan: err.Error()})
+			continue
+		}
+		if lama.ID != 0 {
+			t.ID, t.CreatedAt = lama.ID, lama.CreatedAt
+		}
+
+		baru := t.ID == 0
+		if err := db.Save(&t).Error; err != nil {
+			hasil.Gagal = append(hasil.Gagal, BarisGagalImpor{Baris: nomor, Kode: kode, Pesan: err.Error()})
+			continue
+		}
+		if baru {
+			hasil.Dibuat++
+		} else {
+			hasil.Diperbarui++
+		}
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: len(hasil.Gagal) == 0,
+		Message: fmt.Sprintf("Impor selesai: %d dibuat, %d diperbarui, %d gagal",
+			hasil.Dibuat, hasil.Diperbarui, len(hasil.Gagal)),
+		Data: hasil,
+	})
+}
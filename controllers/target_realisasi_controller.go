@@ -0,0 +1,561 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CapaianTarget membandingkan realisasi kering (kg) dengan target pada satu
+// periode. Capaian nil jika periode tersebut tidak punya target.
+type CapaianTarget struct {
+	Realisasi float64  `json:"realisasi"`
+	Target    float64  `json:"target"`
+	Varians   float64  `json:"varians"`
+	Capaian   *float64 `json:"capaian_persen"`
+}
+
+// RealisasiTarget adalah realisasi vs target satu kelompok target untuk
+// hari ini, s/d hari ini (awal bulan sampai tanggal) dan s/d bulan ini
+// (awal tahun sampai tanggal)
+type RealisasiTarget struct {
+	AfdelingID     uint          `json:"afdeling_id"`
+	Afdeling       string        `json:"afdeling"`
+	TipeProduksi   string        `json:"tipe_produksi"`
+	MandorID       *uint         `json:"mandor_id,omitempty"`
+	Mandor         string        `json:"mandor,omitempty"`
+	PetaID         *uint         `json:"peta_id,omitempty"`
+	Blok           string        `json:"blok,omitempty"`
+	HariIni        CapaianTarget `json:"hari_ini"`
+	SampaiHariIni  CapaianTarget `json:"sampai_hari_ini"`
+	SampaiBulanIni CapaianTarget `json:"sampai_bulan_ini"`
+}
+
+func hitungCapaian(realisasi, target float64) CapaianTarget {
+	c := CapaianTarget{
+		Realisasi: roundTo(realisasi, 2),
+		Target:    roundTo(target, 2),
+		Varians:   roundTo(realisasi-target, 2),
+	}
+	if target > 0 {
+		persen := roundTo(realisasi/target*100, 2)
+		c.Capaian = &persen
+	}
+	return c
+}
+
+// ================== PEMECAHAN TARGET ==================
+
+type bobotPola struct {
+	bulan, hari []float64
+}
+
+// bobotPolaRata dipakai untuk target tanpa pola: semua bulan dan hari sama
+var bobotPolaRata = bobotPola{
+	bulan: []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+	hari:  []float64{1, 1, 1, 1, 1, 1, 1},
+}
+
+func muatPolaDistribusi(db *gorm.DB) (map[uint]bobotPola, error) {
+	var list []models.PolaDistribusi
+	if err := db.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	hasil := make(map[uint]bobotPola, len(list))
+	for _, p := range list {
+		bulan, hari, err := p.Bobot()
+		if err != nil {
+			log.Printf("⚠️ Pola distribusi %s diabaikan: %v", p.Kode, err)
+			continue
+		}
+		hasil[p.ID] = bobotPola{bulan: bulan, hari: hari}
+	}
+	return hasil, nil
+}
+
+func bobotTarget(t *models.TargetProduksi, pola map[uint]bobotPola) bobotPola {
+	if t.PolaDistribusiID != nil {
+		if b, ok := pola[*t.PolaDistribusiID]; ok {
+			return b
+		}
+	}
+	return bobotPolaRata
+}
+
+func jumlahBobot(b []float64) float64 {
+	total := 0.0
+	for _, v := range b {
+		total += v
+	}
+	return total
+}
+
+// kunciTarget mengelompokkan target tahunan dan bulanan yang berlaku untuk
+// afdeling, tipe, mandor dan blok yang sama (0 berarti tidak diisi)
+type kunciTarget struct {
+	AfdelingID   uint
+	TipeProduksi string
+	MandorID     uint
+	PetaID       uint
+}
+
+type kelompokTarget struct {
+	kunciTarget
+	contoh  *models.TargetProduksi
+	tahunan map[int]*models.TargetProduksi
+	bulanan map[[2]int]*models.TargetProduksi
+}
+
+func kunciDariTarget(t *models.TargetProduksi) kunciTarget {
+	k := kunciTarget{AfdelingID: t.AfdelingID, TipeProduksi: t.TipeProduksi}
+	if t.MandorID != nil {
+		k.MandorID = *t.MandorID
+	}
+	if t.PetaID != nil {
+		k.PetaID = *t.PetaID
+	}
+	return k
+}
+
+// kelompokkanTarget mengelompokkan target dengan urutan tetap (afdeling,
+// tipe, lalu mandor/blok)
+func kelompokkanTarget(list []models.TargetProduksi) []*kelompokTarget {
+	indeks := make(map[kunciTarget]*kelompokTarget)
+	var hasil []*kelompokTarget
+	for i := range list {
+		t := &list[i]
+		k := kunciDariTarget(t)
+		g, ok := indeks[k]
+		if !ok {
+			g = &kelompokTarget{
+				kunciTarget: k,
+				contoh:      t,
+				tahunan:     make(map[int]*models.TargetProduksi),
+				bulanan:     make(map[[2]int]*models.TargetProduksi),
+			}
+			indeks[k] = g
+			hasil = append(hasil, g)
+		}
+		if t.Bulan == 0 {
+			g.tahunan[t.Tahun] = t
+		} else {
+			g.bulanan[[2]int{t.Tahun, t.Bulan}] = t
+		}
+	}
+	sort.SliceStable(hasil, func(i, j int) bool {
+		a, b := hasil[i].kunciTarget, hasil[j].kunciTarget
+		if a.AfdelingID != b.AfdelingID {
+			return a.AfdelingID < b.AfdelingID
+		}
+		if a.TipeProduksi != b.TipeProduksi {
+			return a.TipeProduksi < b.TipeProduksi
+		}
+		if a.MandorID != b.MandorID {
+			return a.MandorID < b.MandorID
+		}
+		return a.PetaID < b.PetaID
+	})
+	return hasil
+}
+
+// targetBulan mengembalikan target satu bulan beserta bobot hariannya.
+// Target bulanan menggantikan bagian bulan itu dari target tahunan.
+func (g *kelompokTarget) targetBulan(tahun, bulan int, pola map[uint]bobotPola) (float64, bobotPola) {
+	if t, ok := g.bulanan[[2]int{tahun, bulan}]; ok {
+		return t.KeringKg, bobotTarget(t, pola)
+	}
+	t, ok := g.tahunan[tahun]
+	if !ok {
+		return 0, bobotPolaRata
+	}
+	b := bobotTarget(t, pola)
+	return t.KeringKg * b.bulan[bulan-1] / jumlahBobot(b.bulan), b
+}
+
+// targetRentang memecah target ke hari menurut bobot hari pola lalu
+// menjumlahkan hari-hari dari awal sampai akhir (inklusif)
+func (g *kelompokTarget) targetRentang(awal, akhir time.Time, pola map[uint]bobotPola) float64 {
+	awal = time.Date(awal.Year(), awal.Month(), awal.Day(), 0, 0, 0, 0, time.UTC)
+	akhir = time.Date(akhir.Year(), akhir.Month(), akhir.Day(), 0, 0, 0, 0, time.UTC)
+
+	total := 0.0
+	for bulan := time.Date(awal.Year(), awal.Month(), 1, 0, 0, 0, 0, time.UTC); !bulan.After(akhir); bulan = bulan.AddDate(0, 1, 0) {
+		target, b := g.targetBulan(bulan.Year(), int(bulan.Month()), pola)
+		if target == 0 {
+			continue
+		}
+		bobotBulan, bobotRentang := 0.0, 0.0
+		for hari := bulan; hari.Month() == bulan.Month(); hari = hari.AddDate(0, 0, 1) {
+			// Weekday Minggu = 0, sedangkan bobot hari dimulai Senin
+			bobot := b.hari[(int(hari.Weekday())+6)%7]
+			bobotBulan += bobot
+			if !hari.Before(awal) && !hari.After(akhir) {
+				bobotRentang += bobot
+			}
+		}
+		if bobotBulan > 0 {
+			total += target * bobotRentang / bobotBulan
+		}
+	}
+	return total
+}
+
+// ================== REALISASI ==================
+
+// barisRealisasi adalah kering jumlah dari rekap per afdeling, tipe dan
+// mandor pada satu periode
+type barisRealisasi struct {
+	AfdelingID   uint
+	TipeProduksi string
+	NIK          string
+	Mandor       string
+	Kering       float64
+}
+
+// periodeTarget adalah tiga periode capaian untuk satu tanggal
+type periodeTarget struct {
+	awal, akhir time.Time
+}
+
+func periodeCapaian(tanggal time.Time) [3]periodeTarget {
+	return [3]periodeTarget{
+		{tanggal, tanggal},
+		{time.Date(tanggal.Year(), tanggal.Month(), 1, 0, 0, 0, 0, tanggal.Location()), tanggal},
+		{time.Date(tanggal.Year(), 1, 1, 0, 0, 0, 0, tanggal.Location()), tanggal},
+	}
+}
+
+// penghitungRealisasi menyimpan hasil query per periode agar setiap
+// kelompok target tidak memicu query baru
+type penghitungRealisasi struct {
+	db      *gorm.DB
+	rekap   map[periodeTarget][]barisRealisasi
+	blok    map[string]map[uint]float64
+	mandors map[uint]models.Mandor
+}
+
+func newPenghitungRealisasi(db *gorm.DB) *penghitungRealisasi {
+	return &penghitungRealisasi{
+		db:      db,
+		rekap:   make(map[periodeTarget][]barisRealisasi),
+		blok:    make(map[string]map[uint]float64),
+		mandors: make(map[uint]models.Mandor),
+	}
+}
+
+func (p *penghitungRealisasi) barisRekap(per periodeTarget) ([]barisRealisasi, error) {
+	if baris, ok := p.rekap[per]; ok {
+		return baris, nil
+	}
+	var baris []barisRealisasi
+	err := p.db.Model(&models.Rekap{}).
+		Select("afdeling_id, tipe_produksi, nik, mandor, COALESCE(SUM(hari_ini_kering_jumlah), 0) as kering").
+		Where("tipe_produksi != ? AND afdeling_id IS NOT NULL AND DATE(tanggal) BETWEEN ? AND ?",
+			"REKAPITULASI", per.awal.Format("2006-01-02"), per.akhir.Format("2006-01-02")).
+		Group("afdeling_id, tipe_produksi, nik, mandor").
+		Scan(&baris).Error
+	if err != nil {
+		return nil, err
+	}
+	p.rekap[per] = baris
+	return baris, nil
+}
+
+func (p *penghitungRealisasi) keringBlok(g *kelompokTarget, per periodeTarget) (float64, error) {
+	kunci := fmt.Sprintf("%d|%s|%s|%s", g.AfdelingID, g.TipeProduksi, per.awal.Format("2006-01-02"), per.akhir.Format("2006-01-02"))
+	perBlok, ok := p.blok[kunci]
+	if !ok {
+		hasil, err := hitungProduksiBlok(p.db, filterProduksiBlok{
+			Awal:         per.awal,
+			Akhir:        per.akhir,
+			AfdelingID:   g.AfdelingID,
+			TipeProduksi: g.TipeProduksi,
+			Sumber:       sumberDataRekap,
+		})
+		if err != nil {
+			return 0, err
+		}
+		perBlok = make(map[uint]float64, len(hasil.Blok))
+		for _, b := range hasil.Blok {
+			perBlok[b.PetaID] = b.Kering
+		}
+		p.blok[kunci] = perBlok
+	}
+	return perBlok[g.PetaID], nil
+}
+
+func (p *penghitungRealisasi) mandor(id uint) (models.Mandor, error) {
+	if m, ok := p.mandors[id]; ok {
+		return m, nil
+	}
+	var m models.Mandor
+	if err := p.db.First(&m, id).Error; err != nil {
+		return m, err
+	}
+	p.mandors[id] = m
+	return m, nil
+}
+
+// realisasi menjumlahkan kering kelompok target pada satu periode. Mandor
+// dicocokkan lewat NIK, atau nama jika NIK mandor kosong.
+func (p *penghitungRealisasi) realisasi(g *kelompokTarget, per periodeTarget) (float64, error) {
+	if g.PetaID != 0 {
+		return p.keringBlok(g, per)
+	}
+
+	var nik, nama string
+	if g.MandorID != 0 {
+		m, err := p.mandor(g.MandorID)
+		if err != nil {
+			return 0, err
+		}
+		nik, nama = strings.TrimSpace(m.NIK), strings.ToUpper(strings.TrimSpace(m.Nama))
+	}
+
+	baris, err := p.barisRekap(per)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, b := range baris {
+		if b.AfdelingID != g.AfdelingID {
+			continue
+		}
+		if g.TipeProduksi != "" && b.TipeProduksi != g.TipeProduksi {
+			continue
+		}
+		if g.MandorID != 0 {
+			if nik != "" && strings.TrimSpace(b.NIK) != nik {
+				continue
+			}
+			if nik == "" && strings.ToUpper(strings.TrimSpace(b.Mandor)) != nama {
+				continue
+			}
+		}
+		total += b.Kering
+	}
+	return total, nil
+}
+
+// capaianKelompok menghitung ketiga periode untuk satu kelompok target
+func (p *penghitungRealisasi) capaianKelompok(g *kelompokTarget, tanggal time.Time, pola map[uint]bobotPola) (RealisasiTarget, error) {
+	hasil := RealisasiTarget{AfdelingID: g.AfdelingID, TipeProduksi: g.TipeProduksi}
+	if g.contoh.Afdeling != nil {
+		hasil.Afdeling = g.contoh.Afdeling.Nama
+	}
+	if g.MandorID != 0 {
+		hasil.MandorID = g.contoh.MandorID
+		if g.contoh.Mandor != nil {
+			hasil.Mandor = g.contoh.Mandor.Nama
+		}
+	}
+	if g.PetaID != 0 {
+		hasil.PetaID = g.contoh.PetaID
+		if g.contoh.Peta != nil {
+			hasil.Blok = g.contoh.Peta.Code
+		}
+	}
+
+	var capaian [3]CapaianTarget
+	for i, per := range periodeCapaian(tanggal) {
+		realisasi, err := p.realisasi(g, per)
+		if err != nil {
+			return hasil, err
+		}
+		capaian[i] = hitungCapaian(realisasi, g.targetRentang(per.awal, per.akhir, pola))
+	}
+	hasil.HariIni, hasil.SampaiHariIni, hasil.SampaiBulanIni = capaian[0], capaian[1], capaian[2]
+	return hasil, nil
+}
+
+// muatTargetTahun mengambil target satu tahun, opsional untuk satu afdeling
+func muatTargetTahun(db *gorm.DB, tahun int, afdelingID uint) ([]models.TargetProduksi, error) {
+	query := db.Preload("Afdeling").Preload("Mandor").Preload("Peta").
+		Where("tahun = ?", tahun).
+		Order("afdeling_id asc, tipe_produksi asc, bulan asc, id asc")
+	if afdelingID != 0 {
+		query = query.Where("afdeling_id = ?", afdelingID)
+	}
+	var list []models.TargetProduksi
+	err := query.Find(&list).Error
+	return list, err
+}
+
+// targetAfdeling menghitung capaian total satu afdeling dari target tingkat
+// afdeling (tanpa mandor dan blok). Target semua tipe dipakai jika ada,
+// selain itu target per tipe dijumlahkan. Nil jika afdeling tidak punya
+// target pada tahun tersebut.
+func targetAfdeling(db *gorm.DB, afd *models.Afdeling, tanggal time.Time) (*RealisasiTarget, error) {
+	list, err := muatTargetTahun(db, tanggal.Year(), afd.ID)
+	if err != nil {
+		return nil, err
+	}
+	var kelompok []*kelompokTarget
+	for _, g := range kelompokkanTarget(list) {
+		if g.MandorID == 0 && g.PetaID == 0 {
+			kelompok = append(kelompok, g)
+		}
+	}
+	if len(kelompok) == 0 {
+		return nil, nil
+	}
+	for _, g := range kelompok {
+		if g.TipeProduksi == "" {
+			kelompok = []*kelompokTarget{g}
+			break
+		}
+	}
+
+	pola, err := muatPolaDistribusi(db)
+	if err != nil {
+		return nil, err
+	}
+	p := newPenghitungRealisasi(db)
+	var realisasi, target [3]float64
+	for _, g := range kelompok {
+		for i, per := range periodeCapaian(tanggal) {
+			r, err := p.realisasi(g, per)
+			if err != nil {
+				return nil, err
+			}
+			realisasi[i] += r
+			target[i] += g.targetRentang(per.awal, per.akhir, pola)
+		}
+	}
+
+	return &RealisasiTarget{
+		AfdelingID:     afd.ID,
+		Afdeling:       afd.Nama,
+		HariIni:        hitungCapaian(realisasi[0], target[0]),
+		SampaiHariIni:  hitungCapaian(realisasi[1], target[1]),
+		SampaiBulanIni: hitungCapaian(realisasi[2], target[2]),
+	}, nil
+}
+
+// GetRealisasiTarget mengembalikan realisasi vs target untuk setiap
+// kelompok target pada tahun tanggal yang diminta. Parameter: tanggal
+// (default hari ini), afdeling dan tipeProduksi (opsional), format
+// (xlsx/csv untuk unduhan).
+func GetRealisasiTarget(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format, err := formatLaporan(r)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	tanggal, err := parseTanggalParam(r, "tanggal", time.Now().Truncate(24*time.Hour))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	var afdelingID uint
+	namaAfdeling := "Semua"
+	if afdeling := q.Get("afdeling"); afdeling != "" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+			return
+		}
+		afdelingID, namaAfdeling = afd.ID, afd.Nama
+	}
+	tipe, err := normalisasiTipeTarget(q.Get("tipeProduksi"))
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+
+	db := config.GetDB()
+	list, err := muatTargetTahun(db, tanggal.Year(), afdelingID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil data target: " + err.Error(),
+		})
+		return
+	}
+	pola, err := muatPolaDistribusi(db)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{
+			Success: false,
+			Message: "Gagal mengambil pola distribusi: " + err.Error(),
+		})
+		return
+	}
+
+	p := newPenghitungRealisasi(db)
+	hasil := []RealisasiTarget{}
+	for _, g := range kelompokkanTarget(list) {
+		if q.Get("tipeProduksi") != "" && g.TipeProduksi != tipe {
+			continue
+		}
+		c, err := p.capaianKelompok(g, tanggal, pola)
+		if err != nil {
+			respondJSON(w, http.StatusInternalServerError, APIResponse{
+				Success: false,
+				Message: "Gagal menghitung realisasi: " + err.Error(),
+			})
+			return
+		}
+		hasil = append(hasil, c)
+	}
+
+	if format != "" {
+		kirimLaporan(w, r, format, "realisasi_target", tabelRealisasiTarget(hasil, namaAfdeling, tanggal))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Realisasi target per %s", tanggal.Format("2006-01-02")),
+		Data:    hasil,
+	})
+}
+
+// nilaiCapaian mengosongkan sel capaian jika periode tidak punya target
+func nilaiCapaian(c CapaianTarget) interface{} {
+	if c.Capaian == nil {
+		return nil
+	}
+	return *c.Capaian
+}
+
+func tabelRealisasiTarget(list []RealisasiTarget, afdeling string, tanggal time.Time) TabelLaporan {
+	kolom := []KolomLaporan{{Judul: "Afdeling"}, {Judul: "Tipe Produksi"}, {Judul: "Mandor"}, {Judul: "Blok"}}
+	for _, periode := range []string{"Hari Ini", "S/D Hari Ini", "S/D Bulan Ini"} {
+		kolom = append(kolom,
+			KolomLaporan{Judul: "Realisasi " + periode + " (kg)", Jenis: kolomAngka},
+			KolomLaporan{Judul: "Target " + periode + " (kg)", Jenis: kolomAngka},
+			KolomLaporan{Judul: "Varians " + periode + " (kg)", Jenis: kolomAngka},
+			KolomLaporan{Judul: "Capaian " + periode + " (%)", Jenis: kolomPersen},
+		)
+	}
+
+	baris := make([][]interface{}, 0, len(list))
+	for _, t := range list {
+		tipe := t.TipeProduksi
+		if tipe == "" {
+			tipe = "SEMUA"
+		}
+		b := []interface{}{t.Afdeling, tipe, t.Mandor, t.Blok}
+		for _, c := range []CapaianTarget{t.HariIni, t.SampaiHariIni, t.SampaiBulanIni} {
+			b = append(b, c.Realisasi, c.Target, c.Varians, nilaiCapaian(c))
+		}
+		baris = append(baris, b)
+	}
+
+	return TabelLaporan{
+		Judul:  "Realisasi vs Target Produksi",
+		Filter: []string{"Afdeling: " + afdeling, "Tanggal: " + tanggal.Format("2006-01-02")},
+		Kolom:  kolom,
+		Baris:  baris,
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 )
 
-// UpdatePenyadapMandor akan memanggil dua fungsi pembaruan data
+// UpdatePenyadapMandor akan memanggil fungsi pembaruan data penyadap, mandor,
+// lalu riwayat penugasan penyadap ke mandor
 func UpdatePenyadapMandor(idMaster uint64) {
 	updatePenyadap(idMaster)
 	updateMandor(idMaster)
+	updatePenugasan(idMaster)
 }
 
 // ------------------------------------------
@@ -75,7 +77,7 @@ func updateMandor(idMaster uint64) {
 
 	for _, mandor := range uniqueMandor {
 		var existing models.Mandor
-		err := db.Where("nik = ? AND tahun_tanam", mandor.NIK, mandor.TahunTanam).First(&existing).Error
+		err := db.Where("nik = ? AND tahun_tanam = ?", mandor.NIK, mandor.TahunTanam).First(&existing).Error
 		if err != nil {
 			if err := db.Create(&mandor).Error; err != nil {
 				fmt.Println("Gagal menambahkan mandor:", err)
@@ -83,3 +85,92 @@ func updateMandor(idMaster uint64) {
 		}
 	}
 }
+
+// ------------------------------------------
+// Update Penugasan berdasarkan data Produksi
+// ------------------------------------------
+// Nama mandor di sheet produksi sering salah ketik, jadi dicocokkan ke mandor
+// di sheet REKAP master yang sama (yang punya NIK) dengan pencocokan nama mirip.
+func updatePenugasan(idMaster uint64) {
+	db := config.GetDB()
+
+	var master models.Master
+	if err := db.First(&master, idMaster).Error; err != nil {
+		fmt.Println("Gagal mengambil data master:", err)
+		return
+	}
+
+	var produksis []models.Produksi
+	if err := db.Where("id_master = ?", idMaster).Find(&produksis).Error; err != nil {
+		fmt.Println("Gagal mengambil data produksi:", err)
+		return
+	}
+
+	var kandidat []models.Mandor
+	if err := db.Model(&models.Rekap{}).
+		Select("DISTINCT nik, mandor AS nama, tahun_tanam").
+		Where("id_master = ? AND tipe_produksi != ?", idMaster, "REKAPITULASI").
+		Scan(&kandidat).Error; err != nil {
+		fmt.Println("Gagal mengambil mandor dari rekap:", err)
+		return
+	}
+	if len(kandidat) == 0 {
+		// Master tanpa sheet REKAP: pakai daftar mandor yang sudah dikenal
+		db.Find(&kandidat)
+	}
+
+	mandorIDs := make(map[string]uint)
+	penyadapIDs := make(map[string]uint)
+	done := make(map[string]bool)
+	tidakCocok := make(map[string]bool)
+
+	for _, p := range produksis {
+		mandor, ok := cocokkanMandor(p.Mandor, p.TahunTanam, kandidat)
+		if !ok {
+			tidakCocok[p.Mandor+" ("+p.TahunTanam+")"] = true
+			continue
+		}
+
+		mandorKey := mandor.NIK + "|" + mandor.TahunTanam
+		mandorID, ok := mandorIDs[mandorKey]
+		if !ok {
+			var m models.Mandor
+			if err := db.Where("nik = ? AND tahun_tanam = ?", mandor.NIK, mandor.TahunTanam).First(&m).Error; err != nil {
+				continue
+			}
+			mandorID = m.ID
+			mandorIDs[mandorKey] = mandorID
+		}
+
+		penyadapID, ok := penyadapIDs[p.NIK]
+		if !ok {
+			var penyadap models.Penyadap
+			if err := db.Where("nik = ?", p.NIK).First(&penyadap).Error; err != nil {
+				continue
+			}
+			penyadapID = penyadap.ID
+			penyadapIDs[p.NIK] = penyadapID
+		}
+
+		key := fmt.Sprintf("%d|%d|%s|%s", penyadapID, mandorID, mandor.TahunTanam, p.Tanggal.Format("2006-01-02"))
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		pm := models.PenyadapMandor{
+			PenyadapID: penyadapID,
+			MandorID:   mandorID,
+			AfdelingID: master.AfdelingID,
+			TahunTanam: mandor.TahunTanam,
+			Sumber:     models.SumberPenugasanImpor,
+		}
+		if err := catatPenugasan(db, pm, p.Tanggal); err != nil {
+			fmt.Println("Gagal mencatat penugasan:", err)
+		}
+	}
+
+	for nama := range tidakCocok {
+		fmt.Printf("⚠️  Mandor '%s' di sheet produksi tidak cocok dengan mandor mana pun\n", nama)
+	}
+}
@@ -51,6 +51,15 @@ func CreateUpload(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	afd, err := resolveAfdeling(afdeling, true)
+	if err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{
+			Success: false,
+			Message: "Afdeling tidak valid: " + err.Error(),
+		})
+		return
+	}
+	afdeling = afd.Nama
 
 	// Get tanggal from form
 	tanggalStr := r.FormValue("tanggal")
@@ -162,6 +171,13 @@ func CreateUpload(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if r := recover(); r != nil {
 				log.Printf("Panic recovered in background process: %v", r)
+				umumkanImpor(PeristiwaImporGagal, afdeling, "Impor "+header.Filename+" gagal", map[string]interface{}{
+					"upload_id": upload.ID,
+					"file_name": header.Filename,
+					"tanggal":   tanggal.Format("2006-01-02"),
+					"afdeling":  afdeling,
+					"kesalahan": []string{fmt.Sprint(r)},
+				})
 			}
 		}()
 
@@ -177,6 +193,13 @@ func CreateUpload(w http.ResponseWriter, r *http.Request) {
 
 		if err := excelToCSV(uploadPath, "csv", tanggal, afdeling, header.Filename); err != nil {
 			log.Printf("Error converting Excel to CSV: %v", err)
+			umumkanImpor(PeristiwaImporGagal, afdeling, "Impor "+header.Filename+" gagal", map[string]interface{}{
+				"upload_id": upload.ID,
+				"file_name": header.Filename,
+				"tanggal":   tanggal.Format("2006-01-02"),
+				"afdeling":  afdeling,
+				"kesalahan": []string{err.Error()},
+			})
 			return
 		}
 
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Granularitas pengelompokan seri visualisasi
+const (
+	granularitasHari    = "day"
+	granularitasMinggu  = "week"
+	granularitasBulan   = "month"
+	granularitasKuartal = "quarter"
+	granularitasTahun   = "year"
+)
+
+// Batas jendela rata-rata bergerak (dalam jumlah titik)
+const maksRataBergerak = 365
+
+// opsiVisualisasi adalah pengaturan seri yang sama untuk visualisasi rekap
+// dan produksi
+type opsiVisualisasi struct {
+	Granularitas string
+	RataBergerak int
+}
+
+// parseOpsiVisualisasi membaca parameter granularity (day, week, month,
+// quarter, year; default day) dan movingAverage (jumlah titik, 0 = tanpa
+// rata-rata bergerak)
+func parseOpsiVisualisasi(r *http.Request) (opsiVisualisasi, error) {
+	q := r.URL.Query()
+	return buatOpsiVisualisasi(q.Get("granularity"), q.Get("movingAverage"))
+}
+
+func buatOpsiVisualisasi(granularitas, rataBergerak string) (opsiVisualisasi, error) {
+	opsi := opsiVisualisasi{Granularitas: granularitasHari}
+
+	switch granularitas {
+	case "":
+	case granularitasHari, granularitasMinggu, granularitasBulan, granularitasKuartal, granularitasTahun:
+		opsi.Granularitas = granularitas
+	default:
+		return opsi, fmt.Errorf("Parameter granularity tidak valid. Gunakan: day, week, month, quarter, atau year")
+	}
+
+	if rataBergerak != "" {
+		n, err := strconv.Atoi(rataBergerak)
+		if err != nil || n < 0 || n > maksRataBergerak {
+			return opsi, fmt.Errorf("Parameter movingAverage harus angka 0-%d", maksRataBergerak)
+		}
+		opsi.RataBergerak = n
+	}
+	return opsi, nil
+}
+
+// kunciGranularitas mengubah tanggal menjadi label periode yang urut
+// sebagai teks: 2024-03-15, 2024-W11 (minggu ISO), 2024-03, 2024-Q1, 2024
+func kunciGranularitas(t time.Time, granularitas string) string {
+	switch granularitas {
+	case granularitasMinggu:
+		tahun, minggu := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", tahun, minggu)
+	case granularitasBulan:
+		return t.Format("2006-01")
+	case granularitasKuartal:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	case granularitasTahun:
+		return strconv.Itoa(t.Year())
+	}
+	return t.Format("2006-01-02")
+}
+
+// sumbuGranularitas membuat semua label periode dari awal sampai akhir
+// (inklusif) agar periode tanpa data tetap muncul di sumbu
+func sumbuGranularitas(awal, akhir time.Time, granularitas string) []string {
+	var label []string
+	for t := awal; !t.After(akhir); t = t.AddDate(0, 0, 1) {
+		k := kunciGranularitas(t, granularitas)
+		if len(label) == 0 || label[len(label)-1] != k {
+			label = append(label, k)
+		}
+	}
+	return label
+}
+
+// sumbuSeri membuat sumbu periode penuh untuk satu seri: rentang permintaan
+// awal..akhir, diperluas bila ada data di luarnya. Tanggal nol (parameter
+// tidak valid) diganti tanggal data pertama atau terakhir.
+func sumbuSeri(awal, akhir, pertama, terakhir time.Time, granularitas string) []string {
+	if awal.IsZero() || pertama.Before(awal) {
+		awal = pertama
+	}
+	if akhir.IsZero() || terakhir.After(akhir) {
+		akhir = terakhir
+	}
+	return sumbuGranularitas(awal, akhir, granularitas)
+}
+
+// petakanKeLabel mengambil nilai rata-rata bergerak pada sumbu penuh untuk
+// setiap label seri, yang hanya berisi periode dengan data
+func petakanKeLabel(sumbu []string, rata []*float64, label []string) []*float64 {
+	posisi := make(map[string]int, len(sumbu))
+	for i, k := range sumbu {
+		posisi[k] = i
+	}
+	hasil := make([]*float64, len(label))
+	for i, k := range label {
+		if j, ok := posisi[k]; ok {
+			hasil[i] = rata[j]
+		}
+	}
+	return hasil
+}
+
+// rataBergerak menghitung rata-rata n periode terakhir pada sumbu penuh untuk
+// nilai yang bisa dijumlahkan. nil berarti periode tanpa data dan tidak ikut
+// dirata-rata. Hasil nil sampai jendela mencakup n periode, dan juga nil bila
+// seluruh jendela kosong.
+func rataBergerak(nilai []*float64, n int) []*float64 {
+	hasil := make([]*float64, len(nilai))
+	jumlah, ada := 0.0, 0
+	for i, v := range nilai {
+		if v != nil {
+			jumlah += *v
+			ada++
+		}
+		if i >= n && nilai[i-n] != nil {
+			jumlah -= *nilai[i-n]
+			ada--
+		}
+		if i >= n-1 && ada > 0 {
+			rata := roundTo(jumlah/float64(ada), 2)
+			hasil[i] = &rata
+		}
+	}
+	return hasil
+}
+
+// rataBergerakRasio menghitung satuan rasio dari jumlah komponen n periode
+// terakhir pada sumbu penuh, sehingga periode dengan volume besar berbobot
+// lebih besar. Aturan nil sama dengan rataBergerak.
+func rataBergerakRasio(titik []*tempDataPoint, satuan string, n int) []*float64 {
+	hasil := make([]*float64, len(titik))
+	for i := n - 1; i < len(titik); i++ {
+		var jumlah tempDataPoint
+		ada := false
+		for _, t := range titik[i-n+1 : i+1] {
+			if t != nil {
+				jumlah.tambah(*t)
+				ada = true
+			}
+		}
+		if ada {
+			v := roundTo(jumlah.nilai(satuan), 2)
+			hasil[i] = &v
+		}
+	}
+	return hasil
+}
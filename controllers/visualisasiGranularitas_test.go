@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/models"
+	"testing"
+	"time"
+)
+
+func angka(v float64) *float64 { return &v }
+
+func samaNilai(a, b []*float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i] == nil) != (b[i] == nil) || (a[i] != nil && *a[i] != *b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func tampilNilai(v []*float64) []interface{} {
+	hasil := make([]interface{}, len(v))
+	for i, p := range v {
+		if p != nil {
+			hasil[i] = *p
+		}
+	}
+	return hasil
+}
+
+func TestRataBergerak(t *testing.T) {
+	tests := []struct {
+		nama  string
+		nilai []*float64
+		n     int
+		ingin []*float64
+	}{
+		{"penuh", []*float64{angka(1), angka(2), angka(3), angka(4)}, 2,
+			[]*float64{nil, angka(1.5), angka(2.5), angka(3.5)}},
+		{"periode kosong tidak ikut dirata-rata", []*float64{angka(10), nil, angka(20), nil, nil, nil}, 3,
+			[]*float64{nil, nil, angka(15), angka(20), angka(20), nil}},
+		{"jendela belum penuh", []*float64{angka(5), angka(5)}, 3,
+			[]*float64{nil, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := rataBergerak(tt.nilai, tt.n); !samaNilai(got, tt.ingin) {
+				t.Errorf("rataBergerak = %v, ingin %v", tampilNilai(got), tampilNilai(tt.ingin))
+			}
+		})
+	}
+}
+
+func TestRataBergerakRasio(t *testing.T) {
+	titik := []*tempDataPoint{
+		{HKO: 10, KeringJumlah: 100},
+		nil,
+		{HKO: 30, KeringJumlah: 600},
+		nil,
+	}
+	// Jendela 2: periode tanpa data tidak menurunkan rasio
+	ingin := []*float64{nil, angka(10), angka(20), angka(20)}
+	if got := rataBergerakRasio(titik, "produksi_per_taper", 2); !samaNilai(got, ingin) {
+		t.Errorf("rataBergerakRasio = %v, ingin %v", tampilNilai(got), tampilNilai(ingin))
+	}
+}
+
+// Data jarang: jendela 3 hari dihitung pada tanggal kalender, bukan 3 titik data
+func TestAggregateProduksiDataSumbuPenuh(t *testing.T) {
+	tgl := func(s string) time.Time {
+		v, _ := time.Parse("2006-01-02", s)
+		return v
+	}
+	produksi := []models.Produksi{
+		{Tanggal: tgl("2025-01-01"), BasahLatek: 30},
+		{Tanggal: tgl("2025-01-02"), BasahLatek: 60},
+		{Tanggal: tgl("2025-01-10"), BasahLatek: 90},
+	}
+	opsi := opsiVisualisasi{Granularitas: granularitasHari, RataBergerak: 3}
+
+	hasil := aggregateProduksiData(produksi, "basah_latek", opsi, tgl("2025-01-01"), tgl("2025-01-10"))
+	if len(hasil.Labels) != 3 || len(hasil.MovingAverage) != 3 {
+		t.Fatalf("labels %v, moving average %d titik", hasil.Labels, len(hasil.MovingAverage))
+	}
+	// 01-01 dan 01-02 belum punya jendela 3 hari; 01-10 hanya memuat dirinya
+	ingin := []*float64{nil, nil, angka(90)}
+	if !samaNilai(hasil.MovingAverage, ingin) {
+		t.Errorf("moving average = %v, ingin %v", tampilNilai(hasil.MovingAverage), tampilNilai(ingin))
+	}
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Batas jumlah seri dalam satu permintaan visualisasi multi
+const maksSeriVisualisasi = 12
+
+// SpesifikasiSeri adalah satu garis grafik dengan parameter yang sama
+// seperti /api/visualisasi. tipeData penyadap memakai data produksi,
+// selebihnya (total, afdeling, mandor) memakai rekap.
+type SpesifikasiSeri struct {
+	Nama         string `json:"nama"`
+	TipeData     string `json:"tipeData"`
+	Afdeling     string `json:"afdeling"`
+	IdMandor     int    `json:"idMandor"`
+	IdPenyadap   uint   `json:"idPenyadap"`
+	TipeProduksi string `json:"tipeProduksi"`
+	Satuan       string `json:"satuan"`
+}
+
+// visualisasiMultiRequest adalah body POST /api/visualisasi/multi
+type visualisasiMultiRequest struct {
+	TanggalAwal   string            `json:"tanggalAwal"`
+	TanggalAkhir  string            `json:"tanggalAkhir"`
+	Granularity   string            `json:"granularity"`
+	MovingAverage int               `json:"movingAverage"`
+	Series        []SpesifikasiSeri `json:"series"`
+}
+
+// SeriVisualisasi adalah nilai satu seri yang sejajar dengan Labels; nil
+// berarti tidak ada data pada periode tersebut
+type SeriVisualisasi struct {
+	Nama          string     `json:"nama"`
+	Sumber        string     `json:"sumber"`
+	TipeData      string     `json:"tipeData"`
+	Satuan        string     `json:"satuan"`
+	Data          []*float64 `json:"data"`
+	MovingAverage []*float64 `json:"moving_average,omitempty"`
+}
+
+type VisualisasiMultiResponse struct {
+	Labels []string          `json:"labels"`
+	Series []SeriVisualisasi `json:"series"`
+}
+
+// ambilSeriVisualisasi menjalankan satu spesifikasi seri dengan fungsi yang
+// sama seperti endpoint satu seri, lalu mengembalikan nilai per label. Untuk
+// sumber rekap komponen per label ikut dikembalikan agar rata-rata bergerak
+// satuan rasio bisa dihitung ulang pada sumbu bersama.
+func ambilSeriVisualisasi(s *SpesifikasiSeri, tanggalAwal, tanggalAkhir string, opsi opsiVisualisasi) (map[string]float64, map[string]*tempDataPoint, error) {
+	nilai := make(map[string]float64)
+
+	if s.TipeData == "penyadap" {
+		if s.IdPenyadap == 0 {
+			return nil, nil, fmt.Errorf("idPenyadap wajib diisi untuk tipeData 'penyadap'")
+		}
+		if !validSatuanVisualisasiProduksi[s.Satuan] {
+			return nil, nil, fmt.Errorf("satuan '%s' tidak valid untuk sumber produksi. Gunakan: basah_latek, sheet, basah_lump, br_cr, atau total_produksi", s.Satuan)
+		}
+		nik := getNikPenyadapById(s.IdPenyadap)
+		if nik == "" {
+			return nil, nil, fmt.Errorf("penyadap dengan ID %d tidak ditemukan", s.IdPenyadap)
+		}
+		if s.Nama == "" {
+			s.Nama = "Penyadap " + nik + " - " + s.Satuan
+		}
+		result, err := visualisasiProduksiPenyadap(nik, s.TipeProduksi, tanggalAwal, tanggalAkhir, s.Satuan, opsi)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, d := range result.Data {
+			nilai[d.Tanggal] = d.Value
+		}
+		return nilai, nil, nil
+	}
+
+	if !validSatuanRekap[s.Satuan] {
+		return nil, nil, fmt.Errorf("satuan '%s' tidak valid untuk sumber rekap", s.Satuan)
+	}
+
+	var afdelingID uint
+	if s.Afdeling != "" && s.Afdeling != "-" {
+		afd, err := resolveAfdeling(s.Afdeling, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		afdelingID = afd.ID
+	}
+
+	var result VisualisasiResponse
+	var err error
+	switch s.TipeData {
+	case "total":
+		if s.Nama == "" {
+			s.Nama = "Total - " + s.Satuan
+		}
+		result, err = visualisasiTotal(s.TipeProduksi, tanggalAwal, tanggalAkhir, s.Satuan, opsi)
+	case "afdeling":
+		if afdelingID == 0 {
+			return nil, nil, fmt.Errorf("afdeling wajib diisi untuk tipeData 'afdeling'")
+		}
+		if s.Nama == "" {
+			s.Nama = "Afdeling " + s.Afdeling + " - " + s.Satuan
+		}
+		result, err = visualisasiAfdeling(s.TipeProduksi, afdelingID, tanggalAwal, tanggalAkhir, s.Satuan, opsi)
+	case "mandor":
+		if s.IdMandor == 0 {
+			return nil, nil, fmt.Errorf("idMandor wajib diisi untuk tipeData 'mandor'")
+		}
+		nikMandor, tahunTanam, errMandor := getMandorByID(s.IdMandor)
+		if errMandor != nil {
+			return nil, nil, fmt.Errorf("mandor dengan ID %d tidak ditemukan", s.IdMandor)
+		}
+		if s.Nama == "" {
+			s.Nama = "Mandor " + nikMandor + " - " + s.Satuan
+		}
+		result, err = visualisasiMandor(s.TipeProduksi, afdelingID, nikMandor, tahunTanam, tanggalAwal, tanggalAkhir, s.Satuan, opsi)
+	default:
+		return nil, nil, fmt.Errorf("tipeData tidak valid. Gunakan: penyadap, mandor, total, atau afdeling")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, d := range result.Data {
+		nilai[d.Tanggal] = d.Value
+	}
+	return nilai, result.komponen, nil
+}
+
+// GetVisualisasiMulti menggabungkan beberapa seri visualisasi (rekap dan
+// produksi boleh dicampur) pada satu sumbu tanggal. Semua periode antara
+// tanggalAwal dan tanggalAkhir muncul di sumbu; periode tanpa data bernilai
+// null. Mendukung ?format=xlsx/csv.
+func GetVisualisasiMulti(w http.ResponseWriter, r *http.Request) {
+	format, err := formatLaporan(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req visualisasiMultiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Format JSON tidak valid: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	awal, errAwal := time.Parse("2006-01-02", req.TanggalAwal)
+	akhir, errAkhir := time.Parse("2006-01-02", req.TanggalAkhir)
+	if errAwal != nil || errAkhir != nil {
+		http.Error(w, "tanggalAwal dan tanggalAkhir wajib diisi (format: YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	if akhir.Before(awal) {
+		http.Error(w, "tanggalAkhir tidak boleh lebih awal dari tanggalAwal", http.StatusBadRequest)
+		return
+	}
+	if len(req.Series) == 0 || len(req.Series) > maksSeriVisualisasi {
+		http.Error(w, fmt.Sprintf("series wajib berisi 1-%d seri", maksSeriVisualisasi), http.StatusBadRequest)
+		return
+	}
+
+	// Validasi granularity dan movingAverage sama dengan endpoint satu seri
+	opsi, err := buatOpsiVisualisasi(req.Granularity, strconv.Itoa(req.MovingAverage))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := VisualisasiMultiResponse{
+		Labels: sumbuGranularitas(awal, akhir, opsi.Granularitas),
+		Series: make([]SeriVisualisasi, 0, len(req.Series)),
+	}
+
+	// Rata-rata bergerak dihitung di sini setelah seri disejajarkan ke sumbu
+	// bersama, bukan dari hasil satu seri
+	opsiSeri := opsi
+	opsiSeri.RataBergerak = 0
+
+	for i := range req.Series {
+		s := &req.Series[i]
+		nilai, komponen, err := ambilSeriVisualisasi(s, req.TanggalAwal, req.TanggalAkhir, opsiSeri)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Seri ke-%d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+
+		seri := SeriVisualisasi{
+			Nama:     s.Nama,
+			Sumber:   sumberDataRekap,
+			TipeData: s.TipeData,
+			Satuan:   s.Satuan,
+			Data:     make([]*float64, len(result.Labels)),
+		}
+		if s.TipeData == "penyadap" {
+			seri.Sumber = sumberDataProduksi
+		}
+		for j, label := range result.Labels {
+			if v, ok := nilai[label]; ok {
+				seri.Data[j] = &v
+			}
+		}
+		if opsi.RataBergerak > 1 {
+			if komponen != nil && satuanRasio[s.Satuan] {
+				titik := make([]*tempDataPoint, len(result.Labels))
+				for j, label := range result.Labels {
+					titik[j] = komponen[label]
+				}
+				seri.MovingAverage = rataBergerakRasio(titik, s.Satuan, opsi.RataBergerak)
+			} else {
+				seri.MovingAverage = rataBergerak(seri.Data, opsi.RataBergerak)
+			}
+		}
+		result.Series = append(result.Series, seri)
+	}
+
+	if format != "" {
+		kirimLaporan(w, r, format, "visualisasi_multi", tabelVisualisasiMulti(result, opsi, req.TanggalAwal, req.TanggalAkhir))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// tabelVisualisasiMulti menyusun ekspor satu baris per periode dan satu
+// kolom per seri
+func tabelVisualisasiMulti(result VisualisasiMultiResponse, opsi opsiVisualisasi, tanggalAwal, tanggalAkhir string) TabelLaporan {
+	kolomWaktu := KolomLaporan{Judul: "Tanggal", Jenis: kolomTanggal}
+	if opsi.Granularitas != granularitasHari {
+		kolomWaktu = KolomLaporan{Judul: "Periode"}
+	}
+	tabel := TabelLaporan{
+		Judul:  "Visualisasi Produksi",
+		Filter: []string{"Periode: " + tanggalAwal + " s/d " + tanggalAkhir, "Granularitas: " + opsi.Granularitas},
+		Kolom:  []KolomLaporan{kolomWaktu},
+	}
+	for _, s := range result.Series {
+		tabel.Kolom = append(tabel.Kolom, KolomLaporan{Judul: s.Nama, Jenis: kolomAngka})
+	}
+
+	for i, label := range result.Labels {
+		var waktu interface{} = label
+		if opsi.Granularitas == granularitasHari {
+			waktu, _ = time.Parse("2006-01-02", label)
+		}
+		baris := []interface{}{waktu}
+		for _, s := range result.Series {
+			var v interface{}
+			if s.Data[i] != nil {
+				v = *s.Data[i]
+			}
+			baris = append(baris, v)
+		}
+		tabel.Baris = append(tabel.Baris, baris)
+	}
+	return tabel
+}
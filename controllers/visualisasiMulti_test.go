@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config/configtest"
+	"app-inputan-ptpn/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Rata-rata bergerak seri multi dihitung pada sumbu bersama yang berisi null
+func TestVisualisasiMultiRataBergerakSetelahDisejajarkan(t *testing.T) {
+	db := configtest.InitTestDB(t)
+
+	penyadap := models.Penyadap{NamaPenyadap: "Penyadap Uji", NIK: "P-01"}
+	master := models.Master{Tanggal: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Afdeling: "Gebugan", NamaFile: "uji.xlsx"}
+	if err := db.Create(&penyadap).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&master).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []struct {
+		hari  int
+		latek float64
+	}{{1, 30}, {5, 90}} {
+		produksi := models.Produksi{
+			Tanggal: time.Date(2025, 1, p.hari, 0, 0, 0, 0, time.UTC), TipeProduksi: "BAKU", TahunTanam: "2010",
+			Mandor: "Mandor Uji", NIK: penyadap.NIK, NamaPenyadap: penyadap.NamaPenyadap, Afdeling: "Gebugan",
+			BasahLatek: p.latek, IdMaster: master.ID,
+		}
+		if err := db.Create(&produksi).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	body, _ := json.Marshal(visualisasiMultiRequest{
+		TanggalAwal: "2025-01-01", TanggalAkhir: "2025-01-05", MovingAverage: 2,
+		Series: []SpesifikasiSeri{{TipeData: "penyadap", IdPenyadap: penyadap.ID, Satuan: "basah_latek"}},
+	})
+	rec := httptest.NewRecorder()
+	GetVisualisasiMulti(rec, httptest.NewRequest(http.MethodPost, "/api/visualisasi/multi", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/visualisasi/multi = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var hasil VisualisasiMultiResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &hasil); err != nil {
+		t.Fatal(err)
+	}
+	if len(hasil.Labels) != 5 || len(hasil.Series) != 1 {
+		t.Fatalf("labels %v, %d seri", hasil.Labels, len(hasil.Series))
+	}
+	seri := hasil.Series[0]
+	if ingin := []*float64{angka(30), nil, nil, nil, angka(90)}; !samaNilai(seri.Data, ingin) {
+		t.Errorf("data = %v, ingin %v", tampilNilai(seri.Data), tampilNilai(ingin))
+	}
+	// Jendela 2 hari pada sumbu kalender, bukan dua titik data berurutan
+	if ingin := []*float64{nil, angka(30), nil, nil, angka(90)}; !samaNilai(seri.MovingAverage, ingin) {
+		t.Errorf("moving average = %v, ingin %v", tampilNilai(seri.MovingAverage), tampilNilai(ingin))
+	}
+}
@@ -14,6 +14,9 @@ import (
 type VisualisasiProduksiResponse struct {
 	Labels []string            `json:"labels"`
 	Data   []ProduksiDataPoint `json:"data"`
+
+	// Rata-rata bergerak sejajar dengan Labels
+	MovingAverage []*float64 `json:"moving_average,omitempty"`
 }
 
 type ProduksiDataPoint struct {
@@ -36,6 +39,12 @@ func GetVisualisasiProduksi(w http.ResponseWriter, r *http.Request) {
 	tipeProduksi := r.URL.Query().Get("tipeProduksi")
 	idPenyadap := r.URL.Query().Get("idPenyadap")
 
+	opsi, err := parseOpsiVisualisasi(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	uintIdPenyadap64, err := strconv.ParseUint(idPenyadap, 10, 64)
 	if err != nil {
 		http.Error(w, "Parameter idPenyadap tidak valid", http.StatusBadRequest)
@@ -58,21 +67,12 @@ func GetVisualisasiProduksi(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validasi satuan - field yang tersedia di model Produksi
-	validSatuan := map[string]bool{
-		"basah_latek":    true,
-		"sheet":          true,
-		"basah_lump":     true,
-		"br_cr":          true,
-		"total_produksi": true,
-	}
-
-	if !validSatuan[satuan] {
+	if !validSatuanVisualisasiProduksi[satuan] {
 		http.Error(w, "Parameter satuan tidak valid. Gunakan: basah_latek, sheet, basah_lump, atau br_cr", http.StatusBadRequest)
 		return
 	}
 
-	result, err := visualisasiProduksiPenyadap(nikPenyadap, tipeProduksi, tanggalAwal, tanggalAkhir, satuan)
+	result, err := visualisasiProduksiPenyadap(nikPenyadap, tipeProduksi, tanggalAwal, tanggalAkhir, satuan, opsi)
 	if err != nil {
 		http.Error(w, "Error mengambil data: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -82,15 +82,24 @@ func GetVisualisasiProduksi(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
-func visualisasiProduksiPenyadap(nikPenyadap, tipeProduksi, tanggalAwal, tanggalAkhir, satuan string) (VisualisasiProduksiResponse, error) {
+// validSatuanVisualisasiProduksi adalah field yang tersedia di model Produksi
+var validSatuanVisualisasiProduksi = map[string]bool{
+	"basah_latek":    true,
+	"sheet":          true,
+	"basah_lump":     true,
+	"br_cr":          true,
+	"total_produksi": true,
+}
+
+func visualisasiProduksiPenyadap(nikPenyadap, tipeProduksi, tanggalAwal, tanggalAkhir, satuan string, opsi opsiVisualisasi) (VisualisasiProduksiResponse, error) {
 	var produksiList []models.Produksi
 	db := config.GetDB()
 	query := db.Model(&models.Produksi{})
 
 	// Filter berdasarkan tanggal
-	startDate, _ := time.Parse("2006-01-02", tanggalAwal)
+	awal, _ := time.Parse("2006-01-02", tanggalAwal)
 	endDate, _ := time.Parse("2006-01-02", tanggalAkhir)
-	startDate = startDate.AddDate(0, 0, -1)
+	startDate := awal.AddDate(0, 0, -1)
 	query = query.Where("tanggal BETWEEN ? AND ?", startDate, endDate)
 
 	// Filter NIK Penyadap
@@ -111,15 +120,15 @@ func visualisasiProduksiPenyadap(nikPenyadap, tipeProduksi, tanggalAwal, tanggal
 		}, nil
 	}
 
-	return aggregateProduksiData(produksiList, satuan), nil
+	return aggregateProduksiData(produksiList, satuan, opsi, awal, endDate), nil
 }
 
-func aggregateProduksiData(produksiList []models.Produksi, satuan string) VisualisasiProduksiResponse {
+func aggregateProduksiData(produksiList []models.Produksi, satuan string, opsi opsiVisualisasi, awal, akhir time.Time) VisualisasiProduksiResponse {
 	dataMap := make(map[string]float64)
 
-	// Agregasi data per tanggal
+	// Agregasi data per tanggal atau per periode granularitas
 	for _, produksi := range produksiList {
-		dateStr := produksi.Tanggal.Format("2006-01-02")
+		dateStr := kunciGranularitas(produksi.Tanggal, opsi.Granularitas)
 
 		switch satuan {
 		case "basah_latek":
@@ -153,8 +162,21 @@ func aggregateProduksiData(produksiList []models.Produksi, satuan string) Visual
 		})
 	}
 
-	return VisualisasiProduksiResponse{
+	result := VisualisasiProduksiResponse{
 		Labels: dates,
 		Data:   data,
 	}
+	// Semua satuan produksi bisa dijumlahkan, jadi rata-rata biasa pada sumbu
+	// penuh seperti aggregateData
+	if opsi.RataBergerak > 1 {
+		sumbu := sumbuSeri(awal, akhir, produksiList[0].Tanggal, produksiList[len(produksiList)-1].Tanggal, opsi.Granularitas)
+		nilai := make([]*float64, len(sumbu))
+		for i, k := range sumbu {
+			if v, ok := dataMap[k]; ok {
+				nilai[i] = &v
+			}
+		}
+		result.MovingAverage = petakanKeLabel(sumbu, rataBergerak(nilai, opsi.RataBergerak), dates)
+	}
+	return result
 }
@@ -4,15 +4,25 @@ import (
 	"app-inputan-ptpn/config"
 	"app-inputan-ptpn/models"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type VisualisasiResponse struct {
 	Labels []string    `json:"labels"`
 	Data   []DataPoint `json:"data"`
+
+	// Rata-rata bergerak sejajar dengan Labels, nil untuk titik awal yang
+	// jendelanya belum mencakup n periode
+	MovingAverage []*float64 `json:"moving_average,omitempty"`
+
+	// Komponen per label, dipakai visualisasi multi untuk menghitung ulang
+	// rata-rata bergerak satuan rasio setelah disejajarkan
+	komponen map[string]*tempDataPoint
 }
 
 type DataPoint struct {
@@ -40,6 +50,17 @@ func GetVisualisasiRekap(w http.ResponseWriter, r *http.Request) {
 	tanggalAkhir := r.URL.Query().Get("tanggalAkhir")
 	satuan := r.URL.Query().Get("satuan")
 
+	format, err := formatLaporan(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opsi, err := parseOpsiVisualisasi(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Validasi parameter wajib
 	if tipeData == "" {
 		http.Error(w, "Parameter tipeData tidak boleh kosong", http.StatusBadRequest)
@@ -55,40 +76,34 @@ func GetVisualisasiRekap(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validasi satuan - hanya field hari_ini yang valid
-	validSatuan := map[string]bool{
-		"hko":                true,
-		"basah_latek_kebun":  true,
-		"basah_latek_pabrik": true,
-		"basah_latek_persen": true,
-		"basah_lump_kebun":   true,
-		"basah_lump_pabrik":  true,
-		"basah_lump_persen":  true,
-		"k3_sheet":           true,
-		"kering_sheet":       true,
-		"kering_br_cr":       true,
-		"kering_jumlah":      true,
-		"produksi_per_taper": true,
-		"total_produksi":     true,
-	}
-
-	if !validSatuan[satuan] {
+	if !validSatuanRekap[satuan] {
 		http.Error(w, "Parameter satuan tidak valid", http.StatusBadRequest)
 		return
 	}
 
+	// Afdeling harus terdaftar; data lama afdeling nonaktif tetap boleh dilihat
+	var afdelingID uint
+	if afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		afdelingID = afd.ID
+	}
+
 	var nikMandor, tahunTanam string
 	var result VisualisasiResponse
-	var err error
 
 	switch tipeData {
 	case "total":
-		result, err = visualisasiTotal(tipeProduksi, tanggalAwal, tanggalAkhir, satuan)
+		result, err = visualisasiTotal(tipeProduksi, tanggalAwal, tanggalAkhir, satuan, opsi)
 	case "afdeling":
-		if afdeling == "" {
+		if afdelingID == 0 {
 			http.Error(w, "Parameter afdeling tidak boleh kosong untuk tipe 'afdeling'", http.StatusBadRequest)
 			return
 		}
-		result, err = visualisasiAfdeling(tipeProduksi, afdeling, tanggalAwal, tanggalAkhir, satuan)
+		result, err = visualisasiAfdeling(tipeProduksi, afdelingID, tanggalAwal, tanggalAkhir, satuan, opsi)
 	case "mandor":
 		// Validasi idMandor dulu sebelum konversi
 		if idMandor == "" {
@@ -109,7 +124,7 @@ func GetVisualisasiRekap(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		result, err = visualisasiMandor(tipeProduksi, afdeling, nikMandor, tahunTanam, tanggalAwal, tanggalAkhir, satuan)
+		result, err = visualisasiMandor(tipeProduksi, afdelingID, nikMandor, tahunTanam, tanggalAwal, tanggalAkhir, satuan, opsi)
 	default:
 		http.Error(w, "Parameter tipeData tidak valid. Gunakan: total, afdeling, atau mandor", http.StatusBadRequest)
 		return
@@ -120,18 +135,109 @@ func GetVisualisasiRekap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format != "" {
+		filter := []string{
+			"Tipe Data: " + tipeData,
+			"Periode: " + tanggalAwal + " s/d " + tanggalAkhir,
+			"Granularitas: " + opsi.Granularitas,
+		}
+		if afdelingID != 0 {
+			filter = append(filter, "Afdeling: "+afdeling)
+		}
+		if tipeData == "mandor" {
+			filter = append(filter, "Mandor: NIK "+nikMandor)
+		}
+		if tipeProduksi != "" && tipeProduksi != "-" {
+			filter = append(filter, "Tipe Produksi: "+tipeProduksi)
+		}
+		kirimLaporan(w, r, format, "visualisasi_"+satuan, tabelVisualisasi(result, satuan, opsi, filter))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
 
-func visualisasiTotal(tipeProduksi, tanggalAwal, tanggalAkhir, satuan string) (VisualisasiResponse, error) {
+// validSatuanRekap adalah satuan yang bisa dihitung dari data hari_ini rekap
+var validSatuanRekap = map[string]bool{
+	"hko":                true,
+	"basah_latek_kebun":  true,
+	"basah_latek_pabrik": true,
+	"basah_latek_persen": true,
+	"basah_lump_kebun":   true,
+	"basah_lump_pabrik":  true,
+	"basah_lump_persen":  true,
+	"k3_sheet":           true,
+	"kering_sheet":       true,
+	"kering_br_cr":       true,
+	"kering_jumlah":      true,
+	"produksi_per_taper": true,
+	"total_produksi":     true,
+}
+
+// satuanRasio adalah satuan visualisasi yang tidak dijumlahkan di baris total
+var satuanRasio = map[string]bool{
+	"basah_latek_persen": true,
+	"basah_lump_persen":  true,
+	"k3_sheet":           true,
+	"produksi_per_taper": true,
+}
+
+// tabelVisualisasi menyusun tabel ekspor satu seri visualisasi per tanggal
+// atau per periode granularitas, ditambah kolom rata-rata bergerak jika ada
+func tabelVisualisasi(result VisualisasiResponse, satuan string, opsi opsiVisualisasi, filter []string) TabelLaporan {
+	kolom := KolomLaporan{Judul: satuan, Jenis: kolomAngka, Jumlah: !satuanRasio[satuan]}
+	switch {
+	case satuan == "hko":
+		kolom.Jenis = kolomBulat
+	case strings.HasSuffix(satuan, "_persen") || satuan == "k3_sheet":
+		kolom.Jenis = kolomPersen
+	}
+
+	kolomWaktu := KolomLaporan{Judul: "Tanggal", Jenis: kolomTanggal}
+	if opsi.Granularitas != granularitasHari {
+		kolomWaktu = KolomLaporan{Judul: "Periode"}
+	}
+	tabel := TabelLaporan{
+		Judul:  "Visualisasi Produksi - " + satuan,
+		Filter: filter,
+		Kolom:  []KolomLaporan{kolomWaktu, kolom},
+	}
+	if result.MovingAverage != nil {
+		tabel.Kolom = append(tabel.Kolom, KolomLaporan{
+			Judul: fmt.Sprintf("Rata-rata Bergerak %d", opsi.RataBergerak),
+			Jenis: kolom.Jenis,
+		})
+	}
+	for i, d := range result.Data {
+		var waktu interface{} = d.Tanggal
+		if opsi.Granularitas == granularitasHari {
+			waktu, _ = time.Parse("2006-01-02", d.Tanggal)
+		}
+		baris := []interface{}{waktu, d.Value}
+		if result.MovingAverage != nil {
+			var rata interface{}
+			if result.MovingAverage[i] != nil {
+				rata = *result.MovingAverage[i]
+			}
+			baris = append(baris, rata)
+		}
+		tabel.Baris = append(tabel.Baris, baris)
+	}
+	if kolom.Jumlah {
+		tabel.hitungTotal()
+	}
+	return tabel
+}
+
+func visualisasiTotal(tipeProduksi, tanggalAwal, tanggalAkhir, satuan string, opsi opsiVisualisasi) (VisualisasiResponse, error) {
 	var rekaps []models.Rekap
 	db := config.GetDB()
 	query := db.Model(&models.Rekap{})
 
-	startDate, _ := time.Parse("2006-01-02", tanggalAwal)
+	awal, _ := time.Parse("2006-01-02", tanggalAwal)
 	endDate, _ := time.Parse("2006-01-02", tanggalAkhir)
-	startDate = startDate.AddDate(0, 0, -1)
+	startDate := awal.AddDate(0, 0, -1)
 	query = query.Where("tanggal BETWEEN ? AND ?", startDate, endDate)
 
 	// FIX: Exclude tipe_produksi = REKAPITULASI
@@ -151,19 +257,19 @@ func visualisasiTotal(tipeProduksi, tanggalAwal, tanggalAkhir, satuan string) (V
 		}, nil
 	}
 
-	return aggregateData(rekaps, satuan), nil
+	return aggregateData(rekaps, satuan, opsi, awal, endDate), nil
 }
 
-func visualisasiAfdeling(tipeProduksi, afdeling, tanggalAwal, tanggalAkhir, satuan string) (VisualisasiResponse, error) {
+func visualisasiAfdeling(tipeProduksi string, afdelingID uint, tanggalAwal, tanggalAkhir, satuan string, opsi opsiVisualisasi) (VisualisasiResponse, error) {
 	var rekaps []models.Rekap
 	db := config.GetDB()
 	query := db.Model(&models.Rekap{})
 
-	startDate, _ := time.Parse("2006-01-02", tanggalAwal)
+	awal, _ := time.Parse("2006-01-02", tanggalAwal)
 	endDate, _ := time.Parse("2006-01-02", tanggalAkhir)
-	startDate = startDate.AddDate(0, 0, -1)
+	startDate := awal.AddDate(0, 0, -1)
 	query = query.Where("tanggal BETWEEN ? AND ?", startDate, endDate)
-	query = query.Where("afdeling = ?", afdeling)
+	query = query.Where("afdeling_id = ?", afdelingID)
 
 	// Exclude tipe_produksi = REKAPITULASI
 	query = query.Where("tipe_produksi != ?", "REKAPITULASI")
@@ -181,17 +287,17 @@ func visualisasiAfdeling(tipeProduksi, afdeling, tanggalAwal, tanggalAkhir, satu
 			Data:   []DataPoint{},
 		}, nil
 	}
-	return aggregateData(rekaps, satuan), nil
+	return aggregateData(rekaps, satuan, opsi, awal, endDate), nil
 }
 
-func visualisasiMandor(tipeProduksi, afdeling, nikMandor, tahunTanam, tanggalAwal, tanggalAkhir, satuan string) (VisualisasiResponse, error) {
+func visualisasiMandor(tipeProduksi string, afdelingID uint, nikMandor, tahunTanam, tanggalAwal, tanggalAkhir, satuan string, opsi opsiVisualisasi) (VisualisasiResponse, error) {
 	var rekaps []models.Rekap
 	db := config.GetDB()
 	query := db.Model(&models.Rekap{})
 
-	startDate, _ := time.Parse("2006-01-02", tanggalAwal)
+	awal, _ := time.Parse("2006-01-02", tanggalAwal)
 	endDate, _ := time.Parse("2006-01-02", tanggalAkhir)
-	startDate = startDate.AddDate(0, 0, -1)
+	startDate := awal.AddDate(0, 0, -1)
 	query = query.Where("tanggal BETWEEN ? AND ?", startDate, endDate)
 
 	// Wajib filter berdasarkan NIK mandor
@@ -205,8 +311,8 @@ func visualisasiMandor(tipeProduksi, afdeling, nikMandor, tahunTanam, tanggalAwa
 		query = query.Where("tahun_tanam = ?", tahunTanam)
 	}
 
-	if afdeling != "" && afdeling != "-" {
-		query = query.Where("afdeling = ?", afdeling)
+	if afdelingID != 0 {
+		query = query.Where("afdeling_id = ?", afdelingID)
 	}
 
 	if tipeProduksi != "" && tipeProduksi != "-" {
@@ -223,7 +329,7 @@ func visualisasiMandor(tipeProduksi, afdeling, nikMandor, tahunTanam, tanggalAwa
 		}, nil
 	}
 
-	return aggregateData(rekaps, satuan), nil
+	return aggregateData(rekaps, satuan, opsi, awal, endDate), nil
 }
 
 type tempDataPoint struct {
@@ -237,28 +343,90 @@ type tempDataPoint struct {
 	KeringJumlah     float64
 }
 
-func aggregateData(rekaps []models.Rekap, satuan string) VisualisasiResponse {
+// tambahRekap menambahkan nilai hari_ini satu baris rekap
+func (p *tempDataPoint) tambahRekap(rekap models.Rekap) {
+	p.HKO += rekap.HKOHariIni
+	p.BasahLatekKebun += rekap.HariIniBasahLatekKebun
+	p.BasahLatekPabrik += rekap.HariIniBasahLatekPabrik
+	p.BasahLumpKebun += rekap.HariIniBasahLumpKebun
+	p.BasahLumpPabrik += rekap.HariIniBasahLumpPabrik
+	p.KeringSheet += rekap.HariIniKeringSheet
+	p.KeringBrCr += rekap.HariIniKeringBrCr
+	p.KeringJumlah += rekap.HariIniKeringJumlah
+}
+
+// tambah menjumlahkan dua titik, dipakai untuk total beberapa tanggal
+func (p *tempDataPoint) tambah(q tempDataPoint) {
+	p.HKO += q.HKO
+	p.BasahLatekKebun += q.BasahLatekKebun
+	p.BasahLatekPabrik += q.BasahLatekPabrik
+	p.BasahLumpKebun += q.BasahLumpKebun
+	p.BasahLumpPabrik += q.BasahLumpPabrik
+	p.KeringSheet += q.KeringSheet
+	p.KeringBrCr += q.KeringBrCr
+	p.KeringJumlah += q.KeringJumlah
+}
+
+// nilai menghitung satu satuan dari nilai yang sudah dijumlahkan. Satuan
+// rasio (persen, K3, per taper) dihitung dari jumlahnya, bukan dijumlahkan.
+func (p *tempDataPoint) nilai(satuan string) float64 {
+	var value float64
+	switch satuan {
+	case "hko":
+		value = float64(p.HKO)
+	case "basah_latek_kebun":
+		value = p.BasahLatekKebun
+	case "basah_latek_pabrik":
+		value = p.BasahLatekPabrik
+	case "basah_latek_persen":
+		if p.BasahLatekKebun > 0 {
+			value = ((p.BasahLatekKebun - p.BasahLatekPabrik) / p.BasahLatekKebun) * 100
+		}
+	case "basah_lump_kebun":
+		value = p.BasahLumpKebun
+	case "basah_lump_pabrik":
+		value = p.BasahLumpPabrik
+	case "basah_lump_persen":
+		if p.BasahLumpKebun > 0 {
+			value = ((p.BasahLumpKebun - p.BasahLumpPabrik) / p.BasahLumpKebun) * 100
+		}
+	case "k3_sheet":
+		if p.BasahLatekPabrik > 0 {
+			value = (p.KeringSheet / p.BasahLatekPabrik) * 100
+		}
+	case "kering_sheet":
+		value = p.KeringSheet
+	case "kering_br_cr":
+		value = p.KeringBrCr
+	case "kering_jumlah":
+		value = p.KeringJumlah
+	case "produksi_per_taper":
+		if p.HKO > 0 {
+			value = p.KeringJumlah / float64(p.HKO)
+		}
+	case "total_produksi":
+		// Total produksi = basah latek kebun + basah lump kebun
+		value = p.BasahLumpPabrik + p.KeringSheet
+	}
+	return value
+}
+
+// aggregateData mengelompokkan rekap per periode. Rata-rata bergerak dihitung
+// pada sumbu penuh awal..akhir agar periode tanpa data tidak menyempitkan
+// jendela, lalu disejajarkan dengan Labels.
+func aggregateData(rekaps []models.Rekap, satuan string, opsi opsiVisualisasi, awal, akhir time.Time) VisualisasiResponse {
 	dataMap := make(map[string]*tempDataPoint)
 
-	// Agregasi data per tanggal
+	// Agregasi data per tanggal atau per periode granularitas
 	for _, rekap := range rekaps {
-		dateStr := rekap.Tanggal.Format("2006-01-02")
+		dateStr := kunciGranularitas(rekap.Tanggal, opsi.Granularitas)
 
 		if _, exists := dataMap[dateStr]; !exists {
 			dataMap[dateStr] = &tempDataPoint{}
 		}
 
-		point := dataMap[dateStr]
-
 		// Agregasi hanya data hari_ini
-		point.HKO += rekap.HKOHariIni
-		point.BasahLatekKebun += rekap.HariIniBasahLatekKebun
-		point.BasahLatekPabrik += rekap.HariIniBasahLatekPabrik
-		point.BasahLumpKebun += rekap.HariIniBasahLumpKebun
-		point.BasahLumpPabrik += rekap.HariIniBasahLumpPabrik
-		point.KeringSheet += rekap.HariIniKeringSheet
-		point.KeringBrCr += rekap.HariIniKeringBrCr
-		point.KeringJumlah += rekap.HariIniKeringJumlah
+		dataMap[dateStr].tambahRekap(rekap)
 	}
 
 	// Extract dan sort tanggal
@@ -267,60 +435,45 @@ func aggregateData(rekaps []models.Rekap, satuan string) VisualisasiResponse {
 		dates = append(dates, date)
 	}
 
-	// Sort tanggal secara ascending
+	// Sort tanggal secara ascending (kunci granularitas juga urut sebagai teks)
 	sort.Strings(dates)
 
-	// Build data berdasarkan urutan tanggal
+	// Build data berdasarkan urutan tanggal. Satuan rasio dihitung dari
+	// jumlah komponen setiap periode, bukan dirata-rata.
 	var data []DataPoint
 	for _, date := range dates {
-		point := dataMap[date]
-		var value float64
-
-		switch satuan {
-		case "hko":
-			value = float64(point.HKO)
-		case "basah_latek_kebun":
-			value = point.BasahLatekKebun
-		case "basah_latek_pabrik":
-			value = point.BasahLatekPabrik
-		case "basah_latek_persen":
-			if point.BasahLatekKebun > 0 {
-				value = ((point.BasahLatekKebun - point.BasahLatekPabrik) / point.BasahLatekKebun) * 100
-			}
-		case "basah_lump_kebun":
-			value = point.BasahLumpKebun
-		case "basah_lump_pabrik":
-			value = point.BasahLumpPabrik
-		case "basah_lump_persen":
-			if point.BasahLumpKebun > 0 {
-				value = ((point.BasahLumpKebun - point.BasahLumpPabrik) / point.BasahLumpKebun) * 100
-			}
-		case "k3_sheet":
-			if point.BasahLatekPabrik > 0 {
-				value = (point.KeringSheet / point.BasahLatekPabrik) * 100
-			}
-		case "kering_sheet":
-			value = point.KeringSheet
-		case "kering_br_cr":
-			value = point.KeringBrCr
-		case "kering_jumlah":
-			value = point.KeringJumlah
-		case "produksi_per_taper":
-			if point.HKO > 0 {
-				value = point.KeringJumlah / float64(point.HKO)
-			}
-		case "total_produksi":
-			// Total produksi = basah latek kebun + basah lump kebun
-			value = point.BasahLumpPabrik + point.KeringSheet
-		}
+		value := dataMap[date].nilai(satuan)
 		data = append(data, DataPoint{
 			Tanggal: date,
 			Value:   value,
 		})
 	}
 
-	return VisualisasiResponse{
-		Labels: dates,
-		Data:   data,
+	result := VisualisasiResponse{
+		Labels:   dates,
+		Data:     data,
+		komponen: dataMap,
+	}
+	if opsi.RataBergerak > 1 {
+		sumbu := sumbuSeri(awal, akhir, rekaps[0].Tanggal, rekaps[len(rekaps)-1].Tanggal, opsi.Granularitas)
+		var rata []*float64
+		if satuanRasio[satuan] {
+			titik := make([]*tempDataPoint, len(sumbu))
+			for i, k := range sumbu {
+				titik[i] = dataMap[k]
+			}
+			rata = rataBergerakRasio(titik, satuan, opsi.RataBergerak)
+		} else {
+			nilai := make([]*float64, len(sumbu))
+			for i, k := range sumbu {
+				if p, ok := dataMap[k]; ok {
+					v := p.nilai(satuan)
+					nilai[i] = &v
+				}
+			}
+			rata = rataBergerak(nilai, opsi.RataBergerak)
+		}
+		result.MovingAverage = petakanKeLabel(sumbu, rata, dates)
 	}
+	return result
 }
@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// jenisUjiWebhook dikirim oleh endpoint uji, tidak bisa dilanggan
+const jenisUjiWebhook = "webhook.test"
+
+type webhookInput struct {
+	Nama     string   `json:"nama"`
+	URL      string   `json:"url"`
+	Rahasia  string   `json:"rahasia"`
+	Jenis    []string `json:"jenis"`
+	Afdeling string   `json:"afdeling"`
+	Aktif    *bool    `json:"aktif"`
+}
+
+// terapkanInputWebhook memvalidasi input lalu mengisi webhook. Rahasia
+// dibuat otomatis jika kosong saat membuat baru dan tidak berubah jika
+// kosong saat memperbarui.
+func terapkanInputWebhook(input webhookInput, h *models.Webhook) error {
+	input.Nama = strings.TrimSpace(input.Nama)
+	input.URL = strings.TrimSpace(input.URL)
+	if input.Nama == "" || input.URL == "" {
+		return fmt.Errorf("Field 'nama' dan 'url' wajib diisi")
+	}
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url harus berupa alamat http atau https")
+	}
+
+	var jenis []string
+	for _, j := range input.Jenis {
+		j = strings.TrimSpace(j)
+		if j == "" || slices.Contains(jenis, j) {
+			continue
+		}
+		if j != "*" && !slices.Contains(daftarJenisPeristiwa, j) {
+			return fmt.Errorf("jenis '%s' tidak dikenal. Gunakan: %s atau *", j, strings.Join(daftarJenisPeristiwa, ", "))
+		}
+		jenis = append(jenis, j)
+	}
+	if len(jenis) == 0 {
+		return fmt.Errorf("Field 'jenis' wajib diisi minimal satu jenis peristiwa")
+	}
+
+	h.AfdelingID, h.Afdeling = nil, nil
+	if afdeling := strings.TrimSpace(input.Afdeling); afdeling != "" && afdeling != "-" {
+		afd, err := resolveAfdeling(afdeling, false)
+		if err != nil {
+			return err
+		}
+		h.AfdelingID, h.Afdeling = &afd.ID, &afd
+	}
+
+	if rahasia := strings.TrimSpace(input.Rahasia); rahasia != "" {
+		if len(rahasia) < 16 {
+			return fmt.Errorf("rahasia minimal 16 karakter")
+		}
+		h.Rahasia = rahasia
+	} else if h.Rahasia == "" {
+		h.Rahasia = idAcak(24)
+	}
+
+	h.Nama = input.Nama
+	h.URL = input.URL
+	h.Jenis = strings.Join(jenis, ",")
+	if input.Aktif != nil {
+		h.Aktif = *input.Aktif
+	}
+	return nil
+}
+
+// GetAllWebhook menampilkan semua langganan webhook tanpa rahasianya
+func GetAllWebhook(w http.ResponseWriter, r *http.Request) {
+	var list []models.Webhook
+	if err := config.DB.Preload("Afdeling").Order("nama asc").Find(&list).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengambil data webhook: " + err.Error()})
+		return
+	}
+	for i := range list {
+		list[i].Rahasia = ""
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d webhook", len(list)),
+		Data:    list,
+	})
+}
+
+// CreateWebhook menambah langganan. Rahasia hanya ditampilkan di respons
+// ini, simpan untuk memverifikasi signature.
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var input webhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+
+	h := models.Webhook{Aktif: true, DibuatOleh: usernameDariRequest(r)}
+	if err := terapkanInputWebhook(input, &h); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	// Create mengisi Aktif=false dengan default kolom (true), jadi
+	// nonaktifkan setelahnya
+	aktif := h.Aktif
+	if err := config.DB.Create(&h).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menyimpan webhook: " + err.Error()})
+		return
+	}
+	if !aktif {
+		config.DB.Model(&h).Update("aktif", false)
+		h.Aktif = false
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{
+		Success: true,
+		Message: "Webhook berhasil ditambahkan",
+		Data:    h,
+	})
+}
+
+// UpdateWebhook mengubah langganan; rahasia lama dipertahankan jika kosong
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var h models.Webhook
+	if err := config.DB.First(&h, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Webhook tidak ditemukan"})
+		return
+	}
+
+	var input webhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: "Format JSON tidak valid: " + err.Error()})
+		return
+	}
+	if err := terapkanInputWebhook(input, &h); err != nil {
+		respondJSON(w, http.StatusBadRequest, APIResponse{Success: false, Message: err.Error()})
+		return
+	}
+	if err := config.DB.Omit("Afdeling").Save(&h).Error; err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal memperbarui webhook: " + err.Error()})
+		return
+	}
+	h.Rahasia = ""
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Webhook berhasil diperbarui",
+		Data:    h,
+	})
+}
+
+// DeleteWebhook menghapus langganan beserta log pengirimannya
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	var h models.Webhook
+	if err := config.DB.First(&h, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Webhook tidak ditemukan"})
+		return
+	}
+
+	err := config.DB.Where("pengiriman_id IN (?)",
+		config.DB.Model(&models.PengirimanWebhook{}).Select("id").Where("webhook_id = ?", h.ID),
+	).Delete(&models.PercobaanWebhook{}).Error
+	if err == nil {
+		err = config.DB.Where("webhook_id = ?", h.ID).Delete(&models.PengirimanWebhook{}).Error
+	}
+	if err == nil {
+		err = config.DB.Delete(&h).Error
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal menghapus webhook: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: "Webhook berhasil dihapus",
+	})
+}
+
+// mulaiPengirimanWebhook mencatat lalu mengirim payload ke satu webhook
+func mulaiPengirimanWebhook(p models.PengirimanWebhook) (models.PengirimanWebhook, error) {
+	p.ID = 0
+	p.Status = models.StatusWebhookMenunggu
+	p.Percobaan, p.KodeRespons, p.Kesalahan = 0, 0, ""
+	p.BerikutnyaPada, p.TerkirimPada = nil, nil
+	p.RiwayatPercobaan = nil
+	p.CreatedAt, p.UpdatedAt = time.Time{}, time.Time{}
+	if err := config.DB.Create(&p).Error; err != nil {
+		return p, err
+	}
+	go jalankanPengirimanWebhook(p.ID)
+	return p, nil
+}
+
+// UjiWebhook mengirim peristiwa webhook.test ke satu webhook (walaupun
+// tidak aktif) untuk memeriksa alamat dan verifikasi signature penerima
+func UjiWebhook(w http.ResponseWriter, r *http.Request) {
+	var h models.Webhook
+	if err := config.DB.First(&h, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Webhook tidak ditemukan"})
+		return
+	}
+
+	uji := Peristiwa{
+		ID:    idAcak(12),
+		Jenis: jenisUjiWebhook,
+		Waktu: time.Now(),
+		Pesan: "Uji webhook " + h.Nama + " oleh " + usernameDariRequest(r),
+	}
+	payload, _ := json.Marshal(uji)
+
+	p, err := mulaiPengirimanWebhook(models.PengirimanWebhook{
+		WebhookID:   h.ID,
+		IDPeristiwa: uji.ID,
+		Jenis:       uji.Jenis,
+		Payload:     string(payload),
+	})
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mencatat pengiriman: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, APIResponse{
+		Success: true,
+		Message: "Pengiriman uji dijadwalkan, lihat log pengiriman untuk hasilnya",
+		Data:    p,
+	})
+}
+
+// GetPengirimanWebhook menampilkan log pengiriman terbaru beserta riwayat
+// setiap percobaannya. Filter opsional: webhook_id, status, jenis,
+// id_peristiwa, limit (default 100, maks 1000).
+func GetPengirimanWebhook(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := config.DB.Model(&models.PengirimanWebhook{})
+
+	if v := q.Get("webhook_id"); v != "" {
+		query = query.Where("webhook_id = ?", v)
+	}
+	if v := strings.ToUpper(q.Get("status")); v != "" {
+		if v != models.StatusWebhookMenunggu && v != models.StatusWebhookBerhasil && v != models.StatusWebhookGagal {
+			respondJSON(w, http.StatusBadRequest, APIResponse{
+				Success: false,
+				Message: "Parameter status tidak valid. Gunakan: MENUNGGU, BERHASIL, atau GAGAL",
+			})
+			return
+		}
+		query = query.Where("status = ?", v)
+	}
+	if v := q.Get("jenis"); v != "" {
+		query = query.Where("jenis = ?", v)
+	}
+	if v := q.Get("id_peristiwa"); v != "" {
+		query = query.Where("id_peristiwa = ?", v)
+	}
+
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	var list []models.PengirimanWebhook
+	err := query.Preload("RiwayatPercobaan", func(db *gorm.DB) *gorm.DB {
+		return db.Order("ke asc")
+	}).Order("id desc").Limit(limit).Find(&list).Error
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mengambil log pengiriman: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d pengiriman", len(list)),
+		Data:    list,
+	})
+}
+
+// UlangPengirimanWebhook mengirim ulang payload yang sama sebagai
+// pengiriman baru (ulang_dari menunjuk ke pengiriman asal). Pengiriman
+// yang masih dicoba tidak bisa diulang.
+func UlangPengirimanWebhook(w http.ResponseWriter, r *http.Request) {
+	var asal models.PengirimanWebhook
+	if err := config.DB.First(&asal, mux.Vars(r)["id"]).Error; err != nil {
+		respondJSON(w, http.StatusNotFound, APIResponse{Success: false, Message: "Pengiriman tidak ditemukan"})
+		return
+	}
+	if asal.Status == models.StatusWebhookMenunggu {
+		respondJSON(w, http.StatusConflict, APIResponse{
+			Success: false,
+			Message: "Pengiriman masih dalam proses percobaan ulang",
+		})
+		return
+	}
+
+	baru := asal
+	baru.UlangDari = &asal.ID
+	baru, err := mulaiPengirimanWebhook(baru)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, APIResponse{Success: false, Message: "Gagal mencatat pengiriman: " + err.Error()})
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Pengiriman %d dijadwalkan ulang", asal.ID),
+		Data:    baru,
+	})
+}
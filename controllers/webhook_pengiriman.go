@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/models"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Header yang dikirim bersama setiap payload. Penerima memverifikasi
+// X-Webhook-Signature = "sha256=" + hex(HMAC-SHA256(rahasia, timestamp + "." + body)).
+const (
+	headerWebhookID        = "X-Webhook-Id"
+	headerWebhookJenis     = "X-Webhook-Event"
+	headerWebhookTimestamp = "X-Webhook-Timestamp"
+	headerWebhookSignature = "X-Webhook-Signature"
+)
+
+var (
+	// maksPercobaanWebhook termasuk percobaan pertama
+	maksPercobaanWebhook = 6
+	// jedaDasarWebhook digandakan setiap kali gagal: 2s, 4s, 8s, ...
+	jedaDasarWebhook = 2 * time.Second
+	jedaMaksWebhook  = 10 * time.Minute
+
+	klienWebhook = &http.Client{Timeout: 10 * time.Second}
+)
+
+// MulaiWebhook mendaftarkan webhook sebagai penerima peristiwa dan
+// melanjutkan pengiriman yang tertunda sebelum aplikasi terakhir berhenti.
+// WEBHOOK_MAKS_PERCOBAAN mengubah batas percobaan (default 6).
+func MulaiWebhook() {
+	if v := os.Getenv("WEBHOOK_MAKS_PERCOBAAN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maksPercobaanWebhook = n
+		}
+	}
+
+	DaftarkanPenerima("webhook", teruskanKeWebhook)
+
+	var tertunda []models.PengirimanWebhook
+	if err := config.GetDB().Where("status = ?", models.StatusWebhookMenunggu).Find(&tertunda).Error; err != nil {
+		log.Printf("Gagal memuat pengiriman webhook tertunda: %v", err)
+		return
+	}
+	for _, p := range tertunda {
+		go jalankanPengirimanWebhook(p.ID)
+	}
+	log.Printf("✓ Webhook aktif (%d pengiriman tertunda dilanjutkan)", len(tertunda))
+}
+
+// cocokJenisWebhook memeriksa daftar jenis langganan ("*" = semua)
+func cocokJenisWebhook(daftar, jenis string) bool {
+	for _, j := range strings.Split(daftar, ",") {
+		j = strings.TrimSpace(j)
+		if j == "*" || j == jenis {
+			return true
+		}
+	}
+	return false
+}
+
+// teruskanKeWebhook mencatat satu pengiriman untuk setiap webhook aktif
+// yang berlangganan jenis dan afdeling peristiwa, lalu mengirimkannya
+func teruskanKeWebhook(p Peristiwa) {
+	db := config.GetDB()
+	var hooks []models.Webhook
+	if err := db.Where("aktif = ?", true).Find(&hooks).Error; err != nil {
+		log.Printf("Gagal memuat webhook: %v", err)
+		return
+	}
+
+	var payload []byte
+	for _, h := range hooks {
+		if !cocokJenisWebhook(h.Jenis, p.Jenis) {
+			continue
+		}
+		if h.AfdelingID != nil && p.AfdelingID != 0 && *h.AfdelingID != p.AfdelingID {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(p); err != nil {
+				log.Printf("Gagal membuat payload webhook %s: %v", p.Jenis, err)
+				return
+			}
+		}
+		pengiriman := models.PengirimanWebhook{
+			WebhookID:   h.ID,
+			IDPeristiwa: p.ID,
+			Jenis:       p.Jenis,
+			Payload:     string(payload),
+			Status:      models.StatusWebhookMenunggu,
+		}
+		if err := db.Create(&pengiriman).Error; err != nil {
+			log.Printf("Gagal mencatat pengiriman webhook %d: %v", h.ID, err)
+			continue
+		}
+		go jalankanPengirimanWebhook(pengiriman.ID)
+	}
+}
+
+// tandaTanganWebhook menghitung signature untuk header X-Webhook-Signature
+func tandaTanganWebhook(rahasia, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(rahasia))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// jedaWebhook adalah jeda sebelum percobaan berikutnya setelah percobaan
+// ke-n gagal (backoff eksponensial)
+func jedaWebhook(n int) time.Duration {
+	jeda := jedaDasarWebhook
+	for i := 1; i < n && jeda < jedaMaksWebhook; i++ {
+		jeda *= 2
+	}
+	if jeda > jedaMaksWebhook {
+		return jedaMaksWebhook
+	}
+	return jeda
+}
+
+// kirimSekaliWebhook melakukan satu percobaan HTTP POST; 2xx berarti
+// berhasil
+func kirimSekaliWebhook(h models.Webhook, p models.PengirimanWebhook) (int, error) {
+	body := []byte(p.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "app-inputan-ptpn-webhook/1")
+	req.Header.Set(headerWebhookID, strconv.FormatUint(uint64(p.ID), 10))
+	req.Header.Set(headerWebhookJenis, p.Jenis)
+	req.Header.Set(headerWebhookTimestamp, timestamp)
+	req.Header.Set(headerWebhookSignature, tandaTanganWebhook(h.Rahasia, timestamp, body))
+
+	resp, err := klienWebhook.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	ringkas, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(ringkas)))
+	}
+	return resp.StatusCode, nil
+}
+
+// jalankanPengirimanWebhook mencoba mengirim sampai berhasil atau
+// percobaan habis. Setiap percobaan dicatat satu baris di
+// percobaan_webhooks dan status pengiriman ikut diperbarui, sehingga
+// pengiriman bisa dilanjutkan setelah aplikasi dijalankan ulang.
+func jalankanPengirimanWebhook(id uint) {
+	db := config.GetDB()
+	for {
+		var p models.PengirimanWebhook
+		if err := db.First(&p, id).Error; err != nil || p.Status != models.StatusWebhookMenunggu {
+			return
+		}
+		if p.BerikutnyaPada != nil {
+			time.Sleep(time.Until(*p.BerikutnyaPada))
+		}
+
+		var h models.Webhook
+		if err := db.First(&h, p.WebhookID).Error; err != nil {
+			db.Model(&p).Updates(map[string]interface{}{
+				"status":    models.StatusWebhookGagal,
+				"kesalahan": "webhook sudah dihapus",
+			})
+			return
+		}
+
+		mulai := time.Now()
+		kode, err := kirimSekaliWebhook(h, p)
+		sekarang := time.Now()
+		percobaan := models.PercobaanWebhook{
+			PengirimanID: p.ID,
+			Ke:           p.Percobaan + 1,
+			KodeRespons:  kode,
+			DurasiMs:     sekarang.Sub(mulai).Milliseconds(),
+		}
+		if err != nil {
+			percobaan.Kesalahan = err.Error()
+		}
+		perubahan := map[string]interface{}{
+			"percobaan":    p.Percobaan + 1,
+			"kode_respons": kode,
+		}
+		switch {
+		case err == nil:
+			perubahan["status"] = models.StatusWebhookBerhasil
+			perubahan["kesalahan"] = ""
+			perubahan["terkirim_pada"] = sekarang
+			perubahan["berikutnya_pada"] = nil
+		case p.Percobaan+1 >= maksPercobaanWebhook:
+			perubahan["status"] = models.StatusWebhookGagal
+			perubahan["kesalahan"] = err.Error()
+			perubahan["berikutnya_pada"] = nil
+			log.Printf("❌ Webhook %s (%d) gagal setelah %d percobaan: %v", h.Nama, p.ID, p.Percobaan+1, err)
+		default:
+			perubahan["kesalahan"] = err.Error()
+			perubahan["berikutnya_pada"] = sekarang.Add(jedaWebhook(p.Percobaan + 1))
+		}
+		// Log percobaan dan status pengiriman disimpan bersama
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&percobaan).Error; err != nil {
+				return err
+			}
+			return tx.Model(&p).Updates(perubahan).Error
+		}); err != nil {
+			log.Printf("Gagal memperbarui pengiriman webhook %d: %v", p.ID, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"app-inputan-ptpn/config/configtest"
+	"app-inputan-ptpn/models"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const rahasiaUji = "rahasia-webhook-uji-123"
+
+// penerimaUji mencatat setiap permintaan dan memverifikasi signature-nya.
+// kode berisi status HTTP untuk setiap permintaan berurutan; setelah habis
+// penerima membalas 200.
+type penerimaUji struct {
+	mu       sync.Mutex
+	kode     []int
+	diterima int
+	salah    []string
+}
+
+// hasil mengembalikan jumlah permintaan dan signature yang tidak cocok
+func (p *penerimaUji) hasil() (int, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.diterima, p.salah
+}
+
+func (p *penerimaUji) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp := r.Header.Get(headerWebhookTimestamp)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if got, ingin := r.Header.Get(headerWebhookSignature), tandaTanganWebhook(rahasiaUji, timestamp, body); got != ingin {
+		p.salah = append(p.salah, got)
+	}
+	kode := http.StatusOK
+	if p.diterima < len(p.kode) {
+		kode = p.kode[p.diterima]
+	}
+	p.diterima++
+	w.WriteHeader(kode)
+}
+
+func siapkanWebhookUji(t *testing.T, kode ...int) (*gorm.DB, *penerimaUji, models.PengirimanWebhook) {
+	t.Helper()
+	db := configtest.InitTestDB(t)
+
+	lamaJeda := jedaDasarWebhook
+	jedaDasarWebhook = time.Millisecond
+	t.Cleanup(func() { jedaDasarWebhook = lamaJeda })
+
+	penerima := &penerimaUji{kode: kode}
+	srv := httptest.NewServer(penerima)
+	t.Cleanup(srv.Close)
+
+	h := models.Webhook{Nama: "Uji", URL: srv.URL, Rahasia: rahasiaUji, Jenis: "*", Aktif: true}
+	if err := db.Create(&h).Error; err != nil {
+		t.Fatal(err)
+	}
+	p := models.PengirimanWebhook{
+		WebhookID:   h.ID,
+		IDPeristiwa: "peristiwa-1",
+		Jenis:       "day.closed",
+		Payload:     `{"id":"peristiwa-1","jenis":"day.closed"}`,
+		Status:      models.StatusWebhookMenunggu,
+	}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, penerima, p
+}
+
+func percobaanPengiriman(t *testing.T, db *gorm.DB, id uint) []models.PercobaanWebhook {
+	t.Helper()
+	var list []models.PercobaanWebhook
+	if err := db.Where("pengiriman_id = ?", id).Order("ke asc").Find(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestTandaTanganWebhook(t *testing.T) {
+	// Acuan: printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac rahasia
+	ingin := "sha256=efc40694ade7cea9d382b67309b4bdda5bb978766141c75a19fd823196cbedd5"
+	if got := tandaTanganWebhook("rahasia", "1700000000", []byte(`{"a":1}`)); got != ingin {
+		t.Fatalf("signature = %s, ingin %s", got, ingin)
+	}
+	if tandaTanganWebhook("rahasia", "1700000001", []byte(`{"a":1}`)) == ingin {
+		t.Error("timestamp harus ikut ditandatangani")
+	}
+}
+
+// 5xx dicoba ulang dengan backoff; setiap percobaan tercatat satu baris
+func TestPengirimanWebhookDicobaUlang(t *testing.T) {
+	db, penerima, p := siapkanWebhookUji(t, http.StatusInternalServerError, http.StatusBadGateway)
+
+	jalankanPengirimanWebhook(p.ID)
+
+	diterima, salah := penerima.hasil()
+	if diterima != 3 {
+		t.Fatalf("penerima menerima %d permintaan, ingin 3", diterima)
+	}
+	if len(salah) > 0 {
+		t.Fatalf("signature tidak cocok dengan HMAC timestamp.body: %v", salah)
+	}
+
+	var hasil models.PengirimanWebhook
+	db.First(&hasil, p.ID)
+	if hasil.Status != models.StatusWebhookBerhasil || hasil.Percobaan != 3 || hasil.KodeRespons != http.StatusOK {
+		t.Fatalf("pengiriman = %s, %d percobaan, kode %d; ingin BERHASIL, 3, 200", hasil.Status, hasil.Percobaan, hasil.KodeRespons)
+	}
+
+	log := percobaanPengiriman(t, db, p.ID)
+	ingin := []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}
+	if len(log) != len(ingin) {
+		t.Fatalf("%d baris percobaan, ingin %d", len(log), len(ingin))
+	}
+	for i, c := range log {
+		if c.Ke != i+1 || c.KodeRespons != ingin[i] {
+			t.Errorf("percobaan %d = ke %d kode %d, ingin ke %d kode %d", i, c.Ke, c.KodeRespons, i+1, ingin[i])
+		}
+		if (c.Kesalahan == "") != (ingin[i] == http.StatusOK) {
+			t.Errorf("percobaan %d kesalahan = %q", i+1, c.Kesalahan)
+		}
+	}
+}
+
+func TestPengirimanWebhookGagalSetelahBatas(t *testing.T) {
+	lama := maksPercobaanWebhook
+	maksPercobaanWebhook = 3
+	t.Cleanup(func() { maksPercobaanWebhook = lama })
+
+	db, penerima, p := siapkanWebhookUji(t, 500, 500, 500, 500)
+
+	jalankanPengirimanWebhook(p.ID)
+
+	var hasil models.PengirimanWebhook
+	db.First(&hasil, p.ID)
+	if diterima, _ := penerima.hasil(); hasil.Status != models.StatusWebhookGagal || diterima != 3 {
+		t.Fatalf("status %s setelah %d permintaan, ingin GAGAL setelah 3", hasil.Status, diterima)
+	}
+	if n := len(percobaanPengiriman(t, db, p.ID)); n != 3 {
+		t.Errorf("%d baris percobaan, ingin 3", n)
+	}
+}
+
+func TestJedaWebhook(t *testing.T) {
+	lama := jedaDasarWebhook
+	jedaDasarWebhook = time.Second
+	t.Cleanup(func() { jedaDasarWebhook = lama })
+
+	tests := []struct {
+		n     int
+		ingin time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{20, jedaMaksWebhook},
+	}
+	for _, tt := range tests {
+		if got := jedaWebhook(tt.n); got != tt.ingin {
+			t.Errorf("jedaWebhook(%d) = %v, ingin %v", tt.n, got, tt.ingin)
+		}
+	}
+}
+
+func TestUlangPengirimanWebhook(t *testing.T) {
+	lama := maksPercobaanWebhook
+	maksPercobaanWebhook = 1
+	t.Cleanup(func() { maksPercobaanWebhook = lama })
+	db, penerima, p := siapkanWebhookUji(t, http.StatusServiceUnavailable)
+
+	ulang := func(id uint) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/webhook/pengiriman/"+strconv.Itoa(int(id))+"/ulang", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(int(id))})
+		return panggilHandler(t, UlangPengirimanWebhook, req, nil)
+	}
+
+	// Pengiriman yang masih menunggu tidak boleh diulang
+	if code := ulang(p.ID); code != http.StatusConflict {
+		t.Fatalf("ulang pengiriman MENUNGGU = %d, ingin 409", code)
+	}
+
+	jalankanPengirimanWebhook(p.ID)
+	if code := ulang(p.ID); code != http.StatusAccepted {
+		t.Fatalf("ulang pengiriman GAGAL = %d, ingin 202", code)
+	}
+
+	// Pengiriman ulang berjalan di goroutine; tunggu sampai selesai
+	var baru models.PengirimanWebhook
+	batas := time.Now().Add(2 * time.Second)
+	for {
+		err := db.Where("ulang_dari = ?", p.ID).First(&baru).Error
+		if err == nil && baru.Status != models.StatusWebhookMenunggu {
+			break
+		}
+		if time.Now().After(batas) {
+			t.Fatalf("pengiriman ulang tidak selesai: %+v (%v)", baru, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if baru.ID == p.ID || baru.Payload != p.Payload || baru.Status != models.StatusWebhookBerhasil {
+		t.Fatalf("pengiriman ulang = %+v, ingin baris baru BERHASIL dengan payload sama", baru)
+	}
+	if n := len(percobaanPengiriman(t, db, baru.ID)); n != 1 {
+		t.Errorf("pengiriman ulang punya %d baris percobaan, ingin 1", n)
+	}
+	if diterima, salah := penerima.hasil(); diterima != 2 || len(salah) > 0 {
+		t.Errorf("penerima: %d permintaan, signature salah %v; ingin 2 dan tidak ada yang salah", diterima, salah)
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	db := configtest.InitTestDB(t)
+	db.Create(&models.User{Username: "kepala", Password: "x", Role: models.RoleAdmin})
+	db.Create(&models.User{Username: "operator", Password: "x", Role: models.RoleOperator})
+
+	handler := AdminMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(w, http.StatusOK, APIResponse{Success: true})
+	})
+	tests := []struct {
+		username string
+		ingin    int
+	}{
+		{"kepala", http.StatusOK},
+		{"operator", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/webhook", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "username", tt.username))
+		if code := panggilHandler(t, handler, req, nil); code != tt.ingin {
+			t.Errorf("username %q = %d, ingin %d", tt.username, code, tt.ingin)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package geo
+
+import "math"
+
+// Jari-jari ekuator WGS84 dalam meter, sama dengan yang dipakai Google Maps
+// dan Turf sehingga hasilnya bisa dibandingkan dengan aplikasi peta lain
+const radiusBumi = 6378137.0
+
+// LuasHa menghitung luas geodesik multipolygon dalam hektar. Lubang
+// (inner ring) dikurangkan dari cincin luarnya.
+func (mp MultiPolygon) LuasHa() float64 {
+	total := 0.0
+	for _, poly := range mp {
+		for i, ring := range poly {
+			luas := math.Abs(ring.luasBertanda())
+			if i == 0 {
+				total += luas
+			} else {
+				total -= luas
+			}
+		}
+	}
+	return total / 10000
+}
+
+// luasBertanda memakai rumus spherical excess untuk poligon kecil di bola:
+// A = R²/2 * Σ (λ2-λ1) * (2 + sin φ1 + sin φ2)
+func (ring Ring) luasBertanda() float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	sum := 0.0
+	for i := 0; i < len(ring)-1; i++ {
+		p1, p2 := ring[i], ring[i+1]
+		lng1, lat1 := radian(p1[0]), radian(p1[1])
+		lng2, lat2 := radian(p2[0]), radian(p2[1])
+		sum += (lng2 - lng1) * (2 + math.Sin(lat1) + math.Sin(lat2))
+	}
+	return sum * radiusBumi * radiusBumi / 2
+}
+
+func radian(derajat float64) float64 {
+	return derajat * math.Pi / 180
+}
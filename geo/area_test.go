@@ -0,0 +1,85 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// luasPersegiHa adalah luas persegi lintang/bujur di bola secara analitik:
+// R² * Δλ * |sin φ2 - sin φ1|
+func luasPersegiHa(lng1, lat1, lng2, lat2 float64) float64 {
+	return radiusBumi * radiusBumi * math.Abs(radian(lng2-lng1)) *
+		math.Abs(math.Sin(radian(lat2))-math.Sin(radian(lat1))) / 10000
+}
+
+// luasProyeksiHa menghitung luas dengan proyeksi equirectangular lokal dan
+// rumus shoelace, sebagai pembanding independen untuk poligon kecil
+func luasProyeksiHa(ring Ring) float64 {
+	lat0 := radian(ring[0][1])
+	sum := 0.0
+	for i := 0; i < len(ring)-1; i++ {
+		x1 := radian(ring[i][0]) * math.Cos(lat0) * radiusBumi
+		y1 := radian(ring[i][1]) * radiusBumi
+		x2 := radian(ring[i+1][0]) * math.Cos(lat0) * radiusBumi
+		y2 := radian(ring[i+1][1]) * radiusBumi
+		sum += x1*y2 - x2*y1
+	}
+	return math.Abs(sum) / 2 / 10000
+}
+
+func balik(r Ring) Ring {
+	hasil := make(Ring, len(r))
+	for i, p := range r {
+		hasil[len(r)-1-i] = p
+	}
+	return hasil
+}
+
+func TestLuasHa(t *testing.T) {
+	// Kotak 0,01° x 0,01° di lintang kebun Ngobo
+	persegi := Ring{{110.44, -7.17}, {110.45, -7.17}, {110.45, -7.16}, {110.44, -7.16}, {110.44, -7.17}}
+
+	tests := []struct {
+		nama    string
+		bentuk  MultiPolygon
+		ingin   float64
+		toleran float64 // relatif
+	}{
+		{"persegi analitik", MultiPolygon{{persegi}}, luasPersegiHa(110.44, -7.17, 110.45, -7.16), 1e-6},
+		{"persegi arah terbalik", MultiPolygon{{balik(persegi)}}, luasPersegiHa(110.44, -7.17, 110.45, -7.16), 1e-6},
+		{"KANTOR AFD SETRO", kantorAfdSetro, luasProyeksiHa(kantorAfdSetro[0][0]), 1e-3},
+		{"cincin kurang dari tiga titik", MultiPolygon{{Ring{{110.44, -7.17}, {110.45, -7.17}}}}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			got := tt.bentuk.LuasHa()
+			if math.Abs(got-tt.ingin) > tt.toleran*tt.ingin {
+				t.Errorf("LuasHa = %.6f ha, ingin %.6f ha", got, tt.ingin)
+			}
+		})
+	}
+}
+
+func TestLuasHaDikurangiLubang(t *testing.T) {
+	pm := placemarkNgobo(t, "KM-IE10-17-STR003")
+
+	luar, lubang := 0.0, 0.0
+	for _, poly := range pm.Polygons {
+		luar += MultiPolygon{{poly[0]}}.LuasHa()
+		for _, r := range poly[1:] {
+			lubang += MultiPolygon{{r}}.LuasHa()
+		}
+	}
+	if lubang <= 0 {
+		t.Fatal("lubang STR003 seharusnya punya luas")
+	}
+
+	got := pm.Polygons.LuasHa()
+	if math.Abs(got-(luar-lubang)) > 1e-9 {
+		t.Errorf("LuasHa = %.6f ha, ingin luas luar %.6f - lubang %.6f", got, luar, lubang)
+	}
+	// Luas blok sekitar 42,15 ha
+	if math.Abs(got-42.15) > 0.01 {
+		t.Errorf("LuasHa STR003 = %.4f ha, ingin sekitar 42,15 ha", got)
+	}
+}
@@ -0,0 +1,89 @@
+package geo
+
+// Contains memeriksa apakah titik berada di dalam salah satu poligon.
+// Titik tepat di tepi dianggap di dalam agar titik batas antar blok tetap
+// mendapat blok.
+func (mp MultiPolygon) Contains(p Point) bool {
+	for _, poly := range mp {
+		if poly.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains memeriksa titik terhadap cincin luar dan lubang poligon. Titik
+// di dalam lubang dianggap di luar poligon.
+func (poly Polygon) Contains(p Point) bool {
+	if len(poly) == 0 || !poly[0].memuat(p) {
+		return false
+	}
+	for _, lubang := range poly[1:] {
+		if lubang.memuat(p) && !lubang.diTepi(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Bounds mengembalikan kotak pembatas [minLng, minLat, maxLng, maxLat]
+func (mp MultiPolygon) Bounds() [4]float64 {
+	b := [4]float64{180, 90, -180, -90}
+	for _, poly := range mp {
+		for _, ring := range poly {
+			for _, pt := range ring {
+				b[0] = min(b[0], pt[0])
+				b[1] = min(b[1], pt[1])
+				b[2] = max(b[2], pt[0])
+				b[3] = max(b[3], pt[1])
+			}
+		}
+	}
+	return b
+}
+
+// DalamBounds memeriksa titik terhadap kotak pembatas dari Bounds
+func DalamBounds(b [4]float64, p Point) bool {
+	return p[0] >= b[0] && p[0] <= b[2] && p[1] >= b[1] && p[1] <= b[3]
+}
+
+// memuat memakai ray casting: tarik garis horizontal dari titik ke timur dan
+// hitung berapa sisi yang dipotong. Ganjil berarti di dalam. Koordinat
+// dipakai apa adanya (lng/lat) karena blok kebun jauh lebih kecil dari
+// distorsi proyeksi.
+func (ring Ring) memuat(p Point) bool {
+	if len(ring) < 3 {
+		return false
+	}
+	if ring.diTepi(p) {
+		return true
+	}
+
+	x, y := p[0], p[1]
+	dalam := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			dalam = !dalam
+		}
+	}
+	return dalam
+}
+
+// diTepi memeriksa apakah titik terletak pada salah satu sisi cincin
+func (ring Ring) diTepi(p Point) bool {
+	const eps = 1e-12
+	for i := 0; i < len(ring)-1; i++ {
+		a, b := ring[i], ring[i+1]
+		cross := (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+		if cross > eps || cross < -eps {
+			continue
+		}
+		if p[0] >= min(a[0], b[0]) && p[0] <= max(a[0], b[0]) &&
+			p[1] >= min(a[1], b[1]) && p[1] <= max(a[1], b[1]) {
+			return true
+		}
+	}
+	return false
+}
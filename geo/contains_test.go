@@ -0,0 +1,95 @@
+package geo
+
+import (
+	"os"
+	"testing"
+)
+
+// kantorAfdSetro adalah poligon "KANTOR AFD SETRO" dari KEBUN_NGOBO.kml
+var kantorAfdSetro = MultiPolygon{{{
+	{110.44457, -7.163125},
+	{110.444252, -7.162949},
+	{110.444186, -7.162946},
+	{110.444145, -7.162997},
+	{110.444075, -7.163086},
+	{110.444035, -7.163122},
+	{110.444012, -7.163146},
+	{110.444274, -7.163381},
+	{110.44457, -7.163125},
+}}}
+
+// placemarkNgobo membaca satu placemark dari templates/kml/KEBUN_NGOBO.kml
+func placemarkNgobo(t *testing.T, nama string) Placemark {
+	t.Helper()
+	data, err := os.ReadFile("../templates/kml/KEBUN_NGOBO.kml")
+	if err != nil {
+		t.Fatalf("gagal membaca KML: %v", err)
+	}
+	placemarks, err := Parse(data)
+	if err != nil {
+		t.Fatalf("gagal mengurai KML: %v", err)
+	}
+	for _, pm := range placemarks {
+		if pm.Name == nama {
+			return pm
+		}
+	}
+	t.Fatalf("placemark %s tidak ada di KEBUN_NGOBO.kml", nama)
+	return Placemark{}
+}
+
+func TestContainsPoligonSederhana(t *testing.T) {
+	tests := []struct {
+		nama  string
+		titik Point
+		ingin bool
+	}{
+		{"di dalam", Point{110.4443, -7.1631}, true},
+		{"di luar timur", Point{110.4450, -7.1631}, false},
+		{"di luar selatan", Point{110.4443, -7.1640}, false},
+		{"di dalam kotak pembatas tapi di luar poligon", Point{110.444020, -7.162960}, false},
+		{"tepat di titik sudut", Point{110.44457, -7.163125}, true},
+		{"di tengah sisi", Point{110.444411, -7.163037}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := kantorAfdSetro.Contains(tt.titik); got != tt.ingin {
+				t.Errorf("Contains(%v) = %v, ingin %v", tt.titik, got, tt.ingin)
+			}
+		})
+	}
+}
+
+// KM-IE10-17-STR003 terdiri dari dua poligon; poligon kedua punya dua lubang
+func TestContainsMultiPoligonBerlubang(t *testing.T) {
+	pm := placemarkNgobo(t, "KM-IE10-17-STR003")
+	if len(pm.Polygons) != 2 || len(pm.Polygons[1]) != 3 {
+		t.Fatalf("struktur STR003 = %d poligon, %d cincin di poligon kedua; ingin 2 dan 3",
+			len(pm.Polygons), len(pm.Polygons[1]))
+	}
+
+	tests := []struct {
+		nama  string
+		titik Point
+		ingin bool
+	}{
+		{"di poligon pertama", Point{110.4397, -7.1633}, true},
+		{"di poligon kedua dekat lubang", Point{110.4406, -7.1586}, true},
+		{"di dalam lubang", Point{110.4406443, -7.158568}, false},
+		{"di sudut lubang", Point{110.4406416, -7.1585694}, true},
+		{"di luar kedua poligon", Point{110.4407, -7.1586}, false},
+		{"jauh di luar", Point{110.4500, -7.1700}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := pm.Polygons.Contains(tt.titik); got != tt.ingin {
+				t.Errorf("Contains(%v) = %v, ingin %v", tt.titik, got, tt.ingin)
+			}
+		})
+	}
+
+	// Titik di lubang tetap di dalam cincin luar
+	if !pm.Polygons[1][0].memuat(Point{110.4406443, -7.158568}) {
+		t.Error("titik di lubang seharusnya di dalam cincin luar")
+	}
+}
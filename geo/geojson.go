@@ -0,0 +1,68 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Geometry adalah objek geometry GeoJSON (Polygon atau MultiPolygon)
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Tipe mengembalikan jenis geometry GeoJSON yang sesuai
+func (mp MultiPolygon) Tipe() string {
+	if len(mp) == 1 {
+		return "Polygon"
+	}
+	return "MultiPolygon"
+}
+
+// GeoJSON mengubah multipolygon menjadi teks geometry GeoJSON. Blok dengan
+// satu poligon disimpan sebagai Polygon agar mudah dibaca klien.
+func (mp MultiPolygon) GeoJSON() (string, error) {
+	if len(mp) == 0 {
+		return "", errors.New("geometri kosong")
+	}
+
+	var coords interface{} = mp
+	if len(mp) == 1 {
+		coords = mp[0]
+	}
+	raw, err := json.Marshal(coords)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(Geometry{Type: mp.Tipe(), Coordinates: raw})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ParseGeoJSON membaca teks geometry Polygon/MultiPolygon yang disimpan
+func ParseGeoJSON(s string) (MultiPolygon, error) {
+	var g Geometry
+	if err := json.Unmarshal([]byte(s), &g); err != nil {
+		return nil, fmt.Errorf("GeoJSON tidak valid: %w", err)
+	}
+
+	switch g.Type {
+	case "Polygon":
+		var poly Polygon
+		if err := json.Unmarshal(g.Coordinates, &poly); err != nil {
+			return nil, fmt.Errorf("koordinat Polygon tidak valid: %w", err)
+		}
+		return MultiPolygon{poly}, nil
+	case "MultiPolygon":
+		var mp MultiPolygon
+		if err := json.Unmarshal(g.Coordinates, &mp); err != nil {
+			return nil, fmt.Errorf("koordinat MultiPolygon tidak valid: %w", err)
+		}
+		return mp, nil
+	}
+	return nil, fmt.Errorf("tipe geometry '%s' tidak didukung", g.Type)
+}
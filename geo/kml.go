@@ -0,0 +1,182 @@
+// Package geo berisi pengolahan geometri blok kebun: membaca KML/KMZ dan
+// konversi ke GeoJSON, tanpa library atau binary eksternal.
+package geo
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Point adalah koordinat [lng, lat] sesuai urutan GeoJSON
+type Point [2]float64
+
+// Ring adalah cincin tertutup; titik pertama sama dengan titik terakhir
+type Ring []Point
+
+// Polygon adalah cincin luar diikuti lubang (inner ring) jika ada
+type Polygon []Ring
+
+// MultiPolygon adalah kumpulan poligon satu blok
+type MultiPolygon []Polygon
+
+// Placemark adalah satu placemark KML. Placemark tanpa poligon (garis/titik)
+// tetap dikembalikan dengan Polygons kosong agar bisa dilaporkan.
+type Placemark struct {
+	Name     string
+	Polygons MultiPolygon
+}
+
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	Polygons []kmlPolygon       `xml:"Polygon"`
+	Multi    []kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type kmlPlacemark struct {
+	Name     string             `xml:"name"`
+	Polygons []kmlPolygon       `xml:"Polygon"`
+	Multi    []kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+// Parse membaca file KML atau KMZ (zip berisi .kml), dikenali dari isinya
+func Parse(data []byte) ([]Placemark, error) {
+	if bytes.HasPrefix(data, []byte("PK")) {
+		return ParseKMZ(data)
+	}
+	return ParseKML(bytes.NewReader(data))
+}
+
+// ParseKMZ membaca file .kml pertama di dalam arsip KMZ (biasanya doc.kml)
+func ParseKMZ(data []byte) ([]Placemark, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("file KMZ tidak valid: %w", err)
+	}
+
+	var kml *zip.File
+	for _, f := range zr.File {
+		if !strings.HasSuffix(strings.ToLower(f.Name), ".kml") {
+			continue
+		}
+		if kml == nil || strings.EqualFold(f.Name, "doc.kml") {
+			kml = f
+		}
+	}
+	if kml == nil {
+		return nil, errors.New("file KMZ tidak berisi file .kml")
+	}
+
+	rc, err := kml.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ParseKML(rc)
+}
+
+// ParseKML membaca semua Placemark, termasuk yang berada di dalam Folder
+func ParseKML(r io.Reader) ([]Placemark, error) {
+	dec := xml.NewDecoder(r)
+	var hasil []Placemark
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("file KML tidak valid: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		var pm kmlPlacemark
+		if err := dec.DecodeElement(&pm, &start); err != nil {
+			return nil, fmt.Errorf("placemark tidak valid: %w", err)
+		}
+
+		polys := pm.Polygons
+		for _, m := range pm.Multi {
+			polys = append(polys, m.semuaPolygon()...)
+		}
+
+		placemark := Placemark{Name: strings.TrimSpace(pm.Name)}
+		for _, p := range polys {
+			poly, err := p.toPolygon()
+			if err != nil {
+				return nil, fmt.Errorf("placemark '%s': %w", placemark.Name, err)
+			}
+			if poly != nil {
+				placemark.Polygons = append(placemark.Polygons, poly)
+			}
+		}
+		hasil = append(hasil, placemark)
+	}
+
+	return hasil, nil
+}
+
+func (m kmlMultiGeometry) semuaPolygon() []kmlPolygon {
+	polys := m.Polygons
+	for _, sub := range m.Multi {
+		polys = append(polys, sub.semuaPolygon()...)
+	}
+	return polys
+}
+
+func (p kmlPolygon) toPolygon() (Polygon, error) {
+	outer, err := parseCoordinates(p.Outer)
+	if err != nil {
+		return nil, err
+	}
+	if len(outer) < 4 {
+		return nil, nil
+	}
+
+	poly := Polygon{outer}
+	for _, s := range p.Inner {
+		inner, err := parseCoordinates(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(inner) >= 4 {
+			poly = append(poly, inner)
+		}
+	}
+	return poly, nil
+}
+
+// parseCoordinates membaca "lng,lat[,alt] lng,lat[,alt] ..." dan menutup
+// cincin jika titik terakhir tidak sama dengan titik pertama
+func parseCoordinates(s string) (Ring, error) {
+	var ring Ring
+	for _, tuple := range strings.Fields(s) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("koordinat '%s' tidak valid", tuple)
+		}
+		lng, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("koordinat '%s' tidak valid", tuple)
+		}
+		ring = append(ring, Point{lng, lat})
+	}
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(ring, ring[0])
+	}
+	return ring, nil
+}
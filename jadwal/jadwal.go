@@ -0,0 +1,168 @@
+// Package jadwal membaca ekspresi jadwal gaya cron lima kolom:
+//
+//	menit jam tanggal bulan hari
+//
+// Setiap kolom menerima "*", angka, rentang "a-b", langkah "*/n" atau
+// "a-b/n" dan daftar dipisah koma. Hari 0 dan 7 berarti Minggu. Singkatan
+// @hourly, @daily, @weekly dan @monthly juga dikenali. Seperti cron, jika
+// kolom tanggal dan hari sama-sama dibatasi maka cukup salah satu cocok.
+package jadwal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var singkatan = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Jadwal adalah ekspresi yang sudah dibaca; setiap kolom disimpan sebagai
+// bit set nilai yang diizinkan
+type Jadwal struct {
+	teks                       string
+	menit, jam, tanggal, bulan uint64
+	hari                       uint64
+	tanggalBebas, hariBebas    bool
+}
+
+type batasKolom struct {
+	nama        string
+	bawah, atas int
+}
+
+var kolomJadwal = []batasKolom{
+	{"menit", 0, 59},
+	{"jam", 0, 23},
+	{"tanggal", 1, 31},
+	{"bulan", 1, 12},
+	{"hari", 0, 7},
+}
+
+// Parse membaca ekspresi jadwal
+func Parse(s string) (Jadwal, error) {
+	teks := strings.TrimSpace(s)
+	if pengganti, ok := singkatan[strings.ToLower(teks)]; ok {
+		teks = pengganti
+	}
+	bagian := strings.Fields(teks)
+	if len(bagian) != len(kolomJadwal) {
+		return Jadwal{}, fmt.Errorf("jadwal '%s' harus berisi 5 kolom: menit jam tanggal bulan hari", s)
+	}
+
+	var nilai [5]uint64
+	for i, b := range bagian {
+		v, err := parseKolom(b, kolomJadwal[i])
+		if err != nil {
+			return Jadwal{}, fmt.Errorf("jadwal '%s': %v", s, err)
+		}
+		nilai[i] = v
+	}
+	// Minggu boleh ditulis 7
+	if nilai[4]&(1<<7) != 0 {
+		nilai[4] = nilai[4]&^(1<<7) | 1
+	}
+
+	return Jadwal{
+		teks:         strings.Join(bagian, " "),
+		menit:        nilai[0],
+		jam:          nilai[1],
+		tanggal:      nilai[2],
+		bulan:        nilai[3],
+		hari:         nilai[4],
+		tanggalBebas: strings.HasPrefix(bagian[2], "*"),
+		hariBebas:    strings.HasPrefix(bagian[4], "*"),
+	}, nil
+}
+
+func parseKolom(s string, k batasKolom) (uint64, error) {
+	var hasil uint64
+	for _, bagian := range strings.Split(s, ",") {
+		rentang, langkah := bagian, 1
+		if i := strings.Index(bagian, "/"); i >= 0 {
+			n, err := strconv.Atoi(bagian[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("langkah '%s' pada kolom %s tidak valid", bagian, k.nama)
+			}
+			rentang, langkah = bagian[:i], n
+		}
+
+		bawah, atas := k.bawah, k.atas
+		if rentang != "*" {
+			a, b, adaRentang := strings.Cut(rentang, "-")
+			var err error
+			if bawah, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("nilai '%s' pada kolom %s tidak valid", bagian, k.nama)
+			}
+			atas = bawah
+			if adaRentang {
+				if atas, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("nilai '%s' pada kolom %s tidak valid", bagian, k.nama)
+				}
+			} else if langkah > 1 {
+				// "5/15" berarti mulai 5 sampai batas atas
+				atas = k.atas
+			}
+		}
+		if bawah < k.bawah || atas > k.atas || bawah > atas {
+			return 0, fmt.Errorf("nilai '%s' pada kolom %s di luar rentang %d-%d", bagian, k.nama, k.bawah, k.atas)
+		}
+
+		for v := bawah; v <= atas; v += langkah {
+			hasil |= 1 << uint(v)
+		}
+	}
+	return hasil, nil
+}
+
+// Cocok memeriksa apakah menit t termasuk jadwal
+func (j Jadwal) Cocok(t time.Time) bool {
+	if j.menit&(1<<uint(t.Minute())) == 0 ||
+		j.jam&(1<<uint(t.Hour())) == 0 ||
+		j.bulan&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	return j.cocokHari(t)
+}
+
+// Berikutnya mengembalikan menit pertama setelah t yang cocok, atau waktu
+// nol jika tidak ada dalam lima tahun (misalnya 31 Februari)
+func (j Jadwal) Berikutnya(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	batas := t.AddDate(5, 0, 0)
+	for t.Before(batas) {
+		switch {
+		case j.bulan&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !j.cocokHari(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case j.jam&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case j.menit&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cocokHari menggabungkan kolom tanggal dan hari seperti cron
+func (j Jadwal) cocokHari(t time.Time) bool {
+	cocokTanggal := j.tanggal&(1<<uint(t.Day())) != 0
+	cocokHari := j.hari&(1<<uint(t.Weekday())) != 0
+	if j.tanggalBebas || j.hariBebas {
+		return cocokTanggal && cocokHari
+	}
+	return cocokTanggal || cocokHari
+}
+
+// String mengembalikan ekspresi lima kolom
+func (j Jadwal) String() string {
+	return j.teks
+}
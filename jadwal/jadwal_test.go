@@ -0,0 +1,102 @@
+package jadwal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		teks  string
+		ingin string // kosong berarti harus error
+	}{
+		{"*/15 * * * *", "*/15 * * * *"},
+		{"  0 6 * * 1-5 ", "0 6 * * 1-5"},
+		{"@daily", "0 0 * * *"},
+		{"@DAILY", "0 0 * * *"},
+		{"@monthly", "0 0 1 * *"},
+		{"0 0 * * 7", "0 0 * * 7"},
+		{"5/20,0 8-17/3 1,15 * *", "5/20,0 8-17/3 1,15 * *"},
+		{"", ""},
+		{"* * * *", ""},
+		{"* * * * * *", ""},
+		{"@setiap", ""},
+		{"60 * * * *", ""},
+		{"* 24 * * *", ""},
+		{"* * 0 * *", ""},
+		{"* * * 13 *", ""},
+		{"* * * * 8", ""},
+		{"*/0 * * * *", ""},
+		{"5-1 * * * *", ""},
+		{"a * * * *", ""},
+		{"1-x * * * *", ""},
+	}
+	for _, tt := range tests {
+		j, err := Parse(tt.teks)
+		if tt.ingin == "" {
+			if err == nil {
+				t.Errorf("Parse(%q) = %q, ingin error", tt.teks, j)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.teks, err)
+			continue
+		}
+		if j.String() != tt.ingin {
+			t.Errorf("Parse(%q).String() = %q, ingin %q", tt.teks, j, tt.ingin)
+		}
+	}
+}
+
+func TestBerikutnya(t *testing.T) {
+	waktu := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		nama  string
+		teks  string
+		dari  string
+		ingin string // kosong berarti tidak ada jadwal berikutnya
+	}{
+		{"daily ke hari berikutnya", "@daily", "2025-03-10 10:00:00", "2025-03-11 00:00:00"},
+		{"daily melewati akhir bulan", "@daily", "2025-01-31 10:00:00", "2025-02-01 00:00:00"},
+		{"daily melewati akhir tahun", "@daily", "2025-12-31 23:59:00", "2026-01-01 00:00:00"},
+		{"tepat pada jadwal tidak dihitung", "30 6 * * *", "2025-03-10 06:30:00", "2025-03-11 06:30:00"},
+		{"detik dibulatkan ke menit berikutnya", "*/15 * * * *", "2025-03-10 10:07:30", "2025-03-10 10:15:00"},
+		{"pergantian jam", "*/15 * * * *", "2025-03-10 10:50:00", "2025-03-10 11:00:00"},
+		{"monthly", "@monthly", "2025-01-15 08:00:00", "2025-02-01 00:00:00"},
+		{"tanggal 31 melewati Februari", "0 0 31 * *", "2025-01-31 00:00:00", "2025-03-31 00:00:00"},
+		{"29 Februari tahun kabisat", "0 0 29 2 *", "2025-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"hari 7 berarti Minggu", "0 0 * * 7", "2025-03-10 00:00:00", "2025-03-16 00:00:00"},
+		{"hari kerja melewati akhir pekan", "0 6 * * 1-5", "2025-03-07 07:00:00", "2025-03-10 06:00:00"},
+		// Tanggal dan hari sama-sama dibatasi: cukup salah satu cocok
+		{"tanggal atau hari", "0 8 1 * 1", "2025-03-01 09:00:00", "2025-03-03 08:00:00"},
+		{"tanggal yang tidak pernah ada", "0 0 30 2 *", "2025-01-01 00:00:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			j, err := Parse(tt.teks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := j.Berikutnya(waktu(tt.dari))
+			if tt.ingin == "" {
+				if !got.IsZero() {
+					t.Errorf("Berikutnya = %v, ingin waktu nol", got)
+				}
+				return
+			}
+			if ingin := waktu(tt.ingin); !got.Equal(ingin) {
+				t.Errorf("Berikutnya(%s) = %v, ingin %v", tt.dari, got, ingin)
+			}
+			if !j.Cocok(got) {
+				t.Errorf("Cocok(%v) = false untuk hasil Berikutnya", got)
+			}
+		})
+	}
+}
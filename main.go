@@ -2,6 +2,7 @@ package main
 
 import (
 	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/controllers"
 	"app-inputan-ptpn/routes"
 	"app-inputan-ptpn/seed"
 	"fmt"
@@ -22,6 +23,12 @@ func main() {
 		log.Println("✓ .env file loaded successfully")
 	}
 
+	// Perintah migrasi skema: go run . migrate [up|down|status] [n]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Banner aplikasi
 	printBanner()
 
@@ -47,6 +54,15 @@ func main() {
 	routes.SetupRoutes()
 	fmt.Println("✓ Routing berhasil dikonfigurasi")
 
+	// Analisis anomali produksi setiap malam
+	controllers.MulaiJobAnomali()
+
+	// Teruskan peristiwa ke webhook sistem lain
+	controllers.MulaiWebhook()
+
+	// Email peristiwa dan laporan terjadwal
+	controllers.MulaiEmail()
+
 	// Start server di goroutine
 	serverReady := make(chan bool)
 	go func() {
@@ -101,7 +117,7 @@ func printBanner() {
 ║                                                              ║
 ║              Sistem Input Data Produksi PTPN                 ║
 ║                      Version 1.0.0                           ║
-║                     (MySQL Database)                         ║
+║                 (MySQL / SQLite Database)                    ║
 ║                                                              ║
 ╚══════════════════════════════════════════════════════════════╝
 `
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"app-inputan-ptpn/config"
+	"app-inputan-ptpn/migrations"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// runMigrate menjalankan perintah migrasi skema database:
+//
+//	go run . migrate up [n]     terapkan semua (atau n) migrasi yang tertunda
+//	go run . migrate down [n]   batalkan migrasi terakhir (atau n terakhir)
+//	go run . migrate status     tampilkan status setiap migrasi
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		printMigrateUsage()
+		os.Exit(1)
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			log.Fatalf("❌ Jumlah langkah tidak valid: %s", args[1])
+		}
+		steps = n
+	}
+
+	config.ConnectDB()
+
+	switch args[0] {
+	case "up":
+		done, err := migrations.Up(config.DB, steps)
+		for _, m := range done {
+			fmt.Printf("✓ %04d_%s diterapkan\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(done) == 0 {
+			fmt.Println("✓ Skema sudah versi terbaru")
+		}
+	case "down":
+		done, err := migrations.Down(config.DB, steps)
+		for _, m := range done {
+			fmt.Printf("✓ %04d_%s dibatalkan\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(done) == 0 {
+			fmt.Println("✓ Tidak ada migrasi untuk dibatalkan")
+		}
+	case "status":
+		list, err := migrations.Status(config.DB)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("%-8s %-32s %-10s %s\n", "VERSI", "NAMA", "STATUS", "DITERAPKAN")
+		for _, st := range list {
+			status := "pending"
+			appliedAt := "-"
+			if st.Applied {
+				status = "applied"
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if st.ChecksumMismatch {
+				status = "MISMATCH"
+			}
+			if st.Unknown {
+				status = "UNKNOWN"
+			}
+			fmt.Printf("%-8d %-32s %-10s %s\n", st.Version, st.Name, status, appliedAt)
+		}
+	default:
+		printMigrateUsage()
+		os.Exit(1)
+	}
+}
+
+func printMigrateUsage() {
+	fmt.Println("Penggunaan: go run . migrate [up|down|status] [n]")
+	fmt.Println("  up [n]    terapkan semua (atau n) migrasi yang tertunda")
+	fmt.Println("  down [n]  batalkan migrasi terakhir (default 1)")
+	fmt.Println("  status    tampilkan status migrasi")
+}
@@ -0,0 +1,284 @@
+package migrations
+
+// Skema awal, sama dengan hasil AutoMigrate sebelum ada sistem migrasi.
+// Memakai IF NOT EXISTS agar database lama bisa langsung diadopsi.
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS users (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				username VARCHAR(100) NOT NULL,
+				password VARCHAR(255) NOT NULL,
+				last_login DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				CONSTRAINT uni_users_username UNIQUE (username)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS uploads (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tanggal DATETIME(3) NOT NULL,
+				file_name VARCHAR(255) NOT NULL,
+				file_path VARCHAR(500) NOT NULL,
+				file_size BIGINT NOT NULL,
+				mime_type VARCHAR(100),
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				deleted_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_uploads_tanggal (tanggal),
+				INDEX idx_uploads_deleted_at (deleted_at)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS masters (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tanggal DATE NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				nama_file VARCHAR(255) NOT NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS peta (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				blok VARCHAR(255),
+				code VARCHAR(255) NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				luas FLOAT NOT NULL DEFAULT 0,
+				jumlah_pohon BIGINT NOT NULL DEFAULT 0,
+				jenis_kebun VARCHAR(255),
+				tahun_tanam VARCHAR(255),
+				kloon VARCHAR(255),
+				PRIMARY KEY (id)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS penyadaps (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				nama_penyadap VARCHAR(100) NOT NULL,
+				nik VARCHAR(100) NOT NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				deleted_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_penyadaps_nik (nik),
+				INDEX idx_penyadaps_deleted_at (deleted_at)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS mandors (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tahun_tanam LONGTEXT NOT NULL,
+				nik LONGTEXT,
+				nama LONGTEXT,
+				PRIMARY KEY (id)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS produksis (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tanggal DATE NOT NULL,
+				tipe_produksi VARCHAR(100) NOT NULL,
+				tahun_tanam VARCHAR(10) NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				nama_penyadap VARCHAR(100) NOT NULL,
+				basah_latek DOUBLE NOT NULL DEFAULT 0,
+				sheet DOUBLE NOT NULL DEFAULT 0,
+				basah_lump DOUBLE NOT NULL DEFAULT 0,
+				br_cr DOUBLE NOT NULL DEFAULT 0,
+				afdeling VARCHAR(100) NOT NULL,
+				total_produksi DECIMAL(10,2) DEFAULT 0,
+				id_master BIGINT UNSIGNED NOT NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_produksis_tanggal (tanggal),
+				INDEX idx_produksis_nik (nik),
+				INDEX idx_produksis_id_master (id_master)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS rekaps (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tanggal DATE NOT NULL,
+				tipe_produksi TEXT NOT NULL,
+				tahun_tanam VARCHAR(10) NOT NULL,
+				nik VARCHAR(20) NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				hko_hari_ini BIGINT DEFAULT 0,
+				hko_sampai_hari_ini BIGINT DEFAULT 0,
+				hari_ini_basah_latek_kebun DECIMAL(10,2) DEFAULT 0,
+				hari_ini_basah_latek_pabrik DECIMAL(10,2) DEFAULT 0,
+				hari_ini_basah_latek_persen DECIMAL(5,2) DEFAULT 0,
+				hari_ini_basah_lump_kebun DECIMAL(10,2) DEFAULT 0,
+				hari_ini_basah_lump_pabrik DECIMAL(10,2) DEFAULT 0,
+				hari_ini_basah_lump_persen DECIMAL(5,2) DEFAULT 0,
+				hari_ini_k3_sheet DECIMAL(10,2) DEFAULT 0,
+				hari_ini_kering_sheet DECIMAL(10,2) DEFAULT 0,
+				hari_ini_kering_br_cr DECIMAL(10,2) DEFAULT 0,
+				hari_ini_kering_jumlah DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_basah_latek_kebun DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_basah_latek_pabrik DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_basah_latek_persen DECIMAL(5,2) DEFAULT 0,
+				sampai_hari_ini_basah_lump_kebun DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_basah_lump_pabrik DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_basah_lump_persen DECIMAL(5,2) DEFAULT 0,
+				sampai_hari_ini_k3_sheet DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_kering_sheet DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_kering_br_cr DECIMAL(10,2) DEFAULT 0,
+				sampai_hari_ini_kering_jumlah DECIMAL(10,2) DEFAULT 0,
+				produksi_per_taper_hari_ini DECIMAL(10,2) DEFAULT 0,
+				produksi_per_taper_sampai_hari_ini DECIMAL(10,2) DEFAULT 0,
+				total_produksi_hari_ini DECIMAL(10,2) DEFAULT 0,
+				total_produksi_sampai_hari_ini DECIMAL(10,2) DEFAULT 0,
+				afdeling VARCHAR(100) NOT NULL,
+				id_master BIGINT UNSIGNED NOT NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_rekaps_tanggal (tanggal),
+				INDEX idx_rekaps_nik (nik),
+				INDEX idx_rekaps_afdeling (afdeling),
+				INDEX idx_rekaps_id_master (id_master)
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS rekaps`},
+			{SQL: `DROP TABLE IF EXISTS produksis`},
+			{SQL: `DROP TABLE IF EXISTS mandors`},
+			{SQL: `DROP TABLE IF EXISTS penyadaps`},
+			{SQL: `DROP TABLE IF EXISTS peta`},
+			{SQL: `DROP TABLE IF EXISTS masters`},
+			{SQL: `DROP TABLE IF EXISTS uploads`},
+			{SQL: `DROP TABLE IF EXISTS users`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS users (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				username VARCHAR(100) NOT NULL,
+				password VARCHAR(255) NOT NULL,
+				last_login DATETIME,
+				CONSTRAINT uni_users_username UNIQUE (username)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS uploads (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tanggal DATETIME NOT NULL,
+				file_name VARCHAR(255) NOT NULL,
+				file_path VARCHAR(500) NOT NULL,
+				file_size INTEGER NOT NULL,
+				mime_type VARCHAR(100),
+				created_at DATETIME,
+				updated_at DATETIME,
+				deleted_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_uploads_tanggal ON uploads (tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_uploads_deleted_at ON uploads (deleted_at)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS masters (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tanggal DATE NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				nama_file VARCHAR(255) NOT NULL,
+				created_at DATETIME,
+				updated_at DATETIME
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS peta (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				blok VARCHAR(255),
+				code VARCHAR(255) NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				luas REAL NOT NULL DEFAULT 0,
+				jumlah_pohon INTEGER NOT NULL DEFAULT 0,
+				jenis_kebun VARCHAR(255),
+				tahun_tanam VARCHAR(255),
+				kloon VARCHAR(255)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS penyadaps (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				nama_penyadap VARCHAR(100) NOT NULL,
+				nik VARCHAR(100) NOT NULL,
+				created_at DATETIME,
+				updated_at DATETIME,
+				deleted_at DATETIME
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_penyadaps_nik ON penyadaps (nik)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_penyadaps_deleted_at ON penyadaps (deleted_at)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS mandors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tahun_tanam TEXT NOT NULL,
+				nik TEXT,
+				nama TEXT
+			)`},
+			// Di SQLite foreign key tidak bisa ditambahkan lewat ALTER TABLE,
+			// jadi FK ke masters (migrasi 2 di MySQL) langsung ditulis di sini.
+			{SQL: `CREATE TABLE IF NOT EXISTS produksis (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tanggal DATE NOT NULL,
+				tipe_produksi VARCHAR(100) NOT NULL,
+				tahun_tanam VARCHAR(10) NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				nama_penyadap VARCHAR(100) NOT NULL,
+				basah_latek REAL NOT NULL DEFAULT 0,
+				sheet REAL NOT NULL DEFAULT 0,
+				basah_lump REAL NOT NULL DEFAULT 0,
+				br_cr REAL NOT NULL DEFAULT 0,
+				afdeling VARCHAR(100) NOT NULL,
+				total_produksi NUMERIC DEFAULT 0,
+				id_master INTEGER NOT NULL,
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_produksis_master FOREIGN KEY (id_master) REFERENCES masters(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_produksis_tanggal ON produksis (tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_produksis_nik ON produksis (nik)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_produksis_id_master ON produksis (id_master)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS rekaps (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tanggal DATE NOT NULL,
+				tipe_produksi TEXT NOT NULL,
+				tahun_tanam VARCHAR(10) NOT NULL,
+				nik VARCHAR(20) NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				hko_hari_ini INTEGER DEFAULT 0,
+				hko_sampai_hari_ini INTEGER DEFAULT 0,
+				hari_ini_basah_latek_kebun NUMERIC DEFAULT 0,
+				hari_ini_basah_latek_pabrik NUMERIC DEFAULT 0,
+				hari_ini_basah_latek_persen NUMERIC DEFAULT 0,
+				hari_ini_basah_lump_kebun NUMERIC DEFAULT 0,
+				hari_ini_basah_lump_pabrik NUMERIC DEFAULT 0,
+				hari_ini_basah_lump_persen NUMERIC DEFAULT 0,
+				hari_ini_k3_sheet NUMERIC DEFAULT 0,
+				hari_ini_kering_sheet NUMERIC DEFAULT 0,
+				hari_ini_kering_br_cr NUMERIC DEFAULT 0,
+				hari_ini_kering_jumlah NUMERIC DEFAULT 0,
+				sampai_hari_ini_basah_latek_kebun NUMERIC DEFAULT 0,
+				sampai_hari_ini_basah_latek_pabrik NUMERIC DEFAULT 0,
+				sampai_hari_ini_basah_latek_persen NUMERIC DEFAULT 0,
+				sampai_hari_ini_basah_lump_kebun NUMERIC DEFAULT 0,
+				sampai_hari_ini_basah_lump_pabrik NUMERIC DEFAULT 0,
+				sampai_hari_ini_basah_lump_persen NUMERIC DEFAULT 0,
+				sampai_hari_ini_k3_sheet NUMERIC DEFAULT 0,
+				sampai_hari_ini_kering_sheet NUMERIC DEFAULT 0,
+				sampai_hari_ini_kering_br_cr NUMERIC DEFAULT 0,
+				sampai_hari_ini_kering_jumlah NUMERIC DEFAULT 0,
+				produksi_per_taper_hari_ini NUMERIC DEFAULT 0,
+				produksi_per_taper_sampai_hari_ini NUMERIC DEFAULT 0,
+				total_produksi_hari_ini NUMERIC DEFAULT 0,
+				total_produksi_sampai_hari_ini NUMERIC DEFAULT 0,
+				afdeling VARCHAR(100) NOT NULL,
+				id_master INTEGER NOT NULL,
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_rekaps_master FOREIGN KEY (id_master) REFERENCES masters(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_rekaps_tanggal ON rekaps (tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_rekaps_nik ON rekaps (nik)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_rekaps_afdeling ON rekaps (afdeling)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_rekaps_id_master ON rekaps (id_master)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS rekaps`},
+			{SQL: `DROP TABLE IF EXISTS produksis`},
+			{SQL: `DROP TABLE IF EXISTS mandors`},
+			{SQL: `DROP TABLE IF EXISTS penyadaps`},
+			{SQL: `DROP TABLE IF EXISTS peta`},
+			{SQL: `DROP TABLE IF EXISTS masters`},
+			{SQL: `DROP TABLE IF EXISTS uploads`},
+			{SQL: `DROP TABLE IF EXISTS users`},
+		},
+	})
+}
@@ -0,0 +1,93 @@
+package migrations
+
+// Foreign key Produksi/Rekap ke Master dengan CASCADE, sebelumnya ditambahkan
+// oleh addForeignKeyConstraints di setiap startup.
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "master_foreign_keys",
+		Up: []Step{
+			{
+				SQL: `ALTER TABLE produksis
+					ADD CONSTRAINT fk_produksis_master
+					FOREIGN KEY (id_master) REFERENCES masters(id)
+					ON DELETE CASCADE ON UPDATE CASCADE`,
+				SkipIf: constraintExists("produksis", "fk_produksis_master"),
+			},
+			{
+				SQL: `ALTER TABLE rekaps
+					ADD CONSTRAINT fk_rekaps_master
+					FOREIGN KEY (id_master) REFERENCES masters(id)
+					ON DELETE CASCADE ON UPDATE CASCADE`,
+				SkipIf: constraintExists("rekaps", "fk_rekaps_master"),
+			},
+		},
+		Down: []Step{
+			{
+				SQL:    `ALTER TABLE rekaps DROP FOREIGN KEY fk_rekaps_master`,
+				SkipIf: constraintMissing("rekaps", "fk_rekaps_master"),
+			},
+			{
+				SQL:    `ALTER TABLE produksis DROP FOREIGN KEY fk_produksis_master`,
+				SkipIf: constraintMissing("produksis", "fk_produksis_master"),
+			},
+		},
+		// SQLite: FK sudah ditulis di CREATE TABLE migrasi 1
+		SQLiteUp:   []Step{},
+		SQLiteDown: []Step{},
+	})
+}
+
+func constraintExists(table, constraint string) string {
+	return `SELECT COUNT(*) FROM information_schema.table_constraints
+		WHERE constraint_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND constraint_name = '` + constraint + `'`
+}
+
+func constraintMissing(table, constraint string) string {
+	return `SELECT COUNT(*) = 0 FROM information_schema.table_constraints
+		WHERE constraint_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND constraint_name = '` + constraint + `'`
+}
+
+func indexExists(table, index string) string {
+	return `SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND index_name = '` + index + `'`
+}
+
+func indexMissing(table, index string) string {
+	return `SELECT COUNT(*) = 0 FROM information_schema.statistics
+		WHERE table_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND index_name = '` + index + `'`
+}
+
+func sqliteIndexExists(index string) string {
+	return `SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = '` + index + `'`
+}
+
+func columnExists(table, column string) string {
+	return `SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND column_name = '` + column + `'`
+}
+
+func columnMissing(table, column string) string {
+	return `SELECT COUNT(*) = 0 FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND column_name = '` + column + `'`
+}
+
+func sqliteColumnExists(table, column string) string {
+	return `SELECT COUNT(*) FROM pragma_table_info('` + table + `') WHERE name = '` + column + `'`
+}
+
+func sqliteColumnMissing(table, column string) string {
+	return `SELECT COUNT(*) = 0 FROM pragma_table_info('` + table + `') WHERE name = '` + column + `'`
+}
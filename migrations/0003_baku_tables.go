@@ -0,0 +1,187 @@
+package migrations
+
+// Tabel input baku harian (mandor, penyadap, detail) dan log operasi sync
+// perangkat lapangan. Kolom tipe memakai VARCHAR karena MySQL tidak bisa
+// mengindeks TEXT tanpa panjang prefix.
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "baku_tables",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_mandors (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tahun_tanam BIGINT UNSIGNED NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				tipe VARCHAR(50) NOT NULL DEFAULT 'BAKU',
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				deleted_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_baku_mandors_tipe (tipe),
+				INDEX idx_baku_mandors_deleted_at (deleted_at)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_penyadaps (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				uuid VARCHAR(36) NOT NULL,
+				id_baku_mandor BIGINT UNSIGNED NOT NULL,
+				id_penyadap BIGINT UNSIGNED NOT NULL,
+				tanggal DATETIME(3) NOT NULL,
+				tipe VARCHAR(50) NOT NULL DEFAULT 'BAKU',
+				tahun_tanam BIGINT UNSIGNED,
+				basah_latex DOUBLE DEFAULT 0,
+				sheet DOUBLE DEFAULT 0,
+				basah_lump DOUBLE DEFAULT 0,
+				br_cr DOUBLE DEFAULT 0,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				deleted_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_baku_penyadaps_uuid (uuid),
+				INDEX idx_baku_penyadaps_id_baku_mandor (id_baku_mandor),
+				INDEX idx_baku_penyadaps_id_penyadap (id_penyadap),
+				INDEX idx_baku_penyadaps_tanggal (tanggal),
+				INDEX idx_baku_penyadaps_tipe (tipe),
+				INDEX idx_baku_penyadaps_updated_at (updated_at),
+				INDEX idx_baku_penyadaps_deleted_at (deleted_at)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_details (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tanggal DATETIME(3) NOT NULL,
+				id_baku_mandor BIGINT UNSIGNED NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				tahun_tanam BIGINT UNSIGNED,
+				tipe VARCHAR(50) NOT NULL DEFAULT 'BAKU',
+				jumlah_pabrik_basah_latek DOUBLE DEFAULT 0,
+				jumlah_kebun_basah_latek DOUBLE DEFAULT 0,
+				selisih_basah_latek DOUBLE DEFAULT 0,
+				persentase_selisih_basah_latek DOUBLE DEFAULT 0,
+				jumlah_sheet DOUBLE DEFAULT 0,
+				k3_sheet DOUBLE DEFAULT 0,
+				jumlah_pabrik_basah_lump DOUBLE DEFAULT 0,
+				jumlah_kebun_basah_lump DOUBLE DEFAULT 0,
+				selisih_basah_lump DOUBLE DEFAULT 0,
+				persentase_selisih_basah_lump DOUBLE DEFAULT 0,
+				jumlah_br_cr DOUBLE DEFAULT 0,
+				k3_br_cr DOUBLE DEFAULT 0,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				deleted_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_baku_details_tanggal (tanggal),
+				INDEX idx_baku_details_id_baku_mandor (id_baku_mandor),
+				INDEX idx_baku_details_mandor (mandor),
+				INDEX idx_baku_details_tipe (tipe),
+				INDEX idx_baku_details_deleted_at (deleted_at)
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS sync_operations (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				op_id VARCHAR(36) NOT NULL,
+				device_id VARCHAR(100) NOT NULL,
+				entry_uuid VARCHAR(36) NOT NULL,
+				action VARCHAR(20) NOT NULL,
+				status VARCHAR(20) NOT NULL,
+				message TEXT,
+				created_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_sync_operations_op_id (op_id),
+				INDEX idx_sync_operations_device_id (device_id),
+				INDEX idx_sync_operations_entry_uuid (entry_uuid)
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS sync_operations`},
+			{SQL: `DROP TABLE IF EXISTS baku_details`},
+			{SQL: `DROP TABLE IF EXISTS baku_penyadaps`},
+			{SQL: `DROP TABLE IF EXISTS baku_mandors`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_mandors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tahun_tanam INTEGER NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				tipe VARCHAR(50) NOT NULL DEFAULT 'BAKU',
+				created_at DATETIME,
+				updated_at DATETIME,
+				deleted_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_mandors_tipe ON baku_mandors (tipe)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_mandors_deleted_at ON baku_mandors (deleted_at)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_penyadaps (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				uuid VARCHAR(36) NOT NULL,
+				id_baku_mandor INTEGER NOT NULL,
+				id_penyadap INTEGER NOT NULL,
+				tanggal DATETIME NOT NULL,
+				tipe VARCHAR(50) NOT NULL DEFAULT 'BAKU',
+				tahun_tanam INTEGER,
+				basah_latex REAL DEFAULT 0,
+				sheet REAL DEFAULT 0,
+				basah_lump REAL DEFAULT 0,
+				br_cr REAL DEFAULT 0,
+				created_at DATETIME,
+				updated_at DATETIME,
+				deleted_at DATETIME
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_baku_penyadaps_uuid ON baku_penyadaps (uuid)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_penyadaps_id_baku_mandor ON baku_penyadaps (id_baku_mandor)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_penyadaps_id_penyadap ON baku_penyadaps (id_penyadap)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_penyadaps_tanggal ON baku_penyadaps (tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_penyadaps_tipe ON baku_penyadaps (tipe)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_penyadaps_updated_at ON baku_penyadaps (updated_at)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_penyadaps_deleted_at ON baku_penyadaps (deleted_at)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_details (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tanggal DATETIME NOT NULL,
+				id_baku_mandor INTEGER NOT NULL,
+				mandor VARCHAR(100) NOT NULL,
+				afdeling VARCHAR(100) NOT NULL,
+				tahun_tanam INTEGER,
+				tipe VARCHAR(50) NOT NULL DEFAULT 'BAKU',
+				jumlah_pabrik_basah_latek REAL DEFAULT 0,
+				jumlah_kebun_basah_latek REAL DEFAULT 0,
+				selisih_basah_latek REAL DEFAULT 0,
+				persentase_selisih_basah_latek REAL DEFAULT 0,
+				jumlah_sheet REAL DEFAULT 0,
+				k3_sheet REAL DEFAULT 0,
+				jumlah_pabrik_basah_lump REAL DEFAULT 0,
+				jumlah_kebun_basah_lump REAL DEFAULT 0,
+				selisih_basah_lump REAL DEFAULT 0,
+				persentase_selisih_basah_lump REAL DEFAULT 0,
+				jumlah_br_cr REAL DEFAULT 0,
+				k3_br_cr REAL DEFAULT 0,
+				created_at DATETIME,
+				updated_at DATETIME,
+				deleted_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_details_tanggal ON baku_details (tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_details_id_baku_mandor ON baku_details (id_baku_mandor)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_details_mandor ON baku_details (mandor)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_details_tipe ON baku_details (tipe)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_details_deleted_at ON baku_details (deleted_at)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS sync_operations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				op_id VARCHAR(36) NOT NULL,
+				device_id VARCHAR(100) NOT NULL,
+				entry_uuid VARCHAR(36) NOT NULL,
+				action VARCHAR(20) NOT NULL,
+				status VARCHAR(20) NOT NULL,
+				message TEXT,
+				created_at DATETIME
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_sync_operations_op_id ON sync_operations (op_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_sync_operations_device_id ON sync_operations (device_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_sync_operations_entry_uuid ON sync_operations (entry_uuid)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS sync_operations`},
+			{SQL: `DROP TABLE IF EXISTS baku_details`},
+			{SQL: `DROP TABLE IF EXISTS baku_penyadaps`},
+			{SQL: `DROP TABLE IF EXISTS baku_mandors`},
+		},
+	})
+}
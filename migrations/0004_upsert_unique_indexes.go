@@ -0,0 +1,142 @@
+package migrations
+
+// Unique index yang dibutuhkan upsert saveBatchRekap (ON DUPLICATE KEY) dan
+// saveProduksi. Baris ganda dari impor lama harus dibuang agar index bisa
+// dibuat: id terbesar dipertahankan, sisanya dipindah ke rekaps_karantina /
+// produksis_karantina (tidak dihapus saat rollback) sehingga bisa diperiksa
+// dan dikembalikan manual.
+//
+// DDL MySQL tidak transaksional, jadi setiap langkah harus aman diulang
+// setelah migrasi gagal di tengah: ALTER dilewati jika tipe kolom sudah
+// sesuai, dan baris yang sudah dikarantina tidak disalin dua kali
+// (INSERT IGNORE pada primary key).
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "upsert_unique_indexes",
+		Up: []Step{
+			{
+				SQL:    `ALTER TABLE rekaps MODIFY tipe_produksi VARCHAR(100) NOT NULL`,
+				SkipIf: columnTypeIs("rekaps", "tipe_produksi", "varchar(100)"),
+			},
+			{
+				SQL:    `CREATE TABLE IF NOT EXISTS rekaps_karantina LIKE rekaps`,
+				SkipIf: indexExists("rekaps", "idx_rekaps_upsert"),
+			},
+			{
+				SQL: `INSERT IGNORE INTO rekaps_karantina
+					SELECT r1.* FROM rekaps r1
+					WHERE EXISTS (
+						SELECT 1 FROM rekaps r2
+						WHERE r2.tanggal = r1.tanggal
+							AND r2.tipe_produksi = r1.tipe_produksi
+							AND r2.nik = r1.nik
+							AND r2.mandor = r1.mandor
+							AND r2.tahun_tanam = r1.tahun_tanam
+							AND r2.id > r1.id
+					)`,
+				SkipIf: indexExists("rekaps", "idx_rekaps_upsert"),
+			},
+			{
+				SQL: `DELETE r1 FROM rekaps r1
+					JOIN rekaps_karantina k ON k.id = r1.id`,
+				SkipIf: indexExists("rekaps", "idx_rekaps_upsert"),
+			},
+			{
+				SQL:    `CREATE UNIQUE INDEX idx_rekaps_upsert ON rekaps (tanggal, tipe_produksi, nik, mandor, tahun_tanam)`,
+				SkipIf: indexExists("rekaps", "idx_rekaps_upsert"),
+			},
+			{
+				SQL:    `CREATE TABLE IF NOT EXISTS produksis_karantina LIKE produksis`,
+				SkipIf: indexExists("produksis", "idx_produksis_upsert"),
+			},
+			{
+				SQL: `INSERT IGNORE INTO produksis_karantina
+					SELECT p1.* FROM produksis p1
+					WHERE EXISTS (
+						SELECT 1 FROM produksis p2
+						WHERE p2.tanggal = p1.tanggal
+							AND p2.tipe_produksi = p1.tipe_produksi
+							AND p2.nik = p1.nik
+							AND p2.mandor = p1.mandor
+							AND p2.tahun_tanam = p1.tahun_tanam
+							AND p2.id > p1.id
+					)`,
+				SkipIf: indexExists("produksis", "idx_produksis_upsert"),
+			},
+			{
+				SQL: `DELETE p1 FROM produksis p1
+					JOIN produksis_karantina k ON k.id = p1.id`,
+				SkipIf: indexExists("produksis", "idx_produksis_upsert"),
+			},
+			{
+				SQL:    `CREATE UNIQUE INDEX idx_produksis_upsert ON produksis (tanggal, tipe_produksi, nik, mandor, tahun_tanam)`,
+				SkipIf: indexExists("produksis", "idx_produksis_upsert"),
+			},
+		},
+		Down: []Step{
+			{
+				SQL:    `DROP INDEX idx_produksis_upsert ON produksis`,
+				SkipIf: indexMissing("produksis", "idx_produksis_upsert"),
+			},
+			{
+				SQL:    `DROP INDEX idx_rekaps_upsert ON rekaps`,
+				SkipIf: indexMissing("rekaps", "idx_rekaps_upsert"),
+			},
+			{
+				SQL:    `ALTER TABLE rekaps MODIFY tipe_produksi TEXT NOT NULL`,
+				SkipIf: columnTypeIs("rekaps", "tipe_produksi", "text"),
+			},
+		},
+		// SQLite tidak membatasi panjang kolom, jadi cukup karantina dan index
+		SQLiteUp: []Step{
+			{
+				SQL:    `CREATE TABLE IF NOT EXISTS rekaps_karantina AS SELECT * FROM rekaps WHERE 0`,
+				SkipIf: sqliteIndexExists("idx_rekaps_upsert"),
+			},
+			{
+				SQL: `INSERT INTO rekaps_karantina
+					SELECT * FROM rekaps WHERE id NOT IN (
+						SELECT MAX(id) FROM rekaps
+						GROUP BY tanggal, tipe_produksi, nik, mandor, tahun_tanam
+					)`,
+				SkipIf: sqliteIndexExists("idx_rekaps_upsert"),
+			},
+			{
+				SQL:    `DELETE FROM rekaps WHERE id IN (SELECT id FROM rekaps_karantina)`,
+				SkipIf: sqliteIndexExists("idx_rekaps_upsert"),
+			},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_rekaps_upsert ON rekaps (tanggal, tipe_produksi, nik, mandor, tahun_tanam)`},
+			{
+				SQL:    `CREATE TABLE IF NOT EXISTS produksis_karantina AS SELECT * FROM produksis WHERE 0`,
+				SkipIf: sqliteIndexExists("idx_produksis_upsert"),
+			},
+			{
+				SQL: `INSERT INTO produksis_karantina
+					SELECT * FROM produksis WHERE id NOT IN (
+						SELECT MAX(id) FROM produksis
+						GROUP BY tanggal, tipe_produksi, nik, mandor, tahun_tanam
+					)`,
+				SkipIf: sqliteIndexExists("idx_produksis_upsert"),
+			},
+			{
+				SQL:    `DELETE FROM produksis WHERE id IN (SELECT id FROM produksis_karantina)`,
+				SkipIf: sqliteIndexExists("idx_produksis_upsert"),
+			},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_produksis_upsert ON produksis (tanggal, tipe_produksi, nik, mandor, tahun_tanam)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP INDEX IF EXISTS idx_produksis_upsert`},
+			{SQL: `DROP INDEX IF EXISTS idx_rekaps_upsert`},
+		},
+	})
+}
+
+// columnTypeIs bernilai > 0 jika tipe kolom (mis. "varchar(100)") sudah sama
+func columnTypeIs(table, column, columnType string) string {
+	return `SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND column_name = '` + column + `'
+		AND LOWER(column_type) = '` + columnType + `'`
+}
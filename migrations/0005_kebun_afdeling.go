@@ -0,0 +1,184 @@
+package migrations
+
+// Master data kebun dan afdeling. Semua tabel yang menyimpan afdeling sebagai
+// teks bebas mendapat kolom afdeling_id, lalu teks lama ("Setro", "setro ",
+// "AFD SETRO") dinormalisasi ke kode afdeling dan namanya diseragamkan.
+var afdelingTables = []string{"masters", "rekaps", "produksis", "baku_mandors", "baku_details", "peta"}
+
+// afdelingKodeExpr adalah padanan SQL dari models.NormalizeAfdelingKode,
+// kecuali spasi di tengah teks yang tidak dirapatkan; migrasi 0020
+// merapikannya.
+func afdelingKodeExpr(col string) string {
+	upper := "UPPER(TRIM(" + col + "))"
+	return `REPLACE(TRIM(CASE
+			WHEN ` + upper + ` LIKE 'AFDELING %' THEN SUBSTR(` + upper + `, 10)
+			WHEN ` + upper + ` LIKE 'AFD. %' THEN SUBSTR(` + upper + `, 6)
+			WHEN ` + upper + ` LIKE 'AFD.%' THEN SUBSTR(` + upper + `, 5)
+			WHEN ` + upper + ` LIKE 'AFD %' THEN SUBSTR(` + upper + `, 5)
+			ELSE ` + upper + `
+		END), 'JATIROENGGO', 'JATIRUNGGO')`
+}
+
+// afdelingSeedSteps mengisi afdeling resmi Kebun Ngobo, lalu menambahkan
+// afdeling lain yang ditemukan di data lama agar tidak ada baris yang hilang
+// relasinya. Nama afdeling tambahan bisa dirapikan lewat API afdeling.
+func afdelingSeedSteps(insertIgnore, now string) []Step {
+	steps := []Step{
+		{SQL: insertIgnore + ` INTO kebuns (kode, nama, aktif, created_at, updated_at)
+			VALUES ('NGOBO', 'Kebun Ngobo', 1, ` + now + `, ` + now + `)`},
+		{SQL: insertIgnore + ` INTO afdelings (kebun_id, kode, nama, aktif, created_at, updated_at)
+			SELECT k.id, v.kode, v.nama, 1, ` + now + `, ` + now + `
+			FROM kebuns k, (
+				SELECT 'GEBUGAN' AS kode, 'Gebugan' AS nama
+				UNION ALL SELECT 'SETRO', 'Setro'
+				UNION ALL SELECT 'JATIRUNGGO', 'Jatirunggo'
+				UNION ALL SELECT 'KLEPU', 'Klepu'
+			) v
+			WHERE k.kode = 'NGOBO'`},
+	}
+	for _, table := range afdelingTables {
+		kode := afdelingKodeExpr("t.afdeling")
+		steps = append(steps, Step{SQL: insertIgnore + ` INTO afdelings (kebun_id, kode, nama, aktif, created_at, updated_at)
+			SELECT DISTINCT (SELECT id FROM kebuns WHERE kode = 'NGOBO'), ` + kode + `, ` + kode + `, 1, ` + now + `, ` + now + `
+			FROM ` + table + ` t
+			WHERE ` + kode + ` <> ''`})
+	}
+	for _, table := range afdelingTables {
+		kode := afdelingKodeExpr(table + ".afdeling")
+		steps = append(steps, Step{SQL: `UPDATE ` + table + ` SET
+			afdeling_id = (SELECT a.id FROM afdelings a WHERE a.kode = ` + kode + `),
+			afdeling = COALESCE((SELECT a.nama FROM afdelings a WHERE a.kode = ` + kode + `), afdeling)`})
+	}
+	return steps
+}
+
+func init() {
+	up := []Step{
+		{SQL: `CREATE TABLE IF NOT EXISTS kebuns (
+			id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			kode VARCHAR(50) NOT NULL,
+			nama VARCHAR(100) NOT NULL,
+			aktif BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at DATETIME(3) NULL,
+			updated_at DATETIME(3) NULL,
+			PRIMARY KEY (id),
+			UNIQUE INDEX idx_kebuns_kode (kode)
+		)`},
+		{SQL: `CREATE TABLE IF NOT EXISTS afdelings (
+			id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			kebun_id BIGINT UNSIGNED NOT NULL,
+			kode VARCHAR(50) NOT NULL,
+			nama VARCHAR(100) NOT NULL,
+			aktif BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at DATETIME(3) NULL,
+			updated_at DATETIME(3) NULL,
+			PRIMARY KEY (id),
+			UNIQUE INDEX idx_afdelings_kode (kode),
+			INDEX idx_afdelings_kebun_id (kebun_id),
+			CONSTRAINT fk_afdelings_kebun FOREIGN KEY (kebun_id) REFERENCES kebuns(id)
+				ON DELETE RESTRICT ON UPDATE CASCADE
+		)`},
+	}
+	var down []Step
+	sqliteUp := []Step{
+		{SQL: `CREATE TABLE IF NOT EXISTS kebuns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kode VARCHAR(50) NOT NULL,
+			nama VARCHAR(100) NOT NULL,
+			aktif BOOLEAN NOT NULL DEFAULT 1,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`},
+		{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_kebuns_kode ON kebuns (kode)`},
+		{SQL: `CREATE TABLE IF NOT EXISTS afdelings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kebun_id INTEGER NOT NULL,
+			kode VARCHAR(50) NOT NULL,
+			nama VARCHAR(100) NOT NULL,
+			aktif BOOLEAN NOT NULL DEFAULT 1,
+			created_at DATETIME,
+			updated_at DATETIME,
+			CONSTRAINT fk_afdelings_kebun FOREIGN KEY (kebun_id) REFERENCES kebuns(id)
+				ON DELETE RESTRICT ON UPDATE CASCADE
+		)`},
+		{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_afdelings_kode ON afdelings (kode)`},
+		{SQL: `CREATE INDEX IF NOT EXISTS idx_afdelings_kebun_id ON afdelings (kebun_id)`},
+	}
+	var sqliteDown []Step
+
+	for _, table := range afdelingTables {
+		fk := "fk_" + table + "_afdeling"
+		index := "idx_" + table + "_afdeling_id"
+
+		up = append(up,
+			Step{
+				SQL:    `ALTER TABLE ` + table + ` ADD COLUMN afdeling_id BIGINT UNSIGNED NULL`,
+				SkipIf: columnExists(table, "afdeling_id"),
+			},
+			Step{
+				SQL:    `CREATE INDEX ` + index + ` ON ` + table + ` (afdeling_id)`,
+				SkipIf: indexExists(table, index),
+			},
+			Step{
+				SQL: `ALTER TABLE ` + table + `
+					ADD CONSTRAINT ` + fk + `
+					FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE RESTRICT ON UPDATE CASCADE`,
+				SkipIf: constraintExists(table, fk),
+			},
+		)
+		down = append(down,
+			Step{
+				SQL:    `ALTER TABLE ` + table + ` DROP FOREIGN KEY ` + fk,
+				SkipIf: constraintMissing(table, fk),
+			},
+			Step{
+				SQL:    `DROP INDEX ` + index + ` ON ` + table,
+				SkipIf: indexMissing(table, index),
+			},
+			Step{
+				SQL:    `ALTER TABLE ` + table + ` DROP COLUMN afdeling_id`,
+				SkipIf: columnMissing(table, "afdeling_id"),
+			},
+		)
+
+		// SQLite hanya bisa menambah FK lewat definisi kolom baru
+		sqliteUp = append(sqliteUp,
+			Step{
+				SQL:    `ALTER TABLE ` + table + ` ADD COLUMN afdeling_id INTEGER REFERENCES afdelings(id) ON DELETE RESTRICT ON UPDATE CASCADE`,
+				SkipIf: sqliteColumnExists(table, "afdeling_id"),
+			},
+			Step{SQL: `CREATE INDEX IF NOT EXISTS ` + index + ` ON ` + table + ` (afdeling_id)`},
+		)
+		// Kolom yang dipakai foreign key tidak bisa di-DROP COLUMN di SQLite,
+		// jadi rollback hanya mengosongkannya sebelum tabel afdeling dihapus.
+		sqliteDown = append(sqliteDown,
+			Step{SQL: `DROP INDEX IF EXISTS ` + index},
+			Step{
+				SQL:    `UPDATE ` + table + ` SET afdeling_id = NULL`,
+				SkipIf: sqliteColumnMissing(table, "afdeling_id"),
+			},
+		)
+	}
+
+	up = append(up, afdelingSeedSteps("INSERT IGNORE", "NOW(3)")...)
+	sqliteUp = append(sqliteUp, afdelingSeedSteps("INSERT OR IGNORE", "CURRENT_TIMESTAMP")...)
+
+	down = append(down,
+		Step{SQL: `DROP TABLE IF EXISTS afdelings`},
+		Step{SQL: `DROP TABLE IF EXISTS kebuns`},
+	)
+	sqliteDown = append(sqliteDown,
+		Step{SQL: `DROP TABLE IF EXISTS afdelings`},
+		Step{SQL: `DROP TABLE IF EXISTS kebuns`},
+	)
+
+	register(Migration{
+		Version:    5,
+		Name:       "kebun_afdeling",
+		Up:         up,
+		Down:       down,
+		SQLiteUp:   sqliteUp,
+		SQLiteDown: sqliteDown,
+	})
+}
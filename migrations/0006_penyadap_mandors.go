@@ -0,0 +1,65 @@
+package migrations
+
+// Riwayat penugasan penyadap ke kelompok mandor, diisi otomatis saat impor
+// dan bisa dikoreksi lewat API penugasan.
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "penyadap_mandors",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS penyadap_mandors (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				penyadap_id BIGINT UNSIGNED NOT NULL,
+				mandor_id BIGINT UNSIGNED NOT NULL,
+				afdeling_id BIGINT UNSIGNED NULL,
+				tahun_tanam VARCHAR(10),
+				blok VARCHAR(255),
+				valid_from DATE NOT NULL,
+				valid_to DATE NULL,
+				sumber VARCHAR(20) NOT NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_penyadap_mandors_penyadap (penyadap_id, valid_from),
+				INDEX idx_penyadap_mandors_mandor (mandor_id, valid_from),
+				INDEX idx_penyadap_mandors_afdeling_id (afdeling_id),
+				CONSTRAINT fk_penyadap_mandors_penyadap FOREIGN KEY (penyadap_id) REFERENCES penyadaps(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_penyadap_mandors_mandor FOREIGN KEY (mandor_id) REFERENCES mandors(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_penyadap_mandors_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE RESTRICT ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS penyadap_mandors`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS penyadap_mandors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				penyadap_id INTEGER NOT NULL,
+				mandor_id INTEGER NOT NULL,
+				afdeling_id INTEGER NULL,
+				tahun_tanam VARCHAR(10),
+				blok VARCHAR(255),
+				valid_from DATE NOT NULL,
+				valid_to DATE NULL,
+				sumber VARCHAR(20) NOT NULL,
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_penyadap_mandors_penyadap FOREIGN KEY (penyadap_id) REFERENCES penyadaps(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_penyadap_mandors_mandor FOREIGN KEY (mandor_id) REFERENCES mandors(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_penyadap_mandors_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE RESTRICT ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_penyadap_mandors_penyadap ON penyadap_mandors (penyadap_id, valid_from)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_penyadap_mandors_mandor ON penyadap_mandors (mandor_id, valid_from)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_penyadap_mandors_afdeling_id ON penyadap_mandors (afdeling_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS penyadap_mandors`},
+		},
+	})
+}
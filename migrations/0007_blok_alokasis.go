@@ -0,0 +1,58 @@
+package migrations
+
+// Alokasi eksplisit produksi mandor/penyadap ke blok Peta
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "blok_alokasis",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS blok_alokasis (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				peta_id BIGINT UNSIGNED NOT NULL,
+				mandor_id BIGINT UNSIGNED NULL,
+				penyadap_id BIGINT UNSIGNED NULL,
+				bobot DOUBLE NOT NULL DEFAULT 0,
+				keterangan VARCHAR(255),
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_blok_alokasis_peta_id (peta_id),
+				INDEX idx_blok_alokasis_mandor_id (mandor_id),
+				INDEX idx_blok_alokasis_penyadap_id (penyadap_id),
+				CONSTRAINT fk_blok_alokasis_peta FOREIGN KEY (peta_id) REFERENCES peta(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_blok_alokasis_mandor FOREIGN KEY (mandor_id) REFERENCES mandors(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_blok_alokasis_penyadap FOREIGN KEY (penyadap_id) REFERENCES penyadaps(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS blok_alokasis`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS blok_alokasis (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				peta_id INTEGER NOT NULL,
+				mandor_id INTEGER NULL,
+				penyadap_id INTEGER NULL,
+				bobot REAL NOT NULL DEFAULT 0,
+				keterangan VARCHAR(255),
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_blok_alokasis_peta FOREIGN KEY (peta_id) REFERENCES peta(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_blok_alokasis_mandor FOREIGN KEY (mandor_id) REFERENCES mandors(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_blok_alokasis_penyadap FOREIGN KEY (penyadap_id) REFERENCES penyadaps(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_blok_alokasis_peta_id ON blok_alokasis (peta_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_blok_alokasis_mandor_id ON blok_alokasis (mandor_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_blok_alokasis_penyadap_id ON blok_alokasis (penyadap_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS blok_alokasis`},
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package migrations
+
+// Poligon blok hasil impor KML/KMZ, disimpan sebagai teks GeoJSON
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "peta_geometris",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS peta_geometris (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				code VARCHAR(255) NOT NULL,
+				nama VARCHAR(255),
+				peta_id BIGINT UNSIGNED NULL,
+				tipe VARCHAR(20) NOT NULL,
+				jumlah_poligon INT NOT NULL DEFAULT 0,
+				geojson LONGTEXT NOT NULL,
+				sumber_file VARCHAR(255),
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_peta_geometris_code (code),
+				INDEX idx_peta_geometris_peta_id (peta_id),
+				CONSTRAINT fk_peta_geometris_peta FOREIGN KEY (peta_id) REFERENCES peta(id)
+					ON DELETE SET NULL ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS peta_geometris`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS peta_geometris (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				code VARCHAR(255) NOT NULL,
+				nama VARCHAR(255),
+				peta_id INTEGER NULL,
+				tipe VARCHAR(20) NOT NULL,
+				jumlah_poligon INTEGER NOT NULL DEFAULT 0,
+				geojson TEXT NOT NULL,
+				sumber_file VARCHAR(255),
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_peta_geometris_peta FOREIGN KEY (peta_id) REFERENCES peta(id)
+					ON DELETE SET NULL ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_peta_geometris_code ON peta_geometris (code)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_peta_geometris_peta_id ON peta_geometris (peta_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS peta_geometris`},
+		},
+	})
+}
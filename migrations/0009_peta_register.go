@@ -0,0 +1,144 @@
+package migrations
+
+// Register blok: kode unik, hapus lunak, tahun tanam terstruktur dan riwayat
+// perubahan. Kode ganda yang sudah ada diberi akhiran "#<id>" agar indeks
+// unik bisa dibuat; baris dengan id terkecil mempertahankan kodenya.
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "peta_register",
+		Up: []Step{
+			{
+				SQL:    `ALTER TABLE peta ADD COLUMN deleted_at DATETIME(3) NULL`,
+				SkipIf: columnExists("peta", "deleted_at"),
+			},
+			{
+				SQL:    `CREATE INDEX idx_peta_deleted_at ON peta (deleted_at)`,
+				SkipIf: indexExists("peta", "idx_peta_deleted_at"),
+			},
+			{
+				SQL:    `ALTER TABLE peta ADD COLUMN tahun_tanam_angka INT NULL`,
+				SkipIf: columnExists("peta", "tahun_tanam_angka"),
+			},
+			{
+				SQL:    `ALTER TABLE peta ADD COLUMN tahun_tanam_ex BOOLEAN NOT NULL DEFAULT FALSE`,
+				SkipIf: columnExists("peta", "tahun_tanam_ex"),
+			},
+			{SQL: `UPDATE peta
+				SET tahun_tanam = TRIM(tahun_tanam),
+					tahun_tanam_angka = CAST(TRIM(tahun_tanam) AS UNSIGNED),
+					tahun_tanam_ex = FALSE
+				WHERE TRIM(tahun_tanam) REGEXP '^[0-9]{4}$'`},
+			{SQL: `UPDATE peta
+				SET tahun_tanam_angka = CAST(RIGHT(TRIM(tahun_tanam), 4) AS UNSIGNED),
+					tahun_tanam_ex = TRUE,
+					tahun_tanam = CONCAT('ex ', RIGHT(TRIM(tahun_tanam), 4))
+				WHERE UPPER(TRIM(tahun_tanam)) REGEXP '^EX[.]? ?[0-9]{4}$'`},
+			{
+				SQL: `UPDATE peta p
+					JOIN (SELECT code, MIN(id) AS keep_id FROM peta GROUP BY code HAVING COUNT(*) > 1) d
+						ON d.code = p.code
+					SET p.code = CONCAT(p.code, '#', p.id)
+					WHERE p.id <> d.keep_id`,
+				SkipIf: indexExists("peta", "idx_peta_code"),
+			},
+			{
+				SQL:    `CREATE UNIQUE INDEX idx_peta_code ON peta (code)`,
+				SkipIf: indexExists("peta", "idx_peta_code"),
+			},
+			{SQL: `CREATE TABLE IF NOT EXISTS peta_riwayats (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				peta_id BIGINT UNSIGNED NOT NULL,
+				code VARCHAR(255) NOT NULL,
+				aksi VARCHAR(20) NOT NULL,
+				perubahan LONGTEXT,
+				username VARCHAR(100),
+				created_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_peta_riwayats_peta_id (peta_id, created_at)
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS peta_riwayats`},
+			{
+				SQL:    `DROP INDEX idx_peta_code ON peta`,
+				SkipIf: indexMissing("peta", "idx_peta_code"),
+			},
+			{
+				SQL:    `ALTER TABLE peta DROP COLUMN tahun_tanam_ex`,
+				SkipIf: columnMissing("peta", "tahun_tanam_ex"),
+			},
+			{
+				SQL:    `ALTER TABLE peta DROP COLUMN tahun_tanam_angka`,
+				SkipIf: columnMissing("peta", "tahun_tanam_angka"),
+			},
+			{
+				SQL:    `DROP INDEX idx_peta_deleted_at ON peta`,
+				SkipIf: indexMissing("peta", "idx_peta_deleted_at"),
+			},
+			{
+				SQL:    `ALTER TABLE peta DROP COLUMN deleted_at`,
+				SkipIf: columnMissing("peta", "deleted_at"),
+			},
+		},
+		SQLiteUp: []Step{
+			{
+				SQL:    `ALTER TABLE peta ADD COLUMN deleted_at DATETIME`,
+				SkipIf: sqliteColumnExists("peta", "deleted_at"),
+			},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_peta_deleted_at ON peta (deleted_at)`},
+			{
+				SQL:    `ALTER TABLE peta ADD COLUMN tahun_tanam_angka INTEGER`,
+				SkipIf: sqliteColumnExists("peta", "tahun_tanam_angka"),
+			},
+			{
+				SQL:    `ALTER TABLE peta ADD COLUMN tahun_tanam_ex NUMERIC NOT NULL DEFAULT 0`,
+				SkipIf: sqliteColumnExists("peta", "tahun_tanam_ex"),
+			},
+			{SQL: `UPDATE peta
+				SET tahun_tanam = TRIM(tahun_tanam),
+					tahun_tanam_angka = CAST(TRIM(tahun_tanam) AS INTEGER),
+					tahun_tanam_ex = 0
+				WHERE TRIM(tahun_tanam) GLOB '[0-9][0-9][0-9][0-9]'`},
+			{SQL: `UPDATE peta
+				SET tahun_tanam_angka = CAST(SUBSTR(TRIM(tahun_tanam), -4) AS INTEGER),
+					tahun_tanam_ex = 1,
+					tahun_tanam = 'ex ' || SUBSTR(TRIM(tahun_tanam), -4)
+				WHERE UPPER(TRIM(tahun_tanam)) GLOB 'EX*[0-9][0-9][0-9][0-9]'
+					AND LENGTH(TRIM(tahun_tanam)) <= 8`},
+			{
+				SQL: `UPDATE peta SET code = code || '#' || id
+					WHERE id NOT IN (SELECT MIN(id) FROM peta GROUP BY code)`,
+				SkipIf: sqliteIndexExists("idx_peta_code"),
+			},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_peta_code ON peta (code)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS peta_riwayats (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				peta_id INTEGER NOT NULL,
+				code VARCHAR(255) NOT NULL,
+				aksi VARCHAR(20) NOT NULL,
+				perubahan TEXT,
+				username VARCHAR(100),
+				created_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_peta_riwayats_peta_id ON peta_riwayats (peta_id, created_at)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS peta_riwayats`},
+			{SQL: `DROP INDEX IF EXISTS idx_peta_code`},
+			{SQL: `DROP INDEX IF EXISTS idx_peta_deleted_at`},
+			{
+				SQL:    `ALTER TABLE peta DROP COLUMN tahun_tanam_ex`,
+				SkipIf: sqliteColumnMissing("peta", "tahun_tanam_ex"),
+			},
+			{
+				SQL:    `ALTER TABLE peta DROP COLUMN tahun_tanam_angka`,
+				SkipIf: sqliteColumnMissing("peta", "tahun_tanam_angka"),
+			},
+			{
+				SQL:    `ALTER TABLE peta DROP COLUMN deleted_at`,
+				SkipIf: sqliteColumnMissing("peta", "deleted_at"),
+			},
+		},
+	})
+}
@@ -0,0 +1,109 @@
+package migrations
+
+// Target produksi (RKAP) per afdeling, tipe produksi dan opsional per mandor
+// atau blok, beserta pola distribusi untuk memecah target ke bulan dan hari.
+// Dua pola bawaan diisi: RATA (semua hari sama) dan HARI_KERJA (Minggu 0).
+func polaDistribusiSeedSteps(insertIgnore, now string) []Step {
+	return []Step{
+		{SQL: insertIgnore + ` INTO pola_distribusis (kode, nama, bobot_bulan, bobot_hari, created_at, updated_at)
+			VALUES ('RATA', 'Rata per hari kalender', '1,1,1,1,1,1,1,1,1,1,1,1', '1,1,1,1,1,1,1', ` + now + `, ` + now + `)`},
+		{SQL: insertIgnore + ` INTO pola_distribusis (kode, nama, bobot_bulan, bobot_hari, created_at, updated_at)
+			VALUES ('HARI_KERJA', 'Senin-Sabtu, tanpa Minggu', '1,1,1,1,1,1,1,1,1,1,1,1', '1,1,1,1,1,1,0', ` + now + `, ` + now + `)`},
+	}
+}
+
+func init() {
+	up := []Step{
+		{SQL: `CREATE TABLE IF NOT EXISTS pola_distribusis (
+			id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			kode VARCHAR(50) NOT NULL,
+			nama VARCHAR(100) NOT NULL,
+			bobot_bulan VARCHAR(255) NOT NULL,
+			bobot_hari VARCHAR(100) NOT NULL,
+			created_at DATETIME(3) NULL,
+			updated_at DATETIME(3) NULL,
+			PRIMARY KEY (id),
+			UNIQUE INDEX idx_pola_distribusis_kode (kode)
+		)`},
+		{SQL: `CREATE TABLE IF NOT EXISTS target_produksis (
+			id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+			tahun INT NOT NULL,
+			bulan INT NOT NULL DEFAULT 0,
+			afdeling_id BIGINT UNSIGNED NOT NULL,
+			tipe_produksi VARCHAR(100) NOT NULL DEFAULT '',
+			mandor_id BIGINT UNSIGNED NULL,
+			peta_id BIGINT UNSIGNED NULL,
+			kering_kg DOUBLE NOT NULL DEFAULT 0,
+			pola_distribusi_id BIGINT UNSIGNED NULL,
+			keterangan VARCHAR(255),
+			created_at DATETIME(3) NULL,
+			updated_at DATETIME(3) NULL,
+			PRIMARY KEY (id),
+			INDEX idx_target_produksis_periode (afdeling_id, tahun, bulan),
+			INDEX idx_target_produksis_mandor_id (mandor_id),
+			INDEX idx_target_produksis_peta_id (peta_id),
+			CONSTRAINT fk_target_produksis_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+				ON DELETE RESTRICT ON UPDATE CASCADE,
+			CONSTRAINT fk_target_produksis_mandor FOREIGN KEY (mandor_id) REFERENCES mandors(id)
+				ON DELETE CASCADE ON UPDATE CASCADE,
+			CONSTRAINT fk_target_produksis_peta FOREIGN KEY (peta_id) REFERENCES peta(id)
+				ON DELETE CASCADE ON UPDATE CASCADE,
+			CONSTRAINT fk_target_produksis_pola FOREIGN KEY (pola_distribusi_id) REFERENCES pola_distribusis(id)
+				ON DELETE SET NULL ON UPDATE CASCADE
+		)`},
+	}
+	up = append(up, polaDistribusiSeedSteps("INSERT IGNORE", "NOW(3)")...)
+
+	sqliteUp := []Step{
+		{SQL: `CREATE TABLE IF NOT EXISTS pola_distribusis (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kode VARCHAR(50) NOT NULL,
+			nama VARCHAR(100) NOT NULL,
+			bobot_bulan VARCHAR(255) NOT NULL,
+			bobot_hari VARCHAR(100) NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`},
+		{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_pola_distribusis_kode ON pola_distribusis (kode)`},
+		{SQL: `CREATE TABLE IF NOT EXISTS target_produksis (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tahun INTEGER NOT NULL,
+			bulan INTEGER NOT NULL DEFAULT 0,
+			afdeling_id INTEGER NOT NULL,
+			tipe_produksi VARCHAR(100) NOT NULL DEFAULT '',
+			mandor_id INTEGER NULL,
+			peta_id INTEGER NULL,
+			kering_kg REAL NOT NULL DEFAULT 0,
+			pola_distribusi_id INTEGER NULL,
+			keterangan VARCHAR(255),
+			created_at DATETIME,
+			updated_at DATETIME,
+			CONSTRAINT fk_target_produksis_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+				ON DELETE RESTRICT ON UPDATE CASCADE,
+			CONSTRAINT fk_target_produksis_mandor FOREIGN KEY (mandor_id) REFERENCES mandors(id)
+				ON DELETE CASCADE ON UPDATE CASCADE,
+			CONSTRAINT fk_target_produksis_peta FOREIGN KEY (peta_id) REFERENCES peta(id)
+				ON DELETE CASCADE ON UPDATE CASCADE,
+			CONSTRAINT fk_target_produksis_pola FOREIGN KEY (pola_distribusi_id) REFERENCES pola_distribusis(id)
+				ON DELETE SET NULL ON UPDATE CASCADE
+		)`},
+		{SQL: `CREATE INDEX IF NOT EXISTS idx_target_produksis_periode ON target_produksis (afdeling_id, tahun, bulan)`},
+		{SQL: `CREATE INDEX IF NOT EXISTS idx_target_produksis_mandor_id ON target_produksis (mandor_id)`},
+		{SQL: `CREATE INDEX IF NOT EXISTS idx_target_produksis_peta_id ON target_produksis (peta_id)`},
+	}
+	sqliteUp = append(sqliteUp, polaDistribusiSeedSteps("INSERT OR IGNORE", "CURRENT_TIMESTAMP")...)
+
+	down := []Step{
+		{SQL: `DROP TABLE IF EXISTS target_produksis`},
+		{SQL: `DROP TABLE IF EXISTS pola_distribusis`},
+	}
+
+	register(Migration{
+		Version:    10,
+		Name:       "target_produksi",
+		Up:         up,
+		Down:       down,
+		SQLiteUp:   sqliteUp,
+		SQLiteDown: down,
+	})
+}
@@ -0,0 +1,81 @@
+package migrations
+
+// Antrian temuan analisis anomali produksi harian (lonjakan nilai, rasio K3
+// tidak wajar dan HKO yang tidak sesuai data penyadap).
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "anomali",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS anomalis (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				kunci VARCHAR(191) NOT NULL,
+				tanggal DATE NOT NULL,
+				jenis VARCHAR(20) NOT NULL,
+				sumber VARCHAR(20) NOT NULL,
+				ref_id BIGINT UNSIGNED NOT NULL,
+				ukuran VARCHAR(50) NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				nama VARCHAR(100),
+				mandor VARCHAR(100),
+				tipe_produksi VARCHAR(100),
+				afdeling VARCHAR(100),
+				afdeling_id BIGINT UNSIGNED NULL,
+				nilai DOUBLE NOT NULL DEFAULT 0,
+				acuan DOUBLE NOT NULL DEFAULT 0,
+				skor DOUBLE NOT NULL DEFAULT 0,
+				pesan VARCHAR(255),
+				status VARCHAR(20) NOT NULL DEFAULT 'BARU',
+				catatan TEXT,
+				ditinjau_oleh VARCHAR(100),
+				diakui_pada DATETIME(3) NULL,
+				diselesaikan_pada DATETIME(3) NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_anomalis_kunci (kunci),
+				INDEX idx_anomalis_status (status, tanggal),
+				INDEX idx_anomalis_nik (nik),
+				INDEX idx_anomalis_afdeling_id (afdeling_id)
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS anomalis`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS anomalis (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				kunci VARCHAR(191) NOT NULL,
+				tanggal DATE NOT NULL,
+				jenis VARCHAR(20) NOT NULL,
+				sumber VARCHAR(20) NOT NULL,
+				ref_id INTEGER NOT NULL,
+				ukuran VARCHAR(50) NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				nama VARCHAR(100),
+				mandor VARCHAR(100),
+				tipe_produksi VARCHAR(100),
+				afdeling VARCHAR(100),
+				afdeling_id INTEGER NULL,
+				nilai REAL NOT NULL DEFAULT 0,
+				acuan REAL NOT NULL DEFAULT 0,
+				skor REAL NOT NULL DEFAULT 0,
+				pesan VARCHAR(255),
+				status VARCHAR(20) NOT NULL DEFAULT 'BARU',
+				catatan TEXT,
+				ditinjau_oleh VARCHAR(100),
+				diakui_pada DATETIME,
+				diselesaikan_pada DATETIME,
+				created_at DATETIME,
+				updated_at DATETIME
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_anomalis_kunci ON anomalis (kunci)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_anomalis_status ON anomalis (status, tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_anomalis_nik ON anomalis (nik)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_anomalis_afdeling_id ON anomalis (afdeling_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS anomalis`},
+		},
+	})
+}
@@ -0,0 +1,103 @@
+package migrations
+
+// Ambang selisih basah kebun vs pabrik per afdeling/tipe dan daftar
+// peringatan hari-mandor yang melewatinya. Satu ambang bawaan 5% untuk
+// semua afdeling dan tipe diisi agar peringatan langsung berjalan.
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "selisih_kebun_pabrik",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS ambang_selisihs (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				afdeling_id BIGINT UNSIGNED NULL,
+				tipe_produksi VARCHAR(100) NOT NULL DEFAULT '',
+				batas_latek_persen DOUBLE NOT NULL DEFAULT 0,
+				batas_lump_persen DOUBLE NOT NULL DEFAULT 0,
+				keterangan VARCHAR(255),
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_ambang_selisihs_afdeling_id (afdeling_id),
+				CONSTRAINT fk_ambang_selisihs_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+			{SQL: `INSERT INTO ambang_selisihs (afdeling_id, tipe_produksi, batas_latek_persen, batas_lump_persen, keterangan, created_at, updated_at)
+				SELECT NULL, '', 5, 5, 'Ambang bawaan', NOW(3), NOW(3) FROM DUAL
+				WHERE NOT EXISTS (SELECT 1 FROM ambang_selisihs)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS peringatan_selisihs (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				kunci VARCHAR(191) NOT NULL,
+				tanggal DATE NOT NULL,
+				sumber VARCHAR(20) NOT NULL,
+				ref_id BIGINT UNSIGNED NOT NULL,
+				jenis VARCHAR(20) NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				mandor VARCHAR(100),
+				tipe_produksi VARCHAR(100),
+				afdeling VARCHAR(100),
+				afdeling_id BIGINT UNSIGNED NULL,
+				kebun DOUBLE NOT NULL DEFAULT 0,
+				pabrik DOUBLE NOT NULL DEFAULT 0,
+				persen DOUBLE NOT NULL DEFAULT 0,
+				batas DOUBLE NOT NULL DEFAULT 0,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_peringatan_selisihs_kunci (kunci),
+				INDEX idx_peringatan_selisihs_tanggal (tanggal),
+				INDEX idx_peringatan_selisihs_nik (nik),
+				INDEX idx_peringatan_selisihs_afdeling_id (afdeling_id)
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS peringatan_selisihs`},
+			{SQL: `DROP TABLE IF EXISTS ambang_selisihs`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS ambang_selisihs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				afdeling_id INTEGER NULL,
+				tipe_produksi VARCHAR(100) NOT NULL DEFAULT '',
+				batas_latek_persen REAL NOT NULL DEFAULT 0,
+				batas_lump_persen REAL NOT NULL DEFAULT 0,
+				keterangan VARCHAR(255),
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_ambang_selisihs_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_ambang_selisihs_afdeling_id ON ambang_selisihs (afdeling_id)`},
+			{SQL: `INSERT INTO ambang_selisihs (afdeling_id, tipe_produksi, batas_latek_persen, batas_lump_persen, keterangan, created_at, updated_at)
+				SELECT NULL, '', 5, 5, 'Ambang bawaan', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+				WHERE NOT EXISTS (SELECT 1 FROM ambang_selisihs)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS peringatan_selisihs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				kunci VARCHAR(191) NOT NULL,
+				tanggal DATE NOT NULL,
+				sumber VARCHAR(20) NOT NULL,
+				ref_id INTEGER NOT NULL,
+				jenis VARCHAR(20) NOT NULL,
+				nik VARCHAR(50) NOT NULL,
+				mandor VARCHAR(100),
+				tipe_produksi VARCHAR(100),
+				afdeling VARCHAR(100),
+				afdeling_id INTEGER NULL,
+				kebun REAL NOT NULL DEFAULT 0,
+				pabrik REAL NOT NULL DEFAULT 0,
+				persen REAL NOT NULL DEFAULT 0,
+				batas REAL NOT NULL DEFAULT 0,
+				created_at DATETIME,
+				updated_at DATETIME
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_peringatan_selisihs_kunci ON peringatan_selisihs (kunci)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_peringatan_selisihs_tanggal ON peringatan_selisihs (tanggal)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_peringatan_selisihs_nik ON peringatan_selisihs (nik)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_peringatan_selisihs_afdeling_id ON peringatan_selisihs (afdeling_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS peringatan_selisihs`},
+			{SQL: `DROP TABLE IF EXISTS ambang_selisihs`},
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+// Penanda penutupan hari per afdeling; memicu peristiwa day.closed.
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "penutupan_hari",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS penutupan_haris (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				tanggal DATE NOT NULL,
+				afdeling_id BIGINT UNSIGNED NOT NULL,
+				ditutup_oleh VARCHAR(100),
+				catatan TEXT,
+				created_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				UNIQUE INDEX idx_penutupan_haris_tanggal_afdeling (tanggal, afdeling_id),
+				INDEX idx_penutupan_haris_afdeling_id (afdeling_id),
+				CONSTRAINT fk_penutupan_haris_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS penutupan_haris`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS penutupan_haris (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tanggal DATE NOT NULL,
+				afdeling_id INTEGER NOT NULL,
+				ditutup_oleh VARCHAR(100),
+				catatan TEXT,
+				created_at DATETIME
+			)`},
+			{SQL: `CREATE UNIQUE INDEX IF NOT EXISTS idx_penutupan_haris_tanggal_afdeling ON penutupan_haris (tanggal, afdeling_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_penutupan_haris_afdeling_id ON penutupan_haris (afdeling_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS penutupan_haris`},
+		},
+	})
+}
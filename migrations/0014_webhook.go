@@ -0,0 +1,91 @@
+package migrations
+
+// Langganan webhook keluar dan log pengirimannya.
+func init() {
+	register(Migration{
+		Version: 14,
+		Name:    "webhook",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS webhooks (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				nama VARCHAR(100) NOT NULL,
+				url VARCHAR(500) NOT NULL,
+				rahasia VARCHAR(255) NOT NULL,
+				jenis VARCHAR(500) NOT NULL,
+				afdeling_id BIGINT UNSIGNED NULL,
+				aktif BOOLEAN NOT NULL DEFAULT TRUE,
+				dibuat_oleh VARCHAR(100),
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_webhooks_afdeling_id (afdeling_id),
+				CONSTRAINT fk_webhooks_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS pengiriman_webhooks (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				webhook_id BIGINT UNSIGNED NOT NULL,
+				id_peristiwa VARCHAR(64) NOT NULL,
+				jenis VARCHAR(50) NOT NULL,
+				payload LONGTEXT NOT NULL,
+				status VARCHAR(20) NOT NULL DEFAULT 'MENUNGGU',
+				percobaan INT NOT NULL DEFAULT 0,
+				kode_respons INT NOT NULL DEFAULT 0,
+				kesalahan TEXT,
+				berikutnya_pada DATETIME(3) NULL,
+				terkirim_pada DATETIME(3) NULL,
+				ulang_dari BIGINT UNSIGNED NULL,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_pengiriman_webhooks_webhook_id (webhook_id),
+				INDEX idx_pengiriman_webhooks_id_peristiwa (id_peristiwa),
+				INDEX idx_pengiriman_webhooks_status (status),
+				CONSTRAINT fk_pengiriman_webhooks_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS pengiriman_webhooks`},
+			{SQL: `DROP TABLE IF EXISTS webhooks`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS webhooks (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				nama VARCHAR(100) NOT NULL,
+				url VARCHAR(500) NOT NULL,
+				rahasia VARCHAR(255) NOT NULL,
+				jenis VARCHAR(500) NOT NULL,
+				afdeling_id INTEGER NULL,
+				aktif BOOLEAN NOT NULL DEFAULT 1,
+				dibuat_oleh VARCHAR(100),
+				created_at DATETIME,
+				updated_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_webhooks_afdeling_id ON webhooks (afdeling_id)`},
+			{SQL: `CREATE TABLE IF NOT EXISTS pengiriman_webhooks (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				webhook_id INTEGER NOT NULL,
+				id_peristiwa VARCHAR(64) NOT NULL,
+				jenis VARCHAR(50) NOT NULL,
+				payload TEXT NOT NULL,
+				status VARCHAR(20) NOT NULL DEFAULT 'MENUNGGU',
+				percobaan INTEGER NOT NULL DEFAULT 0,
+				kode_respons INTEGER NOT NULL DEFAULT 0,
+				kesalahan TEXT,
+				berikutnya_pada DATETIME,
+				terkirim_pada DATETIME,
+				ulang_dari INTEGER NULL,
+				created_at DATETIME,
+				updated_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_pengiriman_webhooks_webhook_id ON pengiriman_webhooks (webhook_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_pengiriman_webhooks_id_peristiwa ON pengiriman_webhooks (id_peristiwa)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_pengiriman_webhooks_status ON pengiriman_webhooks (status)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS pengiriman_webhooks`},
+			{SQL: `DROP TABLE IF EXISTS webhooks`},
+		},
+	})
+}
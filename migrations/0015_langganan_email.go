@@ -0,0 +1,56 @@
+package migrations
+
+// Preferensi email per pengguna: laporan harian terjadwal dan peristiwa.
+func init() {
+	register(Migration{
+		Version: 15,
+		Name:    "langganan_email",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS langganan_emails (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				user_id BIGINT UNSIGNED NOT NULL,
+				email VARCHAR(255) NOT NULL,
+				jenis VARCHAR(50) NOT NULL,
+				afdeling_id BIGINT UNSIGNED NULL,
+				jadwal VARCHAR(100),
+				format VARCHAR(50),
+				aktif BOOLEAN NOT NULL DEFAULT TRUE,
+				terakhir_kirim DATETIME(3) NULL,
+				terakhir_gagal TEXT,
+				created_at DATETIME(3) NULL,
+				updated_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_langganan_emails_user_id (user_id),
+				INDEX idx_langganan_emails_afdeling_id (afdeling_id),
+				CONSTRAINT fk_langganan_emails_user FOREIGN KEY (user_id) REFERENCES users(id)
+					ON DELETE CASCADE ON UPDATE CASCADE,
+				CONSTRAINT fk_langganan_emails_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS langganan_emails`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS langganan_emails (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL,
+				email VARCHAR(255) NOT NULL,
+				jenis VARCHAR(50) NOT NULL,
+				afdeling_id INTEGER NULL,
+				jadwal VARCHAR(100),
+				format VARCHAR(50),
+				aktif BOOLEAN NOT NULL DEFAULT 1,
+				terakhir_kirim DATETIME,
+				terakhir_gagal TEXT,
+				created_at DATETIME,
+				updated_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_langganan_emails_user_id ON langganan_emails (user_id)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_langganan_emails_afdeling_id ON langganan_emails (afdeling_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS langganan_emails`},
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+// Log perubahan BakuPenyadap sebagai cursor delta sync yang monoton. Entri
+// yang sudah ada dicatat sekali agar perangkat yang sinkron dari awal tetap
+// menerima semuanya.
+func init() {
+	register(Migration{
+		Version: 16,
+		Name:    "baku_perubahan",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_perubahans (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				baku_penyadap_id BIGINT UNSIGNED NOT NULL,
+				created_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_baku_perubahans_baku_penyadap_id (baku_penyadap_id)
+			)`},
+			{
+				SQL: `INSERT INTO baku_perubahans (baku_penyadap_id, created_at)
+					SELECT id, NOW(3) FROM baku_penyadaps ORDER BY updated_at, id`,
+				SkipIf: `SELECT COUNT(*) FROM baku_perubahans`,
+			},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS baku_perubahans`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS baku_perubahans (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				baku_penyadap_id INTEGER NOT NULL,
+				created_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_baku_perubahans_baku_penyadap_id ON baku_perubahans (baku_penyadap_id)`},
+			{SQL: `INSERT INTO baku_perubahans (baku_penyadap_id, created_at)
+				SELECT id, CURRENT_TIMESTAMP FROM baku_penyadaps ORDER BY updated_at, id`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS baku_perubahans`},
+		},
+	})
+}
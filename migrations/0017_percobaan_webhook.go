@@ -0,0 +1,42 @@
+package migrations
+
+// Log per percobaan HTTP pengiriman webhook.
+func init() {
+	register(Migration{
+		Version: 17,
+		Name:    "percobaan_webhook",
+		Up: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS percobaan_webhooks (
+				id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+				pengiriman_id BIGINT UNSIGNED NOT NULL,
+				ke INT NOT NULL,
+				kode_respons INT NOT NULL DEFAULT 0,
+				kesalahan TEXT,
+				durasi_ms BIGINT NOT NULL DEFAULT 0,
+				created_at DATETIME(3) NULL,
+				PRIMARY KEY (id),
+				INDEX idx_percobaan_webhooks_pengiriman_id (pengiriman_id),
+				CONSTRAINT fk_percobaan_webhooks_pengiriman FOREIGN KEY (pengiriman_id) REFERENCES pengiriman_webhooks(id)
+					ON DELETE CASCADE ON UPDATE CASCADE
+			)`},
+		},
+		Down: []Step{
+			{SQL: `DROP TABLE IF EXISTS percobaan_webhooks`},
+		},
+		SQLiteUp: []Step{
+			{SQL: `CREATE TABLE IF NOT EXISTS percobaan_webhooks (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				pengiriman_id INTEGER NOT NULL,
+				ke INTEGER NOT NULL,
+				kode_respons INTEGER NOT NULL DEFAULT 0,
+				kesalahan TEXT,
+				durasi_ms INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME
+			)`},
+			{SQL: `CREATE INDEX IF NOT EXISTS idx_percobaan_webhooks_pengiriman_id ON percobaan_webhooks (pengiriman_id)`},
+		},
+		SQLiteDown: []Step{
+			{SQL: `DROP TABLE IF EXISTS percobaan_webhooks`},
+		},
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+// Peran pengguna (admin/operator). Pengguna pertama, yaitu admin bawaan,
+// dijadikan admin agar pengaturan khusus admin tetap bisa diakses.
+func init() {
+	register(Migration{
+		Version: 18,
+		Name:    "user_role",
+		Up: []Step{
+			{
+				SQL:    `ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'operator'`,
+				SkipIf: columnExists("users", "role"),
+			},
+			{
+				SQL: `UPDATE users u JOIN (SELECT MIN(id) AS id FROM users) pertama ON u.id = pertama.id
+					SET u.role = 'admin'`,
+				SkipIf: `SELECT COUNT(*) FROM users WHERE role = 'admin'`,
+			},
+		},
+		Down: []Step{
+			{
+				SQL:    `ALTER TABLE users DROP COLUMN role`,
+				SkipIf: columnMissing("users", "role"),
+			},
+		},
+		SQLiteUp: []Step{
+			{
+				SQL:    `ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'operator'`,
+				SkipIf: sqliteColumnExists("users", "role"),
+			},
+			{
+				SQL:    `UPDATE users SET role = 'admin' WHERE id = (SELECT MIN(id) FROM users)`,
+				SkipIf: `SELECT COUNT(*) FROM users WHERE role = 'admin'`,
+			},
+		},
+		SQLiteDown: []Step{
+			{
+				SQL:    `ALTER TABLE users DROP COLUMN role`,
+				SkipIf: sqliteColumnMissing("users", "role"),
+			},
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package migrations
+
+// Foreign key afdeling pada tabel pengaturan (ambang selisih, penutupan hari,
+// webhook, langganan email) diganti dari CASCADE ke RESTRICT agar menghapus
+// afdeling tidak diam-diam menghapus pengaturannya. Penghapusan afdeling
+// yang masih dipakai ditolak oleh API.
+var afdelingFKCascadeTables = []string{"ambang_selisihs", "penutupan_haris", "webhooks", "langganan_emails"}
+
+// fkDeleteRuleBukan bernilai > 0 jika foreign key tidak ada atau aturan
+// ON DELETE-nya bukan rule
+func fkDeleteRuleBukan(table, fk, rule string) string {
+	return `SELECT COUNT(*) = 0 FROM information_schema.referential_constraints
+		WHERE constraint_schema = DATABASE()
+		AND table_name = '` + table + `'
+		AND constraint_name = '` + fk + `'
+		AND delete_rule = '` + rule + `'`
+}
+
+// gantiFKAfdeling membuat langkah MySQL untuk mengganti aturan ON DELETE
+// foreign key afdeling dari lama ke baru
+func gantiFKAfdeling(lama, baru string) []Step {
+	var steps []Step
+	for _, table := range afdelingFKCascadeTables {
+		fk := "fk_" + table + "_afdeling"
+		steps = append(steps,
+			Step{
+				SQL:    `ALTER TABLE ` + table + ` DROP FOREIGN KEY ` + fk,
+				SkipIf: fkDeleteRuleBukan(table, fk, lama),
+			},
+			Step{
+				SQL: `ALTER TABLE ` + table + `
+					ADD CONSTRAINT ` + fk + `
+					FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE ` + baru + ` ON UPDATE CASCADE`,
+				SkipIf: constraintExists(table, fk),
+			},
+		)
+	}
+	return steps
+}
+
+// bangunUlangAmbangSelisih membuat ulang ambang_selisihs di SQLite dengan
+// aturan ON DELETE baru; SQLite tidak bisa mengubah foreign key yang ada.
+// Migrasi SQLite berjalan dalam transaksi, jadi langkah ini tidak
+// tertinggal setengah jalan.
+func bangunUlangAmbangSelisih(rule string) []Step {
+	sudah := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'ambang_selisihs'
+		AND sql LIKE '%ON DELETE ` + rule + `%'`
+	return []Step{
+		{
+			SQL: `CREATE TABLE ambang_selisihs_baru (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				afdeling_id INTEGER NULL,
+				tipe_produksi VARCHAR(100) NOT NULL DEFAULT '',
+				batas_latek_persen REAL NOT NULL DEFAULT 0,
+				batas_lump_persen REAL NOT NULL DEFAULT 0,
+				keterangan VARCHAR(255),
+				created_at DATETIME,
+				updated_at DATETIME,
+				CONSTRAINT fk_ambang_selisihs_afdeling FOREIGN KEY (afdeling_id) REFERENCES afdelings(id)
+					ON DELETE ` + rule + ` ON UPDATE CASCADE
+			)`,
+			SkipIf: sudah,
+		},
+		{
+			SQL: `INSERT INTO ambang_selisihs_baru
+				(id, afdeling_id, tipe_produksi, batas_latek_persen, batas_lump_persen, keterangan, created_at, updated_at)
+				SELECT id, afdeling_id, tipe_produksi, batas_latek_persen, batas_lump_persen, keterangan, created_at, updated_at
+				FROM ambang_selisihs`,
+			SkipIf: sudah,
+		},
+		{SQL: `DROP TABLE ambang_selisihs`, SkipIf: sudah},
+		{SQL: `ALTER TABLE ambang_selisihs_baru RENAME TO ambang_selisihs`, SkipIf: sudah},
+		{SQL: `CREATE INDEX IF NOT EXISTS idx_ambang_selisihs_afdeling_id ON ambang_selisihs (afdeling_id)`},
+	}
+}
+
+func init() {
+	register(Migration{
+		Version: 19,
+		Name:    "afdeling_fk_restrict",
+		Up:      gantiFKAfdeling("CASCADE", "RESTRICT"),
+		Down:    gantiFKAfdeling("RESTRICT", "CASCADE"),
+		// Di SQLite hanya ambang_selisihs yang punya foreign key afdeling
+		SQLiteUp:   bangunUlangAmbangSelisih("RESTRICT"),
+		SQLiteDown: bangunUlangAmbangSelisih("CASCADE"),
+	})
+}
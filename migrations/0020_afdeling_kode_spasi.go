@@ -0,0 +1,67 @@
+package migrations
+
+// Migrasi 0005 tidak merapatkan spasi di tengah teks afdeling seperti
+// models.NormalizeAfdelingKode, sehingga "KEBUN  BARU" dan "KEBUN BARU" bisa
+// menjadi dua afdeling. Kode dan nama dirapikan; afdeling kembar digabung ke
+// id terkecil dan semua rujukannya dipindah sebelum kembarannya dihapus.
+
+// afdelingIDTables adalah tabel yang punya kolom afdeling_id saat migrasi ini
+var afdelingIDTables = []string{
+	"masters", "rekaps", "produksis", "baku_mandors", "baku_details", "peta", "anomalis", "peringatan_selisihs",
+	"penyadap_mandors", "target_produksis", "ambang_selisihs", "penutupan_haris", "webhooks", "langganan_emails",
+}
+
+// afdelingTeksTables adalah tabel yang juga menyimpan nama afdeling sebagai teks
+var afdelingTeksTables = []string{"masters", "rekaps", "produksis", "baku_mandors", "baku_details", "peta", "anomalis", "peringatan_selisihs"}
+
+// spasiTunggalExpr mengganti karakter kontrol dengan spasi lalu merapatkan
+// spasi berurutan (sampai 64 spasi) menjadi satu
+func spasiTunggalExpr(expr string, kontrol []string) string {
+	for _, k := range kontrol {
+		expr = "REPLACE(" + expr + ", " + k + ", ' ')"
+	}
+	for i := 0; i < 6; i++ {
+		expr = "REPLACE(" + expr + ", '  ', ' ')"
+	}
+	return expr
+}
+
+func afdelingKodeSpasiSteps(kontrol []string) []Step {
+	kode := func(col string) string { return spasiTunggalExpr(col, kontrol) }
+	// Afdeling yang setelah dirapikan sama dengan afdeling ber-id lebih kecil
+	kembar := `SELECT a.id FROM afdelings a, afdelings b
+		WHERE b.id < a.id AND ` + kode("b.kode") + ` = ` + kode("a.kode")
+
+	var steps []Step
+	for _, table := range afdelingIDTables {
+		steps = append(steps, Step{SQL: `UPDATE ` + table + ` SET afdeling_id = (
+				SELECT MIN(b.id) FROM afdelings a, afdelings b
+				WHERE a.id = ` + table + `.afdeling_id AND ` + kode("b.kode") + ` = ` + kode("a.kode") + `
+			)
+			WHERE afdeling_id IN (` + kembar + `)`})
+	}
+	steps = append(steps,
+		// Dibungkus tabel turunan karena MySQL menolak subquery ke tabel yang dihapus
+		Step{SQL: `DELETE FROM afdelings WHERE id IN (SELECT id FROM (` + kembar + `) k)`},
+		Step{SQL: `UPDATE afdelings SET nama = ` + kode("nama") + `, kode = ` + kode("kode") + `
+			WHERE kode <> ` + kode("kode") + ` OR nama <> ` + kode("nama")},
+	)
+	for _, table := range afdelingTeksTables {
+		steps = append(steps, Step{SQL: `UPDATE ` + table + ` SET
+			afdeling = (SELECT a.nama FROM afdelings a WHERE a.id = ` + table + `.afdeling_id)
+			WHERE afdeling_id IS NOT NULL AND afdeling <> ` + kode("afdeling")})
+	}
+	return steps
+}
+
+func init() {
+	register(Migration{
+		Version: 20,
+		Name:    "afdeling_kode_spasi",
+		// Kode lama tidak bisa dikembalikan; rollback tidak mengubah data
+		Up:         afdelingKodeSpasiSteps([]string{`'\t'`, `'\r'`, `'\n'`}),
+		Down:       []Step{},
+		SQLiteUp:   afdelingKodeSpasiSteps([]string{`char(9)`, `char(13)`, `char(10)`}),
+		SQLiteDown: []Step{},
+	})
+}
@@ -0,0 +1,75 @@
+package migrations_test
+
+import (
+	"app-inputan-ptpn/config/configtest"
+	"app-inputan-ptpn/migrations"
+	"app-inputan-ptpn/models"
+	"testing"
+)
+
+// Afdeling lama dari 0005 dengan spasi ganda dirapikan sama seperti
+// models.NormalizeAfdelingKode, dan kembarannya digabung
+func TestMigrasiAfdelingKodeSpasi(t *testing.T) {
+	db := configtest.InitTestDB(t)
+	if _, err := migrations.Down(db, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var kebun models.Kebun
+	if err := db.First(&kebun).Error; err != nil {
+		t.Fatal(err)
+	}
+	afdeling := func(kode string) models.Afdeling {
+		a := models.Afdeling{KebunID: kebun.ID, Kode: kode, Nama: kode, Aktif: true}
+		if err := db.Create(&a).Error; err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	rapi := afdeling("KEBUN BARU")
+	ganda := afdeling("KEBUN  BARU")
+	tunggal := afdeling("TENGAH\t  UTARA")
+
+	mandor := func(a models.Afdeling) models.BakuMandor {
+		m := models.BakuMandor{TahunTanam: 2010, NIK: "M-" + a.Kode, Mandor: "Mandor", Afdeling: a.Nama, AfdelingID: &a.ID}
+		if err := db.Create(&m).Error; err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	mandorGanda := mandor(ganda)
+	mandorTunggal := mandor(tunggal)
+	if err := db.Create(&models.Webhook{Nama: "ERP", URL: "http://erp.local", Rahasia: "rahasia-webhook-uji-123", Jenis: "*", AfdelingID: &ganda.ID, Aktif: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrations.Up(db, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int64
+	db.Model(&models.Afdeling{}).Where("id = ?", ganda.ID).Count(&n)
+	if n != 0 {
+		t.Error("afdeling kembar tidak dihapus")
+	}
+	var a models.Afdeling
+	db.First(&a, tunggal.ID)
+	if a.Kode != models.NormalizeAfdelingKode(tunggal.Kode) || a.Nama != "TENGAH UTARA" {
+		t.Errorf("afdeling = %q/%q, ingin kode %q", a.Kode, a.Nama, models.NormalizeAfdelingKode(tunggal.Kode))
+	}
+
+	var hasil models.BakuMandor
+	db.First(&hasil, mandorGanda.ID)
+	if hasil.AfdelingID == nil || *hasil.AfdelingID != rapi.ID || hasil.Afdeling != rapi.Nama {
+		t.Errorf("mandor afdeling kembar = %v/%q, ingin %d/%q", hasil.AfdelingID, hasil.Afdeling, rapi.ID, rapi.Nama)
+	}
+	var hasilTunggal models.BakuMandor
+	db.First(&hasilTunggal, mandorTunggal.ID)
+	if hasilTunggal.Afdeling != "TENGAH UTARA" {
+		t.Errorf("teks afdeling mandor = %q, ingin TENGAH UTARA", hasilTunggal.Afdeling)
+	}
+	db.Model(&models.Webhook{}).Where("afdeling_id = ?", rapi.ID).Count(&n)
+	if n != 1 {
+		t.Error("webhook afdeling kembar tidak dipindah")
+	}
+}
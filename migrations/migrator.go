@@ -0,0 +1,362 @@
+// Package migrations menyimpan perubahan skema database secara berurutan.
+// Setiap migrasi punya versi, langkah up/down, dan checksum yang dicatat di
+// tabel schema_migrations, sehingga skema hanya berubah lewat perintah
+// `migrate` dan server cukup memverifikasi versinya saat startup.
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Step adalah satu perintah SQL dalam migrasi
+type Step struct {
+	SQL string
+	// SkipIf (opsional) adalah query COUNT; jika hasilnya > 0 langkah dilewati.
+	// Dipakai untuk database lama yang skemanya sudah dibuat oleh AutoMigrate.
+	SkipIf string
+}
+
+// Migration adalah satu versi skema. Up/Down untuk MySQL, SQLiteUp/SQLiteDown
+// untuk SQLite (pengembangan lokal dan instalasi satu PC). Slice kosong
+// (bukan nil) berarti migrasi tidak perlu langkah apa pun di dialek tersebut.
+type Migration struct {
+	Version    int
+	Name       string
+	Up         []Step
+	Down       []Step
+	SQLiteUp   []Step
+	SQLiteDown []Step
+}
+
+// steps mengembalikan langkah up/down sesuai dialek database
+func (m Migration) steps(dialect string) (up, down []Step, err error) {
+	if dialect != "sqlite" {
+		return m.Up, m.Down, nil
+	}
+	if m.SQLiteUp == nil || m.SQLiteDown == nil {
+		return nil, nil, fmt.Errorf("migrasi %d (%s) belum punya langkah untuk sqlite", m.Version, m.Name)
+	}
+	return m.SQLiteUp, m.SQLiteDown, nil
+}
+
+// Checksum dihitung dari seluruh SQL migrasi untuk dialek tersebut. Migrasi
+// yang sudah diterapkan tidak boleh diubah; buat migrasi baru jika skema perlu
+// berubah lagi.
+func (m Migration) Checksum(dialect string) string {
+	up, down := m.Up, m.Down
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00", m.Version, m.Name)
+	if dialect == "sqlite" {
+		up, down = m.SQLiteUp, m.SQLiteDown
+		fmt.Fprintf(h, "sqlite\x00")
+	}
+	for _, s := range up {
+		fmt.Fprintf(h, "up\x00%s\x00%s\x00", s.SQL, s.SkipIf)
+	}
+	for _, s := range down {
+		fmt.Fprintf(h, "down\x00%s\x00%s\x00", s.SQL, s.SkipIf)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SchemaMigration adalah baris di tabel schema_migrations
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	Checksum  string    `gorm:"type:varchar(64);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus adalah status satu migrasi untuk perintah `migrate status`
+type MigrationStatus struct {
+	Version          int
+	Name             string
+	Applied          bool
+	AppliedAt        *time.Time
+	ChecksumMismatch bool
+	Unknown          bool // tercatat di database tetapi tidak ada di kode
+}
+
+var registry []Migration
+
+// register dipanggil dari init() di setiap file migrasi
+func register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migrasi versi %d terdaftar dua kali", m.Version))
+		}
+	}
+	registry = append(registry, m)
+}
+
+// All mengembalikan semua migrasi terurut berdasarkan versi
+func All() []Migration {
+	list := make([]Migration, len(registry))
+	copy(list, registry)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// LatestVersion adalah versi skema yang diharapkan oleh kode
+func LatestVersion() int {
+	all := All()
+	if len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].Version
+}
+
+func ensureSchemaTable(db *gorm.DB) error {
+	// go-sqlite3 hanya mengonversi kolom bertipe persis DATETIME ke time.Time
+	datetime := "DATETIME(3)"
+	if db.Dialector.Name() == "sqlite" {
+		datetime = "DATETIME"
+	}
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at ` + datetime + ` NOT NULL,
+		PRIMARY KEY (version)
+	)`).Error
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := db.Order("version asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Up menerapkan migrasi yang belum diterapkan. steps <= 0 berarti semuanya.
+func Up(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, fmt.Errorf("gagal membuat tabel schema_migrations: %w", err)
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	dialect := db.Dialector.Name()
+	if err := checkChecksums(applied, dialect); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range All() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		up, _, err := m.steps(dialect)
+		if err != nil {
+			return done, err
+		}
+		err = dalamTransaksi(db, func(tx *gorm.DB) error {
+			if err := runSteps(tx, up); err != nil {
+				return fmt.Errorf("migrasi %d (%s) gagal: %w", m.Version, m.Name, err)
+			}
+			if err := tx.Create(&SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				Checksum:  m.Checksum(dialect),
+				AppliedAt: time.Now(),
+			}).Error; err != nil {
+				return fmt.Errorf("gagal mencatat migrasi %d: %w", m.Version, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down membatalkan migrasi terakhir sebanyak steps (minimal 1)
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, fmt.Errorf("gagal membuat tabel schema_migrations: %w", err)
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	dialect := db.Dialector.Name()
+	if err := checkChecksums(applied, dialect); err != nil {
+		return nil, err
+	}
+
+	all := All()
+	var done []Migration
+	for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		_, down, err := m.steps(dialect)
+		if err != nil {
+			return done, err
+		}
+		err = dalamTransaksi(db, func(tx *gorm.DB) error {
+			if err := runSteps(tx, down); err != nil {
+				return fmt.Errorf("rollback migrasi %d (%s) gagal: %w", m.Version, m.Name, err)
+			}
+			if err := tx.Delete(&SchemaMigration{}, m.Version).Error; err != nil {
+				return fmt.Errorf("gagal menghapus catatan migrasi %d: %w", m.Version, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Status mengembalikan status semua migrasi, termasuk versi yang tidak dikenal kode
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, fmt.Errorf("gagal membuat tabel schema_migrations: %w", err)
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	dialect := db.Dialector.Name()
+	known := make(map[int]bool)
+	var list []MigrationStatus
+	for _, m := range All() {
+		known[m.Version] = true
+		st := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			st.Applied = true
+			st.AppliedAt = &appliedAt
+			st.ChecksumMismatch = row.Checksum != m.Checksum(dialect)
+		}
+		list = append(list, st)
+	}
+	for version, row := range applied {
+		if known[version] {
+			continue
+		}
+		appliedAt := row.AppliedAt
+		list = append(list, MigrationStatus{
+			Version:   version,
+			Name:      row.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Verify memastikan skema database sama dengan versi yang diharapkan kode.
+// Tidak mengubah apa pun; dipanggil saat startup server.
+func Verify(db *gorm.DB) error {
+	list, err := Status(db)
+	if err != nil {
+		return err
+	}
+
+	var pending, mismatch, unknown []string
+	for _, st := range list {
+		label := fmt.Sprintf("%04d_%s", st.Version, st.Name)
+		switch {
+		case st.Unknown:
+			unknown = append(unknown, label)
+		case !st.Applied:
+			pending = append(pending, label)
+		case st.ChecksumMismatch:
+			mismatch = append(mismatch, label)
+		}
+	}
+
+	var problems []string
+	if len(pending) > 0 {
+		problems = append(problems, "migrasi belum diterapkan: "+strings.Join(pending, ", "))
+	}
+	if len(mismatch) > 0 {
+		problems = append(problems, "checksum berbeda: "+strings.Join(mismatch, ", "))
+	}
+	if len(unknown) > 0 {
+		problems = append(problems, "versi database lebih baru dari kode: "+strings.Join(unknown, ", "))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("skema database tidak sesuai (versi kode %d): %s", LatestVersion(), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func checkChecksums(applied map[int]SchemaMigration, dialect string) error {
+	for _, m := range All() {
+		row, ok := applied[m.Version]
+		if ok && row.Checksum != m.Checksum(dialect) {
+			return fmt.Errorf("checksum migrasi %d (%s) berbeda dengan yang sudah diterapkan; jangan ubah migrasi lama", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// dalamTransaksi menjalankan langkah satu migrasi beserta catatannya di
+// schema_migrations dalam satu transaksi jika dialek mendukung DDL
+// transaksional (SQLite), sehingga migrasi yang gagal di tengah jalan tidak
+// meninggalkan skema setengah jadi. DDL di MySQL melakukan commit implisit,
+// jadi di sana langkah dijalankan langsung: langkah yang sudah berhasil tetap
+// berlaku walaupun langkah berikutnya gagal, dan `migrate up` berikutnya
+// menjalankan ulang migrasi itu dari langkah pertama.
+func dalamTransaksi(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if db.Dialector.Name() != "sqlite" {
+		return fn(db)
+	}
+	return db.Transaction(fn)
+}
+
+// runSteps menjalankan langkah secara berurutan. Di MySQL langkah tidak bisa
+// dibungkus transaksi (lihat dalamTransaksi), jadi migrator tidak menjamin
+// apa pun saat migrasi diulang: setiap langkah MySQL wajib ditulis idempoten
+// sendiri (IF NOT EXISTS, INSERT IGNORE, atau SkipIf).
+func runSteps(db *gorm.DB, steps []Step) error {
+	for _, s := range steps {
+		if s.SkipIf != "" {
+			var count int64
+			if err := db.Raw(s.SkipIf).Scan(&count).Error; err != nil {
+				return fmt.Errorf("cek langkah gagal: %w", err)
+			}
+			if count > 0 {
+				continue
+			}
+		}
+		if err := db.Exec(s.SQL).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
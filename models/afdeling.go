@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Afdeling adalah master data afdeling. Kolom string afdeling di tabel lain
+// tetap disimpan (berisi Nama) agar laporan lama tidak berubah, sedangkan
+// afdeling_id menjadi acuan relasinya.
+type Afdeling struct {
+	ID      uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	KebunID uint   `gorm:"not null;index" json:"kebun_id"`
+	Kode    string `gorm:"size:50;not null;uniqueIndex" json:"kode"`
+	Nama    string `gorm:"size:100;not null" json:"nama"`
+	Aktif   bool   `gorm:"not null" json:"aktif"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Kebun *Kebun `gorm:"foreignKey:KebunID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;" json:"kebun,omitempty"`
+}
+
+func (Afdeling) TableName() string {
+	return "afdelings"
+}
+
+// afdelingAlias memetakan ejaan lama yang pernah dipakai ke kode resmi
+var afdelingAlias = map[string]string{
+	"JATIROENGGO": "JATIRUNGGO",
+}
+
+// NormalizeAfdelingKode mengubah input bebas ("Setro", "setro ", "AFD SETRO")
+// menjadi kode afdeling ("SETRO"). Aturannya sama dengan migrasi 0005 yang
+// spasi gandanya dirapatkan migrasi 0020.
+func NormalizeAfdelingKode(s string) string {
+	kode := strings.Join(strings.Fields(strings.ToUpper(s)), " ")
+	for _, prefix := range []string{"AFDELING ", "AFD. ", "AFD.", "AFD "} {
+		if strings.HasPrefix(kode, prefix) {
+			kode = strings.TrimSpace(kode[len(prefix):])
+			break
+		}
+	}
+	if alias, ok := afdelingAlias[kode]; ok {
+		return alias
+	}
+	return kode
+}
+
+// FindAfdeling mencari afdeling berdasarkan kode (setelah dinormalisasi) atau nama
+func FindAfdeling(db *gorm.DB, input string) (Afdeling, error) {
+	var afdeling Afdeling
+	kode := NormalizeAfdelingKode(input)
+	if kode == "" {
+		return afdeling, gorm.ErrRecordNotFound
+	}
+	err := db.Where("kode = ?", kode).
+		Or("UPPER(nama) = ?", strings.ToUpper(strings.TrimSpace(input))).
+		First(&afdeling).Error
+	return afdeling, err
+}
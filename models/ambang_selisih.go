@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AmbangSelisih adalah batas persentase selisih basah kebun vs pabrik.
+// AfdelingID nil berarti semua afdeling dan TipeProduksi kosong berarti
+// semua tipe; ambang yang paling spesifik yang dipakai. Batas 0 berarti
+// jenis tersebut tidak diperiksa.
+type AmbangSelisih struct {
+	ID               uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	AfdelingID       *uint   `gorm:"index" json:"afdeling_id"`
+	TipeProduksi     string  `gorm:"type:varchar(100);not null;default:''" json:"tipe_produksi"`
+	BatasLatekPersen float64 `gorm:"not null;default:0" json:"batas_latek_persen"`
+	BatasLumpPersen  float64 `gorm:"not null;default:0" json:"batas_lump_persen"`
+	Keterangan       string  `gorm:"type:varchar(255)" json:"keterangan"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Afdeling *Afdeling `gorm:"foreignKey:AfdelingID" json:"afdeling,omitempty"`
+}
+
+func (AmbangSelisih) TableName() string {
+	return "ambang_selisihs"
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Jenis temuan analisis anomali
+const (
+	AnomaliLonjakan = "LONJAKAN" // nilai jauh dari baseline sendiri (robust z-score)
+	AnomaliRasioK3  = "RASIO_K3" // rasio kering/basah di luar rentang wajar
+	AnomaliHKO      = "HKO"      // HKO rekap tidak sesuai jumlah baris penyadap
+)
+
+// Status antrian peninjauan anomali
+const (
+	StatusAnomaliBaru    = "BARU"
+	StatusAnomaliDiakui  = "DIAKUI"
+	StatusAnomaliSelesai = "SELESAI"
+)
+
+// Anomali adalah satu temuan analisis data produksi harian yang menunggu
+// ditinjau. Sumber "produksi" berarti baris penyadap (RefID = produksis.id),
+// "rekap" berarti baris mandor (RefID = rekaps.id). Kunci unik mencegah
+// temuan yang sama tercatat dua kali saat analisis diulang.
+type Anomali struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kunci        string    `gorm:"type:varchar(191);not null;uniqueIndex" json:"kunci"`
+	Tanggal      time.Time `gorm:"type:date;not null;index:idx_anomalis_status,priority:2" json:"tanggal"`
+	Jenis        string    `gorm:"type:varchar(20);not null" json:"jenis"`
+	Sumber       string    `gorm:"type:varchar(20);not null" json:"sumber"`
+	RefID        uint      `gorm:"not null" json:"ref_id"`
+	Ukuran       string    `gorm:"type:varchar(50);not null" json:"ukuran"`
+	NIK          string    `gorm:"type:varchar(50);not null;index" json:"nik"`
+	Nama         string    `gorm:"type:varchar(100)" json:"nama"`
+	Mandor       string    `gorm:"type:varchar(100)" json:"mandor"`
+	TipeProduksi string    `gorm:"type:varchar(100)" json:"tipe_produksi"`
+	Afdeling     string    `gorm:"type:varchar(100)" json:"afdeling"`
+	AfdelingID   *uint     `gorm:"index" json:"afdeling_id"`
+	Nilai        float64   `gorm:"not null;default:0" json:"nilai"`
+	Acuan        float64   `gorm:"not null;default:0" json:"acuan"`
+	Skor         float64   `gorm:"not null;default:0" json:"skor"`
+	Pesan        string    `gorm:"type:varchar(255)" json:"pesan"`
+
+	Status           string     `gorm:"type:varchar(20);not null;default:'BARU';index:idx_anomalis_status,priority:1" json:"status"`
+	Catatan          string     `gorm:"type:text" json:"catatan"`
+	DitinjauOleh     string     `gorm:"type:varchar(100)" json:"ditinjau_oleh"`
+	DiakuiPada       *time.Time `json:"diakui_pada"`
+	DiselesaikanPada *time.Time `json:"diselesaikan_pada"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Anomali) TableName() string {
+	return "anomalis"
+}
@@ -46,10 +46,11 @@ func IsValidTipeProduksi(tipe TipeProduksi) bool {
 type BakuMandor struct {
 	ID         uint         `gorm:"primaryKey;autoIncrement" json:"id"`
 	TahunTanam uint         `gorm:"not null" json:"tahun_tanam"`
-	NIK        string       `gorm:"not null" json:"nik"`
+	NIK        string       `gorm:"size:50;not null" json:"nik"`
 	Mandor     string       `gorm:"size:100;not null" json:"mandor"`
 	Afdeling   string       `gorm:"size:100;not null" json:"afdeling"`
-	Tipe       TipeProduksi `gorm:"type:text; not null; default:'BAKU'; index" json:"tipe"`
+	AfdelingID *uint        `gorm:"index" json:"afdeling_id"`
+	Tipe       TipeProduksi `gorm:"type:varchar(50); not null; default:'BAKU'; index" json:"tipe"`
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
@@ -61,10 +62,11 @@ type BakuMandor struct {
 // FIXED: Change foreign key type to uint (not uint64)
 type BakuPenyadap struct {
 	ID           uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	UUID         string       `gorm:"size:36;not null;uniqueIndex" json:"uuid"`
 	IdBakuMandor uint         `gorm:"not null;index" json:"idBakuMandor"` // FIXED: uint instead of uint64
 	IdPenyadap   uint         `gorm:"not null;index" json:"idPenyadap"`   // FIXED: uint instead of uint64
 	Tanggal      time.Time    `gorm:"not null;index" json:"tanggal"`
-	Tipe         TipeProduksi `gorm:"type:text; not null; default:'BAKU'; index" json:"tipe"`
+	Tipe         TipeProduksi `gorm:"type:varchar(50); not null; default:'BAKU'; index" json:"tipe"`
 	TahunTanam   uint         `gorm:"" json:"tahun_tanam"`
 
 	BasahLatex float64 `gorm:"default:0" json:"basahLatex"`
@@ -73,7 +75,7 @@ type BakuPenyadap struct {
 	BrCr       float64 `gorm:"default:0" json:"brCr"`
 
 	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	UpdatedAt time.Time      `gorm:"index" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 
 	Mandor   BakuMandor `gorm:"foreignKey:IdBakuMandor;references:ID" json:"mandor"`
@@ -87,8 +89,9 @@ type BakuDetail struct {
 	IdBakuMandor uint         `gorm:"not null;index" json:"idBakuMandor"`
 	Mandor       string       `gorm:"size:100;not null;index" json:"mandor"` // Nama mandor
 	Afdeling     string       `gorm:"size:100;not null" json:"afdeling"`     // Afdeling
+	AfdelingID   *uint        `gorm:"index" json:"afdeling_id"`
 	TahunTanam   uint         `gorm:"" json:"tahun_tanam"`
-	Tipe         TipeProduksi `gorm:"type:text; not null; default:'BAKU'; index" json:"tipe"`
+	Tipe         TipeProduksi `gorm:"type:varchar(50); not null; default:'BAKU'; index" json:"tipe"`
 
 	JumlahPabrikBasahLatek      float64 `gorm:"default:0" json:"jumlah_pabrik_basah_latek"`
 	JumlahKebunBasahLatek       float64 `gorm:"default:0" json:"jumlah_kebun_basah_latek"`
@@ -149,6 +152,11 @@ func (bm *BakuMandor) BeforeCreate(tx *gorm.DB) error {
 
 // BeforeCreate hook untuk BakuPenyadap - set tanggal hari ini dan auto-set tipe from mandor
 func (bp *BakuPenyadap) BeforeCreate(tx *gorm.DB) error {
+	// Buat UUID jika entri tidak berasal dari perangkat sync
+	if bp.UUID == "" {
+		bp.UUID = NewUUID()
+	}
+
 	// Set tanggal ke hari ini jika kosong
 	if bp.Tanggal.IsZero() {
 		bp.Tanggal = time.Now().Truncate(24 * time.Hour)
@@ -165,3 +173,21 @@ func (bp *BakuPenyadap) BeforeCreate(tx *gorm.DB) error {
 
 	return nil
 }
+
+// AfterSave mencatat perubahan untuk delta sync. Update massal lewat
+// Model(&BakuPenyadap{}) tidak punya ID; catat dengan CatatPerubahanBaku.
+func (bp *BakuPenyadap) AfterSave(tx *gorm.DB) error {
+	return catatPerubahanBakuPenyadap(tx, bp.ID)
+}
+
+// AfterDelete mencatat soft delete agar terlihat oleh perangkat lain
+func (bp *BakuPenyadap) AfterDelete(tx *gorm.DB) error {
+	return catatPerubahanBakuPenyadap(tx, bp.ID)
+}
+
+func catatPerubahanBakuPenyadap(tx *gorm.DB, id uint) error {
+	if id == 0 {
+		return nil
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Create(&PerubahanBaku{BakuPenyadapID: id}).Error
+}
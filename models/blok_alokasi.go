@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Sumber pemetaan produksi ke blok
+const (
+	SumberBlokOtomatis = "otomatis"
+	SumberBlokManual   = "manual"
+)
+
+// BlokAlokasi memetakan produksi seorang mandor atau penyadap ke blok Peta
+// secara eksplisit. Tanpa alokasi, produksi dipetakan otomatis ke blok dengan
+// afdeling dan tahun tanam yang sama. Bobot 0 berarti dibagi menurut luas blok.
+type BlokAlokasi struct {
+	ID         uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	PetaID     uint    `gorm:"not null;index" json:"peta_id"`
+	MandorID   *uint   `gorm:"index" json:"mandor_id"`
+	PenyadapID *uint   `gorm:"index" json:"penyadap_id"`
+	Bobot      float64 `gorm:"not null;default:0" json:"bobot"`
+	Keterangan string  `gorm:"type:varchar(255)" json:"keterangan"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Peta     *Peta     `gorm:"foreignKey:PetaID" json:"peta,omitempty"`
+	Mandor   *Mandor   `gorm:"foreignKey:MandorID" json:"mandor,omitempty"`
+	Penyadap *Penyadap `gorm:"foreignKey:PenyadapID" json:"penyadap,omitempty"`
+}
+
+func (BlokAlokasi) TableName() string {
+	return "blok_alokasis"
+}
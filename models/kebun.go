@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Kebun adalah master data kebun (unit usaha), induk dari afdeling
+type Kebun struct {
+	ID    uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kode  string `gorm:"size:50;not null;uniqueIndex" json:"kode"`
+	Nama  string `gorm:"size:100;not null" json:"nama"`
+	Aktif bool   `gorm:"not null" json:"aktif"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Afdelings []Afdeling `gorm:"foreignKey:KebunID" json:"afdelings,omitempty"`
+}
+
+func (Kebun) TableName() string {
+	return "kebuns"
+}
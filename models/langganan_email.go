@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// JenisLaporanHarian adalah langganan laporan produksi harian terjadwal;
+// jenis lain adalah nama peristiwa (misal "upload.failed") yang dikirim
+// langsung saat terjadi
+const JenisLaporanHarian = "laporan.harian"
+
+// LanggananEmail adalah preferensi email satu pengguna. AfdelingID nil
+// berarti semua afdeling. Jadwal (gaya cron, misal "0 7 * * *") dan
+// Format lampiran ("pdf", "xlsx" atau "pdf,xlsx") hanya dipakai untuk
+// laporan harian.
+type LanggananEmail struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint   `gorm:"not null;index" json:"user_id"`
+	Email      string `gorm:"type:varchar(255);not null" json:"email"`
+	Jenis      string `gorm:"type:varchar(50);not null" json:"jenis"`
+	AfdelingID *uint  `gorm:"index" json:"afdeling_id"`
+	Jadwal     string `gorm:"type:varchar(100)" json:"jadwal"`
+	Format     string `gorm:"type:varchar(50)" json:"format"`
+	Aktif      bool   `gorm:"not null;default:true" json:"aktif"`
+
+	TerakhirKirim *time.Time `json:"terakhir_kirim"`
+	TerakhirGagal string     `gorm:"type:text" json:"terakhir_gagal"`
+	// Berikutnya dihitung dari Jadwal saat ditampilkan
+	Berikutnya *time.Time `gorm:"-" json:"berikutnya,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Afdeling *Afdeling `gorm:"foreignKey:AfdelingID" json:"afdeling,omitempty"`
+}
+
+func (LanggananEmail) TableName() string {
+	return "langganan_emails"
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Sumber data penugasan
+const (
+	SumberPenugasanImpor  = "impor"
+	SumberPenugasanManual = "manual"
+)
+
+// PenyadapMandor mencatat penyadap masuk kelompok mandor mana pada rentang
+// tanggal tertentu. ValidTo nil berarti penugasan masih berlaku.
+type PenyadapMandor struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	PenyadapID uint       `gorm:"not null;index:idx_penyadap_mandors_penyadap,priority:1" json:"penyadap_id"`
+	MandorID   uint       `gorm:"not null;index:idx_penyadap_mandors_mandor,priority:1" json:"mandor_id"`
+	AfdelingID *uint      `gorm:"index" json:"afdeling_id"`
+	TahunTanam string     `gorm:"type:varchar(10)" json:"tahun_tanam"`
+	Blok       string     `gorm:"type:varchar(255)" json:"blok"`
+	ValidFrom  time.Time  `gorm:"type:date;not null;index:idx_penyadap_mandors_penyadap,priority:2;index:idx_penyadap_mandors_mandor,priority:2" json:"valid_from"`
+	ValidTo    *time.Time `gorm:"type:date" json:"valid_to"`
+	Sumber     string     `gorm:"type:varchar(20);not null" json:"sumber"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Penyadap *Penyadap `gorm:"foreignKey:PenyadapID" json:"penyadap,omitempty"`
+	Mandor   *Mandor   `gorm:"foreignKey:MandorID" json:"mandor,omitempty"`
+	Afdeling *Afdeling `gorm:"foreignKey:AfdelingID" json:"afdeling,omitempty"`
+}
+
+func (PenyadapMandor) TableName() string {
+	return "penyadap_mandors"
+}
+
+// BerlakuPada bernilai true jika penugasan mencakup tanggal t
+func (pm PenyadapMandor) BerlakuPada(t time.Time) bool {
+	day := t.Format("2006-01-02")
+	if pm.ValidFrom.Format("2006-01-02") > day {
+		return false
+	}
+	return pm.ValidTo == nil || pm.ValidTo.Format("2006-01-02") >= day
+}
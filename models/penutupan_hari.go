@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PenutupanHari menandai data satu tanggal di satu afdeling sudah lengkap
+// dan diperiksa. Penanda ini tidak mengunci data; gunanya memberi tahu
+// pihak lain (dashboard, webhook, email) bahwa laporan hari itu siap.
+type PenutupanHari struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Tanggal     time.Time `gorm:"type:date;not null;uniqueIndex:idx_penutupan_haris_tanggal_afdeling,priority:1" json:"tanggal"`
+	AfdelingID  uint      `gorm:"not null;uniqueIndex:idx_penutupan_haris_tanggal_afdeling,priority:2" json:"afdeling_id"`
+	DitutupOleh string    `gorm:"type:varchar(100)" json:"ditutup_oleh"`
+	Catatan     string    `gorm:"type:text" json:"catatan"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	Afdeling *Afdeling `gorm:"foreignKey:AfdelingID" json:"afdeling,omitempty"`
+}
+
+func (PenutupanHari) TableName() string {
+	return "penutupan_haris"
+}
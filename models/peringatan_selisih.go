@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Jenis selisih yang diperiksa
+const (
+	SelisihBasahLatek = "basah_latek"
+	SelisihBasahLump  = "basah_lump"
+)
+
+// PeringatanSelisih adalah satu hari-mandor yang selisih kebun vs pabriknya
+// melewati ambang. Sumber "rekap" merujuk rekaps.id, "baku" merujuk
+// baku_details.id. Peringatan dihapus lagi jika data atau ambangnya berubah
+// sehingga tidak lagi melewati batas.
+type PeringatanSelisih struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kunci        string    `gorm:"type:varchar(191);not null;uniqueIndex" json:"kunci"`
+	Tanggal      time.Time `gorm:"type:date;not null;index" json:"tanggal"`
+	Sumber       string    `gorm:"type:varchar(20);not null" json:"sumber"`
+	RefID        uint      `gorm:"not null" json:"ref_id"`
+	Jenis        string    `gorm:"type:varchar(20);not null" json:"jenis"`
+	NIK          string    `gorm:"type:varchar(50);not null;index" json:"nik"`
+	Mandor       string    `gorm:"type:varchar(100)" json:"mandor"`
+	TipeProduksi string    `gorm:"type:varchar(100)" json:"tipe_produksi"`
+	Afdeling     string    `gorm:"type:varchar(100)" json:"afdeling"`
+	AfdelingID   *uint     `gorm:"index" json:"afdeling_id"`
+	Kebun        float64   `gorm:"not null;default:0" json:"kebun"`
+	Pabrik       float64   `gorm:"not null;default:0" json:"pabrik"`
+	Persen       float64   `gorm:"not null;default:0" json:"persen"`
+	Batas        float64   `gorm:"not null;default:0" json:"batas"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (PeringatanSelisih) TableName() string {
+	return "peringatan_selisihs"
+}
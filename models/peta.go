@@ -1,13 +1,68 @@
 package models
 
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tahun tanam boleh ditulis "1999" atau "ex 1999" (tanaman eks tahun tersebut)
+var polaTahunTanam = regexp.MustCompile(`^(?i)(ex\.?\s*)?(\d{4})$`)
+
 type Peta struct {
 	ID          uint    `gorm:"primary_key"`
 	Blok        string  `gorm:"type:varchar(255);"`
-	Code        string  `gorm:"type:varchar(255);not null"`
+	Code        string  `gorm:"type:varchar(255);not null;uniqueIndex:idx_peta_code"`
 	Afdeling    string  `gorm:"type:varchar(100);not null"`
+	AfdelingID  *uint   `gorm:"index"`
 	Luas        float32 `gorm:"not null;default:0"`
 	JumlahPohon int64   `gorm:"not null;default:0"`
 	JenisKebun  string  `gorm:"type:varchar(255);"`
-	TahunTanam  string  `gorm:"type:varchar(255);"`
-	Kloon       string  `gorm:"type:varchar(255);"`
+	// TahunTanam adalah teks tampilan ("1999" / "ex 1999") yang selalu
+	// diturunkan dari TahunTanamAngka dan TahunTanamEx lewat SetTahunTanam
+	TahunTanam      string `gorm:"type:varchar(255);"`
+	TahunTanamAngka *int
+	TahunTanamEx    bool           `gorm:"not null;default:false"`
+	Kloon           string         `gorm:"type:varchar(255);"`
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+// ParseTahunTanam membaca "1999", "ex 1999" atau "Ex.1999". String kosong
+// berarti blok tanpa tahun tanam (emplasemen, kantor) dan bukan error.
+func ParseTahunTanam(s string) (tahun *int, ex bool, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, false, nil
+	}
+	m := polaTahunTanam.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false, errors.New("tahun tanam '" + s + "' tidak valid (gunakan 1999 atau ex 1999)")
+	}
+	t, _ := strconv.Atoi(m[2])
+	if t < 1900 || t > time.Now().Year()+1 {
+		return nil, false, errors.New("tahun tanam " + m[2] + " di luar rentang yang wajar")
+	}
+	return &t, m[1] != "", nil
+}
+
+// FormatTahunTanam menghasilkan teks tampilan tahun tanam
+func FormatTahunTanam(tahun *int, ex bool) string {
+	if tahun == nil {
+		return ""
+	}
+	if ex {
+		return "ex " + strconv.Itoa(*tahun)
+	}
+	return strconv.Itoa(*tahun)
+}
+
+// SetTahunTanam mengisi kolom terstruktur sekaligus teks tampilannya
+func (p *Peta) SetTahunTanam(tahun *int, ex bool) {
+	p.TahunTanamAngka = tahun
+	p.TahunTanamEx = ex && tahun != nil
+	p.TahunTanam = FormatTahunTanam(tahun, p.TahunTanamEx)
 }
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// PetaGeometri menyimpan poligon satu placemark KML sebagai teks GeoJSON.
+// Code adalah nama placemark yang dinormalisasi (huruf besar) dan menjadi
+// kunci impor ulang; PetaID terisi jika ada baris Peta dengan kode yang sama.
+type PetaGeometri struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code          string `gorm:"type:varchar(255);not null;uniqueIndex" json:"code"`
+	Nama          string `gorm:"type:varchar(255)" json:"nama"`
+	PetaID        *uint  `gorm:"index" json:"peta_id"`
+	Tipe          string `gorm:"type:varchar(20);not null" json:"tipe"`
+	JumlahPoligon int    `gorm:"not null;default:0" json:"jumlah_poligon"`
+	GeoJSON       string `gorm:"column:geojson;type:longtext;not null" json:"-"`
+	SumberFile    string `gorm:"type:varchar(255)" json:"sumber_file"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Peta *Peta `gorm:"foreignKey:PetaID" json:"peta,omitempty"`
+}
+
+func (PetaGeometri) TableName() string {
+	return "peta_geometris"
+}
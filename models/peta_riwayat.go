@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Aksi yang dicatat di riwayat peta
+const (
+	AksiPetaBuat     = "buat"
+	AksiPetaUbah     = "ubah"
+	AksiPetaHapus    = "hapus"
+	AksiPetaPulihkan = "pulihkan"
+	AksiPetaImpor    = "impor"
+)
+
+// PetaRiwayat mencatat satu perubahan data blok. Perubahan berisi JSON
+// {"field": {"lama": ..., "baru": ...}} untuk field yang berubah saja.
+type PetaRiwayat struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PetaID    uint      `gorm:"not null;index:idx_peta_riwayats_peta_id,priority:1" json:"peta_id"`
+	Code      string    `gorm:"type:varchar(255);not null" json:"code"`
+	Aksi      string    `gorm:"type:varchar(20);not null" json:"aksi"`
+	Perubahan string    `gorm:"type:longtext" json:"perubahan"`
+	Username  string    `gorm:"type:varchar(100)" json:"username"`
+	CreatedAt time.Time `gorm:"index:idx_peta_riwayats_peta_id,priority:2" json:"created_at"`
+}
+
+func (PetaRiwayat) TableName() string {
+	return "peta_riwayats"
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kode pola distribusi bawaan yang diisi migrasi
+const PolaDistribusiRata = "RATA"
+
+// PolaDistribusi membagi target ke periode yang lebih kecil. BobotBulan (12
+// angka, Januari-Desember) membagi target tahunan ke bulan; BobotHari (7
+// angka, Senin-Minggu) membagi target bulan ke hari. Keduanya disimpan
+// sebagai angka dipisah koma, mis. "1,1,1,1,1,1,0".
+type PolaDistribusi struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kode       string `gorm:"size:50;not null;uniqueIndex" json:"kode"`
+	Nama       string `gorm:"size:100;not null" json:"nama"`
+	BobotBulan string `gorm:"type:varchar(255);not null" json:"bobot_bulan"`
+	BobotHari  string `gorm:"type:varchar(100);not null" json:"bobot_hari"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (PolaDistribusi) TableName() string {
+	return "pola_distribusis"
+}
+
+// ParseBobot membaca n bobot dipisah koma. Bobot tidak boleh negatif dan
+// jumlahnya harus lebih dari nol.
+func ParseBobot(s string, n int) ([]float64, error) {
+	bagian := strings.Split(s, ",")
+	if len(bagian) != n {
+		return nil, fmt.Errorf("harus berisi %d bobot dipisah koma, ditemukan %d", n, len(bagian))
+	}
+	bobot := make([]float64, n)
+	total := 0.0
+	for i, b := range bagian {
+		v, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("bobot ke-%d ('%s') harus angka >= 0", i+1, strings.TrimSpace(b))
+		}
+		bobot[i] = v
+		total += v
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("jumlah bobot tidak boleh nol")
+	}
+	return bobot, nil
+}
+
+// Bobot mengembalikan bobot bulan (indeks 0 = Januari) dan bobot hari
+// (indeks 0 = Senin)
+func (p PolaDistribusi) Bobot() (bulan, hari []float64, err error) {
+	if bulan, err = ParseBobot(p.BobotBulan, 12); err != nil {
+		return nil, nil, fmt.Errorf("bobot bulan pola %s %v", p.Kode, err)
+	}
+	if hari, err = ParseBobot(p.BobotHari, 7); err != nil {
+		return nil, nil, fmt.Errorf("bobot hari pola %s %v", p.Kode, err)
+	}
+	return bulan, hari, nil
+}
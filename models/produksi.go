@@ -4,17 +4,18 @@ import "time"
 
 type Produksi struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Tanggal      time.Time `gorm:"type:date;not null;index" json:"tanggal"`
-	TipeProduksi string    `gorm:"type:varchar(100);not null" json:"tipe_produksi"`
-	TahunTanam   string    `gorm:"type:varchar(10);not null" json:"tahun_tanam"`
-	Mandor       string    `gorm:"type:varchar(100);not null" json:"mandor"`
-	NIK          string    `gorm:"type:varchar(50);not null;index" json:"nik"`
+	Tanggal      time.Time `gorm:"type:date;not null;index;uniqueIndex:idx_produksis_upsert,priority:1" json:"tanggal"`
+	TipeProduksi string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_produksis_upsert,priority:2" json:"tipe_produksi"`
+	TahunTanam   string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_produksis_upsert,priority:5" json:"tahun_tanam"`
+	Mandor       string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_produksis_upsert,priority:4" json:"mandor"`
+	NIK          string    `gorm:"type:varchar(50);not null;index;uniqueIndex:idx_produksis_upsert,priority:3" json:"nik"`
 	NamaPenyadap string    `gorm:"type:varchar(100);not null" json:"nama_penyadap"`
 	BasahLatek   float64   `gorm:"not null;default:0" json:"basah_latek"`
 	Sheet        float64   `gorm:"not null;default:0" json:"sheet"`
 	BasahLump    float64   `gorm:"not null;default:0" json:"basah_lump"`
 	BrCr         float64   `gorm:"not null;default:0" json:"br_cr"`
 	Afdeling     string    `gorm:"type:varchar(100);not null" json:"afdeling"`
+	AfdelingID   *uint     `gorm:"index" json:"afdeling_id"`
 
 	TotalProduksi float64 `gorm:"type:decimal(10,2);default:0" json:"total_produksi"`
 	// Foreign key - CASCADE sudah benar
@@ -4,11 +4,11 @@ import "time"
 
 type Rekap struct {
 	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Tanggal          time.Time `gorm:"type:date;not null;index" json:"tanggal"`
-	TipeProduksi     string    `gorm:"type:text;not null" json:"tipe_produksi"`
-	TahunTanam       string    `gorm:"type:varchar(10);not null" json:"tahun_tanam"`
-	NIK              string    `gorm:"type:varchar(20);not null;index" json:"nik"`
-	Mandor           string    `gorm:"type:varchar(100);not null" json:"mandor"`
+	Tanggal          time.Time `gorm:"type:date;not null;index;uniqueIndex:idx_rekaps_upsert,priority:1" json:"tanggal"`
+	TipeProduksi     string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_rekaps_upsert,priority:2" json:"tipe_produksi"`
+	TahunTanam       string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_rekaps_upsert,priority:5" json:"tahun_tanam"`
+	NIK              string    `gorm:"type:varchar(20);not null;index;uniqueIndex:idx_rekaps_upsert,priority:3" json:"nik"`
+	Mandor           string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_rekaps_upsert,priority:4" json:"mandor"`
 	HKOHariIni       int       `gorm:"default:0" json:"hko_hari_ini"`
 	HKOSampaiHariIni int       `gorm:"default:0" json:"hko_sampai_hari_ini"`
 
@@ -40,7 +40,8 @@ type Rekap struct {
 	TotalProduksiHariIni       float64 `gorm:"type:decimal(10,2);default:0" json:"total_produksi_hari_ini"`
 	TotalProduksiSampaiHariIni float64 `gorm:"type:decimal(10,2);default:0" json:"total_produksi_sampai_hari_ini"`
 
-	Afdeling string `gorm:"type:varchar(100);not null;index" json:"afdeling"`
+	Afdeling   string `gorm:"type:varchar(100);not null;index" json:"afdeling"`
+	AfdelingID *uint  `gorm:"index" json:"afdeling_id"`
 
 	// Foreign key - CASCADE sudah benar
 	IdMaster uint64 `gorm:"not null;index" json:"id_master"`
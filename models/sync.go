@@ -0,0 +1,83 @@
+package models
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Aksi yang dapat dikirim perangkat lapangan lewat sync
+const (
+	SyncActionCreate = "create"
+	SyncActionUpdate = "update"
+	SyncActionDelete = "delete"
+)
+
+// Status hasil penerapan satu operasi sync
+const (
+	SyncStatusApplied   = "applied"
+	SyncStatusDuplicate = "duplicate"
+	SyncStatusConflict  = "conflict"
+	SyncStatusError     = "error"
+)
+
+// SyncOperation mencatat setiap operasi yang sudah diterapkan dari perangkat,
+// sehingga batch yang dikirim ulang (karena sinyal putus) tidak diterapkan dua kali.
+type SyncOperation struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OpID      string    `gorm:"size:36;not null;uniqueIndex" json:"op_id"`
+	DeviceID  string    `gorm:"size:100;not null;index" json:"device_id"`
+	EntryUUID string    `gorm:"size:36;not null;index" json:"entry_uuid"`
+	Action    string    `gorm:"size:20;not null" json:"action"`
+	Status    string    `gorm:"size:20;not null" json:"status"`
+	Message   string    `gorm:"type:text" json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SyncOperation) TableName() string {
+	return "sync_operations"
+}
+
+// PerubahanBaku adalah log perubahan BakuPenyadap untuk delta sync. ID
+// (autoincrement) dipakai sebagai cursor perangkat: baris dicatat di
+// transaksi yang sama dengan perubahannya, sehingga urutannya tidak
+// bergantung pada jam server seperti updated_at.
+type PerubahanBaku struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	BakuPenyadapID uint      `gorm:"not null;index" json:"baku_penyadap_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (PerubahanBaku) TableName() string {
+	return "baku_perubahans"
+}
+
+// CatatPerubahanBaku mencatat perubahan untuk semua BakuPenyadap yang cocok
+// dengan kondisi. Dipakai untuk update massal yang tidak memanggil hook per
+// entri, misalnya perubahan tipe mandor.
+func CatatPerubahanBaku(tx *gorm.DB, query string, args ...interface{}) error {
+	return tx.Exec(`INSERT INTO baku_perubahans (baku_penyadap_id, created_at)
+		SELECT id, ? FROM baku_penyadaps WHERE `+query+` ORDER BY id`,
+		append([]interface{}{time.Now()}, args...)...).Error
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsValidUUID memeriksa format UUID (8-4-4-4-12 hex)
+func IsValidUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// NewUUID membuat UUID versi 4 secara acak
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("gagal membuat uuid: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TargetProduksi adalah target RKAP produksi kering (kg) untuk satu afdeling
+// dan tahun. Bulan 0 berarti target tahunan yang dipecah ke bulan menurut
+// pola distribusi; target bulanan untuk bulan yang sama menggantikannya.
+// TipeProduksi kosong berarti semua tipe. MandorID atau PetaID (blok) diisi
+// untuk target yang lebih rinci.
+type TargetProduksi struct {
+	ID               uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Tahun            int     `gorm:"not null;index:idx_target_produksis_periode,priority:2" json:"tahun"`
+	Bulan            int     `gorm:"not null;default:0;index:idx_target_produksis_periode,priority:3" json:"bulan"`
+	AfdelingID       uint    `gorm:"not null;index:idx_target_produksis_periode,priority:1" json:"afdeling_id"`
+	TipeProduksi     string  `gorm:"type:varchar(100);not null;default:''" json:"tipe_produksi"`
+	MandorID         *uint   `gorm:"index" json:"mandor_id"`
+	PetaID           *uint   `gorm:"index" json:"peta_id"`
+	KeringKg         float64 `gorm:"not null;default:0" json:"kering_kg"`
+	PolaDistribusiID *uint   `json:"pola_distribusi_id"`
+	Keterangan       string  `gorm:"type:varchar(255)" json:"keterangan"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Afdeling       *Afdeling       `gorm:"foreignKey:AfdelingID" json:"afdeling,omitempty"`
+	Mandor         *Mandor         `gorm:"foreignKey:MandorID" json:"mandor,omitempty"`
+	Peta           *Peta           `gorm:"foreignKey:PetaID" json:"peta,omitempty"`
+	PolaDistribusi *PolaDistribusi `gorm:"foreignKey:PolaDistribusiID" json:"pola_distribusi,omitempty"`
+}
+
+func (TargetProduksi) TableName() string {
+	return "target_produksis"
+}
@@ -2,9 +2,17 @@ package models
 
 import "time"
 
+// Peran pengguna. Admin boleh mengelola pengaturan integrasi seperti
+// webhook; operator hanya mengolah data.
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+)
+
 type User struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
 	Username  string    `gorm:"size:100;not null;unique" json:"username"`
 	Password  string    `gorm:"size:255;not null" json:"password"`
+	Role      string    `gorm:"size:20;not null;default:'operator'" json:"role"`
 	LastLogin time.Time `json:"last_login"`
 }
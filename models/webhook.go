@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Status pengiriman webhook
+const (
+	StatusWebhookMenunggu = "MENUNGGU" // belum berhasil, masih akan dicoba lagi
+	StatusWebhookBerhasil = "BERHASIL"
+	StatusWebhookGagal    = "GAGAL" // percobaan habis
+)
+
+// Webhook adalah langganan sistem lain (ERP, gateway WhatsApp, gudang data)
+// terhadap peristiwa aplikasi. Jenis berisi daftar jenis peristiwa dipisah
+// koma atau "*" untuk semua. AfdelingID nil berarti semua afdeling.
+// Rahasia dipakai untuk tanda tangan HMAC-SHA256 setiap payload.
+type Webhook struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Nama       string `gorm:"type:varchar(100);not null" json:"nama"`
+	URL        string `gorm:"type:varchar(500);not null" json:"url"`
+	Rahasia    string `gorm:"type:varchar(255);not null" json:"rahasia,omitempty"`
+	Jenis      string `gorm:"type:varchar(500);not null" json:"jenis"`
+	AfdelingID *uint  `gorm:"index" json:"afdeling_id"`
+	Aktif      bool   `gorm:"not null;default:true" json:"aktif"`
+	DibuatOleh string `gorm:"type:varchar(100)" json:"dibuat_oleh"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Afdeling *Afdeling `gorm:"foreignKey:AfdelingID" json:"afdeling,omitempty"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// PengirimanWebhook adalah log satu peristiwa ke satu webhook. Payload
+// disimpan apa adanya agar bisa dikirim ulang dengan isi yang sama.
+type PengirimanWebhook struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	WebhookID      uint       `gorm:"not null;index" json:"webhook_id"`
+	IDPeristiwa    string     `gorm:"type:varchar(64);not null;index" json:"id_peristiwa"`
+	Jenis          string     `gorm:"type:varchar(50);not null" json:"jenis"`
+	Payload        string     `gorm:"type:longtext;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'MENUNGGU';index" json:"status"`
+	Percobaan      int        `gorm:"not null;default:0" json:"percobaan"`
+	KodeRespons    int        `gorm:"not null;default:0" json:"kode_respons"`
+	Kesalahan      string     `gorm:"type:text" json:"kesalahan"`
+	BerikutnyaPada *time.Time `json:"berikutnya_pada"`
+	TerkirimPada   *time.Time `json:"terkirim_pada"`
+	UlangDari      *uint      `json:"ulang_dari"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RiwayatPercobaan []PercobaanWebhook `gorm:"foreignKey:PengirimanID" json:"riwayat_percobaan,omitempty"`
+}
+
+func (PengirimanWebhook) TableName() string {
+	return "pengiriman_webhooks"
+}
+
+// PercobaanWebhook adalah log satu percobaan HTTP dari sebuah pengiriman.
+// Ke dimulai dari 1; KodeRespons 0 berarti permintaan tidak sampai.
+type PercobaanWebhook struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PengirimanID uint      `gorm:"not null;index" json:"pengiriman_id"`
+	Ke           int       `gorm:"not null" json:"ke"`
+	KodeRespons  int       `gorm:"not null;default:0" json:"kode_respons"`
+	Kesalahan    string    `gorm:"type:text" json:"kesalahan"`
+	DurasiMs     int64     `gorm:"not null;default:0" json:"durasi_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (PercobaanWebhook) TableName() string {
+	return "percobaan_webhooks"
+}
@@ -0,0 +1,301 @@
+// Package pdf adalah penulis PDF sederhana tanpa dependensi luar: teks
+// Helvetica, garis, kotak, gambar PNG/JPEG, tabel dan grafik garis kecil.
+// Koordinat memakai milimeter dengan titik (0,0) di pojok kiri atas halaman.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Konversi milimeter ke point PDF
+const mm = 72.0 / 25.4
+
+// Rata menentukan perataan teks dalam kotak
+type Rata int
+
+const (
+	Kiri Rata = iota
+	Tengah
+	Kanan
+)
+
+// Warna RGB 0-255
+type Warna [3]uint8
+
+var (
+	Hitam = Warna{0, 0, 0}
+	Putih = Warna{255, 255, 255}
+)
+
+// Dokumen menampung halaman dan gambar sampai ditulis dengan Tulis
+type Dokumen struct {
+	lebar, tinggi float64
+	halaman       []*bytes.Buffer
+	gambar        []*gambarPDF
+	cacheGambar   map[string]int
+
+	bold       bool
+	ukuran     float64
+	warnaIsi   Warna
+	warnaGaris Warna
+	warnaTeks  Warna
+	tebalGaris float64
+
+	MarginAtas, MarginBawah, MarginKiri, MarginKanan float64
+
+	// Judul disimpan di metadata; Footer dicetak di kiri bawah setiap
+	// halaman bersama nomor halaman
+	Judul  string
+	Footer string
+
+	// SetelahHalamanBaru dipanggil setiap kali tabel pindah halaman, mis.
+	// untuk mencetak ulang kop. Nilai kembaliannya posisi y awal isi.
+	SetelahHalamanBaru func(d *Dokumen) float64
+}
+
+// Baru membuat dokumen A4 kosong
+func Baru(landscape bool) *Dokumen {
+	d := &Dokumen{
+		lebar:       210,
+		tinggi:      297,
+		cacheGambar: make(map[string]int),
+		ukuran:      10,
+		warnaIsi:    Putih,
+		tebalGaris:  0.2,
+		MarginAtas:  15, MarginBawah: 15, MarginKiri: 15, MarginKanan: 15,
+	}
+	if landscape {
+		d.lebar, d.tinggi = d.tinggi, d.lebar
+	}
+	return d
+}
+
+func (d *Dokumen) Lebar() float64  { return d.lebar }
+func (d *Dokumen) Tinggi() float64 { return d.tinggi }
+
+// LebarIsi adalah lebar halaman di antara margin kiri dan kanan
+func (d *Dokumen) LebarIsi() float64 { return d.lebar - d.MarginKiri - d.MarginKanan }
+
+// BatasBawah adalah posisi y terakhir sebelum margin bawah
+func (d *Dokumen) BatasBawah() float64 { return d.tinggi - d.MarginBawah }
+
+// HalamanBaru menambah halaman dan menjadikannya halaman aktif
+func (d *Dokumen) HalamanBaru() {
+	d.halaman = append(d.halaman, &bytes.Buffer{})
+}
+
+// JumlahHalaman mengembalikan banyaknya halaman
+func (d *Dokumen) JumlahHalaman() int { return len(d.halaman) }
+
+func (d *Dokumen) isi() *bytes.Buffer {
+	if len(d.halaman) == 0 {
+		d.HalamanBaru()
+	}
+	return d.halaman[len(d.halaman)-1]
+}
+
+func (d *Dokumen) SetFont(bold bool, ukuran float64) {
+	d.bold, d.ukuran = bold, ukuran
+}
+
+func (d *Dokumen) SetWarnaIsi(w Warna)   { d.warnaIsi = w }
+func (d *Dokumen) SetWarnaGaris(w Warna) { d.warnaGaris = w }
+func (d *Dokumen) SetWarnaTeks(w Warna)  { d.warnaTeks = w }
+func (d *Dokumen) SetTebalGaris(t float64) {
+	d.tebalGaris = t
+}
+
+func (w Warna) rg() string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(w[0])/255, float64(w[1])/255, float64(w[2])/255)
+}
+
+// px dan py mengubah koordinat milimeter (kiri atas) ke point PDF (kiri bawah)
+func (d *Dokumen) px(x float64) float64 { return x * mm }
+func (d *Dokumen) py(y float64) float64 { return (d.tinggi - y) * mm }
+
+// Teks menulis teks dengan garis dasar di posisi y
+func (d *Dokumen) Teks(x, y float64, s string) {
+	font := "F1"
+	if d.bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.isi(), "q %s rg BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET Q\n",
+		d.warnaTeks.rg(), font, d.ukuran, d.px(x), d.py(y), escape(winAnsi(s)))
+}
+
+// TeksKotak menulis teks yang diratakan dalam lebar w. Teks yang terlalu
+// panjang dipotong dengan "..".
+func (d *Dokumen) TeksKotak(x, y, w float64, s string, rata Rata) {
+	s = d.Potong(s, w)
+	lebar := d.LebarTeks(s)
+	switch rata {
+	case Tengah:
+		x += (w - lebar) / 2
+	case Kanan:
+		x += w - lebar
+	}
+	d.Teks(x, y, s)
+}
+
+// Potong memendekkan teks agar muat dalam lebar w
+func (d *Dokumen) Potong(s string, w float64) string {
+	if d.LebarTeks(s) <= w {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && d.LebarTeks(string(r)+"..") > w {
+		r = r[:len(r)-1]
+	}
+	return string(r) + ".."
+}
+
+// Garis menggambar garis lurus
+func (d *Dokumen) Garis(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.isi(), "q %s RG %.2f w %.2f %.2f m %.2f %.2f l S Q\n",
+		d.warnaGaris.rg(), d.tebalGaris*mm, d.px(x1), d.py(y1), d.px(x2), d.py(y2))
+}
+
+// GarisBerantai menghubungkan titik-titik {x, y} berurutan
+func (d *Dokumen) GarisBerantai(titik [][2]float64) {
+	if len(titik) < 2 {
+		return
+	}
+	b := d.isi()
+	fmt.Fprintf(b, "q %s RG %.2f w 1 j %.2f %.2f m", d.warnaGaris.rg(), d.tebalGaris*mm, d.px(titik[0][0]), d.py(titik[0][1]))
+	for _, t := range titik[1:] {
+		fmt.Fprintf(b, " %.2f %.2f l", d.px(t[0]), d.py(t[1]))
+	}
+	b.WriteString(" S Q\n")
+}
+
+// Kotak menggambar persegi panjang dengan isi dan/atau tepi
+func (d *Dokumen) Kotak(x, y, w, h float64, isi, tepi bool) {
+	op := ""
+	switch {
+	case isi && tepi:
+		op = "B"
+	case isi:
+		op = "f"
+	case tepi:
+		op = "S"
+	default:
+		return
+	}
+	fmt.Fprintf(d.isi(), "q %s rg %s RG %.2f w %.2f %.2f %.2f %.2f re %s Q\n",
+		d.warnaIsi.rg(), d.warnaGaris.rg(), d.tebalGaris*mm,
+		d.px(x), d.py(y+h), w*mm, h*mm, op)
+}
+
+// Tulis menyusun file PDF lengkap ke w. Footer dicetak saat ini, jadi Tulis
+// cukup dipanggil sekali per dokumen.
+func (d *Dokumen) Tulis(w io.Writer) error {
+	if len(d.halaman) == 0 {
+		d.HalamanBaru()
+	}
+	d.cetakFooter()
+
+	var buf bytes.Buffer
+	var offset []int
+	obj := func(isi string) int {
+		offset = append(offset, buf.Len())
+		n := len(offset)
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", n, isi)
+		return n
+	}
+	stream := func(kamus string, data []byte) int {
+		offset = append(offset, buf.Len())
+		n := len(offset)
+		fmt.Fprintf(&buf, "%d 0 obj\n<<%s /Length %d>>\nstream\n", n, kamus, len(data))
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+		return n
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objek 1 katalog dan 2 pohon halaman; isinya ditulis belakangan
+	// sehingga posisinya dicadangkan dulu
+	offset = append(offset, 0, 0)
+
+	font1 := obj("<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>")
+	font2 := obj("<</Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding>>")
+
+	xobj := ""
+	for i, g := range d.gambar {
+		smask := ""
+		if g.alpha != nil {
+			n := stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+				g.lebar, g.tinggi), g.alpha)
+			smask = fmt.Sprintf(" /SMask %d 0 R", n)
+		}
+		n := stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /%s%s",
+			g.lebar, g.tinggi, g.filter, smask), g.data)
+		xobj += fmt.Sprintf(" /Im%d %d 0 R", i+1, n)
+	}
+	resources := fmt.Sprintf("<</Font <</F1 %d 0 R /F2 %d 0 R>> /XObject <<%s>>>>", font1, font2, xobj)
+
+	kids := ""
+	for _, h := range d.halaman {
+		isi, err := kompres(h.Bytes())
+		if err != nil {
+			return err
+		}
+		c := stream(" /Filter /FlateDecode", isi)
+		p := obj(fmt.Sprintf("<</Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R>>",
+			d.lebar*mm, d.tinggi*mm, resources, c))
+		kids += fmt.Sprintf(" %d 0 R", p)
+	}
+
+	info := obj(fmt.Sprintf("<</Producer (app-inputan-ptpn) /Title (%s) /CreationDate (D:%s)>>",
+		escape(winAnsi(d.Judul)), time.Now().Format("20060102150405")))
+
+	// Katalog dan pohon halaman ditaruh di akhir dengan nomor objek 1 dan 2
+	offset[0] = buf.Len()
+	buf.WriteString("1 0 obj\n<</Type /Catalog /Pages 2 0 R>>\nendobj\n")
+	offset[1] = buf.Len()
+	fmt.Fprintf(&buf, "2 0 obj\n<</Type /Pages /Kids [%s] /Count %d>>\nendobj\n", kids, len(d.halaman))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offset)+1)
+	for _, o := range offset {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<</Size %d /Root 1 0 R /Info %d 0 R>>\nstartxref\n%d\n%%%%EOF\n", len(offset)+1, info, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// cetakFooter menulis Footer dan "Halaman x dari n" di setiap halaman
+func (d *Dokumen) cetakFooter() {
+	aktif := d.halaman
+	bold, ukuran, warna := d.bold, d.ukuran, d.warnaTeks
+	d.SetFont(false, 7)
+	d.SetWarnaTeks(Warna{110, 110, 110})
+	for i := range aktif {
+		d.halaman = aktif[:i+1]
+		y := d.tinggi - d.MarginBawah/2
+		if d.Footer != "" {
+			d.Teks(d.MarginKiri, y, d.Footer)
+		}
+		d.TeksKotak(d.MarginKiri, y, d.LebarIsi(), fmt.Sprintf("Halaman %d dari %d", i+1, len(aktif)), Kanan)
+	}
+	d.halaman = aktif
+	d.bold, d.ukuran, d.warnaTeks = bold, ukuran, warna
+}
+
+func kompres(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
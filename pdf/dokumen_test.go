@@ -0,0 +1,208 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var polaStream = regexp.MustCompile(`(\d+) 0 obj\n<<([^\n]*) /Length (\d+)>>\nstream\n`)
+
+// bacaStream mengembalikan kamus dan isi (sudah didekompres jika FlateDecode)
+// setiap stream di file PDF
+func bacaStream(t *testing.T, file []byte) (kamus []string, isi [][]byte) {
+	t.Helper()
+	for _, m := range polaStream.FindAllSubmatchIndex(file, -1) {
+		panjang, _ := strconv.Atoi(string(file[m[6]:m[7]]))
+		data := file[m[1] : m[1]+panjang]
+		if !bytes.HasPrefix(file[m[1]+panjang:], []byte("\nendstream")) {
+			t.Fatalf("/Length %d tidak berakhir di endstream", panjang)
+		}
+		d := string(file[m[4]:m[5]])
+		if strings.Contains(d, "/FlateDecode") {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("stream tidak bisa didekompres: %v", err)
+			}
+			data, _ = io.ReadAll(zr)
+		}
+		kamus = append(kamus, d)
+		isi = append(isi, data)
+	}
+	return kamus, isi
+}
+
+// isiHalaman menggabungkan semua stream isi halaman (bukan gambar)
+func isiHalaman(t *testing.T, file []byte) string {
+	t.Helper()
+	var b strings.Builder
+	kamus, isi := bacaStream(t, file)
+	for i, d := range kamus {
+		if !strings.Contains(d, "/Image") {
+			b.Write(isi[i])
+		}
+	}
+	return b.String()
+}
+
+func tulis(t *testing.T, d *Dokumen) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := d.Tulis(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// Setiap entri xref harus menunjuk tepat ke awal "N 0 obj"
+func TestTulisStruktur(t *testing.T) {
+	d := Baru(false)
+	d.Judul = "Laporan (uji)"
+	d.Teks(20, 30, "Halaman pertama")
+	d.HalamanBaru()
+	d.Teks(20, 30, "Halaman kedua")
+	file := tulis(t, d)
+
+	if !bytes.HasPrefix(file, []byte("%PDF-")) {
+		t.Fatalf("file diawali %q, ingin %%PDF-", file[:8])
+	}
+	if !bytes.HasSuffix(file, []byte("%%EOF\n")) {
+		t.Errorf("file tidak diakhiri %%%%EOF")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(file)
+	if m == nil {
+		t.Fatal("startxref tidak ditemukan")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(file[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d tidak menunjuk tabel xref: %q", xref, file[xref:xref+10])
+	}
+
+	baris := strings.Split(string(file[xref:]), "\n")
+	jumlah, _ := strconv.Atoi(strings.Fields(baris[1])[1])
+	if baris[2] != "0000000000 65535 f " {
+		t.Errorf("entri xref 0 = %q", baris[2])
+	}
+	for n := 1; n < jumlah; n++ {
+		entri := baris[2+n]
+		if len(entri) != 19 || !strings.HasSuffix(entri, " 00000 n ") {
+			t.Fatalf("entri xref %d = %q, ingin 20 byte dengan akhir baris", n, entri)
+		}
+		offset, _ := strconv.Atoi(entri[:10])
+		if awal := strconv.Itoa(n) + " 0 obj\n"; !bytes.HasPrefix(file[offset:], []byte(awal)) {
+			t.Errorf("xref objek %d menunjuk %q", n, file[offset:min(offset+12, len(file))])
+		}
+	}
+	if baris[2+jumlah] != "trailer" || !strings.Contains(baris[3+jumlah], "/Size "+strconv.Itoa(jumlah)) {
+		t.Errorf("trailer %q tidak memuat /Size %d", baris[2+jumlah:4+jumlah], jumlah)
+	}
+
+	if !bytes.Contains(file, []byte("/Count 2")) {
+		t.Error("pohon halaman tidak memuat /Count 2")
+	}
+	isi := isiHalaman(t, file)
+	for _, teks := range []string{"(Halaman pertama) Tj", "(Halaman kedua) Tj", "(Halaman 1 dari 2) Tj", "(Halaman 2 dari 2) Tj"} {
+		if !strings.Contains(isi, teks) {
+			t.Errorf("isi halaman tidak memuat %q", teks)
+		}
+	}
+}
+
+func TestTeksDiloloskan(t *testing.T) {
+	tests := []struct {
+		teks  string
+		ingin string
+	}{
+		{"Produksi (kg)", `(Produksi \(kg\)) Tj`},
+		{`C:\laporan`, `(C:\\laporan) Tj`},
+		{"a)(b", `(a\)\(b) Tj`},
+		{"Kebun Gebugan – Café", "(Kebun Gebugan \x96 Caf\xe9) Tj"},
+		{"Target ≥ 100 ✓", "(Target ? 100 ?) Tj"},
+		{"dua\nbaris\r", "(dua baris) Tj"},
+	}
+	for _, tt := range tests {
+		d := Baru(false)
+		d.Teks(10, 10, tt.teks)
+		if isi := isiHalaman(t, tulis(t, d)); !strings.Contains(isi, tt.ingin) {
+			t.Errorf("Teks(%q) tidak menghasilkan %q", tt.teks, tt.ingin)
+		}
+	}
+
+	// Judul di metadata tidak dikompres
+	d := Baru(false)
+	d.Judul = `Rekap (Setro) \ Mei`
+	if file := tulis(t, d); !bytes.Contains(file, []byte(`/Title (Rekap \(Setro\) \\ Mei)`)) {
+		t.Errorf("judul metadata tidak diloloskan")
+	}
+}
+
+// Kop laporan memakai logo PNG dengan latar transparan; logo yang sama
+// dipakai di setiap halaman tetapi hanya disimpan sekali
+func TestGambarFileKop(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	// Separuh kiri hijau, separuh kanan transparan
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, color.NRGBA{R: 0, G: 120, B: 60, A: 255})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "logoptpn.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	d := Baru(false)
+	if err := d.GambarFile(path, 15, 15, 24, 0); err != nil {
+		t.Fatal(err)
+	}
+	d.HalamanBaru()
+	if err := d.GambarFile(path, 15, 15, 24, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.GambarFile(filepath.Join(t.TempDir(), "tidak-ada.png"), 0, 0, 10, 0); err == nil {
+		t.Error("logo yang tidak ada harus mengembalikan error")
+	}
+	file := tulis(t, d)
+
+	kamus, isi := bacaStream(t, file)
+	var rgb, alpha []string
+	for i, k := range kamus {
+		switch {
+		case strings.Contains(k, "/DeviceRGB"):
+			rgb = append(rgb, k)
+			// Diperkecil ke 400x200, tiga byte per piksel
+			if len(isi[i]) != 400*200*3 {
+				t.Errorf("data RGB %d byte, ingin %d", len(isi[i]), 400*200*3)
+			}
+		case strings.Contains(k, "/DeviceGray"):
+			alpha = append(alpha, k)
+		}
+	}
+	if len(rgb) != 1 || len(alpha) != 1 {
+		t.Fatalf("%d gambar RGB dan %d SMask, ingin masing-masing 1", len(rgb), len(alpha))
+	}
+	if !strings.Contains(rgb[0], "/Width 400 /Height 200") || !strings.Contains(rgb[0], "/SMask ") {
+		t.Errorf("kamus gambar = %q", rgb[0])
+	}
+
+	// Lebar 24 mm, tinggi mengikuti rasio 2:1; digambar sekali per halaman
+	gambar := "68.03 0 0 34.02 42.52 765.35 cm /Im1 Do"
+	if n := strings.Count(isiHalaman(t, file), gambar); n != 2 {
+		t.Errorf("logo digambar %d kali dengan %q, ingin 2", n, gambar)
+	}
+}
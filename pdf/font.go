@@ -0,0 +1,69 @@
+package pdf
+
+import "strings"
+
+// Lebar glyph Helvetica dan Helvetica-Bold (satuan 1/1000 em) untuk karakter
+// ASCII 32-126, diambil dari metrik AFM standar Adobe
+var lebarHelvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var lebarHelveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// LebarTeks mengembalikan lebar teks dalam milimeter dengan font aktif
+func (d *Dokumen) LebarTeks(s string) float64 {
+	tabel := &lebarHelvetica
+	if d.bold {
+		tabel = &lebarHelveticaBold
+	}
+	total := 0
+	for _, c := range []byte(winAnsi(s)) {
+		if c >= 32 && c <= 126 {
+			total += tabel[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * d.ukuran / 1000 / mm
+}
+
+// Karakter Unicode di luar Latin-1 yang ada di WinAnsiEncoding
+var petaWinAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '•': 0x95, '–': 0x96, '—': 0x97,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '™': 0x99,
+}
+
+// winAnsi mengubah teks UTF-8 ke byte WinAnsiEncoding; karakter yang tidak
+// tersedia diganti "?"
+func winAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			b.WriteByte(byte(r))
+		case petaWinAnsi[r] != 0:
+			b.WriteByte(petaWinAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// escape meloloskan karakter khusus string literal PDF
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\n", " ")
+	return r.Replace(s)
+}
@@ -0,0 +1,105 @@
+package pdf
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+)
+
+// Gambar diperkecil sampai sisi terpanjang maksimal sebesar ini agar logo
+// beresolusi tinggi tidak membuat file PDF besar
+const sisiMaksGambar = 400
+
+type gambarPDF struct {
+	lebar, tinggi int
+	filter        string
+	data          []byte
+	alpha         []byte
+}
+
+// GambarFile menaruh gambar PNG/JPEG dari file. Jika h = 0 tinggi dihitung
+// dari rasio gambar. Gambar yang sama hanya disimpan sekali di dokumen.
+func (d *Dokumen) GambarFile(path string, x, y, w, h float64) error {
+	idx, ok := d.cacheGambar[path]
+	if !ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("gagal membaca gambar %s: %w", path, err)
+		}
+		g, err := siapkanGambar(img)
+		if err != nil {
+			return err
+		}
+		d.gambar = append(d.gambar, g)
+		idx = len(d.gambar) - 1
+		d.cacheGambar[path] = idx
+	}
+
+	g := d.gambar[idx]
+	if h == 0 {
+		h = w * float64(g.tinggi) / float64(g.lebar)
+	}
+	fmt.Fprintf(d.isi(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n",
+		w*mm, h*mm, d.px(x), d.py(y+h), idx+1)
+	return nil
+}
+
+// siapkanGambar memperkecil gambar (rata-rata kotak) lalu memisahkan kanal
+// RGB dan alpha. Kanal alpha hanya disimpan jika ada piksel transparan.
+func siapkanGambar(img image.Image) (*gambarPDF, error) {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return nil, fmt.Errorf("gambar kosong")
+	}
+	skala := 1
+	for sw/skala > sisiMaksGambar || sh/skala > sisiMaksGambar {
+		skala++
+	}
+	lw, lh := sw/skala, sh/skala
+
+	rgb := make([]byte, 0, lw*lh*3)
+	alpha := make([]byte, 0, lw*lh)
+	transparan := false
+	for y := 0; y < lh; y++ {
+		for x := 0; x < lw; x++ {
+			var r, g, bl, a uint32
+			for dy := 0; dy < skala; dy++ {
+				for dx := 0; dx < skala; dx++ {
+					pr, pg, pb, pa := img.At(b.Min.X+x*skala+dx, b.Min.Y+y*skala+dy).RGBA()
+					r, g, bl, a = r+pr, g+pg, bl+pb, a+pa
+				}
+			}
+			n := uint32(skala * skala)
+			r, g, bl, a = r/n, g/n, bl/n, a/n
+			// RGBA() sudah premultiplied; kembalikan ke warna asli
+			if a > 0 && a < 0xffff {
+				r, g, bl = r*0xffff/a, g*0xffff/a, bl*0xffff/a
+			}
+			rgb = append(rgb, byte(r>>8), byte(g>>8), byte(bl>>8))
+			alpha = append(alpha, byte(a>>8))
+			if a < 0xffff {
+				transparan = true
+			}
+		}
+	}
+
+	data, err := kompres(rgb)
+	if err != nil {
+		return nil, err
+	}
+	g := &gambarPDF{lebar: lw, tinggi: lh, filter: "FlateDecode", data: data}
+	if transparan {
+		if g.alpha, err = kompres(alpha); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,126 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SeriGrafik adalah satu garis pada grafik
+type SeriGrafik struct {
+	Nama  string
+	Nilai []float64
+	Warna Warna
+}
+
+// GrafikGaris menggambar grafik garis kecil di kotak (x, y, w, h) dengan
+// label sumbu X dan legenda. Sumbu Y selalu mulai dari nol.
+func (d *Dokumen) GrafikGaris(x, y, w, h float64, judul string, label []string, seri []SeriGrafik) {
+	d.SetWarnaGaris(warnaTepiTabel)
+	d.SetTebalGaris(0.2)
+	d.Kotak(x, y, w, h, false, true)
+
+	d.SetFont(true, 8)
+	d.SetWarnaTeks(Hitam)
+	d.Teks(x+2, y+4.5, judul)
+
+	// Area plot
+	px, py := x+14, y+8
+	pw, ph := w-18, h-20
+
+	maks := 0.0
+	for _, s := range seri {
+		for _, v := range s.Nilai {
+			maks = math.Max(maks, v)
+		}
+	}
+	langkah := langkahSumbu(maks / 4)
+	atas := langkah * math.Ceil(maks/langkah)
+	if atas == 0 {
+		atas = langkah
+	}
+
+	d.SetFont(false, 6)
+	d.SetWarnaTeks(Warna{90, 90, 90})
+	d.SetWarnaGaris(Warna{220, 220, 220})
+	for v := 0.0; v <= atas+langkah/2; v += langkah {
+		gy := py + ph - v/atas*ph
+		d.Garis(px, gy, px+pw, gy)
+		d.TeksKotak(x+1, gy+1, px-x-2, angkaSingkat(v), Kanan)
+	}
+
+	n := len(label)
+	posisiX := func(i int) float64 {
+		if n <= 1 {
+			return px + pw/2
+		}
+		return px + float64(i)*pw/float64(n-1)
+	}
+	lompat := int(math.Ceil(float64(n) / 8))
+	for i := 0; i < n; i += max(lompat, 1) {
+		d.TeksKotak(posisiX(i)-8, py+ph+3.5, 16, label[i], Tengah)
+	}
+
+	for _, s := range seri {
+		var titik [][2]float64
+		for i, v := range s.Nilai {
+			if i >= n {
+				break
+			}
+			titik = append(titik, [2]float64{posisiX(i), py + ph - v/atas*ph})
+		}
+		d.SetWarnaGaris(s.Warna)
+		d.SetWarnaIsi(s.Warna)
+		d.SetTebalGaris(0.5)
+		d.GarisBerantai(titik)
+		for _, t := range titik {
+			d.Kotak(t[0]-0.5, t[1]-0.5, 1, 1, true, false)
+		}
+	}
+
+	// Legenda di bawah kanan
+	d.SetFont(false, 6.5)
+	d.SetWarnaTeks(Hitam)
+	lx := x + w - 2
+	for i := len(seri) - 1; i >= 0; i-- {
+		lx -= d.LebarTeks(seri[i].Nama)
+		d.Teks(lx, y+h-2, seri[i].Nama)
+		lx -= 5
+		d.SetWarnaIsi(seri[i].Warna)
+		d.Kotak(lx, y+h-4.2, 3.5, 2.5, true, false)
+		lx -= 4
+	}
+	d.SetTebalGaris(0.2)
+}
+
+// langkahSumbu membulatkan jarak garis bantu ke 1, 2 atau 5 kali 10^n
+func langkahSumbu(kasar float64) float64 {
+	if kasar <= 0 {
+		return 1
+	}
+	pangkat := math.Pow(10, math.Floor(math.Log10(kasar)))
+	for _, k := range []float64{1, 2, 5, 10} {
+		if kasar <= k*pangkat {
+			return k * pangkat
+		}
+	}
+	return 10 * pangkat
+}
+
+// angkaSingkat menulis label sumbu: 1500 -> "1,5rb", 2000000 -> "2jt"
+func angkaSingkat(v float64) string {
+	teks := func(x float64, akhiran string) string {
+		s := fmt.Sprintf("%.1f", x)
+		if s[len(s)-2:] == ".0" {
+			s = s[:len(s)-2]
+		}
+		return strings.Replace(s, ".", ",", 1) + akhiran
+	}
+	switch {
+	case v >= 1e6:
+		return teks(v/1e6, "jt")
+	case v >= 1e3:
+		return teks(v/1e3, "rb")
+	}
+	return teks(v, "")
+}
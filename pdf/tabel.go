@@ -0,0 +1,85 @@
+package pdf
+
+import "strings"
+
+// KolomTabel adalah satu kolom tabel. Judul boleh berisi "\n" untuk header
+// dua baris.
+type KolomTabel struct {
+	Judul string
+	Lebar float64
+	Rata  Rata
+}
+
+// BarisTabel adalah satu baris isi. Tebal dipakai untuk baris jumlah.
+type BarisTabel struct {
+	Sel   []string
+	Tebal bool
+}
+
+var (
+	warnaHeaderTabel = Warna{217, 225, 242}
+	warnaJumlahTabel = Warna{242, 242, 242}
+	warnaTepiTabel   = Warna{150, 150, 150}
+)
+
+// Tabel menggambar tabel mulai posisi y dan mengembalikan posisi y di bawah
+// tabel. Jika halaman penuh, tabel berlanjut di halaman baru dengan header
+// dicetak ulang.
+func (d *Dokumen) Tabel(y float64, kolom []KolomTabel, baris []BarisTabel) float64 {
+	const tinggiBaris = 5.5
+	const ukuranFont = 7.5
+
+	barisHeader := 1
+	for _, k := range kolom {
+		if n := strings.Count(k.Judul, "\n") + 1; n > barisHeader {
+			barisHeader = n
+		}
+	}
+	tinggiHeader := float64(barisHeader)*3.5 + 2.5
+
+	header := func() {
+		x := d.MarginKiri
+		d.SetWarnaIsi(warnaHeaderTabel)
+		d.SetWarnaGaris(warnaTepiTabel)
+		d.SetTebalGaris(0.2)
+		d.SetFont(true, ukuranFont)
+		d.SetWarnaTeks(Hitam)
+		for _, k := range kolom {
+			d.Kotak(x, y, k.Lebar, tinggiHeader, true, true)
+			baris := strings.Split(k.Judul, "\n")
+			awal := y + (tinggiHeader-float64(len(baris))*3.5)/2 + 2.8
+			for i, teks := range baris {
+				d.TeksKotak(x+1, awal+float64(i)*3.5, k.Lebar-2, teks, Tengah)
+			}
+			x += k.Lebar
+		}
+		y += tinggiHeader
+	}
+
+	header()
+	for _, b := range baris {
+		if y+tinggiBaris > d.BatasBawah() {
+			d.HalamanBaru()
+			y = d.MarginAtas
+			if d.SetelahHalamanBaru != nil {
+				y = d.SetelahHalamanBaru(d)
+			}
+			header()
+		}
+		x := d.MarginKiri
+		d.SetFont(b.Tebal, ukuranFont)
+		isi := b.Tebal
+		if isi {
+			d.SetWarnaIsi(warnaJumlahTabel)
+		}
+		for i, k := range kolom {
+			d.Kotak(x, y, k.Lebar, tinggiBaris, isi, true)
+			if i < len(b.Sel) {
+				d.TeksKotak(x+1, y+3.9, k.Lebar-2, b.Sel[i], k.Rata)
+			}
+			x += k.Lebar
+		}
+		y += tinggiBaris
+	}
+	return y
+}
@@ -61,6 +61,27 @@ func SetupRoutes() {
 	protected.HandleFunc("/api/mandor/{id}", controllers.UpdateMandor).Methods("PUT")
 	protected.HandleFunc("/api/mandor/{id}", controllers.DeleteMandor).Methods("DELETE")
 
+	// ================== KEBUN & AFDELING API (MASTER DATA) ==================
+	protected.HandleFunc("/api/kebun", controllers.GetAllKebun).Methods("GET")
+	protected.HandleFunc("/api/kebun", controllers.CreateKebun).Methods("POST")
+	protected.HandleFunc("/api/kebun/{id}", controllers.GetKebunByID).Methods("GET")
+	protected.HandleFunc("/api/kebun/{id}", controllers.UpdateKebun).Methods("PUT")
+	protected.HandleFunc("/api/kebun/{id}", controllers.DeleteKebun).Methods("DELETE")
+	protected.HandleFunc("/api/afdeling", controllers.GetAllAfdeling).Methods("GET")
+	protected.HandleFunc("/api/afdeling", controllers.CreateAfdeling).Methods("POST")
+	protected.HandleFunc("/api/afdeling/{id}", controllers.GetAfdelingByID).Methods("GET")
+	protected.HandleFunc("/api/afdeling/{id}", controllers.UpdateAfdeling).Methods("PUT")
+	protected.HandleFunc("/api/afdeling/{id}", controllers.DeleteAfdeling).Methods("DELETE")
+
+	// ================== PENUGASAN PENYADAP KE MANDOR ==================
+	// Route statis HARUS sebelum route dengan parameter {id}
+	protected.HandleFunc("/api/penugasan/kelompok", controllers.GetKelompokMandor).Methods("GET")
+	protected.HandleFunc("/api/penugasan/produksi", controllers.GetProduksiKelompokMandor).Methods("GET")
+	protected.HandleFunc("/api/penugasan", controllers.GetAllPenugasan).Methods("GET")
+	protected.HandleFunc("/api/penugasan", controllers.CreatePenugasan).Methods("POST")
+	protected.HandleFunc("/api/penugasan/{id}", controllers.UpdatePenugasan).Methods("PUT")
+	protected.HandleFunc("/api/penugasan/{id}", controllers.DeletePenugasan).Methods("DELETE")
+
 	// ================== ENHANCED REPORTING API WITH DATE RANGE SUPPORT ==================
 	protected.HandleFunc("/api/reporting/mandor", controllers.GetMandorSummaryAll).Methods("GET")
 	protected.HandleFunc("/api/reporting/mandor/range", controllers.GetMandorSummaryByDateRange).Methods("GET")
@@ -77,11 +98,16 @@ func SetupRoutes() {
 	// ================== PENYADAP API (CRUD + Search) ==================
 	// Search HARUS sebelum route dengan parameter {id}
 	protected.HandleFunc("/api/penyadap/search", controllers.GetPenyadapByName).Methods("GET")
+	protected.HandleFunc("/api/penyadap/peringkat", controllers.GetPeringkatPenyadap).Methods("GET")
 	protected.HandleFunc("/api/penyadap", controllers.GetAllPenyadap).Methods("GET")
 	protected.HandleFunc("/api/penyadap", controllers.CreatePenyadap).Methods("POST")
 	protected.HandleFunc("/api/penyadap/{id}", controllers.UpdatePenyadap).Methods("PUT")
 	protected.HandleFunc("/api/penyadap/{id}", controllers.DeletePenyadap).Methods("DELETE")
 
+	// ================== SYNC BAKU (OFFLINE CAPTURE) ==================
+	protected.HandleFunc("/api/sync/baku", controllers.SyncBaku).Methods("POST")
+	protected.HandleFunc("/api/sync/baku", controllers.GetSyncBakuChanges).Methods("GET")
+
 	//monitoring
 	protected.HandleFunc("/monitoring", controllers.ServeMonitoringPage).Methods("GET")
 
@@ -99,11 +125,67 @@ func SetupRoutes() {
 	// Routes untuk visualisasi
 	protected.HandleFunc("/visualisasi", controllers.ServeVisualisasiPage).Methods("GET")
 	protected.HandleFunc("/api/visualisasi", controllers.GetVisualisasiData).Methods("GET")
+	protected.HandleFunc("/api/visualisasi/multi", controllers.GetVisualisasiMulti).Methods("POST")
 
 	//rekap endpoint
 	protected.HandleFunc("/rekap", controllers.ServeRekapPage).Methods("GET")
 	protected.HandleFunc("/rekap/today", controllers.GetBakuDetailToday).Methods("GET")
 	protected.HandleFunc("/rekap/until-today", controllers.GetBakuDetailUntilTodayThisMonth).Methods("GET")
+	protected.HandleFunc("/rekap/laporan", controllers.DownloadLaporanRekap).Methods("GET")
+
+	//laporan PDF
+	protected.HandleFunc("/api/laporan/pdf/harian", controllers.LaporanPDFHarian).Methods("GET")
+	protected.HandleFunc("/api/laporan/pdf/bulanan", controllers.LaporanPDFBulanan).Methods("GET")
+	protected.HandleFunc("/api/laporan/pdf/mandor", controllers.LaporanPDFMandor).Methods("GET")
+
+	//target RKAP
+	protected.HandleFunc("/api/target/realisasi", controllers.GetRealisasiTarget).Methods("GET")
+	protected.HandleFunc("/api/target/export", controllers.ExportTarget).Methods("GET")
+	protected.HandleFunc("/api/target/import", controllers.ImportTarget).Methods("POST")
+	protected.HandleFunc("/api/target/pola", controllers.GetAllPolaDistribusi).Methods("GET")
+	protected.HandleFunc("/api/target/pola", controllers.CreatePolaDistribusi).Methods("POST")
+	protected.HandleFunc("/api/target/pola/{id}", controllers.UpdatePolaDistribusi).Methods("PUT")
+	protected.HandleFunc("/api/target", controllers.GetAllTarget).Methods("GET")
+	protected.HandleFunc("/api/target", controllers.CreateTarget).Methods("POST")
+	protected.HandleFunc("/api/target/{id}", controllers.UpdateTarget).Methods("PUT")
+	protected.HandleFunc("/api/target/{id}", controllers.DeleteTarget).Methods("DELETE")
+
+	//anomali produksi
+	protected.HandleFunc("/api/anomali", controllers.GetAllAnomali).Methods("GET")
+	protected.HandleFunc("/api/anomali/analisis", controllers.JalankanAnalisisAnomali).Methods("POST")
+	protected.HandleFunc("/api/anomali/{id}/akui", controllers.AkuiAnomali).Methods("PUT")
+	protected.HandleFunc("/api/anomali/{id}/selesai", controllers.SelesaikanAnomali).Methods("PUT")
+
+	//selisih kebun vs pabrik
+	protected.HandleFunc("/api/selisih/ambang", controllers.GetAllAmbangSelisih).Methods("GET")
+	protected.HandleFunc("/api/selisih/ambang", controllers.CreateAmbangSelisih).Methods("POST")
+	protected.HandleFunc("/api/selisih/ambang/{id}", controllers.UpdateAmbangSelisih).Methods("PUT")
+	protected.HandleFunc("/api/selisih/ambang/{id}", controllers.DeleteAmbangSelisih).Methods("DELETE")
+	protected.HandleFunc("/api/selisih/peringatan", controllers.GetPeringatanSelisih).Methods("GET")
+	protected.HandleFunc("/api/selisih/pelanggar", controllers.GetPelanggarSelisih).Methods("GET")
+	protected.HandleFunc("/api/selisih/evaluasi", controllers.EvaluasiSelisih).Methods("POST")
+
+	//penutupan hari dan stream peristiwa
+	protected.HandleFunc("/api/hari/tutup", controllers.GetAllPenutupanHari).Methods("GET")
+	protected.HandleFunc("/api/hari/tutup", controllers.TutupHari).Methods("POST")
+	protected.HandleFunc("/api/hari/tutup/{id}", controllers.BukaHari).Methods("DELETE")
+	protected.HandleFunc("/api/events", controllers.StreamPeristiwa).Methods("GET")
+
+	//webhook keluar, khusus admin
+	protected.HandleFunc("/api/webhook", controllers.AdminMiddleware(controllers.GetAllWebhook)).Methods("GET")
+	protected.HandleFunc("/api/webhook", controllers.AdminMiddleware(controllers.CreateWebhook)).Methods("POST")
+	protected.HandleFunc("/api/webhook/pengiriman", controllers.AdminMiddleware(controllers.GetPengirimanWebhook)).Methods("GET")
+	protected.HandleFunc("/api/webhook/pengiriman/{id}/ulang", controllers.AdminMiddleware(controllers.UlangPengirimanWebhook)).Methods("POST")
+	protected.HandleFunc("/api/webhook/{id}", controllers.AdminMiddleware(controllers.UpdateWebhook)).Methods("PUT")
+	protected.HandleFunc("/api/webhook/{id}", controllers.AdminMiddleware(controllers.DeleteWebhook)).Methods("DELETE")
+	protected.HandleFunc("/api/webhook/{id}/uji", controllers.AdminMiddleware(controllers.UjiWebhook)).Methods("POST")
+
+	//langganan email
+	protected.HandleFunc("/api/email/langganan", controllers.GetLanggananEmail).Methods("GET")
+	protected.HandleFunc("/api/email/langganan", controllers.CreateLanggananEmail).Methods("POST")
+	protected.HandleFunc("/api/email/langganan/{id}", controllers.UpdateLanggananEmail).Methods("PUT")
+	protected.HandleFunc("/api/email/langganan/{id}", controllers.DeleteLanggananEmail).Methods("DELETE")
+	protected.HandleFunc("/api/email/langganan/{id}/kirim", controllers.KirimLanggananEmail).Methods("POST")
 
 	//upload excell
 	protected.HandleFunc("/upload", controllers.ServeUploadPage).Methods("GET")
@@ -121,9 +203,30 @@ func SetupRoutes() {
 	protected.HandleFunc("/peta", controllers.ServePetaPage).Methods("GET")
 	protected.HandleFunc("/api/peta", controllers.GetPetaByCode).Methods("GET")
 	protected.HandleFunc("/api/peta", controllers.CreatePeta).Methods("POST")
+	protected.HandleFunc("/api/peta", controllers.UpdatePetaByCode).Methods("PUT")
+	protected.HandleFunc("/api/peta/export", controllers.ExportPeta).Methods("GET")
+	protected.HandleFunc("/api/peta/import", controllers.ImportPeta).Methods("POST")
+	protected.HandleFunc("/api/peta/produksi", controllers.GetProduksiPerBlok).Methods("GET")
+	protected.HandleFunc("/api/kpi/produktivitas", controllers.GetKPIProduktivitas).Methods("GET")
+	protected.HandleFunc("/api/kpi/kloon", controllers.GetPerbandinganKloon).Methods("GET")
+	protected.HandleFunc("/api/peta/alokasi", controllers.GetAllBlokAlokasi).Methods("GET")
+	protected.HandleFunc("/api/peta/alokasi", controllers.CreateBlokAlokasi).Methods("POST")
+	protected.HandleFunc("/api/peta/alokasi/{id}", controllers.UpdateBlokAlokasi).Methods("PUT")
+	protected.HandleFunc("/api/peta/alokasi/{id}", controllers.DeleteBlokAlokasi).Methods("DELETE")
 	protected.HandleFunc("/api/peta/{id}", controllers.EditPeta).Methods("PUT")
+	protected.HandleFunc("/api/peta/{id}", controllers.DeletePeta).Methods("DELETE")
+	protected.HandleFunc("/api/peta/{id}/restore", controllers.RestorePeta).Methods("POST")
+	protected.HandleFunc("/api/peta/{id}/riwayat", controllers.GetRiwayatPeta).Methods("GET")
 	protected.HandleFunc("/api/all/peta", controllers.GetAllPeta).Methods("GET")
 
+	//endpoint geometri blok
+	protected.HandleFunc("/api/geo/import", controllers.ImportKML).Methods("POST")
+	protected.HandleFunc("/api/geo/laporan", controllers.GetLaporanGeometri).Methods("GET")
+	protected.HandleFunc("/api/geo/blocks", controllers.GetGeoBlocks).Methods("GET")
+	protected.HandleFunc("/api/geo/kualitas", controllers.GetKualitasPeta).Methods("GET")
+	protected.HandleFunc("/api/geo/locate", controllers.LocateBlok).Methods("GET")
+	protected.HandleFunc("/api/geo/locate", controllers.LocateBlokBatch).Methods("POST")
+
 	//endpoint manajemen akun
 	protected.HandleFunc("/manajemen", controllers.ServeAccountManagementPage).Methods("GET")
 	protected.HandleFunc("/api/manajemen/change-username", controllers.ChangeUsername).Methods("POST")
@@ -131,6 +234,7 @@ func SetupRoutes() {
 
 	//endpoint perbandingan
 	protected.HandleFunc("/perbandingan", controllers.ServePerbandinganPage).Methods("GET")
+	protected.HandleFunc("/api/perbandingan", controllers.GetPerbandingan).Methods("GET")
 
 	//endpoint dev
 	protected.HandleFunc("/dev/rekap", dev.GetAllRekap).Methods("GET")
@@ -96,5 +96,12 @@ func SeedMandor() {
 		{TahunTanam: 2006, Mandor: "AHMAD ARIF", NIK: "9006406", Afdeling: "SETRO", Tipe: "BAKU_EKSTERNAL"},
 	}
 
+	for i := range mandors {
+		if afdeling, err := models.FindAfdeling(config.DB, mandors[i].Afdeling); err == nil {
+			mandors[i].Afdeling = afdeling.Nama
+			mandors[i].AfdelingID = &afdeling.ID
+		}
+	}
+
 	config.DB.Create(&mandors)
 }
@@ -24,6 +24,7 @@ func SeedUsers() {
 	adminUser := models.User{
 		Username:  "admin",
 		Password:  config.HashPassword("admin123"),
+		Role:      models.RoleAdmin,
 		LastLogin: time.Now(),
 	}
 
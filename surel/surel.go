@@ -0,0 +1,185 @@
+// Package surel menyusun dan mengirim email lewat SMTP: isi teks dan HTML
+// (multipart/alternative) serta lampiran seperti laporan Excel/PDF.
+// Server diatur lewat variabel lingkungan sehingga server SMTP lokal untuk
+// pengujian (MailHog, smtp4dev, dll.) bisa dipakai tanpa mengubah kode.
+package surel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Konfigurasi server SMTP. Host kosong berarti email dimatikan.
+type Konfigurasi struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Dari     string
+}
+
+// DariEnv membaca SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME,
+// SMTP_PASSWORD dan SMTP_FROM (default SMTP_USERNAME)
+func DariEnv() Konfigurasi {
+	k := Konfigurasi{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     587,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		Dari:     os.Getenv("SMTP_FROM"),
+	}
+	if p, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && p > 0 {
+		k.Port = p
+	}
+	if k.Dari == "" {
+		k.Dari = k.Username
+	}
+	return k
+}
+
+// Aktif menandakan server SMTP sudah diatur
+func (k Konfigurasi) Aktif() bool {
+	return k.Host != ""
+}
+
+// Lampiran adalah satu file yang disertakan di email
+type Lampiran struct {
+	Nama       string
+	TipeKonten string
+	Isi        []byte
+}
+
+// Pesan adalah satu email. Teks dan HTML sebaiknya berisi hal yang sama;
+// klien email memilih salah satu.
+type Pesan struct {
+	Kepada   []string
+	Subjek   string
+	Teks     string
+	HTML     string
+	Lampiran []Lampiran
+}
+
+// Kirim mengirim pesan. STARTTLS dipakai jika server menawarkannya; login
+// hanya dilakukan jika Username diisi.
+func (k Konfigurasi) Kirim(p Pesan) error {
+	if !k.Aktif() {
+		return fmt.Errorf("SMTP_HOST belum diatur")
+	}
+	if len(p.Kepada) == 0 {
+		return fmt.Errorf("penerima email kosong")
+	}
+	dari, err := mail.ParseAddress(k.Dari)
+	if err != nil {
+		return fmt.Errorf("alamat pengirim '%s' tidak valid: %v", k.Dari, err)
+	}
+
+	isi, err := Susun(k.Dari, p)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if k.Username != "" {
+		auth = smtp.PlainAuth("", k.Username, k.Password, k.Host)
+	}
+	alamat := net.JoinHostPort(k.Host, strconv.Itoa(k.Port))
+	return smtp.SendMail(alamat, auth, dari.Address, p.Kepada, isi)
+}
+
+// Susun membuat pesan MIME lengkap: multipart/mixed berisi
+// multipart/alternative (teks, HTML) diikuti lampiran
+func Susun(dari string, p Pesan) ([]byte, error) {
+	var buf bytes.Buffer
+	campuran := multipart.NewWriter(&buf)
+
+	domain := "localhost"
+	if alamat, err := mail.ParseAddress(dari); err == nil {
+		if i := strings.LastIndex(alamat.Address, "@"); i >= 0 {
+			domain = alamat.Address[i+1:]
+		}
+	}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", dari)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(p.Kepada, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", p.Subjek))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%d.%s@%s>\r\n", time.Now().UnixNano(), campuran.Boundary()[:12], domain)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", campuran.Boundary())
+
+	// Bagian isi: teks dan HTML
+	var isiBuf bytes.Buffer
+	alternatif := multipart.NewWriter(&isiBuf)
+	for _, bagian := range []struct{ tipe, isi string }{
+		{"text/plain; charset=utf-8", p.Teks},
+		{"text/html; charset=utf-8", p.HTML},
+	} {
+		if bagian.isi == "" {
+			continue
+		}
+		w, err := alternatif.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {bagian.tipe},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(bagian.isi)); err != nil {
+			return nil, err
+		}
+		qp.Close()
+	}
+	alternatif.Close()
+
+	w, err := campuran.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternatif.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(isiBuf.Bytes())
+
+	for _, l := range p.Lampiran {
+		tipe := l.TipeKonten
+		if tipe == "" {
+			tipe = "application/octet-stream"
+		}
+		nama := mime.QEncoding.Encode("utf-8", l.Nama)
+		w, err := campuran.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=\"%s\"", tipe, nama)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=\"%s\"", nama)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		tulisBase64(w, l.Isi)
+	}
+	campuran.Close()
+
+	return buf.Bytes(), nil
+}
+
+// tulisBase64 menulis isi base64 dengan baris 76 karakter sesuai RFC 2045
+func tulisBase64(w io.Writer, isi []byte) {
+	teks := base64.StdEncoding.EncodeToString(isi)
+	for len(teks) > 76 {
+		w.Write([]byte(teks[:76] + "\r\n"))
+		teks = teks[76:]
+	}
+	w.Write([]byte(teks + "\r\n"))
+}
@@ -0,0 +1,184 @@
+package surel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpUji adalah server SMTP minimal di dalam proses yang menerima satu
+// pesan tanpa TLS dan tanpa login
+type smtpUji struct {
+	dari    string
+	kepada  []string
+	data    []byte
+	selesai chan struct{}
+}
+
+func jalankanSMTPUji(t *testing.T) (*smtpUji, Konfigurasi) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &smtpUji{selesai: make(chan struct{})}
+	go func() {
+		defer close(s.selesai)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.layani(textproto.NewConn(conn))
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return s, Konfigurasi{Host: "127.0.0.1", Port: addr.Port, Dari: "Laporan PTPN <laporan@ptpn.test>"}
+}
+
+func (s *smtpUji) layani(c *textproto.Conn) {
+	c.PrintfLine("220 smtp-uji siap")
+	for {
+		baris, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		perintah := strings.ToUpper(baris)
+		switch {
+		case strings.HasPrefix(perintah, "EHLO"), strings.HasPrefix(perintah, "HELO"):
+			c.PrintfLine("250 smtp-uji")
+		case strings.HasPrefix(perintah, "MAIL FROM:"):
+			s.dari = strings.Trim(baris[len("MAIL FROM:"):], "<> ")
+			c.PrintfLine("250 OK")
+		case strings.HasPrefix(perintah, "RCPT TO:"):
+			s.kepada = append(s.kepada, strings.Trim(baris[len("RCPT TO:"):], "<> "))
+			c.PrintfLine("250 OK")
+		case perintah == "DATA":
+			c.PrintfLine("354 akhiri dengan titik")
+			s.data, _ = io.ReadAll(c.DotReader())
+			c.PrintfLine("250 diterima")
+		case perintah == "QUIT":
+			c.PrintfLine("221 selesai")
+			return
+		default:
+			c.PrintfLine("502 tidak didukung")
+		}
+	}
+}
+
+func TestKirimLewatSMTP(t *testing.T) {
+	server, k := jalankanSMTPUji(t)
+	lampiran := bytes.Repeat([]byte("laporan-produksi;"), 10)
+	err := k.Kirim(Pesan{
+		Kepada:   []string{"asisten@ptpn.test", "manajer@ptpn.test"},
+		Subjek:   "Laporan harian – Afdeling Setro",
+		Teks:     "Total produksi: 1.234 kg",
+		HTML:     "<p>Total produksi: <b>1.234 kg</b></p>",
+		Lampiran: []Lampiran{{Nama: "laporan.xlsx", TipeKonten: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Isi: lampiran}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.selesai
+
+	if server.dari != "laporan@ptpn.test" {
+		t.Errorf("MAIL FROM = %q, ingin laporan@ptpn.test", server.dari)
+	}
+	if strings.Join(server.kepada, ",") != "asisten@ptpn.test,manajer@ptpn.test" {
+		t.Errorf("RCPT TO = %v", server.kepada)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("pesan tidak bisa dibaca: %v\n%s", err, server.data)
+	}
+	subjek, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subjek != "Laporan harian – Afdeling Setro" {
+		t.Errorf("Subject = %q (%v)", subjek, err)
+	}
+	if got := msg.Header.Get("From"); got != k.Dari {
+		t.Errorf("From = %q, ingin %q", got, k.Dari)
+	}
+	if got := msg.Header.Get("To"); got != "asisten@ptpn.test, manajer@ptpn.test" {
+		t.Errorf("To = %q", got)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date tidak valid: %v", err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@ptpn.test>") {
+		t.Errorf("Message-ID = %q, ingin domain pengirim", id)
+	}
+
+	tipe, param, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || tipe != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v), ingin multipart/mixed", tipe, err)
+	}
+	campuran := multipart.NewReader(msg.Body, param["boundary"])
+
+	// Bagian pertama: teks dan HTML sebagai multipart/alternative
+	isi, err := campuran.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tipe, param, _ = mime.ParseMediaType(isi.Header.Get("Content-Type"))
+	if tipe != "multipart/alternative" {
+		t.Fatalf("bagian pertama = %q, ingin multipart/alternative", tipe)
+	}
+	alternatif := multipart.NewReader(isi, param["boundary"])
+	for _, ingin := range []struct{ tipe, isi string }{
+		{"text/plain", "Total produksi: 1.234 kg"},
+		{"text/html", "<p>Total produksi: <b>1.234 kg</b></p>"},
+	} {
+		bagian, err := alternatif.NextPart()
+		if err != nil {
+			t.Fatalf("bagian %s: %v", ingin.tipe, err)
+		}
+		// multipart.Reader membuka quoted-printable secara otomatis
+		teks, _ := io.ReadAll(bagian)
+		if tipe, _, _ := mime.ParseMediaType(bagian.Header.Get("Content-Type")); tipe != ingin.tipe || string(teks) != ingin.isi {
+			t.Errorf("bagian %s = %q, ingin %s %q", tipe, teks, ingin.tipe, ingin.isi)
+		}
+	}
+
+	// Bagian kedua: lampiran base64
+	lamp, err := campuran.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lamp.FileName() != "laporan.xlsx" || lamp.Header.Get("Content-Transfer-Encoding") != "base64" {
+		t.Errorf("lampiran = %q (%s)", lamp.FileName(), lamp.Header.Get("Content-Transfer-Encoding"))
+	}
+	encoded, _ := io.ReadAll(lamp)
+	// DotReader sudah mengubah CRLF menjadi LF
+	semuaBaris := strings.Fields(string(encoded))
+	for _, baris := range semuaBaris {
+		if len(baris) > 76 {
+			t.Errorf("baris base64 %d karakter, maksimal 76", len(baris))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(semuaBaris, ""))
+	if err != nil || !bytes.Equal(decoded, lampiran) {
+		t.Errorf("isi lampiran berbeda (%v)", err)
+	}
+	if _, err := campuran.NextPart(); err != io.EOF {
+		t.Errorf("ada bagian tambahan setelah lampiran: %v", err)
+	}
+}
+
+func TestKirimTanpaKonfigurasi(t *testing.T) {
+	if err := (Konfigurasi{}).Kirim(Pesan{Kepada: []string{"a@ptpn.test"}}); err == nil {
+		t.Error("Kirim tanpa SMTP_HOST harus gagal")
+	}
+	if err := (Konfigurasi{Host: "127.0.0.1", Dari: "a@ptpn.test"}).Kirim(Pesan{}); err == nil {
+		t.Error("Kirim tanpa penerima harus gagal")
+	}
+}